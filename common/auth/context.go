@@ -0,0 +1,16 @@
+package auth
+
+import "context"
+
+type principalKey struct{}
+
+// NewContext returns a copy of ctx carrying the authenticated principal
+func NewContext(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// FromContext returns the principal put into ctx by the auth interceptor
+func FromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(*Principal)
+	return principal, ok && principal != nil
+}
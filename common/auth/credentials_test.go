@@ -0,0 +1,58 @@
+package auth
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestServiceCredentials_GetRequestMetadata(t *testing.T) {
+	tokens := NewTokens("secret", time.Minute)
+	creds := NewServiceCredentials(tokens, "user-service", true)
+
+	md, err := creds.GetRequestMetadata(context.Background())
+	require.NoError(t, err)
+
+	token, ok := strings.CutPrefix(md["authorization"], "Bearer ")
+	require.True(t, ok)
+
+	principal, err := tokens.Parse(token)
+	require.NoError(t, err)
+	assert.Equal(t, &Principal{UserID: "user-service", Role: RoleService}, principal)
+	assert.True(t, creds.RequireTransportSecurity())
+}
+
+func TestBearerToken(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		token  string
+		ok     bool
+	}{
+		{name: "bearer", header: "Bearer abc", token: "abc", ok: true},
+		{name: "lower case scheme", header: "bearer abc", token: "abc", ok: true},
+		{name: "no header"},
+		{name: "other scheme", header: "Basic abc"},
+		{name: "no token", header: "Bearer "},
+		{name: "no scheme", header: "abc"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.header != "" {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", tt.header))
+			}
+
+			token, ok := BearerToken(ctx)
+
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.token, token)
+		})
+	}
+}
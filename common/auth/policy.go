@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"errors"
+	"slices"
+)
+
+// ErrPermissionDenied is returned when a principal may not call a method
+var ErrPermissionDenied = errors.New("permission denied")
+
+// Role is what a principal is allowed to do
+type Role string
+
+const (
+	// RoleAdmin may act on any user
+	RoleAdmin Role = "admin"
+	// RoleService is another service of the platform calling on its own behalf
+	RoleService Role = "service"
+	// RoleUser may only act on their own account
+	RoleUser Role = "user"
+)
+
+// Valid reports whether r is one of the known roles
+func (r Role) Valid() bool {
+	return r == RoleAdmin || r == RoleService || r == RoleUser
+}
+
+// Rule says who may call a method
+type Rule struct {
+	// Public methods can be called without an access token
+	Public bool
+	// Roles may call the method for any resource
+	Roles []Role
+	// Owner, when set, returns the user a request is about. Users may
+	// call the method when that is themselves.
+	Owner func(req any) string
+}
+
+// Policy maps full gRPC method names to the rule for the method. Methods
+// that are not in the policy may not be called by anyone.
+type Policy map[string]Rule
+
+// Authorize returns ErrPermissionDenied unless principal may make req to
+// method. principal may be nil for public methods.
+func (p Policy) Authorize(method string, principal *Principal, req any) error {
+	rule, ok := p[method]
+	if !ok {
+		return ErrPermissionDenied
+	}
+	if rule.Public {
+		return nil
+	}
+	if principal == nil {
+		return ErrPermissionDenied
+	}
+	if slices.Contains(rule.Roles, principal.Role) {
+		return nil
+	}
+	if rule.Owner != nil && principal.Role == RoleUser {
+		if owner := rule.Owner(req); owner != "" && owner == principal.UserID {
+			return nil
+		}
+	}
+	return ErrPermissionDenied
+}
+
+// PublicMethods returns the methods that need no access token
+func (p Policy) PublicMethods() []string {
+	var methods []string
+	for method, rule := range p {
+		if rule.Public {
+			methods = append(methods, method)
+		}
+	}
+	slices.Sort(methods)
+	return methods
+}
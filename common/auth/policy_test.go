@@ -0,0 +1,76 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type ownedRequest struct{ userID string }
+
+var testPolicy = Policy{
+	"/test/Public":  {Public: true},
+	"/test/List":    {Roles: []Role{RoleAdmin}},
+	"/test/Send":    {Roles: []Role{RoleService}},
+	"/test/Get":     {Roles: []Role{RoleAdmin, RoleService}, Owner: func(req any) string { return req.(ownedRequest).userID }},
+	"/test/Account": {Roles: []Role{RoleUser, RoleAdmin}},
+}
+
+func TestPolicy_Authorize_Success(t *testing.T) {
+	user := &Principal{UserID: "user-1", SessionID: "session-1", Role: RoleUser}
+	admin := &Principal{UserID: "admin-1", SessionID: "session-2", Role: RoleAdmin}
+	service := &Principal{UserID: "user-service", Role: RoleService}
+
+	tests := []struct {
+		name      string
+		method    string
+		principal *Principal
+		req       any
+	}{
+		{name: "public without principal", method: "/test/Public"},
+		{name: "admin lists", method: "/test/List", principal: admin},
+		{name: "service sends", method: "/test/Send", principal: service},
+		{name: "user reads themselves", method: "/test/Get", principal: user, req: ownedRequest{userID: "user-1"}},
+		{name: "admin reads anyone", method: "/test/Get", principal: admin, req: ownedRequest{userID: "user-1"}},
+		{name: "service reads anyone", method: "/test/Get", principal: service, req: ownedRequest{userID: "user-1"}},
+		{name: "user manages their account", method: "/test/Account", principal: user},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.NoError(t, testPolicy.Authorize(tt.method, tt.principal, tt.req))
+		})
+	}
+}
+
+func TestPolicy_Authorize_Fail(t *testing.T) {
+	user := &Principal{UserID: "user-1", SessionID: "session-1", Role: RoleUser}
+	admin := &Principal{UserID: "admin-1", SessionID: "session-2", Role: RoleAdmin}
+	service := &Principal{UserID: "user-service", Role: RoleService}
+
+	tests := []struct {
+		name      string
+		method    string
+		principal *Principal
+		req       any
+	}{
+		{name: "method not in policy", method: "/test/Unknown", principal: admin},
+		{name: "no principal", method: "/test/List"},
+		{name: "user lists", method: "/test/List", principal: user},
+		{name: "user sends", method: "/test/Send", principal: user},
+		{name: "admin sends", method: "/test/Send", principal: admin},
+		{name: "user reads someone else", method: "/test/Get", principal: user, req: ownedRequest{userID: "user-2"}},
+		{name: "user reads without an owner", method: "/test/Get", principal: user, req: ownedRequest{}},
+		{name: "service without a session", method: "/test/Account", principal: service},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.ErrorIs(t, testPolicy.Authorize(tt.method, tt.principal, tt.req), ErrPermissionDenied)
+		})
+	}
+}
+
+func TestPolicy_PublicMethods(t *testing.T) {
+	assert.Equal(t, []string{"/test/Public"}, testPolicy.PublicMethods())
+}
@@ -0,0 +1,146 @@
+// Package auth issues and checks the access tokens that authenticate gRPC
+// calls between clients and services, and decides which roles may call
+// which methods.
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Issuer is the "iss" claim of every access token
+const Issuer = "user-service"
+
+var (
+	// ErrInvalidToken is returned for tokens that are malformed, carry a bad
+	// signature or were issued by someone else
+	ErrInvalidToken = errors.New("invalid access token")
+	ErrTokenExpired = errors.New("access token expired")
+)
+
+// Principal is the authenticated caller of a request
+type Principal struct {
+	// UserID is the user, or for RoleService the name of the calling service
+	UserID string
+	// SessionID names the login session the access token was issued for.
+	// Service principals have no session.
+	SessionID string
+	Role      Role
+}
+
+// Tokens issues and checks access tokens: JWTs signed with HS256.
+type Tokens struct {
+	secret []byte
+	ttl    time.Duration
+	now    func() time.Time
+}
+
+func NewTokens(secret string, ttl time.Duration) *Tokens {
+	return &Tokens{
+		secret: []byte(secret),
+		ttl:    ttl,
+		now:    time.Now,
+	}
+}
+
+type header struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ"`
+}
+
+type claims struct {
+	Issuer    string `json:"iss"`
+	Subject   string `json:"sub"`
+	SessionID string `json:"sid,omitempty"`
+	Role      Role   `json:"role"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// encodedHeader is the same for every token, so it is encoded once
+var encodedHeader = mustEncode(header{Alg: "HS256", Typ: "JWT"})
+
+// Issue returns a signed access token for principal and when it expires
+func (t *Tokens) Issue(principal Principal) (string, time.Time, error) {
+	now := t.now()
+	expiresAt := now.Add(t.ttl)
+
+	payload, err := json.Marshal(claims{
+		Issuer:    Issuer,
+		Subject:   principal.UserID,
+		SessionID: principal.SessionID,
+		Role:      principal.Role,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: expiresAt.Unix(),
+	})
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to encode claims: %w", err)
+	}
+
+	signingInput := encodedHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(t.mac(signingInput)), expiresAt, nil
+}
+
+// Parse verifies an access token and returns the principal it was issued for.
+// Only HS256 is accepted, whatever the token header claims.
+func (t *Tokens) Parse(token string) (*Principal, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidToken
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || !hmac.Equal(sig, t.mac(parts[0]+"."+parts[1])) {
+		return nil, ErrInvalidToken
+	}
+
+	var h header
+	if err := decode(parts[0], &h); err != nil || h.Alg != "HS256" {
+		return nil, ErrInvalidToken
+	}
+
+	var c claims
+	if err := decode(parts[1], &c); err != nil {
+		return nil, ErrInvalidToken
+	}
+	if c.Issuer != Issuer || c.Subject == "" || !c.Role.Valid() {
+		return nil, ErrInvalidToken
+	}
+	// Only user tokens belong to a login session
+	if (c.Role == RoleService) != (c.SessionID == "") {
+		return nil, ErrInvalidToken
+	}
+	if !t.now().Before(time.Unix(c.ExpiresAt, 0)) {
+		return nil, ErrTokenExpired
+	}
+
+	return &Principal{UserID: c.Subject, SessionID: c.SessionID, Role: c.Role}, nil
+}
+
+func (t *Tokens) mac(signingInput string) []byte {
+	h := hmac.New(sha256.New, t.secret)
+	h.Write([]byte(signingInput))
+	return h.Sum(nil)
+}
+
+func decode(segment string, v any) error {
+	raw, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, v)
+}
+
+func mustEncode(v any) string {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
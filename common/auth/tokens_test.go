@@ -0,0 +1,92 @@
+package auth
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokens_Parse_Success(t *testing.T) {
+	tests := []struct {
+		name      string
+		principal Principal
+	}{
+		{name: "user", principal: Principal{UserID: "user-1", SessionID: "session-1", Role: RoleUser}},
+		{name: "admin", principal: Principal{UserID: "user-1", SessionID: "session-1", Role: RoleAdmin}},
+		{name: "service", principal: Principal{UserID: "user-service", Role: RoleService}},
+	}
+
+	tokens := NewTokens("secret", time.Minute)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, expiresAt, err := tokens.Issue(tt.principal)
+			require.NoError(t, err)
+
+			parsed, err := tokens.Parse(token)
+
+			require.NoError(t, err)
+			assert.Equal(t, &tt.principal, parsed)
+			assert.WithinDuration(t, time.Now().Add(time.Minute), expiresAt, time.Second)
+		})
+	}
+}
+
+func TestTokens_Parse_Fail(t *testing.T) {
+	tokens := NewTokens("secret", time.Minute)
+	valid, _, err := tokens.Issue(Principal{UserID: "user-1", SessionID: "session-1", Role: RoleUser})
+	require.NoError(t, err)
+	parts := strings.Split(valid, ".")
+
+	other, _, err := NewTokens("other", time.Minute).Issue(Principal{UserID: "user-1", SessionID: "session-1", Role: RoleUser})
+	require.NoError(t, err)
+
+	expired := NewTokens("secret", time.Minute)
+	expired.now = func() time.Time { return time.Now().Add(-time.Hour) }
+	old, _, err := expired.Issue(Principal{UserID: "user-1", SessionID: "session-1", Role: RoleUser})
+	require.NoError(t, err)
+
+	// A token claiming "none" must not be accepted even with a valid signature
+	none := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none","typ":"JWT"}`))
+	noneSigned := none + "." + parts[1] + "." +
+		base64.RawURLEncoding.EncodeToString(tokens.mac(none+"."+parts[1]))
+
+	unknownRole, _, err := tokens.Issue(Principal{UserID: "user-1", SessionID: "session-1", Role: "root"})
+	require.NoError(t, err)
+	userWithoutSession, _, err := tokens.Issue(Principal{UserID: "user-1", Role: RoleUser})
+	require.NoError(t, err)
+	serviceWithSession, _, err := tokens.Issue(Principal{UserID: "user-service", SessionID: "session-1", Role: RoleService})
+	require.NoError(t, err)
+
+	tampered := base64.RawURLEncoding.EncodeToString(
+		[]byte(`{"iss":"user-service","sub":"admin","sid":"session-1","role":"admin","iat":0,"exp":9999999999}`))
+
+	tests := []struct {
+		name        string
+		token       string
+		expectedErr error
+	}{
+		{name: "empty token", token: "", expectedErr: ErrInvalidToken},
+		{name: "missing signature", token: parts[0] + "." + parts[1], expectedErr: ErrInvalidToken},
+		{name: "signed with another secret", token: other, expectedErr: ErrInvalidToken},
+		{name: "tampered claims", token: parts[0] + "." + tampered + "." + parts[2], expectedErr: ErrInvalidToken},
+		{name: "alg none", token: noneSigned, expectedErr: ErrInvalidToken},
+		{name: "unknown role", token: unknownRole, expectedErr: ErrInvalidToken},
+		{name: "user token without session", token: userWithoutSession, expectedErr: ErrInvalidToken},
+		{name: "service token with session", token: serviceWithSession, expectedErr: ErrInvalidToken},
+		{name: "expired", token: old, expectedErr: ErrTokenExpired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal, err := tokens.Parse(tt.token)
+
+			assert.ErrorIs(t, err, tt.expectedErr)
+			assert.Nil(t, principal)
+		})
+	}
+}
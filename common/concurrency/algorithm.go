@@ -0,0 +1,101 @@
+package concurrency
+
+import (
+	"math"
+	"time"
+)
+
+// AIMD grows the limit by one after each call that completes in time while
+// the limit is in use, and cuts it by BackoffRatio when a call is dropped
+// or takes longer than the timeout, like TCP congestion control
+type AIMD struct {
+	limit        float64
+	min, max     float64
+	timeout      time.Duration
+	backoffRatio float64
+}
+
+// NewAIMD creates an AIMD algorithm starting at cfg.InitialLimit
+func NewAIMD(cfg Config) *AIMD {
+	return &AIMD{
+		limit:        float64(cfg.InitialLimit),
+		min:          float64(cfg.MinLimit),
+		max:          float64(cfg.MaxLimit),
+		timeout:      cfg.Timeout,
+		backoffRatio: 0.9,
+	}
+}
+
+func (a *AIMD) Limit() int {
+	return int(a.limit)
+}
+
+func (a *AIMD) Update(s Sample) {
+	switch {
+	case s.Dropped || (a.timeout > 0 && s.RTT > a.timeout):
+		a.limit = max(a.limit*a.backoffRatio, a.min)
+	// An idle server learns nothing about how much it can take
+	case float64(s.InFlight)*2 >= a.limit:
+		a.limit = min(a.limit+1, a.max)
+	}
+}
+
+// Gradient compares each call's latency with a long-term average and
+// shrinks the limit as calls slow down relative to it, growing it by a
+// queue of the limit's square root while they do not. It is a simplified
+// form of Netflix's gradient2 limit.
+type Gradient struct {
+	limit    float64
+	min, max float64
+	// longRTT is an exponential moving average of the latency, in ns
+	longRTT float64
+	// window is how many calls longRTT averages over
+	window float64
+	// tolerance is how much slower than longRTT calls may be before the
+	// limit shrinks
+	tolerance float64
+	// smoothing is how much of the new estimate each call moves the limit
+	smoothing float64
+}
+
+// NewGradient creates a Gradient algorithm starting at cfg.InitialLimit
+func NewGradient(cfg Config) *Gradient {
+	return &Gradient{
+		limit:     float64(cfg.InitialLimit),
+		min:       float64(cfg.MinLimit),
+		max:       float64(cfg.MaxLimit),
+		window:    600,
+		tolerance: 1.5,
+		smoothing: 0.2,
+	}
+}
+
+func (g *Gradient) Limit() int {
+	return int(g.limit)
+}
+
+func (g *Gradient) Update(s Sample) {
+	rtt := float64(max(s.RTT, time.Microsecond))
+	if g.longRTT == 0 {
+		g.longRTT = rtt
+	} else {
+		g.longRTT += (rtt - g.longRTT) / g.window
+	}
+	// After a slow period let the average come back down quickly
+	if g.longRTT/rtt > 2 {
+		g.longRTT *= 0.95
+	}
+
+	// An idle server learns nothing about how much it can take
+	if !s.Dropped && float64(s.InFlight)*2 < g.limit {
+		return
+	}
+
+	gradient := 0.5
+	if !s.Dropped {
+		gradient = max(0.5, min(1, g.tolerance*g.longRTT/rtt))
+	}
+	estimate := g.limit*gradient + math.Sqrt(g.limit)
+
+	g.limit = max(g.min, min(g.max, g.limit*(1-g.smoothing)+estimate*g.smoothing))
+}
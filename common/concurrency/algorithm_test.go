@@ -0,0 +1,105 @@
+package concurrency
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// repeat returns n copies of s
+func repeat(n int, s Sample) []Sample {
+	samples := make([]Sample, n)
+	for i := range samples {
+		samples[i] = s
+	}
+	return samples
+}
+
+func TestAIMD_Update(t *testing.T) {
+	tests := []struct {
+		name    string
+		samples []Sample
+		want    int
+	}{
+		{
+			name:    "fast calls at the limit grow it",
+			samples: []Sample{{RTT: time.Millisecond, InFlight: 10}, {RTT: time.Millisecond, InFlight: 11}},
+			want:    12,
+		},
+		{
+			name:    "idle server keeps the limit",
+			samples: []Sample{{RTT: time.Millisecond, InFlight: 1}},
+			want:    10,
+		},
+		{
+			name:    "dropped call shrinks it",
+			samples: []Sample{{RTT: time.Millisecond, InFlight: 10, Dropped: true}},
+			want:    9,
+		},
+		{
+			name:    "slow call shrinks it",
+			samples: []Sample{{RTT: 2 * time.Second, InFlight: 10}},
+			want:    9,
+		},
+		{
+			name:    "never above the maximum",
+			samples: repeat(50, Sample{RTT: time.Millisecond, InFlight: 20}),
+			want:    20,
+		},
+		{
+			name:    "never below the minimum",
+			samples: repeat(50, Sample{RTT: time.Millisecond, InFlight: 20, Dropped: true}),
+			want:    2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := NewAIMD(testConfig(KindAIMD))
+			for _, s := range tt.samples {
+				a.Update(s)
+			}
+			assert.Equal(t, tt.want, a.Limit())
+		})
+	}
+}
+
+func TestGradient_Update(t *testing.T) {
+	tests := []struct {
+		name    string
+		samples []Sample
+		check   func(t *testing.T, limit int)
+	}{
+		{
+			name:    "steady latency grows the limit to the maximum",
+			samples: repeat(100, Sample{RTT: 10 * time.Millisecond, InFlight: 20}),
+			check:   func(t *testing.T, limit int) { assert.Equal(t, 20, limit) },
+		},
+		{
+			name:    "idle server keeps the limit",
+			samples: []Sample{{RTT: 10 * time.Millisecond, InFlight: 1}},
+			check:   func(t *testing.T, limit int) { assert.Equal(t, 10, limit) },
+		},
+		{
+			name:    "rising latency shrinks the limit",
+			samples: append(repeat(100, Sample{RTT: 10 * time.Millisecond, InFlight: 20}), repeat(20, Sample{RTT: 100 * time.Millisecond, InFlight: 20})...),
+			check:   func(t *testing.T, limit int) { assert.Less(t, limit, 10) },
+		},
+		{
+			name:    "drops shrink the limit",
+			samples: repeat(10, Sample{InFlight: 20, Dropped: true}),
+			check:   func(t *testing.T, limit int) { assert.Less(t, limit, 10) },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGradient(testConfig(KindGradient))
+			for _, s := range tt.samples {
+				g.Update(s)
+			}
+			tt.check(t, g.Limit())
+		})
+	}
+}
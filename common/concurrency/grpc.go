@@ -0,0 +1,83 @@
+package concurrency
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryServerInterceptor rejects calls beyond l's limit with
+// ResourceExhausted. Calls to the exempt methods, such as health checks,
+// are neither limited nor counted.
+func UnaryServerInterceptor(l *Limiter, exempt ...string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		if slices.Contains(exempt, info.FullMethod) {
+			return handler(ctx, req)
+		}
+
+		release, ok := l.Acquire()
+		if !ok {
+			return nil, status.Error(codes.ResourceExhausted, "too many concurrent requests")
+		}
+
+		// Deferred so a panic recovered further out still frees the slot
+		defer func() { release(dropped(ctx, err)) }()
+
+		return handler(ctx, req)
+	}
+}
+
+// dropped reports whether a call ran out of time, the sign of an overloaded
+// server. A handler's own ResourceExhausted, such as a full queue, is a
+// business outcome and does not count.
+func dropped(ctx context.Context, err error) bool {
+	return errors.Is(ctx.Err(), context.DeadlineExceeded) || status.Code(err) == codes.DeadlineExceeded
+}
+
+// NewCollector exports l's limit, the calls in flight and the calls
+// rejected. Register it with the service's registry.
+func NewCollector(namespace string, l *Limiter) prometheus.Collector {
+	return &collector{
+		limit: prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "concurrency_limiter",
+			Name:      "limit",
+			Help:      "Current estimated concurrency limit",
+		}, func() float64 { return float64(l.Limit()) }),
+		inFlight: prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "concurrency_limiter",
+			Name:      "in_flight",
+			Help:      "Number of calls in flight",
+		}, func() float64 { return float64(l.InFlight()) }),
+		rejected: prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "concurrency_limiter",
+			Name:      "rejected_total",
+			Help:      "Total number of calls rejected over the limit",
+		}, func() float64 { return float64(l.Rejected()) }),
+	}
+}
+
+type collector struct {
+	limit    prometheus.GaugeFunc
+	inFlight prometheus.GaugeFunc
+	rejected prometheus.CounterFunc
+}
+
+func (c *collector) Describe(ch chan<- *prometheus.Desc) {
+	c.limit.Describe(ch)
+	c.inFlight.Describe(ch)
+	c.rejected.Describe(ch)
+}
+
+func (c *collector) Collect(ch chan<- prometheus.Metric) {
+	c.limit.Collect(ch)
+	c.inFlight.Collect(ch)
+	c.rejected.Collect(ch)
+}
@@ -0,0 +1,154 @@
+package concurrency
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	sendMethod   = "/email.v1.EmailService/SendEmail"
+	healthMethod = "/grpc.health.v1.Health/Check"
+)
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	tests := []struct {
+		name    string
+		method  string
+		handler grpc.UnaryHandler
+		code    codes.Code
+		dropped bool
+		counted bool
+	}{
+		{
+			name:    "admitted",
+			method:  sendMethod,
+			handler: func(context.Context, any) (any, error) { return "ok", nil },
+			counted: true,
+		},
+		{
+			name:   "handler out of time",
+			method: sendMethod,
+			handler: func(context.Context, any) (any, error) {
+				return nil, status.Error(codes.DeadlineExceeded, "deadline exceeded")
+			},
+			code:    codes.DeadlineExceeded,
+			dropped: true,
+			counted: true,
+		},
+		{
+			name:   "full queue is not dropped",
+			method: sendMethod,
+			handler: func(context.Context, any) (any, error) {
+				return nil, status.Error(codes.ResourceExhausted, "queue full")
+			},
+			code:    codes.ResourceExhausted,
+			counted: true,
+		},
+		{
+			name:   "rejected request is not dropped",
+			method: sendMethod,
+			handler: func(context.Context, any) (any, error) {
+				return nil, status.Error(codes.InvalidArgument, "bad address")
+			},
+			code:    codes.InvalidArgument,
+			counted: true,
+		},
+		{
+			name:    "exempt method",
+			method:  healthMethod,
+			handler: func(context.Context, any) (any, error) { return "ok", nil },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			algorithm := &fixed{limit: 1}
+			interceptor := UnaryServerInterceptor(NewWithAlgorithm(algorithm), healthMethod)
+
+			_, err := interceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: tt.method}, tt.handler)
+
+			assert.Equal(t, tt.code, status.Code(err))
+			if tt.counted {
+				require.Len(t, algorithm.samples, 1)
+				assert.Equal(t, tt.dropped, algorithm.samples[0].Dropped)
+			} else {
+				assert.Empty(t, algorithm.samples)
+			}
+		})
+	}
+}
+
+func TestUnaryServerInterceptor_Limit(t *testing.T) {
+	l := NewWithAlgorithm(&fixed{limit: 1})
+	interceptor := UnaryServerInterceptor(l, healthMethod)
+	info := &grpc.UnaryServerInfo{FullMethod: sendMethod}
+
+	// A call that is still in flight uses up the limit
+	release, ok := l.Acquire()
+	require.True(t, ok)
+
+	handled := false
+	_, err := interceptor(context.Background(), nil, info, func(context.Context, any) (any, error) {
+		handled = true
+		return nil, nil
+	})
+
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.False(t, handled)
+
+	// Exempt methods are still served
+	_, err = interceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: healthMethod},
+		func(context.Context, any) (any, error) { return nil, nil })
+	assert.NoError(t, err)
+
+	release(false)
+	_, err = interceptor(context.Background(), nil, info, func(context.Context, any) (any, error) { return nil, nil })
+	assert.NoError(t, err)
+}
+
+func TestUnaryServerInterceptor_Panic(t *testing.T) {
+	l := NewWithAlgorithm(&fixed{limit: 1})
+	interceptor := UnaryServerInterceptor(l)
+	info := &grpc.UnaryServerInfo{FullMethod: sendMethod}
+
+	// A recovery interceptor further out turns the panic into an error
+	assert.Panics(t, func() {
+		_, _ = interceptor(context.Background(), nil, info, func(context.Context, any) (any, error) {
+			panic("boom")
+		})
+	})
+
+	assert.Zero(t, l.InFlight(), "the panicking call must free its slot")
+	_, err := interceptor(context.Background(), nil, info, func(context.Context, any) (any, error) { return nil, nil })
+	assert.NoError(t, err)
+}
+
+func TestNewCollector(t *testing.T) {
+	l := NewWithAlgorithm(&fixed{limit: 1})
+	_, _ = l.Acquire()
+	_, _ = l.Acquire()
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewCollector("test", l))
+
+	families, err := registry.Gather()
+	require.NoError(t, err)
+
+	got := map[string]float64{}
+	for _, family := range families {
+		m := family.GetMetric()[0]
+		got[family.GetName()] = m.GetGauge().GetValue() + m.GetCounter().GetValue()
+	}
+	assert.Equal(t, map[string]float64{
+		"test_concurrency_limiter_limit":          1,
+		"test_concurrency_limiter_in_flight":      1,
+		"test_concurrency_limiter_rejected_total": 1,
+	}, got)
+}
@@ -0,0 +1,156 @@
+// Package concurrency limits how many calls a server handles at once. Rather
+// than a fixed number, the limit is estimated from the latency of recent
+// calls: it grows while calls are fast and shrinks when they slow down or
+// time out, so excess calls are rejected before they queue up.
+package concurrency
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Algorithm estimates the concurrency limit from completed calls. The
+// Limiter serializes calls to its methods.
+type Algorithm interface {
+	// Limit returns the current limit
+	Limit() int
+	// Update adjusts the limit after a call
+	Update(sample Sample)
+}
+
+// Sample describes a completed call
+type Sample struct {
+	// RTT is how long the call took
+	RTT time.Duration
+	// InFlight is how many calls were in flight when it started,
+	// including itself
+	InFlight int
+	// Dropped is set when the call timed out or was shed for overload
+	Dropped bool
+}
+
+// Kind names an Algorithm in configuration
+type Kind string
+
+const (
+	KindAIMD     Kind = "aimd"
+	KindGradient Kind = "gradient"
+)
+
+// Config configures a Limiter. Calls are not limited when Algorithm is
+// empty.
+type Config struct {
+	// Algorithm is "aimd" or "gradient"
+	Algorithm    Kind `mapstructure:"algorithm"`
+	InitialLimit int  `mapstructure:"initial_limit"`
+	MinLimit     int  `mapstructure:"min_limit"`
+	MaxLimit     int  `mapstructure:"max_limit"`
+	// Timeout is the latency above which AIMD counts a call as dropped
+	Timeout time.Duration `mapstructure:"timeout"`
+}
+
+// Enabled reports whether calls are limited
+func (c Config) Enabled() bool {
+	return c.Algorithm != ""
+}
+
+// Validate reports every problem with an enabled configuration
+func (c Config) Validate() error {
+	if !c.Enabled() {
+		return nil
+	}
+
+	var errs []error
+	if c.Algorithm != KindAIMD && c.Algorithm != KindGradient {
+		errs = append(errs, fmt.Errorf("algorithm %q is not aimd or gradient", c.Algorithm))
+	}
+	if c.MinLimit <= 0 {
+		errs = append(errs, errors.New("min_limit must be greater than 0"))
+	}
+	if c.MaxLimit < c.MinLimit {
+		errs = append(errs, errors.New("max_limit must not be less than min_limit"))
+	}
+	if c.InitialLimit < c.MinLimit || c.InitialLimit > c.MaxLimit {
+		errs = append(errs, errors.New("initial_limit must be between min_limit and max_limit"))
+	}
+	if c.Timeout < 0 {
+		errs = append(errs, errors.New("timeout must not be negative"))
+	}
+	return errors.Join(errs...)
+}
+
+// Limiter admits calls while fewer than its algorithm's limit are in
+// flight
+type Limiter struct {
+	mu        sync.Mutex
+	algorithm Algorithm
+	inFlight  int
+	rejected  uint64
+	now       func() time.Time
+}
+
+// New creates a Limiter with the algorithm cfg names
+func New(cfg Config) *Limiter {
+	if cfg.Algorithm == KindGradient {
+		return NewWithAlgorithm(NewGradient(cfg))
+	}
+	return NewWithAlgorithm(NewAIMD(cfg))
+}
+
+// NewWithAlgorithm creates a Limiter whose limit is set by algorithm
+func NewWithAlgorithm(algorithm Algorithm) *Limiter {
+	return &Limiter{
+		algorithm: algorithm,
+		now:       time.Now,
+	}
+}
+
+// Acquire admits a call, reporting false when the limit is reached. The
+// caller must call release when an admitted call completes, saying whether
+// it was dropped.
+func (l *Limiter) Acquire() (release func(dropped bool), ok bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.inFlight >= l.algorithm.Limit() {
+		l.rejected++
+		return nil, false
+	}
+	l.inFlight++
+
+	inFlight := l.inFlight
+	start := l.now()
+	var once sync.Once
+	return func(dropped bool) {
+		once.Do(func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+
+			l.inFlight--
+			l.algorithm.Update(Sample{RTT: l.now().Sub(start), InFlight: inFlight, Dropped: dropped})
+		})
+	}, true
+}
+
+// Limit returns how many calls may be in flight
+func (l *Limiter) Limit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.algorithm.Limit()
+}
+
+// InFlight returns how many calls are in flight
+func (l *Limiter) InFlight() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.inFlight
+}
+
+// Rejected returns how many calls were rejected
+func (l *Limiter) Rejected() uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.rejected
+}
@@ -0,0 +1,134 @@
+package concurrency
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fixed is an Algorithm with a limit that never changes
+type fixed struct {
+	limit   int
+	samples []Sample
+}
+
+func (f *fixed) Limit() int { return f.limit }
+
+func (f *fixed) Update(s Sample) { f.samples = append(f.samples, s) }
+
+func testConfig(kind Kind) Config {
+	return Config{Algorithm: kind, InitialLimit: 10, MinLimit: 2, MaxLimit: 20, Timeout: time.Second}
+}
+
+func TestLimiter_Acquire_Success(t *testing.T) {
+	algorithm := &fixed{limit: 2}
+	l := NewWithAlgorithm(algorithm)
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	l.now = func() time.Time { return now }
+
+	first, ok := l.Acquire()
+	require.True(t, ok)
+	second, ok := l.Acquire()
+	require.True(t, ok)
+	assert.Equal(t, 2, l.InFlight())
+
+	now = now.Add(50 * time.Millisecond)
+	first(false)
+	// Releasing twice does not free another slot
+	first(false)
+	second(true)
+
+	assert.Equal(t, 0, l.InFlight())
+	assert.Equal(t, []Sample{
+		{RTT: 50 * time.Millisecond, InFlight: 1},
+		{RTT: 50 * time.Millisecond, InFlight: 2, Dropped: true},
+	}, algorithm.samples)
+}
+
+func TestLimiter_Acquire_Fail(t *testing.T) {
+	l := NewWithAlgorithm(&fixed{limit: 1})
+
+	release, ok := l.Acquire()
+	require.True(t, ok)
+
+	_, ok = l.Acquire()
+	assert.False(t, ok)
+	assert.Equal(t, uint64(1), l.Rejected())
+
+	// A released slot can be used again
+	release(false)
+	_, ok = l.Acquire()
+	assert.True(t, ok)
+}
+
+func TestNew(t *testing.T) {
+	assert.IsType(t, &AIMD{}, New(testConfig(KindAIMD)).algorithm)
+	assert.IsType(t, &Gradient{}, New(testConfig(KindGradient)).algorithm)
+	assert.Equal(t, 10, New(testConfig(KindGradient)).Limit())
+}
+
+func TestConfig_Validate_Success(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+	}{
+		{name: "disabled", config: Config{}},
+		{name: "aimd", config: testConfig(KindAIMD)},
+		{name: "gradient", config: testConfig(KindGradient)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.NoError(t, tt.config.Validate())
+		})
+	}
+}
+
+func TestConfig_Validate_Fail(t *testing.T) {
+	tests := []struct {
+		name   string
+		config func(c *Config)
+		err    string
+	}{
+		{
+			name:   "unknown algorithm",
+			config: func(c *Config) { c.Algorithm = "vegas" },
+			err:    `algorithm "vegas" is not aimd or gradient`,
+		},
+		{
+			name:   "no minimum",
+			config: func(c *Config) { c.MinLimit = 0 },
+			err:    "min_limit must be greater than 0",
+		},
+		{
+			name:   "maximum below minimum",
+			config: func(c *Config) { c.MaxLimit = 1 },
+			err:    "max_limit must not be less than min_limit",
+		},
+		{
+			name:   "initial limit out of range",
+			config: func(c *Config) { c.InitialLimit = 30 },
+			err:    "initial_limit must be between min_limit and max_limit",
+		},
+		{
+			name:   "negative timeout",
+			config: func(c *Config) { c.Timeout = -time.Second },
+			err:    "timeout must not be negative",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := testConfig(KindAIMD)
+			tt.config(&config)
+
+			err := config.Validate()
+
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+}
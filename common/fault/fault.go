@@ -0,0 +1,121 @@
+// Package fault injects failures into a service on purpose, so the way its
+// clients cope with errors, slow calls and outages can be tested. Faults are
+// set and removed at runtime, and random ones are drawn from a seeded
+// source, so a test seeing a failure sees it again with the same seed.
+package fault
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Any is the target of faults that apply to every gRPC method
+const Any = "*"
+
+// maxCode is the highest status code gRPC defines
+const maxCode = codes.Unauthenticated
+
+// Fault describes a failure to inject into calls to a target
+type Fault struct {
+	// Name identifies the fault; setting a fault replaces the one with its
+	// name
+	Name string
+	// Target is what the fault applies to: a full gRPC method name such as
+	// "/email.v1.EmailService/SendEmail", Any, or a name the service
+	// injects faults into itself, such as its SMTP sender
+	Target string
+	// Code is the status code calls fail with. With codes.OK calls only
+	// get the latency.
+	Code codes.Code
+	// Message is the status message, "injected fault" when empty
+	Message string
+	// Latency delays calls before they fail or go ahead
+	Latency time.Duration
+	// Probability is the fraction of calls the fault applies to, from 0 to
+	// 1; 0 means every call
+	Probability float64
+	// Schedule limits when the fault is active
+	Schedule Schedule
+	// Seed seeds the fault's random source, the Injector's seed when 0
+	Seed int64
+}
+
+// Schedule says when a fault is active, counted from when it was set. The
+// zero Schedule is always active.
+type Schedule struct {
+	// After delays the first time the fault is active
+	After time.Duration
+	// Every repeats the fault; For must be set with it
+	Every time.Duration
+	// For is how long the fault stays active each time, until it is
+	// removed when 0
+	For time.Duration
+}
+
+// Validate reports every problem with f
+func (f Fault) Validate() error {
+	var errs []error
+	if f.Name == "" {
+		errs = append(errs, errors.New("name is required"))
+	}
+	if f.Target == "" {
+		errs = append(errs, errors.New("target is required"))
+	}
+	if f.Code > maxCode {
+		errs = append(errs, fmt.Errorf("code %d is not a gRPC status code", f.Code))
+	}
+	if f.Code == codes.OK && f.Latency <= 0 {
+		errs = append(errs, errors.New("a code other than OK or a latency is required"))
+	}
+	if f.Latency < 0 {
+		errs = append(errs, errors.New("latency must not be negative"))
+	}
+	if f.Probability < 0 || f.Probability > 1 {
+		errs = append(errs, errors.New("probability must be between 0 and 1"))
+	}
+	if err := f.Schedule.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// Validate reports every problem with s
+func (s Schedule) Validate() error {
+	var errs []error
+	if s.After < 0 || s.Every < 0 || s.For < 0 {
+		errs = append(errs, errors.New("schedule durations must not be negative"))
+	}
+	if s.Every > 0 && (s.For <= 0 || s.For > s.Every) {
+		errs = append(errs, errors.New("schedule for must be between 0 and every"))
+	}
+	return errors.Join(errs...)
+}
+
+// active reports whether the schedule is on elapsed after the fault was set
+func (s Schedule) active(elapsed time.Duration) bool {
+	if elapsed < s.After {
+		return false
+	}
+	elapsed -= s.After
+	if s.Every > 0 {
+		elapsed %= s.Every
+	}
+	return s.For == 0 || elapsed < s.For
+}
+
+// err is the error calls hit by f fail with, nil when f only adds latency
+func (f Fault) err() error {
+	if f.Code == codes.OK {
+		return nil
+	}
+
+	message := f.Message
+	if message == "" {
+		message = "injected fault"
+	}
+	return status.Error(f.Code, message)
+}
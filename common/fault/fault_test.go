@@ -0,0 +1,111 @@
+package fault
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+)
+
+func TestFault_Validate(t *testing.T) {
+	valid := Fault{Name: "down", Target: Any, Code: codes.Unavailable}
+
+	tests := []struct {
+		name   string
+		mutate func(f *Fault)
+		errs   []string
+	}{
+		{name: "valid", mutate: func(*Fault) {}},
+		{name: "latency only", mutate: func(f *Fault) { f.Code, f.Latency = codes.OK, time.Second }},
+		{
+			name:   "missing name and target",
+			mutate: func(f *Fault) { f.Name, f.Target = "", "" },
+			errs:   []string{"name is required", "target is required"},
+		},
+		{
+			name:   "unknown code",
+			mutate: func(f *Fault) { f.Code = 17 },
+			errs:   []string{"code 17 is not a gRPC status code"},
+		},
+		{
+			name:   "no effect",
+			mutate: func(f *Fault) { f.Code = codes.OK },
+			errs:   []string{"a code other than OK or a latency is required"},
+		},
+		{
+			name:   "probability out of range",
+			mutate: func(f *Fault) { f.Probability = 1.5 },
+			errs:   []string{"probability must be between 0 and 1"},
+		},
+		{
+			name:   "negative durations",
+			mutate: func(f *Fault) { f.Latency, f.Schedule.After = -time.Second, -time.Second },
+			errs:   []string{"latency must not be negative", "schedule durations must not be negative"},
+		},
+		{
+			name:   "repeating forever",
+			mutate: func(f *Fault) { f.Schedule.Every = time.Minute },
+			errs:   []string{"schedule for must be between 0 and every"},
+		},
+		{
+			name:   "longer than its period",
+			mutate: func(f *Fault) { f.Schedule = Schedule{Every: time.Minute, For: time.Hour} },
+			errs:   []string{"schedule for must be between 0 and every"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := valid
+			tt.mutate(&f)
+
+			err := f.Validate()
+
+			if len(tt.errs) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			for _, msg := range tt.errs {
+				assert.ErrorContains(t, err, msg)
+			}
+		})
+	}
+}
+
+func TestSchedule_active(t *testing.T) {
+	tests := []struct {
+		name     string
+		schedule Schedule
+		active   []time.Duration
+		inactive []time.Duration
+	}{
+		{
+			name:   "always",
+			active: []time.Duration{0, time.Hour},
+		},
+		{
+			name:     "once",
+			schedule: Schedule{After: time.Minute, For: 30 * time.Second},
+			active:   []time.Duration{time.Minute, 89 * time.Second},
+			inactive: []time.Duration{0, 59 * time.Second, 90 * time.Second, time.Hour},
+		},
+		{
+			name:     "repeating",
+			schedule: Schedule{After: 5 * time.Minute, Every: 5 * time.Minute, For: 30 * time.Second},
+			active:   []time.Duration{5 * time.Minute, 10*time.Minute + 29*time.Second},
+			inactive: []time.Duration{0, 4 * time.Minute, 5*time.Minute + 30*time.Second, 9 * time.Minute},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, elapsed := range tt.active {
+				assert.True(t, tt.schedule.active(elapsed), elapsed)
+			}
+			for _, elapsed := range tt.inactive {
+				assert.False(t, tt.schedule.active(elapsed), elapsed)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package fault
+
+import (
+	"context"
+	"slices"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+)
+
+// UnaryServerInterceptor injects i's faults into calls, targeting each by
+// its full method name. Calls to the exempt methods, such as health checks
+// and the admin methods that remove faults, are left alone.
+func UnaryServerInterceptor(i *Injector, exempt ...string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if slices.Contains(exempt, info.FullMethod) {
+			return handler(ctx, req)
+		}
+
+		if err := i.Inject(ctx, info.FullMethod); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// NewCollector exports how many calls each of i's faults was injected into
+// and whether it is active. Register it with the service's registry.
+func NewCollector(namespace string, i *Injector) prometheus.Collector {
+	return &collector{
+		injector: i,
+		injected: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "fault", "injected_total"),
+			"Total number of calls a fault was injected into",
+			[]string{"fault", "target"}, nil,
+		),
+		active: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "fault", "active"),
+			"Whether a fault is active (1) or not (0)",
+			[]string{"fault", "target"}, nil,
+		),
+	}
+}
+
+type collector struct {
+	injector *Injector
+	injected *prometheus.Desc
+	active   *prometheus.Desc
+}
+
+func (c *collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.injected
+	ch <- c.active
+}
+
+func (c *collector) Collect(ch chan<- prometheus.Metric) {
+	for _, state := range c.injector.List() {
+		var active float64
+		if state.Active {
+			active = 1
+		}
+		ch <- prometheus.MustNewConstMetric(c.injected, prometheus.CounterValue, float64(state.Injected), state.Name, state.Target)
+		ch <- prometheus.MustNewConstMetric(c.active, prometheus.GaugeValue, active, state.Name, state.Target)
+	}
+}
@@ -0,0 +1,67 @@
+package fault
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const healthMethod = "/grpc.health.v1.Health/Check"
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	tests := []struct {
+		name    string
+		method  string
+		code    codes.Code
+		handled bool
+	}{
+		{name: "fault injected", method: sendMethod, code: codes.Unavailable},
+		{name: "exempt method", method: healthMethod, handled: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i := NewInjector()
+			require.NoError(t, i.Set(Fault{Name: "down", Target: Any, Code: codes.Unavailable}))
+			interceptor := UnaryServerInterceptor(i, healthMethod)
+
+			handled := false
+			_, err := interceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: tt.method},
+				func(context.Context, any) (any, error) {
+					handled = true
+					return nil, nil
+				})
+
+			assert.Equal(t, tt.code, status.Code(err))
+			assert.Equal(t, tt.handled, handled)
+		})
+	}
+}
+
+func TestNewCollector(t *testing.T) {
+	i := NewInjector()
+	require.NoError(t, i.Set(Fault{Name: "down", Target: Any, Code: codes.Unavailable}))
+	_ = i.Inject(context.Background(), sendMethod)
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewCollector("test", i))
+
+	families, err := registry.Gather()
+	require.NoError(t, err)
+
+	got := map[string]float64{}
+	for _, family := range families {
+		m := family.GetMetric()[0]
+		got[family.GetName()] = m.GetGauge().GetValue() + m.GetCounter().GetValue()
+	}
+	assert.Equal(t, map[string]float64{
+		"test_fault_injected_total": 1,
+		"test_fault_active":         1,
+	}, got)
+}
@@ -0,0 +1,241 @@
+package fault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+)
+
+// ErrUnknownFault is returned for a fault name that is not set
+var ErrUnknownFault = errors.New("unknown fault")
+
+// State is a fault as it is set in an Injector
+type State struct {
+	Fault
+	// Active is set while the fault's schedule is on
+	Active bool
+	// Injected is how many calls the fault was injected into
+	Injected uint64
+}
+
+// Injector holds the faults set at runtime and injects them into calls.
+// Each fault draws from its own random source, seeded with the fault's seed
+// and name, so the calls a fault hits only depend on the order of the
+// calls to its target.
+type Injector struct {
+	mu     sync.Mutex
+	seed   int64
+	faults []*rule // sorted by name
+	now    func() time.Time
+}
+
+type rule struct {
+	Fault
+	set      time.Time
+	rand     *rand.Rand
+	injected uint64
+}
+
+// Option configures an Injector
+type Option func(*Injector)
+
+// WithSeed seeds the faults that have no seed of their own
+func WithSeed(seed int64) Option {
+	return func(i *Injector) {
+		i.seed = seed
+	}
+}
+
+// WithClock sets the clock schedules are followed with
+func WithClock(now func() time.Time) Option {
+	return func(i *Injector) {
+		i.now = now
+	}
+}
+
+// NewInjector creates an Injector with no faults. Without WithSeed it
+// picks a random seed, which Seed returns so a run can be repeated.
+func NewInjector(opts ...Option) *Injector {
+	i := &Injector{
+		seed: rand.Int64(),
+		now:  time.Now,
+	}
+	for _, opt := range opts {
+		opt(i)
+	}
+	return i
+}
+
+// Seed returns the seed faults without one of their own use
+func (i *Injector) Seed() int64 {
+	return i.seed
+}
+
+// Set adds f, replacing the fault with its name. Its schedule and random
+// source start over.
+func (i *Injector) Set(f Fault) error {
+	if err := f.Validate(); err != nil {
+		return fmt.Errorf("invalid fault: %w", err)
+	}
+
+	seed := f.Seed
+	if seed == 0 {
+		seed = i.seed
+	}
+	name := fnv.New64a()
+	_, _ = name.Write([]byte(f.Name))
+
+	r := &rule{
+		Fault: f,
+		set:   i.now(),
+		rand:  rand.New(rand.NewPCG(uint64(seed), name.Sum64())),
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	n, found := i.find(f.Name)
+	if found {
+		i.faults[n] = r
+	} else {
+		i.faults = slices.Insert(i.faults, n, r)
+	}
+	return nil
+}
+
+// Remove removes the named fault
+func (i *Injector) Remove(name string) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	n, found := i.find(name)
+	if !found {
+		return ErrUnknownFault
+	}
+	i.faults = slices.Delete(i.faults, n, n+1)
+	return nil
+}
+
+// Clear removes every fault
+func (i *Injector) Clear() {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.faults = nil
+}
+
+// List returns the faults set, by name
+func (i *Injector) List() []State {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	now := i.now()
+	states := make([]State, 0, len(i.faults))
+	for _, r := range i.faults {
+		states = append(states, r.state(now))
+	}
+	return states
+}
+
+// Get returns the named fault
+func (i *Injector) Get(name string) (State, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	n, found := i.find(name)
+	if !found {
+		return State{}, ErrUnknownFault
+	}
+	return i.faults[n].state(i.now()), nil
+}
+
+// Down reports whether every gRPC call fails with Unavailable right now,
+// as during a scheduled outage
+func (i *Injector) Down() bool {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	now := i.now()
+	for _, r := range i.faults {
+		if r.Target == Any && r.Code == codes.Unavailable && (r.Probability == 0 || r.Probability == 1) &&
+			r.Schedule.active(now.Sub(r.set)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Inject applies the active faults for target to a call, in name order:
+// it waits out their latency and returns the first error. Faults targeting
+// Any apply to every target that is a gRPC method.
+func (i *Injector) Inject(ctx context.Context, target string) error {
+	hits := i.hits(target)
+
+	for _, f := range hits {
+		if f.Latency > 0 {
+			timer := time.NewTimer(f.Latency)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			case <-timer.C:
+			}
+		}
+		if err := f.err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hits draws the faults that apply to a call to target
+func (i *Injector) hits(target string) []Fault {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	now := i.now()
+	var hits []Fault
+	for _, r := range i.faults {
+		if !r.matches(target) || !r.Schedule.active(now.Sub(r.set)) {
+			continue
+		}
+		// Each matching fault draws, so what a fault hits does not depend
+		// on whether the faults before it hit
+		if r.Probability > 0 && r.rand.Float64() >= r.Probability {
+			continue
+		}
+		r.injected++
+		hits = append(hits, r.Fault)
+	}
+	return hits
+}
+
+func (r *rule) state(now time.Time) State {
+	return State{
+		Fault:    r.Fault,
+		Active:   r.Schedule.active(now.Sub(r.set)),
+		Injected: r.injected,
+	}
+}
+
+// matches reports whether the rule applies to target
+func (r *rule) matches(target string) bool {
+	if r.Target == target {
+		return true
+	}
+	return r.Target == Any && strings.HasPrefix(target, "/")
+}
+
+// find returns where the named fault is or would be inserted
+func (i *Injector) find(name string) (int, bool) {
+	return slices.BinarySearchFunc(i.faults, name, func(r *rule, name string) int {
+		return strings.Compare(r.Name, name)
+	})
+}
@@ -0,0 +1,232 @@
+package fault
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	sendMethod   = "/email.v1.EmailService/SendEmail"
+	statusMethod = "/email.v1.EmailService/GetEmailStatus"
+)
+
+// draws records which of n calls to target fail
+func draws(t *testing.T, i *Injector, target string, n int) []bool {
+	t.Helper()
+
+	failed := make([]bool, n)
+	for call := range failed {
+		failed[call] = i.Inject(context.Background(), target) != nil
+	}
+	return failed
+}
+
+func TestInjector_Inject_Success(t *testing.T) {
+	tests := []struct {
+		name   string
+		faults []Fault
+		target string
+		code   codes.Code
+		msg    string
+	}{
+		{
+			name:   "no faults",
+			target: sendMethod,
+		},
+		{
+			name:   "method fault",
+			faults: []Fault{{Name: "send", Target: sendMethod, Code: codes.Internal, Message: "boom"}},
+			target: sendMethod,
+			code:   codes.Internal,
+			msg:    "boom",
+		},
+		{
+			name:   "other method",
+			faults: []Fault{{Name: "send", Target: sendMethod, Code: codes.Internal}},
+			target: statusMethod,
+		},
+		{
+			name:   "any method",
+			faults: []Fault{{Name: "down", Target: Any, Code: codes.Unavailable}},
+			target: statusMethod,
+			code:   codes.Unavailable,
+			msg:    "injected fault",
+		},
+		{
+			name:   "any does not reach smtp",
+			faults: []Fault{{Name: "down", Target: Any, Code: codes.Unavailable}},
+			target: "smtp",
+		},
+		{
+			name: "first fault by name wins",
+			faults: []Fault{
+				{Name: "b", Target: sendMethod, Code: codes.Internal},
+				{Name: "a", Target: Any, Code: codes.Unavailable},
+			},
+			target: sendMethod,
+			code:   codes.Unavailable,
+			msg:    "injected fault",
+		},
+		{
+			name:   "latency only",
+			faults: []Fault{{Name: "slow", Target: sendMethod, Latency: time.Millisecond}},
+			target: sendMethod,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i := NewInjector(WithSeed(1))
+			for _, f := range tt.faults {
+				require.NoError(t, i.Set(f))
+			}
+
+			err := i.Inject(context.Background(), tt.target)
+
+			assert.Equal(t, tt.code, status.Code(err))
+			if tt.code != codes.OK {
+				assert.Equal(t, tt.msg, status.Convert(err).Message())
+			}
+		})
+	}
+}
+
+func TestInjector_Inject_Latency(t *testing.T) {
+	i := NewInjector()
+	require.NoError(t, i.Set(Fault{Name: "slow", Target: sendMethod, Latency: 20 * time.Millisecond}))
+
+	start := time.Now()
+	require.NoError(t, i.Inject(context.Background(), sendMethod))
+	assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+
+	// Callers that give up stop waiting
+	require.NoError(t, i.Set(Fault{Name: "slow", Target: sendMethod, Latency: time.Hour}))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, i.Inject(ctx, sendMethod), context.DeadlineExceeded)
+}
+
+func TestInjector_Inject_Seeded(t *testing.T) {
+	flaky := Fault{Name: "flaky", Target: sendMethod, Code: codes.Unavailable, Probability: 0.5}
+
+	first := NewInjector(WithSeed(42))
+	require.NoError(t, first.Set(flaky))
+	second := NewInjector(WithSeed(42))
+	require.NoError(t, second.Set(flaky))
+	// Calls to other targets and other faults do not shift the draws
+	require.NoError(t, second.Set(Fault{Name: "other", Target: statusMethod, Code: codes.Internal, Probability: 0.5}))
+	draws(t, second, statusMethod, 10)
+
+	want := draws(t, first, sendMethod, 100)
+	assert.Equal(t, want, draws(t, second, sendMethod, 100))
+	assert.Contains(t, want, true)
+	assert.Contains(t, want, false)
+
+	// Setting the fault again starts it over
+	require.NoError(t, first.Set(flaky))
+	assert.Equal(t, want, draws(t, first, sendMethod, 100))
+
+	// Another seed draws differently, as does the fault's own seed
+	other := NewInjector(WithSeed(43))
+	require.NoError(t, other.Set(flaky))
+	assert.NotEqual(t, want, draws(t, other, sendMethod, 100))
+
+	seeded := flaky
+	seeded.Seed = 7
+	require.NoError(t, first.Set(seeded))
+	assert.NotEqual(t, want, draws(t, first, sendMethod, 100))
+}
+
+func TestInjector_Schedule(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	i := NewInjector(WithClock(func() time.Time { return now }))
+
+	require.NoError(t, i.Set(Fault{
+		Name:     "maintenance",
+		Target:   Any,
+		Code:     codes.Unavailable,
+		Schedule: Schedule{After: time.Minute, Every: time.Minute, For: 10 * time.Second},
+	}))
+
+	assert.False(t, i.Down())
+	assert.NoError(t, i.Inject(context.Background(), sendMethod))
+
+	now = now.Add(time.Minute)
+	assert.True(t, i.Down())
+	assert.Equal(t, codes.Unavailable, status.Code(i.Inject(context.Background(), sendMethod)))
+
+	now = now.Add(10 * time.Second)
+	assert.False(t, i.Down())
+	assert.NoError(t, i.Inject(context.Background(), sendMethod))
+
+	now = now.Add(50 * time.Second)
+	assert.True(t, i.Down())
+}
+
+func TestInjector_Down(t *testing.T) {
+	tests := []struct {
+		name  string
+		fault Fault
+		down  bool
+	}{
+		{name: "outage", fault: Fault{Name: "f", Target: Any, Code: codes.Unavailable}, down: true},
+		{name: "certain outage", fault: Fault{Name: "f", Target: Any, Code: codes.Unavailable, Probability: 1}, down: true},
+		{name: "flaky", fault: Fault{Name: "f", Target: Any, Code: codes.Unavailable, Probability: 0.5}},
+		{name: "one method", fault: Fault{Name: "f", Target: sendMethod, Code: codes.Unavailable}},
+		{name: "other code", fault: Fault{Name: "f", Target: Any, Code: codes.Internal}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i := NewInjector()
+			require.NoError(t, i.Set(tt.fault))
+
+			assert.Equal(t, tt.down, i.Down())
+		})
+	}
+}
+
+func TestInjector_Set_Fail(t *testing.T) {
+	i := NewInjector()
+
+	err := i.Set(Fault{Name: "broken", Target: Any})
+
+	assert.ErrorContains(t, err, "invalid fault")
+	assert.Empty(t, i.List())
+}
+
+func TestInjector_List(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	i := NewInjector(WithSeed(1), WithClock(func() time.Time { return now }))
+
+	later := Fault{Name: "later", Target: Any, Code: codes.Unavailable, Schedule: Schedule{After: time.Hour}}
+	send := Fault{Name: "send", Target: sendMethod, Code: codes.Internal}
+	require.NoError(t, i.Set(send))
+	require.NoError(t, i.Set(later))
+	draws(t, i, sendMethod, 2)
+
+	assert.Equal(t, []State{
+		{Fault: later},
+		{Fault: send, Active: true, Injected: 2},
+	}, i.List())
+
+	state, err := i.Get("send")
+	require.NoError(t, err)
+	assert.Equal(t, State{Fault: send, Active: true, Injected: 2}, state)
+
+	require.NoError(t, i.Remove("send"))
+	_, err = i.Get("send")
+	assert.ErrorIs(t, err, ErrUnknownFault)
+	assert.ErrorIs(t, i.Remove("send"), ErrUnknownFault)
+	assert.Len(t, i.List(), 1)
+
+	i.Clear()
+	assert.Empty(t, i.List())
+	assert.Equal(t, int64(1), i.Seed())
+}
@@ -0,0 +1,160 @@
+// Package health runs the named checks that decide whether a service is
+// alive and whether it is ready to serve traffic.
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Check returns an error when the component it checks is unhealthy
+type Check func(ctx context.Context) error
+
+// Registry holds a service's liveness and readiness checks. A service is
+// alive while it can make progress at all, and ready while it can serve
+// requests; a failing readiness check takes it out of load balancing
+// without restarting it.
+type Registry struct {
+	timeout time.Duration
+
+	mu        sync.RWMutex
+	liveness  map[string]Check
+	readiness map[string]Check
+}
+
+// NewRegistry creates an empty registry. Each check gets at most timeout
+// to finish.
+func NewRegistry(timeout time.Duration) *Registry {
+	return &Registry{
+		timeout:   timeout,
+		liveness:  make(map[string]Check),
+		readiness: make(map[string]Check),
+	}
+}
+
+// AddLiveness registers a check that must pass for the service to be alive.
+// A check registered under the same name replaces the previous one.
+func (r *Registry) AddLiveness(name string, check Check) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.liveness[name] = check
+}
+
+// AddReadiness registers a check that must pass for the service to be ready
+func (r *Registry) AddReadiness(name string, check Check) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.readiness[name] = check
+}
+
+// Liveness runs the liveness checks
+func (r *Registry) Liveness(ctx context.Context) Report {
+	r.mu.RLock()
+	checks := clone(r.liveness)
+	r.mu.RUnlock()
+
+	return r.run(ctx, checks)
+}
+
+// Readiness runs the liveness and readiness checks, as a service that is
+// not alive is not ready either
+func (r *Registry) Readiness(ctx context.Context) Report {
+	r.mu.RLock()
+	checks := clone(r.liveness)
+	for name, check := range r.readiness {
+		checks[name] = check
+	}
+	r.mu.RUnlock()
+
+	return r.run(ctx, checks)
+}
+
+// Watch runs the readiness checks every interval until ctx is done and
+// calls update with each report, e.g. to keep a grpc.health.v1 server
+// current. The first report is made immediately.
+func (r *Registry) Watch(ctx context.Context, interval time.Duration, update func(Report)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		update(r.Readiness(ctx))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// run runs checks concurrently, each within the registry's timeout
+func (r *Registry) run(ctx context.Context, checks map[string]Check) Report {
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		failures = make(map[string]error)
+	)
+
+	for name, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(ctx, r.timeout)
+			defer cancel()
+
+			if err := check(ctx); err != nil {
+				mu.Lock()
+				failures[name] = err
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	return Report{Checked: len(checks), Failures: failures}
+}
+
+func clone(checks map[string]Check) map[string]Check {
+	out := make(map[string]Check, len(checks))
+	for name, check := range checks {
+		out[name] = check
+	}
+	return out
+}
+
+// Report is the outcome of running a set of checks
+type Report struct {
+	// Checked is the number of checks that ran
+	Checked int
+	// Failures holds the error of each check that failed, by name
+	Failures map[string]error
+}
+
+// Healthy reports whether every check passed
+func (r Report) Healthy() bool {
+	return len(r.Failures) == 0
+}
+
+// Err joins the failures, sorted by check name, or returns nil
+func (r Report) Err() error {
+	if r.Healthy() {
+		return nil
+	}
+
+	names := make([]string, 0, len(r.Failures))
+	for name := range r.Failures {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	errs := make([]error, 0, len(names))
+	for _, name := range names {
+		errs = append(errs, fmt.Errorf("%s: %w", name, r.Failures[name]))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,161 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func pass(context.Context) error { return nil }
+
+func fail(context.Context) error { return errors.New("down") }
+
+// hang blocks until the check's deadline
+func hang(ctx context.Context) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestRegistry_Readiness_Success(t *testing.T) {
+	tests := []struct {
+		name      string
+		liveness  map[string]Check
+		readiness map[string]Check
+		checked   int
+	}{
+		{
+			name: "no checks",
+		},
+		{
+			name:      "all checks pass",
+			liveness:  map[string]Check{"process": pass},
+			readiness: map[string]Check{"repository": pass, "queue": pass},
+			checked:   3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRegistry(time.Second)
+			for name, check := range tt.liveness {
+				r.AddLiveness(name, check)
+			}
+			for name, check := range tt.readiness {
+				r.AddReadiness(name, check)
+			}
+
+			report := r.Readiness(context.Background())
+
+			assert.True(t, report.Healthy())
+			assert.NoError(t, report.Err())
+			assert.Equal(t, tt.checked, report.Checked)
+		})
+	}
+}
+
+func TestRegistry_Readiness_Fail(t *testing.T) {
+	tests := []struct {
+		name      string
+		liveness  map[string]Check
+		readiness map[string]Check
+		failed    []string
+		err       string
+	}{
+		{
+			name:      "readiness check fails",
+			readiness: map[string]Check{"repository": pass, "queue": fail},
+			failed:    []string{"queue"},
+			err:       "queue: down",
+		},
+		{
+			name:      "liveness check fails",
+			liveness:  map[string]Check{"process": fail},
+			readiness: map[string]Check{"repository": pass},
+			failed:    []string{"process"},
+			err:       "process: down",
+		},
+		{
+			name:      "check times out",
+			readiness: map[string]Check{"repository": hang},
+			failed:    []string{"repository"},
+			err:       "repository: context deadline exceeded",
+		},
+		{
+			name:      "failures are sorted by name",
+			readiness: map[string]Check{"queue": fail, "circuit_breaker": fail},
+			failed:    []string{"circuit_breaker", "queue"},
+			err:       "circuit_breaker: down\nqueue: down",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRegistry(10 * time.Millisecond)
+			for name, check := range tt.liveness {
+				r.AddLiveness(name, check)
+			}
+			for name, check := range tt.readiness {
+				r.AddReadiness(name, check)
+			}
+
+			report := r.Readiness(context.Background())
+
+			assert.False(t, report.Healthy())
+			for _, name := range tt.failed {
+				assert.Contains(t, report.Failures, name)
+			}
+			assert.Len(t, report.Failures, len(tt.failed))
+			assert.EqualError(t, report.Err(), tt.err)
+		})
+	}
+}
+
+func TestRegistry_Liveness_Success(t *testing.T) {
+	r := NewRegistry(time.Second)
+	r.AddLiveness("process", pass)
+	// Readiness checks do not affect liveness
+	r.AddReadiness("repository", fail)
+
+	report := r.Liveness(context.Background())
+
+	assert.True(t, report.Healthy())
+	assert.Equal(t, 1, report.Checked)
+}
+
+func TestRegistry_Watch_Success(t *testing.T) {
+	r := NewRegistry(time.Second)
+
+	healthy := make(chan bool, 1)
+	healthy <- false
+	r.AddReadiness("queue", func(context.Context) error {
+		ok := <-healthy
+		healthy <- true
+		if !ok {
+			return errors.New("full")
+		}
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	reports := make(chan Report)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		r.Watch(ctx, time.Millisecond, func(report Report) {
+			select {
+			case reports <- report:
+			case <-ctx.Done():
+			}
+		})
+	}()
+
+	require.False(t, (<-reports).Healthy())
+	require.True(t, (<-reports).Healthy())
+
+	cancel()
+	<-done
+}
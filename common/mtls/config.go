@@ -0,0 +1,27 @@
+// Package mtls builds TLS configurations for gRPC servers and clients from
+// certificate files that are reloaded when they change on disk, and checks
+// the SPIFFE IDs of peers.
+package mtls
+
+import "time"
+
+// Config names the files a service's TLS identity is read from. TLS is
+// only used when CertFile and KeyFile are set.
+type Config struct {
+	CertFile string `mapstructure:"cert_file"`
+	KeyFile  string `mapstructure:"key_file"`
+	// CAFile is the bundle peer certificates are verified against. Servers
+	// only ask for client certificates, i.e. use mutual TLS, when it is set.
+	CAFile string `mapstructure:"ca_file"`
+	// TrustDomain is the SPIFFE trust domain peers must belong to, e.g.
+	// "mailflow.local". When empty SPIFFE IDs are not checked and clients
+	// verify the server's host name instead.
+	TrustDomain string `mapstructure:"trust_domain"`
+	// ReloadInterval is how often the files are checked for changes
+	ReloadInterval time.Duration `mapstructure:"reload_interval"`
+}
+
+// Enabled reports whether TLS is configured
+func (c Config) Enabled() bool {
+	return c.CertFile != "" && c.KeyFile != ""
+}
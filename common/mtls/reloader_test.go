@@ -0,0 +1,252 @@
+package mtls
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/popeskul/mailflow/common/logger"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue writes a certificate for the SPIFFE ID and localhost into dir
+// and returns the Config pointing at it
+func (ca *testCA) issue(t *testing.T, dir, spiffeID string) Config {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	id, err := url.Parse(spiffeID)
+	require.NoError(t, err)
+
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		URIs:         []*url.URL{id},
+		DNSNames:     []string{"localhost"},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	cfg := Config{
+		CertFile:       filepath.Join(dir, "cert.pem"),
+		KeyFile:        filepath.Join(dir, "key.pem"),
+		CAFile:         filepath.Join(dir, "ca.pem"),
+		TrustDomain:    "mailflow.local",
+		ReloadInterval: 10 * time.Millisecond,
+	}
+	require.NoError(t, os.WriteFile(cfg.CertFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(cfg.KeyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), 0o600))
+	require.NoError(t, os.WriteFile(cfg.CAFile, ca.pem, 0o600))
+
+	return cfg
+}
+
+func newTestReloader(t *testing.T, cfg Config) *Reloader {
+	t.Helper()
+	r, err := NewReloader(cfg, logger.NewZapLogger(logger.WithOutputs(io.Discard)))
+	require.NoError(t, err)
+	return r
+}
+
+// handshake connects client to server over loopback and returns the
+// errors both sides saw
+func handshake(t *testing.T, server, client *tls.Config) (serverErr, clientErr error) {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer lis.Close()
+
+	done := make(chan error, 1)
+	go func() {
+		conn, err := lis.Accept()
+		if err != nil {
+			done <- err
+			return
+		}
+		defer conn.Close()
+		done <- tls.Server(conn, server).Handshake()
+	}()
+
+	client = client.Clone()
+	client.ServerName = "localhost"
+
+	conn, err := net.Dial("tcp", lis.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	clientErr = tls.Client(conn, client).Handshake()
+	return <-done, clientErr
+}
+
+func TestReloader_Handshake_Success(t *testing.T) {
+	ca := newTestCA(t)
+	server := newTestReloader(t, ca.issue(t, t.TempDir(), "spiffe://mailflow.local/email-service"))
+	client := newTestReloader(t, ca.issue(t, t.TempDir(), "spiffe://mailflow.local/user-service"))
+
+	tests := []struct {
+		name     string
+		allowed  []string
+		serverID string
+	}{
+		{name: "any peer in the trust domain"},
+		{
+			name:     "expected peers",
+			allowed:  []string{"spiffe://mailflow.local/user-service"},
+			serverID: "spiffe://mailflow.local/email-service",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			serverErr, clientErr := handshake(t, server.ServerTLS(tt.allowed...), client.ClientTLS(tt.serverID))
+
+			assert.NoError(t, serverErr)
+			assert.NoError(t, clientErr)
+		})
+	}
+}
+
+func TestReloader_Handshake_Fail(t *testing.T) {
+	ca := newTestCA(t)
+	server := newTestReloader(t, ca.issue(t, t.TempDir(), "spiffe://mailflow.local/email-service"))
+	client := newTestReloader(t, ca.issue(t, t.TempDir(), "spiffe://mailflow.local/user-service"))
+	foreign := newTestReloader(t, ca.issue(t, t.TempDir(), "spiffe://other.example/user-service"))
+	untrusted := newTestReloader(t, newTestCA(t).issue(t, t.TempDir(), "spiffe://mailflow.local/user-service"))
+
+	tests := []struct {
+		name   string
+		server *tls.Config
+		client *tls.Config
+	}{
+		{
+			name:   "client not allowed",
+			server: server.ServerTLS("spiffe://mailflow.local/admin-tool"),
+			client: client.ClientTLS(""),
+		},
+		{
+			name:   "unexpected server",
+			server: server.ServerTLS(),
+			client: client.ClientTLS("spiffe://mailflow.local/other-service"),
+		},
+		{
+			name:   "client from another trust domain",
+			server: server.ServerTLS(),
+			client: foreign.ClientTLS(""),
+		},
+		{
+			name:   "client signed by another CA",
+			server: server.ServerTLS(),
+			client: untrusted.ClientTLS(""),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			serverErr, clientErr := handshake(t, tt.server, tt.client)
+
+			assert.True(t, serverErr != nil || clientErr != nil, "handshake must fail")
+		})
+	}
+}
+
+func TestReloader_Run(t *testing.T) {
+	ca := newTestCA(t)
+	dir := t.TempDir()
+	r := newTestReloader(t, ca.issue(t, dir, "spiffe://mailflow.local/user-service"))
+	require.Equal(t, "spiffe://mailflow.local/user-service", r.ID())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go r.Run(ctx)
+
+	// A half-written rotation is ignored and the old certificate kept
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "cert.pem"), []byte("garbage"), 0o600))
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, "spiffe://mailflow.local/user-service", r.ID())
+
+	ca.issue(t, dir, "spiffe://mailflow.local/user-service-v2")
+
+	assert.Eventually(t, func() bool {
+		return r.ID() == "spiffe://mailflow.local/user-service-v2"
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestNewReloader_Fail(t *testing.T) {
+	cfg := newTestCA(t).issue(t, t.TempDir(), "spiffe://mailflow.local/user-service")
+
+	tests := []struct {
+		name   string
+		mutate func(*Config)
+	}{
+		{name: "missing certificate", mutate: func(c *Config) { c.CertFile = filepath.Join(t.TempDir(), "missing.pem") }},
+		{name: "key does not match", mutate: func(c *Config) { c.KeyFile = c.CAFile }},
+		{name: "missing CA bundle", mutate: func(c *Config) { c.CAFile = filepath.Join(t.TempDir(), "missing.pem") }},
+		{name: "empty CA bundle", mutate: func(c *Config) { c.CAFile = c.KeyFile }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := cfg
+			tt.mutate(&cfg)
+
+			_, err := NewReloader(cfg, logger.NewZapLogger(logger.WithOutputs(io.Discard)))
+
+			assert.Error(t, err)
+		})
+	}
+}
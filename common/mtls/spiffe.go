@@ -0,0 +1,44 @@
+package mtls
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+)
+
+// ErrPeerNotAllowed is returned when a peer's SPIFFE ID is not accepted
+var ErrPeerNotAllowed = errors.New("peer not allowed")
+
+// SPIFFEID returns the SPIFFE ID of a certificate: its only URI SAN, of
+// the form spiffe://<trust domain>/<path>.
+func SPIFFEID(cert *x509.Certificate) (*url.URL, error) {
+	if len(cert.URIs) != 1 {
+		return nil, fmt.Errorf("certificate has %d URI SANs, want one SPIFFE ID", len(cert.URIs))
+	}
+
+	id := cert.URIs[0]
+	if id.Scheme != "spiffe" || id.Host == "" || id.Port() != "" || id.User != nil ||
+		id.Path == "" || id.Path == "/" || id.RawQuery != "" || id.Fragment != "" {
+		return nil, fmt.Errorf("invalid SPIFFE ID %q", id)
+	}
+
+	return id, nil
+}
+
+// checkPeer accepts a peer whose SPIFFE ID is in trustDomain and, when
+// allowed is not empty, one of allowed
+func checkPeer(cert *x509.Certificate, trustDomain string, allowed []string) error {
+	id, err := SPIFFEID(cert)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrPeerNotAllowed, err)
+	}
+	if id.Host != trustDomain {
+		return fmt.Errorf("%w: %s is not in trust domain %s", ErrPeerNotAllowed, id, trustDomain)
+	}
+	if len(allowed) > 0 && !slices.Contains(allowed, id.String()) {
+		return fmt.Errorf("%w: %s", ErrPeerNotAllowed, id)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package mtls
+
+import (
+	"crypto/x509"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func certWithURIs(t *testing.T, uris ...string) *x509.Certificate {
+	t.Helper()
+	cert := &x509.Certificate{}
+	for _, uri := range uris {
+		u, err := url.Parse(uri)
+		require.NoError(t, err)
+		cert.URIs = append(cert.URIs, u)
+	}
+	return cert
+}
+
+func TestSPIFFEID_Success(t *testing.T) {
+	id, err := SPIFFEID(certWithURIs(t, "spiffe://mailflow.local/ns/default/sa/user-service"))
+
+	require.NoError(t, err)
+	assert.Equal(t, "mailflow.local", id.Host)
+	assert.Equal(t, "/ns/default/sa/user-service", id.Path)
+}
+
+func TestSPIFFEID_Fail(t *testing.T) {
+	tests := []struct {
+		name string
+		uris []string
+	}{
+		{name: "no URI SAN"},
+		{name: "two URI SANs", uris: []string{"spiffe://mailflow.local/a", "spiffe://mailflow.local/b"}},
+		{name: "not spiffe", uris: []string{"https://mailflow.local/user-service"}},
+		{name: "no path", uris: []string{"spiffe://mailflow.local"}},
+		{name: "port", uris: []string{"spiffe://mailflow.local:8443/user-service"}},
+		{name: "query", uris: []string{"spiffe://mailflow.local/user-service?x=1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := SPIFFEID(certWithURIs(t, tt.uris...))
+
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testScope = NewScope("users")
+
+func TestCodec_Decode_Success(t *testing.T) {
+	codec := NewCodec([]byte("secret"))
+
+	tests := []struct {
+		name   string
+		cursor Cursor
+	}{
+		{
+			name:   "uuid id",
+			cursor: Cursor{CreatedAt: time.Date(2024, 5, 1, 10, 30, 0, 123456789, time.UTC), ID: "0f8fad5b-d9cb-469f-a165-70867728950e"},
+		},
+		{
+			name:   "empty id",
+			cursor: Cursor{CreatedAt: time.Unix(0, 1)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cursor, err := codec.Decode(testScope, codec.Encode(testScope, tt.cursor))
+
+			require.NoError(t, err)
+			assert.True(t, tt.cursor.CreatedAt.Equal(cursor.CreatedAt))
+			assert.Equal(t, tt.cursor.ID, cursor.ID)
+		})
+	}
+}
+
+func TestCodec_Decode_Fail(t *testing.T) {
+	codec := NewCodec([]byte("secret"))
+	valid := codec.Encode(testScope, Cursor{CreatedAt: time.Now(), ID: "user-1"})
+
+	raw, err := base64.RawURLEncoding.DecodeString(valid)
+	require.NoError(t, err)
+	tampered := append([]byte(nil), raw...)
+	tampered[len(tampered)-macSize-1] ^= 1
+
+	other, err := NewEphemeralCodec()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{name: "raw id", token: "user-1"},
+		{name: "not base64", token: "!!!"},
+		{name: "too short", token: base64.RawURLEncoding.EncodeToString([]byte{version})},
+		{name: "tampered id", token: base64.RawURLEncoding.EncodeToString(tampered)},
+		{name: "other key", token: other.Encode(testScope, Cursor{CreatedAt: time.Now(), ID: "user-1"})},
+		{name: "other kind", token: codec.Encode(NewScope("emails"), Cursor{CreatedAt: time.Now(), ID: "user-1"})},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := codec.Decode(testScope, tt.token)
+
+			assert.ErrorIs(t, err, ErrInvalidToken)
+		})
+	}
+}
+
+func TestCodec_Decode_UnsupportedVersion(t *testing.T) {
+	codec := NewCodec([]byte("secret"))
+
+	payload := make([]byte, headerSize)
+	payload[0] = version + 1
+	token := base64.RawURLEncoding.EncodeToString(append(payload, codec.mac(payload)...))
+
+	_, err := codec.Decode(testScope, token)
+
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	assert.Contains(t, err.Error(), "unsupported version")
+}
+
+func TestNewScope(t *testing.T) {
+	tests := []struct {
+		name  string
+		other Scope
+	}{
+		{name: "other kind", other: NewScope("users", "desc", "sent")},
+		{name: "other order", other: NewScope("emails", "asc", "sent")},
+		{name: "other filter", other: NewScope("emails", "desc", "failed")},
+		{name: "params shifted", other: NewScope("emails", "descsent")},
+		{name: "no params", other: NewScope("emails")},
+	}
+
+	scope := NewScope("emails", "desc", "sent")
+	assert.Equal(t, scope, NewScope("emails", "desc", "sent"))
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.NotEqual(t, scope, tt.other)
+		})
+	}
+}
+
+func TestCursor_Order(t *testing.T) {
+	now := time.Now()
+	cursor := Cursor{CreatedAt: now, ID: "b"}
+
+	assert.True(t, cursor.After(now, "c"))
+	assert.True(t, cursor.After(now.Add(time.Nanosecond), "a"))
+	assert.False(t, cursor.After(now, "b"))
+	assert.True(t, cursor.Before(now, "a"))
+	assert.True(t, cursor.Before(now.Add(-time.Nanosecond), "z"))
+	assert.False(t, cursor.Before(now, "b"))
+}
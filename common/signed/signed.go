@@ -0,0 +1,57 @@
+// Package signed seals payloads into URL-safe tokens signed with
+// HMAC-SHA256, for links that work without a login but cannot be forged.
+package signed
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+)
+
+// ErrInvalidToken is returned for tokens that are malformed or carry a bad signature
+var ErrInvalidToken = errors.New("invalid signed token")
+
+// Signer signs payloads into tokens and opens them again
+type Signer struct {
+	secret []byte
+}
+
+func NewSigner(secret string) *Signer {
+	return &Signer{secret: []byte(secret)}
+}
+
+// Sign returns a token carrying payload and its signature
+func (s *Signer) Sign(payload []byte) string {
+	return base64.RawURLEncoding.EncodeToString(payload) + "." +
+		base64.RawURLEncoding.EncodeToString(s.mac(payload))
+}
+
+// Open verifies a token's signature and returns its payload
+func (s *Signer) Open(token string) ([]byte, error) {
+	encPayload, encSig, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encPayload)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(encSig)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	if !hmac.Equal(sig, s.mac(payload)) {
+		return nil, ErrInvalidToken
+	}
+	return payload, nil
+}
+
+func (s *Signer) mac(payload []byte) []byte {
+	h := hmac.New(sha256.New, s.secret)
+	h.Write(payload)
+	return h.Sum(nil)
+}
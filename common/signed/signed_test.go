@@ -0,0 +1,61 @@
+package signed
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSigner_Open_Success(t *testing.T) {
+	signer := NewSigner("secret")
+
+	tests := []struct {
+		name    string
+		payload []byte
+	}{
+		{name: "text", payload: []byte("newsletter\nalice@example.com")},
+		{name: "binary", payload: []byte{0, 1, 2, 0xff}},
+		{name: "empty", payload: []byte{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := signer.Sign(tt.payload)
+
+			payload, err := signer.Open(token)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.payload, payload)
+		})
+	}
+}
+
+func TestSigner_Open_Fail(t *testing.T) {
+	signer := NewSigner("secret")
+	valid := signer.Sign([]byte("payload"))
+	encPayload, encSig, _ := strings.Cut(valid, ".")
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{name: "empty", token: ""},
+		{name: "no signature", token: encPayload},
+		{name: "payload not base64", token: "!!!." + encSig},
+		{name: "signature not base64", token: encPayload + ".!!!"},
+		{name: "tampered payload", token: base64.RawURLEncoding.EncodeToString([]byte("Payload")) + "." + encSig},
+		{name: "other key", token: NewSigner("other").Sign([]byte("payload"))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload, err := signer.Open(tt.token)
+
+			assert.ErrorIs(t, err, ErrInvalidToken)
+			assert.Nil(t, payload)
+		})
+	}
+}
@@ -0,0 +1,84 @@
+// Package spool keeps items in a local file of JSON lines so that work a
+// service could not finish before shutdown survives a restart.
+package spool
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// File is a spool of T backed by a single file
+type File[T any] struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFile creates a spool backed by the file at path
+func NewFile[T any](path string) *File[T] {
+	return &File[T]{path: path}
+}
+
+// Persist appends items to the spool file
+func (s *File[T]) Persist(_ context.Context, items []T) (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open spool file: %w", err)
+	}
+	defer func() {
+		if closeErr := f.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("failed to close spool file: %w", closeErr)
+		}
+	}()
+
+	enc := json.NewEncoder(f)
+	for i, item := range items {
+		if err := enc.Encode(item); err != nil {
+			return fmt.Errorf("failed to write item %d to spool: %w", i, err)
+		}
+	}
+
+	return f.Sync()
+}
+
+// Load reads every spooled item and empties the spool
+func (s *File[T]) Load() ([]T, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.Open(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open spool file: %w", err)
+	}
+	defer f.Close()
+
+	var items []T
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var item T
+		if err := json.Unmarshal(scanner.Bytes(), &item); err != nil {
+			return nil, fmt.Errorf("failed to decode spooled item: %w", err)
+		}
+		items = append(items, item)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read spool file: %w", err)
+	}
+
+	if err := os.Remove(s.path); err != nil {
+		return nil, fmt.Errorf("failed to clear spool file: %w", err)
+	}
+
+	return items, nil
+}
@@ -0,0 +1,72 @@
+package spool
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type item struct {
+	ID   string
+	Tags []string
+}
+
+func TestFile_PersistAndLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		batches [][]*item
+	}{
+		{name: "empty"},
+		{
+			name:    "single batch",
+			batches: [][]*item{{{ID: "1", Tags: []string{"a"}}, {ID: "2"}}},
+		},
+		{
+			name:    "appends batches",
+			batches: [][]*item{{{ID: "1"}}, {{ID: "2"}, {ID: "3"}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewFile[*item](filepath.Join(t.TempDir(), "spool.jsonl"))
+
+			var expected []*item
+			for _, batch := range tt.batches {
+				require.NoError(t, s.Persist(context.Background(), batch))
+				expected = append(expected, batch...)
+			}
+
+			loaded, err := s.Load()
+			require.NoError(t, err)
+			assert.Equal(t, expected, loaded)
+
+			// Loading empties the spool
+			loaded, err = s.Load()
+			require.NoError(t, err)
+			assert.Empty(t, loaded)
+		})
+	}
+}
+
+func TestFile_Load_Fail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spool.jsonl")
+	require.NoError(t, os.WriteFile(path, []byte("not json\n"), 0o600))
+
+	loaded, err := NewFile[*item](path).Load()
+
+	assert.ErrorContains(t, err, "failed to decode spooled item")
+	assert.Nil(t, loaded)
+}
+
+func TestFile_Persist_Fail(t *testing.T) {
+	s := NewFile[*item](filepath.Join(t.TempDir(), "missing", "spool.jsonl"))
+
+	err := s.Persist(context.Background(), []*item{{ID: "1"}})
+
+	assert.ErrorContains(t, err, "failed to open spool file")
+}
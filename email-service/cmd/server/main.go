@@ -7,21 +7,36 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"slices"
 	"syscall"
-	"time"
 
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	grpchealth "google.golang.org/grpc/health"
+	healthgrpc "google.golang.org/grpc/health/grpc_health_v1"
 
+	"github.com/popeskul/mailflow/common/auth"
+	"github.com/popeskul/mailflow/common/concurrency"
+	"github.com/popeskul/mailflow/common/fault"
+	"github.com/popeskul/mailflow/common/health"
 	"github.com/popeskul/mailflow/common/logger"
+	"github.com/popeskul/mailflow/common/mtls"
+	"github.com/popeskul/mailflow/common/pagination"
+	"github.com/popeskul/mailflow/common/spool"
 	"github.com/popeskul/mailflow/common/tracing"
 	"github.com/popeskul/mailflow/email-service/internal/config"
+	"github.com/popeskul/mailflow/email-service/internal/domain"
 	grpc2 "github.com/popeskul/mailflow/email-service/internal/grpc"
 	"github.com/popeskul/mailflow/email-service/internal/metrics"
 	"github.com/popeskul/mailflow/email-service/internal/repositories/memory"
 	"github.com/popeskul/mailflow/email-service/internal/services"
 	"github.com/popeskul/mailflow/email-service/internal/smtp"
+	"github.com/popeskul/mailflow/email-service/internal/tracking"
+	adminv1 "github.com/popeskul/mailflow/email-service/pkg/api/admin/v1"
 	pb "github.com/popeskul/mailflow/email-service/pkg/api/email/v1"
+	healthpb "github.com/popeskul/mailflow/email-service/pkg/api/health"
 	"github.com/popeskul/ratelimiter"
 )
 
@@ -61,10 +76,54 @@ func main() {
 		)
 	}
 
-	repos := memory.NewRepositories(l)
-	emailSender := smtp.NewSMTPSender(cfg.Email.SMTP, l)
+	cursors := pagination.NewCodec([]byte(cfg.Pagination.CursorSecret))
+	if cfg.Pagination.CursorSecret == "" {
+		l.Warn("pagination.cursor_secret is not set, page tokens will not survive a restart")
+		if cursors, err = pagination.NewEphemeralCodec(); err != nil {
+			l.Fatal("failed to create cursor codec",
+				logger.Field{Key: "error", Value: err},
+			)
+		}
+	}
+
+	// Faults stand in for real failures in resilience tests; admins set
+	// them at runtime and maintenance is one on a schedule
+	var faultOpts []fault.Option
+	if cfg.Faults.Seed != 0 {
+		faultOpts = append(faultOpts, fault.WithSeed(cfg.Faults.Seed))
+	}
+	faults := fault.NewInjector(faultOpts...)
+	if cfg.Email.Maintenance.Enabled {
+		if err := faults.Set(cfg.Email.Maintenance.Fault()); err != nil {
+			l.Fatal("failed to schedule maintenance",
+				logger.Field{Key: "error", Value: err},
+			)
+		}
+	}
+	metrics.Registry.MustRegister(fault.NewCollector("email_service", faults))
+	l.Info("fault injection seeded",
+		logger.Field{Key: "seed", Value: faults.Seed()},
+	)
+
+	repos := memory.NewRepositories(cursors, l)
+	emailSender := smtp.NewFaultSender(smtp.NewSMTPSender(cfg.Email.SMTP, l), faults)
+
+	// Tracking stays off unless enabled in config; a nil tracker disables it
+	var (
+		tracker        services.Tracker
+		trackingSigner *tracking.Signer
+	)
+	if cfg.Email.Tracking.Enabled {
+		trackingSigner = tracking.NewSigner(cfg.Email.Tracking.Secret)
+		tracker = tracking.NewRewriter(trackingSigner, cfg.Email.Tracking.BaseURL)
+	}
+
+	var serviceOpts []services.Option
+	if cfg.Email.Dispatch.SpoolPath != "" {
+		serviceOpts = append(serviceOpts, services.WithSpool(spool.NewFile[*domain.Email](cfg.Email.Dispatch.SpoolPath)))
+	}
 
-	services := services.NewServices(repos, emailSender, limiter, emailMetrics, l)
+	services := services.NewServices(repos, emailSender, limiter, emailMetrics, cfg.Email.Dispatch, tracker, l, serviceOpts...)
 	emailServer := grpc2.NewEmailServer(services.Email(), emailMetrics, l)
 
 	tracingConfig := tracing.Config{
@@ -92,17 +151,85 @@ func main() {
 		}
 	}()
 
+	// Health checks must answer however busy or broken the server is
+	probes := []string{
+		healthpb.HealthService_Check_FullMethodName,
+		healthpb.HealthService_Liveness_FullMethodName,
+		healthpb.HealthService_Readiness_FullMethodName,
+		healthpb.HealthService_Healthz_FullMethodName,
+		healthgrpc.Health_Check_FullMethodName,
+	}
+
+	interceptors := []grpc.UnaryServerInterceptor{
+		grpc2.RecoveryInterceptor(l),
+		// TODO: Replace with NewServerHandler when available
+		// otelgrpc.UnaryServerInterceptor(),
+		grpc2.LoggingInterceptor(l),
+		grpc2.MetricsInterceptor(emailMetrics),
+	}
+	if cfg.Server.ConcurrencyLimit.Enabled() {
+		calls := concurrency.New(cfg.Server.ConcurrencyLimit)
+		metrics.Registry.MustRegister(concurrency.NewCollector("email_service", calls))
+		interceptors = append(interceptors, concurrency.UnaryServerInterceptor(calls, probes...))
+	}
+	if cfg.Auth.Secret != "" {
+		// Tokens are only checked here, so no TTL is needed
+		tokens := auth.NewTokens(cfg.Auth.Secret, 0)
+		interceptors = append(interceptors, grpc2.AuthInterceptor(tokens, grpc2.Policy))
+	} else {
+		l.Warn("auth.secret is not set, requests are not authenticated")
+	}
+	// Faults come last so injected latency holds a concurrency slot, and
+	// never block the admin methods that remove them
+	interceptors = append(interceptors, fault.UnaryServerInterceptor(faults, slices.Concat(probes, []string{
+		adminv1.AdminService_ListFaults_FullMethodName,
+		adminv1.AdminService_SetFault_FullMethodName,
+		adminv1.AdminService_RemoveFault_FullMethodName,
+		adminv1.AdminService_ClearFaults_FullMethodName,
+	})...))
+
 	opts := []grpc.ServerOption{
-		grpc.ChainUnaryInterceptor(
-			grpc2.RecoveryInterceptor(l),
-			// TODO: Replace with NewServerHandler when available
-			// otelgrpc.UnaryServerInterceptor(),
-			grpc2.LoggingInterceptor(l),
-			grpc2.MetricsInterceptor(emailMetrics),
-		),
+		grpc.ChainUnaryInterceptor(interceptors...),
+	}
+	if cfg.TLS.Enabled() {
+		certs, err := mtls.NewReloader(cfg.TLS.Config, l)
+		if err != nil {
+			l.Fatal("failed to load tls certificates",
+				logger.Field{Key: "error", Value: err},
+			)
+		}
+		go certs.Run(context.Background())
+		opts = append(opts, grpc.Creds(credentials.NewTLS(certs.ServerTLS(cfg.TLS.AllowedClients...))))
+	} else {
+		l.Warn("tls is not configured, grpc traffic is not encrypted")
 	}
 	server := grpc.NewServer(opts...)
 	pb.RegisterEmailServiceServer(server, emailServer)
+	if cfg.Faults.AdminEnabled {
+		adminv1.RegisterAdminServiceServer(server, grpc2.NewAdminServer(faults, l))
+	}
+
+	// Ready while the repositories answer and faults do not fail every call,
+	// as during maintenance
+	checks := health.NewRegistry(cfg.Health.CheckTimeout)
+	checks.AddReadiness("repository", repos.Ping)
+	checks.AddReadiness("faults", func(context.Context) error {
+		if faults.Down() {
+			return errors.New("every call fails with injected faults")
+		}
+		return nil
+	})
+	healthServer := grpc2.NewHealthServer(checks, l)
+	healthpb.RegisterHealthServiceServer(server, healthServer)
+
+	// Not serving until the first readiness report
+	grpcHealth := grpchealth.NewServer()
+	grpcHealth.SetServingStatus("", healthgrpc.HealthCheckResponse_NOT_SERVING)
+	healthgrpc.RegisterHealthServer(server, grpcHealth)
+
+	healthCtx, stopHealth := context.WithCancel(context.Background())
+	defer stopHealth()
+	go grpc2.WatchHealth(healthCtx, checks, grpcHealth, cfg.Health.Interval)
 
 	lis, err := net.Listen("tcp", cfg.Server.GRPCPort)
 	if err != nil {
@@ -124,9 +251,20 @@ func main() {
 		}
 	}()
 
+	// The metrics port also serves the health checks over HTTP for probes
+	gateway := runtime.NewServeMux()
+	if err := healthpb.RegisterHealthServiceHandlerServer(context.Background(), gateway, healthServer); err != nil {
+		l.Fatal("failed to register health gateway",
+			logger.Field{Key: "error", Value: err},
+		)
+	}
+	metricsMux := http.NewServeMux()
+	metricsMux.Handle("/metrics", promhttp.HandlerFor(metrics.Registry, promhttp.HandlerOpts{}))
+	metricsMux.Handle("/v1/", gateway)
+
 	metricsServer := &http.Server{
 		Addr:    cfg.Monitor.MetricsPort,
-		Handler: promhttp.HandlerFor(metrics.Registry, promhttp.HandlerOpts{}),
+		Handler: metricsMux,
 	}
 
 	go func() {
@@ -141,13 +279,24 @@ func main() {
 		}
 	}()
 
-	// Run shutdown simulation if enabled
-	if cfg.Email.Maintenance.Enabled {
-		go simulateDowntime(
-			emailServer,
-			cfg.Email.Maintenance,
-			l,
-		)
+	var trackingServer *http.Server
+	if cfg.Email.Tracking.Enabled {
+		trackingServer = &http.Server{
+			Addr:    cfg.Email.Tracking.HTTPPort,
+			Handler: tracking.NewHandler(trackingSigner, services.Email(), l),
+		}
+
+		go func() {
+			l.Info("starting tracking server",
+				logger.Field{Key: "port", Value: cfg.Email.Tracking.HTTPPort},
+			)
+			if err := trackingServer.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+				l.Fatal("failed to serve tracking",
+					logger.Field{Key: "error", Value: err},
+					logger.Field{Key: "port", Value: cfg.Email.Tracking.HTTPPort},
+				)
+			}
+		}()
 	}
 
 	sigCh := make(chan os.Signal, 1)
@@ -156,10 +305,46 @@ func main() {
 
 	l.Info("initiating graceful shutdown")
 
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
 	defer cancel()
 
-	server.GracefulStop()
+	// Tell load balancers to stop sending traffic before the server stops
+	grpcHealth.Shutdown()
+	stopHealth()
+
+	// Stop taking new requests first, then let the workers drain what was accepted.
+	// Calls still running at the deadline are cut off.
+	stopped := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		l.Warn("grpc server did not stop before deadline, closing open calls")
+		server.Stop()
+	}
+
+	report, err := services.Email().Shutdown(ctx)
+	if err != nil {
+		l.Error("failed to drain email queue",
+			logger.Field{Key: "error", Value: err},
+		)
+	}
+	l.Info("email queue shutdown report",
+		logger.Field{Key: "drained", Value: report.Drained},
+		logger.Field{Key: "pending", Value: report.Pending},
+		logger.Field{Key: "dropped", Value: report.Dropped},
+	)
+	if trackingServer != nil {
+		if err := trackingServer.Shutdown(ctx); err != nil {
+			l.Error("failed to shutdown tracking server",
+				logger.Field{Key: "error", Value: err},
+				logger.Field{Key: "port", Value: cfg.Email.Tracking.HTTPPort},
+			)
+		}
+	}
 	if err := metricsServer.Shutdown(ctx); err != nil {
 		l.Error("failed to shutdown metrics server",
 			logger.Field{Key: "error", Value: err},
@@ -169,22 +354,3 @@ func main() {
 
 	l.Info("service stopped")
 }
-
-func simulateDowntime(
-	server *grpc2.EmailServer,
-	maintenance config.MaintenanceConfig,
-	l logger.Logger,
-) {
-	ticker := time.NewTicker(maintenance.Frequency)
-	defer ticker.Stop()
-
-	for range ticker.C {
-		l.Info("service is going down for maintenance",
-			logger.Field{Key: "duration", Value: maintenance.DowntimePeriod},
-		)
-		server.SetDowntime(true)
-		time.Sleep(maintenance.DowntimePeriod)
-		server.SetDowntime(false)
-		l.Info("service is back up")
-	}
-}
@@ -7,27 +7,40 @@ import (
 	"time"
 
 	"github.com/spf13/viper"
+	"google.golang.org/grpc/codes"
 
+	"github.com/popeskul/mailflow/common/concurrency"
+	"github.com/popeskul/mailflow/common/fault"
 	"github.com/popeskul/mailflow/common/logger"
+	"github.com/popeskul/mailflow/common/mtls"
 )
 
 type Config struct {
-	Server  ServerConfig           `mapstructure:"server"`
-	Email   EmailConfig            `mapstructure:"email"`
-	Monitor MonitorConfig          `mapstructure:"monitor"`
-	Trace   TraceConfig            `mapstructure:"trace"`
-	Log     logger.UnmarshalConfig `mapstructure:"logger"`
+	Server     ServerConfig           `mapstructure:"server"`
+	Email      EmailConfig            `mapstructure:"email"`
+	Monitor    MonitorConfig          `mapstructure:"monitor"`
+	Trace      TraceConfig            `mapstructure:"trace"`
+	Pagination PaginationConfig       `mapstructure:"pagination"`
+	Auth       AuthConfig             `mapstructure:"auth"`
+	TLS        TLSConfig              `mapstructure:"tls"`
+	Health     HealthConfig           `mapstructure:"health"`
+	Faults     FaultsConfig           `mapstructure:"faults"`
+	Log        logger.UnmarshalConfig `mapstructure:"logger"`
 }
 
 type ServerConfig struct {
 	GRPCPort        string        `mapstructure:"grpc_port"`
 	ShutdownTimeout time.Duration `mapstructure:"shutdown_timeout"`
+	// ConcurrencyLimit rejects calls beyond an adaptive concurrency limit
+	ConcurrencyLimit concurrency.Config `mapstructure:"concurrency_limit"`
 }
 
 type EmailConfig struct {
 	SMTP        SMTPConfig        `mapstructure:"smtp"`
 	RateLimit   RateLimitConfig   `mapstructure:"rate_limit"`
 	Maintenance MaintenanceConfig `mapstructure:"maintenance"`
+	Dispatch    DispatchConfig    `mapstructure:"dispatch"`
+	Tracking    TrackingConfig    `mapstructure:"tracking"`
 }
 
 type SMTPConfig struct {
@@ -44,12 +57,96 @@ type RateLimitConfig struct {
 	MaxBurst        int `mapstructure:"max_burst"`
 }
 
+// MaintenanceConfig takes the service down for DowntimePeriod every
+// Frequency, starting Frequency after it starts
 type MaintenanceConfig struct {
 	Enabled        bool          `mapstructure:"enabled"`
 	Frequency      time.Duration `mapstructure:"frequency"`
 	DowntimePeriod time.Duration `mapstructure:"downtime_period"`
 }
 
+// Fault is the fault that makes every call fail during maintenance
+func (c MaintenanceConfig) Fault() fault.Fault {
+	return fault.Fault{
+		Name:    "maintenance",
+		Target:  fault.Any,
+		Code:    codes.Unavailable,
+		Message: "service is in maintenance mode",
+		Schedule: fault.Schedule{
+			After: c.Frequency,
+			Every: c.Frequency,
+			For:   c.DowntimePeriod,
+		},
+	}
+}
+
+// DispatchConfig controls the worker pool that delivers accepted emails.
+type DispatchConfig struct {
+	// Workers is the number of concurrent delivery workers
+	Workers int `mapstructure:"workers"`
+	// QueueSize is the number of accepted emails that may wait for a free worker
+	QueueSize int `mapstructure:"queue_size"`
+	// SubmitTimeout is how long SendEmail waits for queue space before rejecting
+	SubmitTimeout time.Duration `mapstructure:"submit_timeout"`
+	// SpoolPath is the file emails not delivered at shutdown are saved to
+	// and sent from at startup. When empty they are dropped.
+	SpoolPath string `mapstructure:"spool_path"`
+}
+
+// TrackingConfig controls open and click tracking. Requests may only opt in
+// to tracking when it is enabled here.
+type TrackingConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// HTTPPort is where the tracking endpoint listens
+	HTTPPort string `mapstructure:"http_port"`
+	// BaseURL is the public address recipients reach the endpoint at
+	BaseURL string `mapstructure:"base_url"`
+	// Secret signs the tracking tokens embedded in emails
+	Secret string `mapstructure:"secret"`
+}
+
+// PaginationConfig controls the page tokens returned by list APIs
+type PaginationConfig struct {
+	// CursorSecret signs page tokens. When empty a random key is used, so
+	// tokens stop working after a restart.
+	CursorSecret string `mapstructure:"cursor_secret"`
+}
+
+// AuthConfig controls who may call the service. Calls are only
+// authenticated when Secret is set.
+type AuthConfig struct {
+	// Secret checks the access tokens issued by the user service and must
+	// match its auth.secret
+	Secret string `mapstructure:"secret"`
+}
+
+// TLSConfig secures the gRPC server. It serves plaintext unless enabled.
+type TLSConfig struct {
+	mtls.Config `mapstructure:",squash"`
+	// AllowedClients are the SPIFFE IDs that may call the server, e.g.
+	// "spiffe://mailflow.local/user-service". When empty any ID in the
+	// trust domain is accepted.
+	AllowedClients []string `mapstructure:"allowed_clients"`
+}
+
+// HealthConfig controls the readiness checks
+type HealthConfig struct {
+	// CheckTimeout is how long a single check may take
+	CheckTimeout time.Duration `mapstructure:"check_timeout"`
+	// Interval is how often the grpc.health.v1 status is refreshed
+	Interval time.Duration `mapstructure:"interval"`
+}
+
+// FaultsConfig controls the faults injected for resilience testing, which
+// admins set at runtime
+type FaultsConfig struct {
+	// Seed makes random faults repeatable; a random seed is used when 0
+	Seed int64 `mapstructure:"seed"`
+	// AdminEnabled serves the AdminService that sets faults. It lets
+	// callers fail every call, so it requires auth.
+	AdminEnabled bool `mapstructure:"admin_enabled"`
+}
+
 type MonitorConfig struct {
 	MetricsPort string `mapstructure:"metrics_port"`
 }
@@ -81,6 +178,11 @@ func LoadConfig() (*Config, error) {
 func setDefaultConfig() {
 	viper.SetDefault("server.grpc_port", ":50052")
 	viper.SetDefault("server.shutdown_timeout", "30s")
+	viper.SetDefault("server.concurrency_limit.algorithm", string(concurrency.KindGradient))
+	viper.SetDefault("server.concurrency_limit.initial_limit", 20)
+	viper.SetDefault("server.concurrency_limit.min_limit", 5)
+	viper.SetDefault("server.concurrency_limit.max_limit", 200)
+	viper.SetDefault("server.concurrency_limit.timeout", "1s")
 
 	viper.SetDefault("email.smtp.enabled", false)
 	viper.SetDefault("email.rate_limit.emails_per_minute", 60)
@@ -88,6 +190,20 @@ func setDefaultConfig() {
 	viper.SetDefault("email.maintenance.enabled", true)
 	viper.SetDefault("email.maintenance.frequency", "5m")
 	viper.SetDefault("email.maintenance.downtime_period", "30s")
+	viper.SetDefault("email.dispatch.workers", 4)
+	viper.SetDefault("email.dispatch.queue_size", 1000)
+	viper.SetDefault("email.dispatch.submit_timeout", "100ms")
+	viper.SetDefault("email.tracking.enabled", false)
+	viper.SetDefault("email.tracking.http_port", ":8083")
+	viper.SetDefault("email.tracking.base_url", "http://localhost:8083")
+
+	viper.SetDefault("tls.reload_interval", "30s")
+
+	viper.SetDefault("health.check_timeout", "2s")
+	viper.SetDefault("health.interval", "5s")
+
+	viper.SetDefault("faults.seed", 0)
+	viper.SetDefault("faults.admin_enabled", false)
 
 	viper.SetDefault("monitor.metrics_port", ":9102")
 
@@ -108,6 +224,9 @@ func validateConfig(config *Config) error {
 	if config.Server.GRPCPort == "" {
 		errors = append(errors, "server.grpc_port is required")
 	}
+	if err := config.Server.ConcurrencyLimit.Validate(); err != nil {
+		errors = append(errors, fmt.Sprintf("server.concurrency_limit: %v", err))
+	}
 
 	if config.Email.SMTP.Enabled {
 		if config.Email.SMTP.Host == "" {
@@ -128,6 +247,54 @@ func validateConfig(config *Config) error {
 		errors = append(errors, "email.rate_limit.max_burst must be greater than 0")
 	}
 
+	if config.Email.Maintenance.Enabled {
+		if err := config.Email.Maintenance.Fault().Validate(); err != nil {
+			errors = append(errors, fmt.Sprintf("email.maintenance: %v", err))
+		}
+	}
+
+	if config.Faults.AdminEnabled && config.Auth.Secret == "" {
+		errors = append(errors, "auth.secret is required when faults.admin_enabled is set")
+	}
+
+	if config.Email.Dispatch.Workers <= 0 {
+		errors = append(errors, "email.dispatch.workers must be greater than 0")
+	}
+	if config.Email.Dispatch.QueueSize <= 0 {
+		errors = append(errors, "email.dispatch.queue_size must be greater than 0")
+	}
+	if config.Email.Dispatch.SubmitTimeout < 0 {
+		errors = append(errors, "email.dispatch.submit_timeout must not be negative")
+	}
+	if config.Email.Tracking.Enabled {
+		if config.Email.Tracking.HTTPPort == "" {
+			errors = append(errors, "email.tracking.http_port is required when tracking is enabled")
+		}
+		if config.Email.Tracking.BaseURL == "" {
+			errors = append(errors, "email.tracking.base_url is required when tracking is enabled")
+		}
+		if config.Email.Tracking.Secret == "" {
+			errors = append(errors, "email.tracking.secret is required when tracking is enabled")
+		}
+	}
+
+	if (config.TLS.CertFile == "") != (config.TLS.KeyFile == "") {
+		errors = append(errors, "tls.cert_file and tls.key_file must be set together")
+	}
+	if config.TLS.Enabled() && config.TLS.ReloadInterval <= 0 {
+		errors = append(errors, "tls.reload_interval must be greater than 0")
+	}
+	if config.TLS.TrustDomain != "" && config.TLS.CAFile == "" {
+		errors = append(errors, "tls.ca_file is required when tls.trust_domain is set")
+	}
+
+	if config.Health.CheckTimeout <= 0 {
+		errors = append(errors, "health.check_timeout must be greater than 0")
+	}
+	if config.Health.Interval <= 0 {
+		errors = append(errors, "health.interval must be greater than 0")
+	}
+
 	if config.Monitor.MetricsPort == "" {
 		errors = append(errors, "monitor.metrics_port is required")
 	}
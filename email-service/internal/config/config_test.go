@@ -7,6 +7,9 @@ import (
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/popeskul/mailflow/common/concurrency"
+	"github.com/popeskul/mailflow/common/mtls"
 )
 
 func TestLoadConfig_Default(t *testing.T) {
@@ -29,6 +32,19 @@ func TestLoadConfig_Default(t *testing.T) {
 	assert.True(t, config.Email.Maintenance.Enabled)
 	assert.Equal(t, 5*time.Minute, config.Email.Maintenance.Frequency)
 	assert.Equal(t, 30*time.Second, config.Email.Maintenance.DowntimePeriod)
+	assert.Equal(t, 4, config.Email.Dispatch.Workers)
+	assert.Equal(t, 1000, config.Email.Dispatch.QueueSize)
+	assert.Equal(t, 100*time.Millisecond, config.Email.Dispatch.SubmitTimeout)
+	assert.False(t, config.Email.Tracking.Enabled)
+	assert.Equal(t, ":8083", config.Email.Tracking.HTTPPort)
+
+	// Check default health config
+	assert.Equal(t, 2*time.Second, config.Health.CheckTimeout)
+	assert.Equal(t, 5*time.Second, config.Health.Interval)
+
+	// Faults are seeded randomly by default and cannot be set at runtime
+	assert.Zero(t, config.Faults.Seed)
+	assert.False(t, config.Faults.AdminEnabled)
 
 	// Check default monitor config
 	assert.Equal(t, ":9102", config.Monitor.MetricsPort)
@@ -64,10 +80,18 @@ func TestValidateConfig_Success(t *testing.T) {
 						EmailsPerMinute: 60,
 						MaxBurst:        10,
 					},
+					Dispatch: DispatchConfig{
+						Workers:   4,
+						QueueSize: 1000,
+					},
 				},
 				Monitor: MonitorConfig{
 					MetricsPort: ":9102",
 				},
+				Health: HealthConfig{
+					CheckTimeout: 2 * time.Second,
+					Interval:     5 * time.Second,
+				},
 			},
 		},
 		{
@@ -87,10 +111,18 @@ func TestValidateConfig_Success(t *testing.T) {
 						EmailsPerMinute: 60,
 						MaxBurst:        10,
 					},
+					Dispatch: DispatchConfig{
+						Workers:   4,
+						QueueSize: 1000,
+					},
 				},
 				Monitor: MonitorConfig{
 					MetricsPort: ":9102",
 				},
+				Health: HealthConfig{
+					CheckTimeout: 2 * time.Second,
+					Interval:     5 * time.Second,
+				},
 			},
 		},
 	}
@@ -117,6 +149,10 @@ func TestValidateConfig_Fail(t *testing.T) {
 						EmailsPerMinute: 60,
 						MaxBurst:        10,
 					},
+					Dispatch: DispatchConfig{
+						Workers:   4,
+						QueueSize: 1000,
+					},
 				},
 				Monitor: MonitorConfig{
 					MetricsPort: ":9102",
@@ -124,6 +160,34 @@ func TestValidateConfig_Fail(t *testing.T) {
 			},
 			expectedError: "server.grpc_port is required",
 		},
+		{
+			name: "unknown concurrency limit algorithm",
+			config: &Config{
+				Server: ServerConfig{
+					GRPCPort: ":50052",
+					ConcurrencyLimit: concurrency.Config{
+						Algorithm:    "vegas",
+						InitialLimit: 20,
+						MinLimit:     5,
+						MaxLimit:     200,
+					},
+				},
+				Email: EmailConfig{
+					RateLimit: RateLimitConfig{
+						EmailsPerMinute: 60,
+						MaxBurst:        10,
+					},
+					Dispatch: DispatchConfig{
+						Workers:   4,
+						QueueSize: 1000,
+					},
+				},
+				Monitor: MonitorConfig{
+					MetricsPort: ":9102",
+				},
+			},
+			expectedError: `server.concurrency_limit: algorithm "vegas" is not aimd or gradient`,
+		},
 		{
 			name: "SMTP enabled but missing host",
 			config: &Config{
@@ -140,6 +204,10 @@ func TestValidateConfig_Fail(t *testing.T) {
 						EmailsPerMinute: 60,
 						MaxBurst:        10,
 					},
+					Dispatch: DispatchConfig{
+						Workers:   4,
+						QueueSize: 1000,
+					},
 				},
 				Monitor: MonitorConfig{
 					MetricsPort: ":9102",
@@ -163,6 +231,10 @@ func TestValidateConfig_Fail(t *testing.T) {
 						EmailsPerMinute: 60,
 						MaxBurst:        10,
 					},
+					Dispatch: DispatchConfig{
+						Workers:   4,
+						QueueSize: 1000,
+					},
 				},
 				Monitor: MonitorConfig{
 					MetricsPort: ":9102",
@@ -186,6 +258,10 @@ func TestValidateConfig_Fail(t *testing.T) {
 						EmailsPerMinute: 60,
 						MaxBurst:        10,
 					},
+					Dispatch: DispatchConfig{
+						Workers:   4,
+						QueueSize: 1000,
+					},
 				},
 				Monitor: MonitorConfig{
 					MetricsPort: ":9102",
@@ -240,11 +316,201 @@ func TestValidateConfig_Fail(t *testing.T) {
 						EmailsPerMinute: 60,
 						MaxBurst:        10,
 					},
+					Dispatch: DispatchConfig{
+						Workers:   4,
+						QueueSize: 1000,
+					},
 				},
 				Monitor: MonitorConfig{},
 			},
 			expectedError: "monitor.metrics_port is required",
 		},
+		{
+			name: "invalid dispatch workers",
+			config: &Config{
+				Server: ServerConfig{
+					GRPCPort: ":50052",
+				},
+				Email: EmailConfig{
+					RateLimit: RateLimitConfig{
+						EmailsPerMinute: 60,
+						MaxBurst:        10,
+					},
+					Dispatch: DispatchConfig{
+						Workers:   0,
+						QueueSize: 1000,
+					},
+				},
+				Monitor: MonitorConfig{
+					MetricsPort: ":9102",
+				},
+			},
+			expectedError: "email.dispatch.workers must be greater than 0",
+		},
+		{
+			name: "invalid dispatch queue size",
+			config: &Config{
+				Server: ServerConfig{
+					GRPCPort: ":50052",
+				},
+				Email: EmailConfig{
+					RateLimit: RateLimitConfig{
+						EmailsPerMinute: 60,
+						MaxBurst:        10,
+					},
+					Dispatch: DispatchConfig{
+						Workers:   4,
+						QueueSize: 0,
+					},
+				},
+				Monitor: MonitorConfig{
+					MetricsPort: ":9102",
+				},
+			},
+			expectedError: "email.dispatch.queue_size must be greater than 0",
+		},
+		{
+			name: "tracking enabled without secret",
+			config: &Config{
+				Server: ServerConfig{
+					GRPCPort: ":50052",
+				},
+				Email: EmailConfig{
+					RateLimit: RateLimitConfig{
+						EmailsPerMinute: 60,
+						MaxBurst:        10,
+					},
+					Dispatch: DispatchConfig{
+						Workers:   4,
+						QueueSize: 1000,
+					},
+					Tracking: TrackingConfig{
+						Enabled:  true,
+						HTTPPort: ":8083",
+						BaseURL:  "http://localhost:8083",
+					},
+				},
+				Monitor: MonitorConfig{
+					MetricsPort: ":9102",
+				},
+			},
+			expectedError: "email.tracking.secret is required when tracking is enabled",
+		},
+		{
+			name: "tls trust domain without ca",
+			config: &Config{
+				Server: ServerConfig{
+					GRPCPort: ":50052",
+				},
+				Email: EmailConfig{
+					RateLimit: RateLimitConfig{
+						EmailsPerMinute: 60,
+						MaxBurst:        10,
+					},
+					Dispatch: DispatchConfig{
+						Workers:   4,
+						QueueSize: 1000,
+					},
+				},
+				Monitor: MonitorConfig{
+					MetricsPort: ":9102",
+				},
+				TLS: TLSConfig{
+					Config: mtls.Config{
+						CertFile:       "/etc/tls/cert.pem",
+						KeyFile:        "/etc/tls/key.pem",
+						TrustDomain:    "mailflow.local",
+						ReloadInterval: time.Minute,
+					},
+				},
+			},
+			expectedError: "tls.ca_file is required when tls.trust_domain is set",
+		},
+		{
+			name: "missing health interval",
+			config: &Config{
+				Server: ServerConfig{
+					GRPCPort: ":50052",
+				},
+				Email: EmailConfig{
+					RateLimit: RateLimitConfig{
+						EmailsPerMinute: 60,
+						MaxBurst:        10,
+					},
+					Dispatch: DispatchConfig{
+						Workers:   4,
+						QueueSize: 1000,
+					},
+				},
+				Monitor: MonitorConfig{
+					MetricsPort: ":9102",
+				},
+				Health: HealthConfig{
+					CheckTimeout: 2 * time.Second,
+				},
+			},
+			expectedError: "health.interval must be greater than 0",
+		},
+		{
+			name: "maintenance longer than its frequency",
+			config: &Config{
+				Server: ServerConfig{
+					GRPCPort: ":50052",
+				},
+				Email: EmailConfig{
+					RateLimit: RateLimitConfig{
+						EmailsPerMinute: 60,
+						MaxBurst:        10,
+					},
+					Maintenance: MaintenanceConfig{
+						Enabled:        true,
+						Frequency:      time.Minute,
+						DowntimePeriod: time.Hour,
+					},
+					Dispatch: DispatchConfig{
+						Workers:   4,
+						QueueSize: 1000,
+					},
+				},
+				Monitor: MonitorConfig{
+					MetricsPort: ":9102",
+				},
+				Health: HealthConfig{
+					CheckTimeout: 2 * time.Second,
+					Interval:     5 * time.Second,
+				},
+			},
+			expectedError: "email.maintenance: schedule for must be between 0 and every",
+		},
+		{
+			name: "fault admin without auth",
+			config: &Config{
+				Server: ServerConfig{
+					GRPCPort: ":50052",
+				},
+				Email: EmailConfig{
+					RateLimit: RateLimitConfig{
+						EmailsPerMinute: 60,
+						MaxBurst:        10,
+					},
+					Dispatch: DispatchConfig{
+						Workers:   4,
+						QueueSize: 1000,
+					},
+				},
+				Monitor: MonitorConfig{
+					MetricsPort: ":9102",
+				},
+				Health: HealthConfig{
+					CheckTimeout: 2 * time.Second,
+					Interval:     5 * time.Second,
+				},
+				Faults: FaultsConfig{
+					AdminEnabled: true,
+				},
+			},
+			expectedError: "auth.secret is required when faults.admin_enabled is set",
+		},
 	}
 
 	for _, tt := range tests {
@@ -265,12 +531,20 @@ func TestSetDefaultConfig(t *testing.T) {
 	// Verify defaults are set
 	assert.Equal(t, ":50052", viper.GetString("server.grpc_port"))
 	assert.Equal(t, "30s", viper.GetString("server.shutdown_timeout"))
+	assert.Equal(t, "gradient", viper.GetString("server.concurrency_limit.algorithm"))
+	assert.Equal(t, 20, viper.GetInt("server.concurrency_limit.initial_limit"))
+	assert.Equal(t, 5, viper.GetInt("server.concurrency_limit.min_limit"))
+	assert.Equal(t, 200, viper.GetInt("server.concurrency_limit.max_limit"))
 	assert.False(t, viper.GetBool("email.smtp.enabled"))
 	assert.Equal(t, 60, viper.GetInt("email.rate_limit.emails_per_minute"))
 	assert.Equal(t, 10, viper.GetInt("email.rate_limit.max_burst"))
 	assert.True(t, viper.GetBool("email.maintenance.enabled"))
 	assert.Equal(t, "5m", viper.GetString("email.maintenance.frequency"))
 	assert.Equal(t, "30s", viper.GetString("email.maintenance.downtime_period"))
+	assert.Equal(t, 4, viper.GetInt("email.dispatch.workers"))
+	assert.Equal(t, 1000, viper.GetInt("email.dispatch.queue_size"))
+	assert.Equal(t, "100ms", viper.GetString("email.dispatch.submit_timeout"))
+	assert.Equal(t, "30s", viper.GetString("tls.reload_interval"))
 	assert.Equal(t, ":9102", viper.GetString("monitor.metrics_port"))
 	assert.Equal(t, "info", viper.GetString("logger.level"))
 	assert.Equal(t, "json", viper.GetString("logger.encoding"))
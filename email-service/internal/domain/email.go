@@ -20,6 +20,36 @@ type Email struct {
 	Status    string
 	CreatedAt time.Time
 	SentAt    *time.Time
+	Tracking  Tracking
+	// HTML marks a body sent as text/html, as it is once tracking rewrote it
+	HTML bool
+	// Category is the mailing category recipients can unsubscribe from
+	Category string
+	// UnsubscribeURL is the one-click unsubscribe endpoint for the recipient
+	UnsubscribeURL string
+	// Tenant the email was sent on behalf of
+	Tenant string
+	Tags   []string
+}
+
+// SendOptions carries optional per-request delivery settings
+type SendOptions struct {
+	Tracking       Tracking
+	Category       string
+	UnsubscribeURL string
+	Tenant         string
+	Tags           []string
+}
+
+// Tracking selects which engagement events are recorded for an email
+type Tracking struct {
+	Opens  bool
+	Clicks bool
+}
+
+// Enabled reports whether any kind of tracking was requested
+func (t Tracking) Enabled() bool {
+	return t.Opens || t.Clicks
 }
 
 func NewEmail(to, subject, body string) *Email {
@@ -0,0 +1,33 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	EventOpen  = "open"
+	EventClick = "click"
+)
+
+// EmailEvent is an engagement event recorded by the tracking endpoint
+type EmailEvent struct {
+	ID         string
+	EmailID    string
+	Type       string
+	URL        string
+	UserAgent  string
+	OccurredAt time.Time
+}
+
+func NewEmailEvent(emailID, eventType, url, userAgent string) *EmailEvent {
+	return &EmailEvent{
+		ID:         uuid.New().String(),
+		EmailID:    emailID,
+		Type:       eventType,
+		URL:        url,
+		UserAgent:  userAgent,
+		OccurredAt: time.Now(),
+	}
+}
@@ -0,0 +1,87 @@
+package domain
+
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+// SortOrder selects the order emails are listed in
+type SortOrder int
+
+const (
+	SortCreatedAsc SortOrder = iota
+	SortCreatedDesc
+)
+
+// ListFilter narrows an email listing. Zero-valued fields match everything;
+// the After bounds are inclusive and the Before bounds exclusive.
+type ListFilter struct {
+	Status          string
+	To              string
+	SubjectContains string
+	Tenant          string
+	Tag             string
+	CreatedAfter    time.Time
+	CreatedBefore   time.Time
+	SentAfter       time.Time
+	SentBefore      time.Time
+}
+
+type ListQuery struct {
+	Filter    ListFilter
+	Order     SortOrder
+	PageSize  int
+	PageToken string
+}
+
+type ListResult struct {
+	Emails        []*Email
+	NextPageToken string
+	// TotalCount is the number of emails matching the filter across all pages
+	TotalCount int
+}
+
+// NormalizeAddress is the form recipient addresses are compared in
+func NormalizeAddress(address string) string {
+	return strings.ToLower(strings.TrimSpace(address))
+}
+
+// Matches reports whether an email satisfies every condition of the filter
+func (f ListFilter) Matches(email *Email) bool {
+	if f.Status != "" && email.Status != f.Status {
+		return false
+	}
+	if f.To != "" && NormalizeAddress(email.To) != NormalizeAddress(f.To) {
+		return false
+	}
+	if f.SubjectContains != "" &&
+		!strings.Contains(strings.ToLower(email.Subject), strings.ToLower(f.SubjectContains)) {
+		return false
+	}
+	if f.Tenant != "" && email.Tenant != f.Tenant {
+		return false
+	}
+	if f.Tag != "" && !slices.Contains(email.Tags, f.Tag) {
+		return false
+	}
+	if !inRange(email.CreatedAt, f.CreatedAfter, f.CreatedBefore) {
+		return false
+	}
+	if !f.SentAfter.IsZero() || !f.SentBefore.IsZero() {
+		if email.SentAt == nil || !inRange(*email.SentAt, f.SentAfter, f.SentBefore) {
+			return false
+		}
+	}
+	return true
+}
+
+func inRange(t, after, before time.Time) bool {
+	if !after.IsZero() && t.Before(after) {
+		return false
+	}
+	if !before.IsZero() && !t.Before(before) {
+		return false
+	}
+	return true
+}
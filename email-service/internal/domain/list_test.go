@@ -0,0 +1,53 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListFilter_Matches_Success(t *testing.T) {
+	created := time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC)
+	sent := created.Add(time.Minute)
+	email := &Email{
+		To:        "User@Example.com",
+		Subject:   "Your Weekly Digest",
+		Status:    StatusSent,
+		CreatedAt: created,
+		SentAt:    &sent,
+		Tenant:    "acme",
+		Tags:      []string{"digest", "weekly"},
+	}
+
+	tests := []struct {
+		name     string
+		filter   ListFilter
+		expected bool
+	}{
+		{name: "empty filter", filter: ListFilter{}, expected: true},
+		{name: "status", filter: ListFilter{Status: StatusSent}, expected: true},
+		{name: "other status", filter: ListFilter{Status: StatusFailed}, expected: false},
+		{name: "recipient ignores case", filter: ListFilter{To: " user@example.COM"}, expected: true},
+		{name: "subject substring ignores case", filter: ListFilter{SubjectContains: "weekly"}, expected: true},
+		{name: "subject mismatch", filter: ListFilter{SubjectContains: "invoice"}, expected: false},
+		{name: "tenant and tag", filter: ListFilter{Tenant: "acme", Tag: "digest"}, expected: true},
+		{name: "missing tag", filter: ListFilter{Tag: "promo"}, expected: false},
+		{name: "created after is inclusive", filter: ListFilter{CreatedAfter: created}, expected: true},
+		{name: "created before is exclusive", filter: ListFilter{CreatedBefore: created}, expected: false},
+		{name: "sent within range", filter: ListFilter{SentAfter: created, SentBefore: sent.Add(time.Second)}, expected: true},
+		{name: "sent outside range", filter: ListFilter{SentAfter: sent.Add(time.Second)}, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.filter.Matches(email))
+		})
+	}
+}
+
+func TestListFilter_Matches_Unsent(t *testing.T) {
+	email := NewEmail("user@example.com", "Subject", "Body")
+
+	assert.False(t, ListFilter{SentBefore: time.Now().Add(time.Hour)}.Matches(email))
+}
@@ -9,6 +9,11 @@ type EmailRepository interface {
 	Save(ctx context.Context, email *Email) error
 	GetByID(ctx context.Context, id string) (*Email, error)
 	UpdateStatus(ctx context.Context, id, status string, sentAt *time.Time) error
-	List(ctx context.Context, pageSize int, pageToken string) ([]*Email, string, error)
+	List(ctx context.Context, query ListQuery) (*ListResult, error)
 	DeleteByID(ctx context.Context, id string) error
 }
+
+type EmailEventRepository interface {
+	Save(ctx context.Context, event *EmailEvent) error
+	ListByEmailID(ctx context.Context, emailID string) ([]*EmailEvent, error)
+}
@@ -0,0 +1,161 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/popeskul/mailflow/common/auth"
+	"github.com/popeskul/mailflow/common/fault"
+	"github.com/popeskul/mailflow/common/logger"
+	adminv1 "github.com/popeskul/mailflow/email-service/pkg/api/admin/v1"
+)
+
+// AdminServer lets admins inject faults into the service at runtime
+type AdminServer struct {
+	adminv1.UnimplementedAdminServiceServer
+	faults *fault.Injector
+	logger logger.Logger
+}
+
+func NewAdminServer(faults *fault.Injector, l logger.Logger) *AdminServer {
+	return &AdminServer{
+		faults: faults,
+		logger: l.Named("admin_server"),
+	}
+}
+
+func (s *AdminServer) ListFaults(_ context.Context, _ *adminv1.ListFaultsRequest) (*adminv1.ListFaultsResponse, error) {
+	states := s.faults.List()
+
+	faults := make([]*adminv1.Fault, 0, len(states))
+	for _, state := range states {
+		faults = append(faults, toProtoFault(state))
+	}
+
+	return &adminv1.ListFaultsResponse{Faults: faults, Seed: s.faults.Seed()}, nil
+}
+
+func (s *AdminServer) SetFault(ctx context.Context, req *adminv1.SetFaultRequest) (*adminv1.SetFaultResponse, error) {
+	f, err := fromProtoFault(req.GetFault())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if err := s.faults.Set(f); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	s.logChange(ctx, "set", f.Name)
+
+	state, err := s.faults.Get(f.Name)
+	if err != nil {
+		// Removed again meanwhile
+		state = fault.State{Fault: f}
+	}
+
+	return &adminv1.SetFaultResponse{Fault: toProtoFault(state)}, nil
+}
+
+func (s *AdminServer) RemoveFault(ctx context.Context, req *adminv1.RemoveFaultRequest) (*adminv1.RemoveFaultResponse, error) {
+	if req.GetName() == "" {
+		return nil, status.Error(codes.InvalidArgument, "fault name is required")
+	}
+
+	if err := s.faults.Remove(req.GetName()); err != nil {
+		if errors.Is(err, fault.ErrUnknownFault) {
+			return nil, status.Errorf(codes.NotFound, "fault %q not found", req.GetName())
+		}
+		return nil, status.Error(codes.Internal, "failed to remove fault")
+	}
+
+	s.logChange(ctx, "remove", req.GetName())
+
+	return &adminv1.RemoveFaultResponse{}, nil
+}
+
+func (s *AdminServer) ClearFaults(ctx context.Context, _ *adminv1.ClearFaultsRequest) (*adminv1.ClearFaultsResponse, error) {
+	s.faults.Clear()
+
+	s.logChange(ctx, "clear", "")
+
+	return &adminv1.ClearFaultsResponse{}, nil
+}
+
+// logChange logs who changed the faults, as injected failures are worth
+// finding in the logs when they surprise someone
+func (s *AdminServer) logChange(ctx context.Context, action, name string) {
+	var caller string
+	if principal, ok := auth.FromContext(ctx); ok {
+		caller = principal.UserID
+	}
+	s.logger.Warn("faults changed",
+		logger.Field{Key: "fault", Value: name},
+		logger.Field{Key: "action", Value: action},
+		logger.Field{Key: "seed", Value: s.faults.Seed()},
+		logger.Field{Key: "caller", Value: caller},
+	)
+}
+
+func fromProtoFault(f *adminv1.Fault) (fault.Fault, error) {
+	if f == nil {
+		return fault.Fault{}, errors.New("fault is required")
+	}
+
+	code, err := parseCode(f.GetCode())
+	if err != nil {
+		return fault.Fault{}, err
+	}
+
+	return fault.Fault{
+		Name:        f.GetName(),
+		Target:      f.GetTarget(),
+		Code:        code,
+		Message:     f.GetMessage(),
+		Latency:     time.Duration(f.GetLatencyMs()) * time.Millisecond,
+		Probability: f.GetProbability(),
+		Schedule: fault.Schedule{
+			After: time.Duration(f.GetSchedule().GetAfterMs()) * time.Millisecond,
+			Every: time.Duration(f.GetSchedule().GetEveryMs()) * time.Millisecond,
+			For:   time.Duration(f.GetSchedule().GetForMs()) * time.Millisecond,
+		},
+		Seed: f.GetSeed(),
+	}, nil
+}
+
+// parseCode looks up a status code by name, e.g. "Unavailable", ignoring
+// case. No name means OK.
+func parseCode(name string) (codes.Code, error) {
+	if name == "" {
+		return codes.OK, nil
+	}
+	for code := codes.OK; code <= codes.Unauthenticated; code++ {
+		if strings.EqualFold(code.String(), name) {
+			return code, nil
+		}
+	}
+	return codes.OK, fmt.Errorf("unknown status code %q", name)
+}
+
+func toProtoFault(state fault.State) *adminv1.Fault {
+	return &adminv1.Fault{
+		Name:        state.Name,
+		Target:      state.Target,
+		Code:        state.Code.String(),
+		Message:     state.Message,
+		LatencyMs:   state.Latency.Milliseconds(),
+		Probability: state.Probability,
+		Schedule: &adminv1.Schedule{
+			AfterMs: state.Schedule.After.Milliseconds(),
+			EveryMs: state.Schedule.Every.Milliseconds(),
+			ForMs:   state.Schedule.For.Milliseconds(),
+		},
+		Seed:     state.Seed,
+		Active:   state.Active,
+		Injected: state.Injected,
+	}
+}
@@ -0,0 +1,70 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthgrpc "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+
+	"github.com/popeskul/mailflow/common/auth"
+	adminv1 "github.com/popeskul/mailflow/email-service/pkg/api/admin/v1"
+	pb "github.com/popeskul/mailflow/email-service/pkg/api/email/v1"
+	healthpb "github.com/popeskul/mailflow/email-service/pkg/api/health"
+)
+
+// Policy says who may call each method. Only other services send email;
+// admins may also look at what was sent and inject faults.
+var Policy = auth.Policy{
+	pb.EmailService_SendEmail_FullMethodName:      {Roles: []auth.Role{auth.RoleService}},
+	pb.EmailService_GetEmailStatus_FullMethodName: {Roles: []auth.Role{auth.RoleAdmin, auth.RoleService}},
+	pb.EmailService_ListEmails_FullMethodName:     {Roles: []auth.Role{auth.RoleAdmin, auth.RoleService}},
+	pb.EmailService_GetEmailEvents_FullMethodName: {Roles: []auth.Role{auth.RoleAdmin, auth.RoleService}},
+
+	// Injecting faults is for resilience testing
+	adminv1.AdminService_ListFaults_FullMethodName:  {Roles: []auth.Role{auth.RoleAdmin}},
+	adminv1.AdminService_SetFault_FullMethodName:    {Roles: []auth.Role{auth.RoleAdmin}},
+	adminv1.AdminService_RemoveFault_FullMethodName: {Roles: []auth.Role{auth.RoleAdmin}},
+	adminv1.AdminService_ClearFaults_FullMethodName: {Roles: []auth.Role{auth.RoleAdmin}},
+
+	// Probes come from load balancers and orchestrators, which have no token
+	healthpb.HealthService_Check_FullMethodName:     {Public: true},
+	healthpb.HealthService_Liveness_FullMethodName:  {Public: true},
+	healthpb.HealthService_Readiness_FullMethodName: {Public: true},
+	healthpb.HealthService_Healthz_FullMethodName:   {Public: true},
+	healthgrpc.Health_Check_FullMethodName:          {Public: true},
+	healthgrpc.Health_Watch_FullMethodName:          {Public: true},
+}
+
+// AuthInterceptor requires a bearer access token issued by the user service
+// for every method but the policy's public ones, checks the caller's role
+// against policy and puts the caller's principal into the context.
+func AuthInterceptor(tokens *auth.Tokens, policy auth.Policy) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		var principal *auth.Principal
+		if rule, ok := policy[info.FullMethod]; !ok || !rule.Public {
+			token, ok := auth.BearerToken(ctx)
+			if !ok {
+				return nil, status.Error(codes.Unauthenticated, "missing access token")
+			}
+
+			var err error
+			principal, err = tokens.Parse(token)
+			switch {
+			case errors.Is(err, auth.ErrTokenExpired):
+				return nil, status.Error(codes.Unauthenticated, "access token expired")
+			case err != nil:
+				return nil, status.Error(codes.Unauthenticated, "invalid access token")
+			}
+			ctx = auth.NewContext(ctx, principal)
+		}
+
+		if err := policy.Authorize(info.FullMethod, principal, req); err != nil {
+			return nil, status.Error(codes.PermissionDenied, "permission denied")
+		}
+
+		return handler(ctx, req)
+	}
+}
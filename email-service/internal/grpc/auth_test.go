@@ -0,0 +1,132 @@
+package grpc
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthgrpc "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/popeskul/mailflow/common/auth"
+	adminv1 "github.com/popeskul/mailflow/email-service/pkg/api/admin/v1"
+	pb "github.com/popeskul/mailflow/email-service/pkg/api/email/v1"
+)
+
+const testSecret = "test-secret"
+
+// issue returns an access token for principal signed with secret
+func issue(t *testing.T, secret string, ttl time.Duration, principal auth.Principal) string {
+	t.Helper()
+
+	token, _, err := auth.NewTokens(secret, ttl).Issue(principal)
+	require.NoError(t, err)
+	return token
+}
+
+// callAs calls method through the interceptor with token as the bearer
+// token, or without one when token is empty
+func callAs(interceptor grpc.UnaryServerInterceptor, method, token string) (*auth.Principal, error) {
+	ctx := context.Background()
+	if token != "" {
+		ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", "Bearer "+token))
+	}
+
+	var principal *auth.Principal
+	_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: method}, func(ctx context.Context, _ any) (any, error) {
+		principal, _ = auth.FromContext(ctx)
+		return "ok", nil
+	})
+	return principal, err
+}
+
+func TestAuthInterceptor_Policy(t *testing.T) {
+	const (
+		OK     = codes.OK
+		Unauth = codes.Unauthenticated
+		Denied = codes.PermissionDenied
+	)
+
+	principals := map[string]auth.Principal{
+		"admin":   {UserID: "admin-1", SessionID: "s-admin", Role: auth.RoleAdmin},
+		"service": {UserID: "user-service", Role: auth.RoleService},
+		"user":    {UserID: "user-1", SessionID: "s-user", Role: auth.RoleUser},
+	}
+
+	// Each method is called by every kind of caller: no token, an admin, a
+	// service and a user
+	tests := []struct {
+		method string
+		none   codes.Code
+		admin  codes.Code
+		svc    codes.Code
+		user   codes.Code
+	}{
+		{pb.EmailService_SendEmail_FullMethodName, Unauth, Denied, OK, Denied},
+		{pb.EmailService_GetEmailStatus_FullMethodName, Unauth, OK, OK, Denied},
+		{pb.EmailService_ListEmails_FullMethodName, Unauth, OK, OK, Denied},
+		{pb.EmailService_GetEmailEvents_FullMethodName, Unauth, OK, OK, Denied},
+		{adminv1.AdminService_ListFaults_FullMethodName, Unauth, OK, Denied, Denied},
+		{adminv1.AdminService_SetFault_FullMethodName, Unauth, OK, Denied, Denied},
+		{adminv1.AdminService_RemoveFault_FullMethodName, Unauth, OK, Denied, Denied},
+		{adminv1.AdminService_ClearFaults_FullMethodName, Unauth, OK, Denied, Denied},
+		{healthgrpc.Health_Check_FullMethodName, OK, OK, OK, OK},
+		{"/email.v1.EmailService/Unknown", Unauth, Denied, Denied, Denied},
+	}
+
+	interceptor := AuthInterceptor(auth.NewTokens(testSecret, time.Hour), Policy)
+	for _, tt := range tests {
+		callers := []struct {
+			name string
+			want codes.Code
+		}{
+			{"anonymous", tt.none},
+			{"admin", tt.admin},
+			{"service", tt.svc},
+			{"user", tt.user},
+		}
+		for _, caller := range callers {
+			t.Run(tt.method+"/"+caller.name, func(t *testing.T) {
+				var token string
+				if p, ok := principals[caller.name]; ok {
+					token = issue(t, testSecret, time.Hour, p)
+				}
+
+				principal, err := callAs(interceptor, tt.method, token)
+
+				assert.Equal(t, caller.want, status.Code(err))
+				if err == nil && token != "" && !Policy[tt.method].Public {
+					require.NotNil(t, principal, "handler sees the caller")
+					assert.Equal(t, principals[caller.name], *principal)
+				}
+			})
+		}
+	}
+}
+
+func TestAuthInterceptor_Tokens(t *testing.T) {
+	service := auth.Principal{UserID: "user-service", Role: auth.RoleService}
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{name: "expired token", token: issue(t, testSecret, -time.Minute, service)},
+		{name: "token signed with another secret", token: issue(t, "other-secret", time.Hour, service)},
+		{name: "malformed token", token: "not-a-token"},
+	}
+
+	interceptor := AuthInterceptor(auth.NewTokens(testSecret, time.Hour), Policy)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := callAs(interceptor, pb.EmailService_SendEmail_FullMethodName, tt.token)
+
+			assert.Equal(t, codes.Unauthenticated, status.Code(err))
+		})
+	}
+}
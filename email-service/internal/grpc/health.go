@@ -0,0 +1,75 @@
+package grpc
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	grpchealth "google.golang.org/grpc/health"
+	healthgrpc "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+
+	"github.com/popeskul/mailflow/common/health"
+	"github.com/popeskul/mailflow/common/logger"
+	pb "github.com/popeskul/mailflow/email-service/pkg/api/email/v1"
+	healthpb "github.com/popeskul/mailflow/email-service/pkg/api/health"
+)
+
+// HealthServer answers health checks from the checks in a registry. A
+// failing check is reported as codes.Unavailable, which the gateway turns
+// into 503 so HTTP probes see it.
+type HealthServer struct {
+	healthpb.UnimplementedHealthServiceServer
+	checks *health.Registry
+	logger logger.Logger
+}
+
+func NewHealthServer(checks *health.Registry, l logger.Logger) *HealthServer {
+	return &HealthServer{
+		checks: checks,
+		logger: l.Named("health_server"),
+	}
+}
+
+// Check runs every check
+func (s *HealthServer) Check(ctx context.Context, _ *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	return s.respond(s.checks.Readiness(ctx), healthpb.HealthStatus_HEALTHY)
+}
+
+// Liveness runs the liveness checks
+func (s *HealthServer) Liveness(ctx context.Context, _ *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	return s.respond(s.checks.Liveness(ctx), healthpb.HealthStatus_ALIVE)
+}
+
+// Readiness runs the liveness and readiness checks
+func (s *HealthServer) Readiness(ctx context.Context, _ *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	return s.respond(s.checks.Readiness(ctx), healthpb.HealthStatus_READY)
+}
+
+// Healthz is Check under its legacy name
+func (s *HealthServer) Healthz(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	return s.Check(ctx, req)
+}
+
+func (s *HealthServer) respond(report health.Report, ok healthpb.HealthStatus) (*healthpb.HealthCheckResponse, error) {
+	if err := report.Err(); err != nil {
+		s.logger.Warn("health check failed",
+			logger.Field{Key: "error", Value: err},
+		)
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	return &healthpb.HealthCheckResponse{Status: ok}, nil
+}
+
+// WatchHealth keeps the grpc.health.v1 status of the server and of the email
+// service current with the readiness checks until ctx is done
+func WatchHealth(ctx context.Context, checks *health.Registry, srv *grpchealth.Server, interval time.Duration) {
+	checks.Watch(ctx, interval, func(report health.Report) {
+		serving := healthgrpc.HealthCheckResponse_SERVING
+		if !report.Healthy() {
+			serving = healthgrpc.HealthCheckResponse_NOT_SERVING
+		}
+		srv.SetServingStatus("", serving)
+		srv.SetServingStatus(pb.EmailService_ServiceDesc.ServiceName, serving)
+	})
+}
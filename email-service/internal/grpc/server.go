@@ -2,25 +2,38 @@ package grpc
 
 import (
 	"context"
-	"sync/atomic"
+	"errors"
+	"net/url"
+	"strconv"
 	"time"
 
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 
 	"github.com/popeskul/mailflow/common/logger"
+	"github.com/popeskul/mailflow/common/pagination"
 	"github.com/popeskul/mailflow/email-service/internal/domain"
 	"github.com/popeskul/mailflow/email-service/internal/metrics"
 	"github.com/popeskul/mailflow/email-service/internal/services"
 	pb "github.com/popeskul/mailflow/email-service/pkg/api/email/v1"
 )
 
+const (
+	// pushbackTrailer tells clients when to retry, in milliseconds, as in
+	// gRPC's retry design
+	pushbackTrailer = "grpc-retry-pushback-ms"
+	// queueFullPushback is how long clients are asked to wait when the
+	// email queue is full, about as long as the dispatcher takes to drain it
+	queueFullPushback = time.Second
+)
+
 type EmailServer struct {
 	pb.UnimplementedEmailServiceServer
 	emailService services.EmailService
 	metrics      *metrics.EmailMetrics
 	logger       logger.Logger
-	isDown       int32 // atomic
 }
 
 func NewEmailServer(emailService services.EmailService, metrics *metrics.EmailMetrics, l logger.Logger) *EmailServer {
@@ -32,16 +45,21 @@ func NewEmailServer(emailService services.EmailService, metrics *metrics.EmailMe
 }
 
 func (s *EmailServer) SendEmail(ctx context.Context, req *pb.SendEmailRequest) (*pb.SendEmailResponse, error) {
-	if atomic.LoadInt32(&s.isDown) == 1 {
-		return nil, status.Error(codes.Unavailable, "service is in maintenance mode")
-	}
-
 	if err := validateSendEmailRequest(req); err != nil {
 		return nil, err
 	}
 
 	start := time.Now()
-	email, err := s.emailService.SendEmail(ctx, req.To, req.Subject, req.Body)
+	email, err := s.emailService.SendEmail(ctx, req.To, req.Subject, req.Body, domain.SendOptions{
+		Tracking: domain.Tracking{
+			Opens:  req.GetTracking().GetOpens(),
+			Clicks: req.GetTracking().GetClicks(),
+		},
+		Category:       req.GetCategory(),
+		UnsubscribeURL: req.GetUnsubscribeUrl(),
+		Tenant:         req.GetTenant(),
+		Tags:           req.GetTags(),
+	})
 	s.metrics.ObserveProcessingDuration(time.Since(start).Seconds())
 
 	if err != nil {
@@ -49,22 +67,36 @@ func (s *EmailServer) SendEmail(ctx context.Context, req *pb.SendEmailRequest) (
 			logger.Field{Key: "error", Value: err},
 			logger.Field{Key: "to", Value: req.To},
 		)
+
+		switch {
+		case errors.Is(err, services.ErrQueueFull):
+			s.pushback(ctx, queueFullPushback)
+			return nil, status.Error(codes.ResourceExhausted, "email queue is full, retry later")
+		case errors.Is(err, services.ErrDispatcherClosed):
+			return nil, status.Error(codes.Unavailable, "service is shutting down")
+		}
+
 		s.metrics.RecordEmailFailed()
 		return nil, status.Error(codes.Internal, "failed to send email")
 	}
 
-	s.metrics.RecordEmailSent()
 	return &pb.SendEmailResponse{
 		Id:     email.ID,
 		Status: email.Status,
 	}, nil
 }
 
-func (s *EmailServer) GetEmailStatus(ctx context.Context, req *pb.GetEmailStatusRequest) (*pb.GetEmailStatusResponse, error) {
-	if atomic.LoadInt32(&s.isDown) == 1 {
-		return nil, status.Error(codes.Unavailable, "service is in maintenance mode")
+// pushback asks the client to wait d before retrying the call
+func (s *EmailServer) pushback(ctx context.Context, d time.Duration) {
+	ms := strconv.FormatInt(d.Milliseconds(), 10)
+	if err := grpc.SetTrailer(ctx, metadata.Pairs(pushbackTrailer, ms)); err != nil {
+		s.logger.Warn("failed to set retry pushback trailer",
+			logger.Field{Key: "error", Value: err},
+		)
 	}
+}
 
+func (s *EmailServer) GetEmailStatus(ctx context.Context, req *pb.GetEmailStatusRequest) (*pb.GetEmailStatusResponse, error) {
 	if req.Id == "" {
 		return nil, status.Error(codes.InvalidArgument, "email id is required")
 	}
@@ -91,12 +123,16 @@ func (s *EmailServer) GetEmailStatus(ctx context.Context, req *pb.GetEmailStatus
 }
 
 func (s *EmailServer) ListEmails(ctx context.Context, req *pb.ListEmailsRequest) (*pb.ListEmailsResponse, error) {
-	if atomic.LoadInt32(&s.isDown) == 1 {
-		return nil, status.Error(codes.Unavailable, "service is in maintenance mode")
+	query, err := toListQuery(req)
+	if err != nil {
+		return nil, err
 	}
 
-	emails, nextPageToken, err := s.emailService.ListEmails(ctx, int(req.PageSize), req.PageToken)
+	result, err := s.emailService.ListEmails(ctx, query)
 	if err != nil {
+		if errors.Is(err, pagination.ErrInvalidToken) {
+			return nil, status.Error(codes.InvalidArgument, "invalid page token")
+		}
 		s.logger.Error("failed to list emails",
 			logger.Field{Key: "error", Value: err},
 			logger.Field{Key: "page_size", Value: req.PageSize},
@@ -106,25 +142,39 @@ func (s *EmailServer) ListEmails(ctx context.Context, req *pb.ListEmailsRequest)
 	}
 
 	var protoEmails []*pb.Email
-	for _, email := range emails {
+	for _, email := range result.Emails {
 		protoEmails = append(protoEmails, toProtoEmail(email))
 	}
 
 	return &pb.ListEmailsResponse{
 		Emails:        protoEmails,
-		NextPageToken: nextPageToken,
+		NextPageToken: result.NextPageToken,
+		TotalCount:    int32(result.TotalCount),
 	}, nil
 }
 
-func (s *EmailServer) SetDowntime(isDown bool) {
-	if isDown {
-		atomic.StoreInt32(&s.isDown, 1)
-		s.metrics.RecordDowntimePeriod()
-		s.logger.Info("service entering maintenance mode")
-	} else {
-		atomic.StoreInt32(&s.isDown, 0)
-		s.logger.Info("service exiting maintenance mode")
+func (s *EmailServer) GetEmailEvents(ctx context.Context, req *pb.GetEmailEventsRequest) (*pb.GetEmailEventsResponse, error) {
+	if req.Id == "" {
+		return nil, status.Error(codes.InvalidArgument, "email id is required")
+	}
+
+	events, err := s.emailService.GetEmailEvents(ctx, req.Id)
+	if err != nil {
+		s.logger.Error("failed to get email events",
+			logger.Field{Key: "error", Value: err},
+			logger.Field{Key: "email_id", Value: req.Id},
+		)
+		return nil, status.Error(codes.NotFound, "email not found")
+	}
+
+	protoEvents := make([]*pb.EmailEvent, 0, len(events))
+	for _, event := range events {
+		protoEvents = append(protoEvents, toProtoEmailEvent(event))
 	}
+
+	return &pb.GetEmailEventsResponse{
+		Events: protoEvents,
+	}, nil
 }
 
 func validateSendEmailRequest(req *pb.SendEmailRequest) error {
@@ -137,9 +187,66 @@ func validateSendEmailRequest(req *pb.SendEmailRequest) error {
 	if req.Body == "" {
 		return status.Error(codes.InvalidArgument, "body is required")
 	}
+	if req.UnsubscribeUrl != "" {
+		u, err := url.Parse(req.UnsubscribeUrl)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return status.Error(codes.InvalidArgument, "unsubscribe url must be an absolute http(s) url")
+		}
+	}
 	return nil
 }
 
+func toListQuery(req *pb.ListEmailsRequest) (domain.ListQuery, error) {
+	query := domain.ListQuery{
+		Filter: domain.ListFilter{
+			Status:          req.Status,
+			To:              req.To,
+			SubjectContains: req.SubjectContains,
+			Tenant:          req.Tenant,
+			Tag:             req.Tag,
+		},
+		PageSize:  int(req.PageSize),
+		PageToken: req.PageToken,
+	}
+
+	switch req.Status {
+	case "", domain.StatusPending, domain.StatusSent, domain.StatusFailed:
+	default:
+		return query, status.Errorf(codes.InvalidArgument, "unknown status %q", req.Status)
+	}
+
+	switch req.Order {
+	case pb.SortOrder_SORT_ORDER_UNSPECIFIED, pb.SortOrder_SORT_ORDER_CREATED_ASC:
+		query.Order = domain.SortCreatedAsc
+	case pb.SortOrder_SORT_ORDER_CREATED_DESC:
+		query.Order = domain.SortCreatedDesc
+	default:
+		return query, status.Errorf(codes.InvalidArgument, "unknown sort order %v", req.Order)
+	}
+
+	for _, bound := range []struct {
+		name  string
+		value string
+		dst   *time.Time
+	}{
+		{"created_after", req.CreatedAfter, &query.Filter.CreatedAfter},
+		{"created_before", req.CreatedBefore, &query.Filter.CreatedBefore},
+		{"sent_after", req.SentAfter, &query.Filter.SentAfter},
+		{"sent_before", req.SentBefore, &query.Filter.SentBefore},
+	} {
+		if bound.value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, bound.value)
+		if err != nil {
+			return query, status.Errorf(codes.InvalidArgument, "%s must be an RFC 3339 timestamp", bound.name)
+		}
+		*bound.dst = t
+	}
+
+	return query, nil
+}
+
 func toProtoEmail(email *domain.Email) *pb.Email {
 	result := &pb.Email{
 		Id:        email.ID,
@@ -148,6 +255,8 @@ func toProtoEmail(email *domain.Email) *pb.Email {
 		Body:      email.Body,
 		Status:    email.Status,
 		CreatedAt: email.CreatedAt.Format(time.RFC3339),
+		Tenant:    email.Tenant,
+		Tags:      email.Tags,
 	}
 
 	if email.SentAt != nil {
@@ -156,3 +265,14 @@ func toProtoEmail(email *domain.Email) *pb.Email {
 
 	return result
 }
+
+func toProtoEmailEvent(event *domain.EmailEvent) *pb.EmailEvent {
+	return &pb.EmailEvent{
+		Id:         event.ID,
+		EmailId:    event.EmailID,
+		Type:       event.Type,
+		Url:        event.URL,
+		UserAgent:  event.UserAgent,
+		OccurredAt: event.OccurredAt.Format(time.RFC3339),
+	}
+}
@@ -9,9 +9,11 @@ type EmailMetrics struct {
 	EmailsSent         prometheus.Counter
 	EmailsQueued       prometheus.Counter
 	EmailsFailed       prometheus.Counter
+	EmailsRejected     prometheus.Counter
 	RateLimitDelays    prometheus.Counter
 	DowntimePeriods    prometheus.Counter
 	QueueSize          prometheus.Gauge
+	ActiveWorkers      prometheus.Gauge
 	ProcessingDuration prometheus.Histogram
 }
 
@@ -32,6 +34,11 @@ func NewEmailMetrics(serviceName string) *EmailMetrics {
 			Name:      "emails_failed_total",
 			Help:      "The total number of failed email sends",
 		}),
+		EmailsRejected: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: serviceName,
+			Name:      "emails_rejected_total",
+			Help:      "The total number of emails rejected because the dispatch queue was full",
+		}),
 		RateLimitDelays: prometheus.NewCounter(prometheus.CounterOpts{
 			Namespace: serviceName,
 			Name:      "rate_limit_delays_total",
@@ -47,6 +54,11 @@ func NewEmailMetrics(serviceName string) *EmailMetrics {
 			Name:      "email_queue_size",
 			Help:      "The current size of the email queue",
 		}),
+		ActiveWorkers: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: serviceName,
+			Name:      "email_active_workers",
+			Help:      "The number of dispatch workers currently delivering an email",
+		}),
 		ProcessingDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
 			Namespace: serviceName,
 			Name:      "email_processing_duration_seconds",
@@ -59,9 +71,11 @@ func NewEmailMetrics(serviceName string) *EmailMetrics {
 	Registry.MustRegister(metrics.EmailsSent,
 		metrics.EmailsQueued,
 		metrics.EmailsFailed,
+		metrics.EmailsRejected,
 		metrics.RateLimitDelays,
 		metrics.DowntimePeriods,
 		metrics.QueueSize,
+		metrics.ActiveWorkers,
 		metrics.ProcessingDuration,
 	)
 
@@ -83,6 +97,11 @@ func (m *EmailMetrics) RecordEmailFailed() {
 	m.EmailsFailed.Inc()
 }
 
+// RecordEmailRejected increases the counter of letters rejected by backpressure
+func (m *EmailMetrics) RecordEmailRejected() {
+	m.EmailsRejected.Inc()
+}
+
 // RecordRateLimitDelay increases the rate limit delay counter
 func (m *EmailMetrics) RecordRateLimitDelay() {
 	m.RateLimitDelays.Inc()
@@ -98,6 +117,11 @@ func (m *EmailMetrics) SetQueueSize(size int) {
 	m.QueueSize.Set(float64(size))
 }
 
+// SetActiveWorkers sets the number of busy dispatch workers
+func (m *EmailMetrics) SetActiveWorkers(count int) {
+	m.ActiveWorkers.Set(float64(count))
+}
+
 // ObserveProcessingDuration records the duration of email processing
 func (m *EmailMetrics) ObserveProcessingDuration(duration float64) {
 	m.ProcessingDuration.Observe(duration)
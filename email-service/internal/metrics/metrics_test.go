@@ -161,9 +161,11 @@ func TestNewEmailMetrics(t *testing.T) {
 			assert.NotNil(t, metrics.EmailsSent)
 			assert.NotNil(t, metrics.EmailsQueued)
 			assert.NotNil(t, metrics.EmailsFailed)
+			assert.NotNil(t, metrics.EmailsRejected)
 			assert.NotNil(t, metrics.RateLimitDelays)
 			assert.NotNil(t, metrics.DowntimePeriods)
 			assert.NotNil(t, metrics.QueueSize)
+			assert.NotNil(t, metrics.ActiveWorkers)
 			assert.NotNil(t, metrics.ProcessingDuration)
 		})
 	}
@@ -268,6 +270,39 @@ func TestEmailMetrics_RecordEmailFailed(t *testing.T) {
 	}
 }
 
+func TestEmailMetrics_RecordEmailRejected(t *testing.T) {
+	tests := []struct {
+		name string
+	}{
+		{
+			name: "record email rejected",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Create a test registry to avoid conflicts
+			testRegistry := prometheus.NewRegistry()
+
+			// Temporarily replace global registry
+			originalRegistry := Registry
+			Registry = testRegistry
+			defer func() {
+				Registry = originalRegistry
+			}()
+
+			metrics := NewEmailMetrics("test")
+
+			metrics.RecordEmailRejected()
+
+			// Verify that metric was recorded
+			mf, err := testRegistry.Gather()
+			assert.NoError(t, err)
+			assert.NotEmpty(t, mf)
+		})
+	}
+}
+
 func TestEmailMetrics_RecordRateLimitDelay(t *testing.T) {
 	tests := []struct {
 		name string
@@ -373,6 +408,45 @@ func TestEmailMetrics_SetQueueSize(t *testing.T) {
 	}
 }
 
+func TestEmailMetrics_SetActiveWorkers(t *testing.T) {
+	tests := []struct {
+		name    string
+		workers int
+	}{
+		{
+			name:    "set active workers",
+			workers: 4,
+		},
+		{
+			name:    "set zero active workers",
+			workers: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Create a test registry to avoid conflicts
+			testRegistry := prometheus.NewRegistry()
+
+			// Temporarily replace global registry
+			originalRegistry := Registry
+			Registry = testRegistry
+			defer func() {
+				Registry = originalRegistry
+			}()
+
+			metrics := NewEmailMetrics("test")
+
+			metrics.SetActiveWorkers(tt.workers)
+
+			// Verify that metric was recorded
+			mf, err := testRegistry.Gather()
+			assert.NoError(t, err)
+			assert.NotEmpty(t, mf)
+		})
+	}
+}
+
 func TestEmailMetrics_ObserveProcessingDuration(t *testing.T) {
 	tests := []struct {
 		name     string
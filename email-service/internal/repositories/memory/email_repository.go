@@ -3,11 +3,14 @@ package memory
 import (
 	"context"
 	"errors"
+	"slices"
 	"sort"
+	"strconv"
 	"sync"
 	"time"
 
 	"github.com/popeskul/mailflow/common/logger"
+	"github.com/popeskul/mailflow/common/pagination"
 	"github.com/popeskul/mailflow/email-service/internal/domain"
 )
 
@@ -17,15 +20,84 @@ var (
 
 type EmailRepositoryContainer struct {
 	emails map[string]*domain.Email
-	mu     *sync.RWMutex
-	logger logger.Logger
+	// byCreated holds every email
+	byCreated   bucket
+	byStatus    index
+	byRecipient index
+	byTenant    index
+	byTag       index
+	cursors     *pagination.Codec
+	mu          *sync.RWMutex
+	logger      logger.Logger
 }
 
-func newEmailRepository(logger logger.Logger) *EmailRepositoryContainer {
+// bucket holds emails ordered by (CreatedAt, ID)
+type bucket []*domain.Email
+
+// search returns where the first email not before pos is
+func (b bucket) search(pos pagination.Cursor) int {
+	return sort.Search(len(b), func(i int) bool {
+		return !pos.Before(b[i].CreatedAt, b[i].ID)
+	})
+}
+
+// searchAfter returns where the first email after pos is
+func (b bucket) searchAfter(pos pagination.Cursor) int {
+	return sort.Search(len(b), func(i int) bool {
+		return pos.After(b[i].CreatedAt, b[i].ID)
+	})
+}
+
+// insert adds email, once however often it is added
+func (b bucket) insert(email *domain.Email) bucket {
+	i := b.search(positionOf(email))
+	if i < len(b) && b[i].ID == email.ID {
+		b[i] = email
+		return b
+	}
+	return slices.Insert(b, i, email)
+}
+
+func (b bucket) remove(email *domain.Email) bucket {
+	i := b.search(positionOf(email))
+	if i < len(b) && b[i].ID == email.ID {
+		return slices.Delete(b, i, i+1)
+	}
+	return b
+}
+
+// index maps a field value to the emails holding it
+type index map[string]bucket
+
+func (ix index) add(key string, email *domain.Email) {
+	if key == "" {
+		return
+	}
+	ix[key] = ix[key].insert(email)
+}
+
+func (ix index) remove(key string, email *domain.Email) {
+	b, ok := ix[key]
+	if !ok {
+		return
+	}
+	if b = b.remove(email); len(b) == 0 {
+		delete(ix, key)
+		return
+	}
+	ix[key] = b
+}
+
+func newEmailRepository(cursors *pagination.Codec, logger logger.Logger) *EmailRepositoryContainer {
 	return &EmailRepositoryContainer{
-		emails: make(map[string]*domain.Email),
-		mu:     &sync.RWMutex{},
-		logger: logger.Named("email_repository"),
+		emails:      make(map[string]*domain.Email),
+		byStatus:    make(index),
+		byRecipient: make(index),
+		byTenant:    make(index),
+		byTag:       make(index),
+		cursors:     cursors,
+		mu:          &sync.RWMutex{},
+		logger:      logger.Named("email_repository"),
 	}
 }
 
@@ -33,7 +105,12 @@ func (r *EmailRepositoryContainer) Save(ctx context.Context, email *domain.Email
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	r.emails[email.ID] = email
+	if old, exists := r.emails[email.ID]; exists {
+		r.unindex(old)
+	}
+	stored := clone(email)
+	r.emails[stored.ID] = stored
+	r.index(stored)
 	return nil
 }
 
@@ -46,7 +123,7 @@ func (r *EmailRepositoryContainer) GetByID(ctx context.Context, id string) (*dom
 		return nil, ErrEmailNotFound
 	}
 
-	return email, nil
+	return clone(email), nil
 }
 
 func (r *EmailRepositoryContainer) UpdateStatus(ctx context.Context, id, status string, sentAt *time.Time) error {
@@ -58,70 +135,204 @@ func (r *EmailRepositoryContainer) UpdateStatus(ctx context.Context, id, status
 		return ErrEmailNotFound
 	}
 
+	r.byStatus.remove(email.Status, email)
 	email.Status = status
 	email.SentAt = sentAt
+	r.byStatus.add(email.Status, email)
 	return nil
 }
 
-func (r *EmailRepositoryContainer) List(ctx context.Context, pageSize int, pageToken string) ([]*domain.Email, string, error) {
+// scopeOf names the listing a page token belongs to, so that a token is only
+// accepted with the sort order and filter it was issued for
+func scopeOf(query domain.ListQuery) pagination.Scope {
+	f := query.Filter
+	return pagination.NewScope("emails",
+		strconv.Itoa(int(query.Order)),
+		f.Status,
+		domain.NormalizeAddress(f.To),
+		f.SubjectContains,
+		f.Tenant,
+		f.Tag,
+		timeParam(f.CreatedAfter),
+		timeParam(f.CreatedBefore),
+		timeParam(f.SentAfter),
+		timeParam(f.SentBefore),
+	)
+}
+
+func timeParam(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+// List returns one page of the emails matching query.Filter. Equality filters
+// are served from the secondary indexes, starting with the most selective one;
+// otherwise every email is scanned. Both are kept in created-at order, so a
+// page is found by binary search and only scanned until it is full. Page
+// tokens are signed cursors naming the last email of the previous page.
+func (r *EmailRepositoryContainer) List(ctx context.Context, query domain.ListQuery) (*domain.ListResult, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
+	pageSize := query.PageSize
 	if pageSize <= 0 {
 		pageSize = 10
 	}
 
-	emails := make([]*domain.Email, len(r.emails))
-	i := 0
-	for _, email := range r.emails {
-		emails[i] = email
-		i++
-	}
-
-	sort.Slice(emails, func(i, j int) bool {
-		if emails[i].CreatedAt.Equal(emails[j].CreatedAt) {
-			return emails[i].ID < emails[j].ID
-		}
-		return emails[i].CreatedAt.Before(emails[j].CreatedAt)
-	})
-
-	startIndex := 0
-	if pageToken != "" {
-		for i, email := range emails {
-			if email.ID == pageToken {
-				startIndex = i + 1
-				break
-			}
+	listScope := scopeOf(query)
+	var cursor *pagination.Cursor
+	if query.PageToken != "" {
+		c, err := r.cursors.Decode(listScope, query.PageToken)
+		if err != nil {
+			return nil, err
 		}
+		cursor = &c
 	}
 
-	if startIndex >= len(emails) {
-		return nil, "", nil
+	filter := query.Filter
+	scope, exact := r.candidates(filter)
+	lo, hi := 0, len(scope)
+	if !filter.CreatedAfter.IsZero() {
+		lo = scope.search(pagination.Cursor{CreatedAt: filter.CreatedAfter})
 	}
+	if !filter.CreatedBefore.IsZero() {
+		hi = scope.search(pagination.Cursor{CreatedAt: filter.CreatedBefore})
+	}
+	scope = scope[lo:max(lo, hi)]
 
-	endIndex := startIndex + pageSize
-	if endIndex > len(emails) {
-		endIndex = len(emails)
+	result := &domain.ListResult{TotalCount: len(scope)}
+	if !exact {
+		result.TotalCount = count(scope, filter)
 	}
 
-	result := emails[startIndex:endIndex]
+	// Cursors are resolved by sort key, so a page boundary survives the
+	// anchoring email being deleted or no longer matching the filter. One
+	// email past the page tells whether there is a next one.
+	var page []*domain.Email
+	if query.Order == domain.SortCreatedDesc {
+		end := len(scope)
+		if cursor != nil {
+			end = scope.search(*cursor)
+		}
+		for i := end - 1; i >= 0 && len(page) <= pageSize; i-- {
+			if filter.Matches(scope[i]) {
+				page = append(page, scope[i])
+			}
+		}
+	} else {
+		start := 0
+		if cursor != nil {
+			start = scope.searchAfter(*cursor)
+		}
+		for i := start; i < len(scope) && len(page) <= pageSize; i++ {
+			if filter.Matches(scope[i]) {
+				page = append(page, scope[i])
+			}
+		}
+	}
 
-	var nextPageToken string
-	if endIndex < len(emails) {
-		nextPageToken = emails[endIndex-1].ID
+	if len(page) > pageSize {
+		page = page[:pageSize]
+		last := page[pageSize-1]
+		result.NextPageToken = r.cursors.Encode(listScope, positionOf(last))
+	}
+	for _, email := range page {
+		result.Emails = append(result.Emails, clone(email))
 	}
 
-	return result, nextPageToken, nil
+	return result, nil
 }
 
 func (r *EmailRepositoryContainer) DeleteByID(ctx context.Context, id string) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	if _, exists := r.emails[id]; !exists {
+	email, exists := r.emails[id]
+	if !exists {
 		return ErrEmailNotFound
 	}
 
+	r.unindex(email)
 	delete(r.emails, id)
 	return nil
 }
+
+// count returns how many emails in scope match filter
+func count(scope bucket, filter domain.ListFilter) int {
+	n := 0
+	for _, email := range scope {
+		if filter.Matches(email) {
+			n++
+		}
+	}
+	return n
+}
+
+// candidates returns the smallest index bucket among the filter's equality
+// conditions, or every email if the filter has none. exact reports whether
+// they all match the filter, its created-at bounds aside.
+func (r *EmailRepositoryContainer) candidates(filter domain.ListFilter) (bucket, bool) {
+	var (
+		best       bucket
+		conditions int
+	)
+	for _, c := range []struct {
+		ix  index
+		key string
+	}{
+		{r.byStatus, filter.Status},
+		{r.byRecipient, domain.NormalizeAddress(filter.To)},
+		{r.byTenant, filter.Tenant},
+		{r.byTag, filter.Tag},
+	} {
+		if c.key == "" {
+			continue
+		}
+		emails := c.ix[c.key]
+		if conditions == 0 || len(emails) < len(best) {
+			best = emails
+		}
+		conditions++
+	}
+	if conditions == 0 {
+		best = r.byCreated
+	}
+
+	exact := conditions <= 1 && filter.SubjectContains == "" &&
+		filter.SentAfter.IsZero() && filter.SentBefore.IsZero()
+	return best, exact
+}
+
+func (r *EmailRepositoryContainer) index(email *domain.Email) {
+	r.byCreated = r.byCreated.insert(email)
+	r.byStatus.add(email.Status, email)
+	r.byRecipient.add(domain.NormalizeAddress(email.To), email)
+	r.byTenant.add(email.Tenant, email)
+	for _, tag := range email.Tags {
+		r.byTag.add(tag, email)
+	}
+}
+
+func (r *EmailRepositoryContainer) unindex(email *domain.Email) {
+	r.byCreated = r.byCreated.remove(email)
+	r.byStatus.remove(email.Status, email)
+	r.byRecipient.remove(domain.NormalizeAddress(email.To), email)
+	r.byTenant.remove(email.Tenant, email)
+	for _, tag := range email.Tags {
+		r.byTag.remove(tag, email)
+	}
+}
+
+func positionOf(email *domain.Email) pagination.Cursor {
+	return pagination.Cursor{CreatedAt: email.CreatedAt, ID: email.ID}
+}
+
+// clone copies an email, so stored emails are only changed under the lock
+// and callers never see them change
+func clone(email *domain.Email) *domain.Email {
+	c := *email
+	c.Tags = slices.Clone(email.Tags)
+	return &c
+}
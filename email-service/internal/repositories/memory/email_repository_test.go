@@ -10,12 +10,13 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/popeskul/mailflow/common/logger"
+	"github.com/popeskul/mailflow/common/pagination"
 	"github.com/popeskul/mailflow/email-service/internal/domain"
 )
 
 func createTestEmailRepository() *EmailRepositoryContainer {
 	testLogger := logger.NewZapLogger()
-	return newEmailRepository(testLogger)
+	return newEmailRepository(pagination.NewCodec([]byte("test-secret")), testLogger)
 }
 
 func createTestEmail(to, subject, body string) *domain.Email {
@@ -78,6 +79,28 @@ func TestEmailRepository_Save_Overwrite(t *testing.T) {
 	assert.Equal(t, "Updated Body", storedEmail.Body)
 }
 
+func TestEmailRepository_Copies(t *testing.T) {
+	repo := createTestEmailRepository()
+	email := createTestEmail("test@example.com", "Subject", "Body")
+	email.Tags = []string{"welcome"}
+	require.NoError(t, repo.Save(context.Background(), email))
+
+	// Neither the saved email nor the ones returned share the stored one
+	email.Status = domain.StatusSent
+	got, err := repo.GetByID(context.Background(), email.ID)
+	require.NoError(t, err)
+	got.Status = domain.StatusFailed
+	got.Tags[0] = "changed"
+	page, err := repo.List(context.Background(), domain.ListQuery{})
+	require.NoError(t, err)
+	page.Emails[0].Status = domain.StatusFailed
+
+	stored, err := repo.GetByID(context.Background(), email.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.StatusPending, stored.Status)
+	assert.Equal(t, []string{"welcome"}, stored.Tags)
+}
+
 func TestEmailRepository_GetByID_Success(t *testing.T) {
 	tests := []struct {
 		name  string
@@ -263,15 +286,18 @@ func TestEmailRepository_List_Success(t *testing.T) {
 			// Wait a bit to ensure different timestamps
 			time.Sleep(time.Millisecond)
 
-			emails, nextToken, err := repo.List(context.Background(), tt.pageSize, tt.pageToken)
+			result, err := repo.List(context.Background(), domain.ListQuery{PageSize: tt.pageSize, PageToken: tt.pageToken})
 
 			assert.NoError(t, err)
-			assert.Equal(t, tt.expected, len(emails))
+			assert.Equal(t, tt.expected, len(result.Emails))
+			assert.Equal(t, len(tt.emails), result.TotalCount)
 
 			if tt.expected > 0 && tt.pageSize > 0 && tt.pageSize < len(tt.emails) {
-				assert.NotEmpty(t, nextToken)
+				assert.NotEmpty(t, result.NextPageToken)
 			}
 
+			emails := result.Emails
+
 			// Verify emails are sorted by creation time
 			for i := 1; i < len(emails); i++ {
 				assert.True(t, emails[i-1].CreatedAt.Before(emails[i].CreatedAt) ||
@@ -299,16 +325,18 @@ func TestEmailRepository_List_WithPageToken(t *testing.T) {
 	}
 
 	// Get first page
-	firstPage, nextToken, err := repo.List(context.Background(), 2, "")
+	first, err := repo.List(context.Background(), domain.ListQuery{PageSize: 2})
 	require.NoError(t, err)
+	firstPage := first.Emails
 	assert.Equal(t, 2, len(firstPage))
-	assert.NotEmpty(t, nextToken)
+	assert.NotEmpty(t, first.NextPageToken)
 
 	// Get second page using token
-	secondPage, finalToken, err := repo.List(context.Background(), 2, nextToken)
+	second, err := repo.List(context.Background(), domain.ListQuery{PageSize: 2, PageToken: first.NextPageToken})
 	require.NoError(t, err)
+	secondPage := second.Emails
 	assert.Equal(t, 2, len(secondPage))
-	assert.Empty(t, finalToken) // Should be empty as this is the last page
+	assert.Empty(t, second.NextPageToken) // Should be empty as this is the last page
 
 	// Verify no overlap between pages
 	firstPageIDs := make(map[string]bool)
@@ -324,17 +352,294 @@ func TestEmailRepository_List_WithPageToken(t *testing.T) {
 func TestEmailRepository_List_InvalidPageToken(t *testing.T) {
 	repo := createTestEmailRepository()
 
-	// Create an email
 	email := createTestEmail("test@example.com", "Test Subject", "Test Body")
-	err := repo.Save(context.Background(), email)
+	require.NoError(t, repo.Save(context.Background(), email))
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{name: "raw email id", token: email.ID},
+		{name: "garbage", token: "invalid-token"},
+		{
+			name:  "signed with another key",
+			token: pagination.NewCodec([]byte("other")).Encode(scopeOf(domain.ListQuery{}), pagination.Cursor{CreatedAt: email.CreatedAt, ID: email.ID}),
+		},
+		{
+			name:  "issued for users",
+			token: pagination.NewCodec([]byte("test-secret")).Encode(pagination.NewScope("users"), pagination.Cursor{CreatedAt: email.CreatedAt, ID: email.ID}),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := repo.List(context.Background(), domain.ListQuery{PageSize: 10, PageToken: tt.token})
+
+			assert.ErrorIs(t, err, pagination.ErrInvalidToken)
+			assert.Nil(t, result)
+		})
+	}
+}
+
+func TestEmailRepository_List_PageTokenOtherQuery(t *testing.T) {
+	repo := createTestEmailRepository()
+	for i := 0; i < 3; i++ {
+		email := createTestEmail("test@example.com", "Test Subject", "Test Body")
+		email.CreatedAt = time.Unix(int64(i), 0)
+		require.NoError(t, repo.Save(context.Background(), email))
+	}
+
+	issued := domain.ListQuery{Order: domain.SortCreatedDesc, Filter: domain.ListFilter{Status: domain.StatusPending}, PageSize: 1}
+	first, err := repo.List(context.Background(), issued)
 	require.NoError(t, err)
+	require.NotEmpty(t, first.NextPageToken)
 
-	// Use invalid page token - this will start from beginning since token not found
-	emails, nextToken, err := repo.List(context.Background(), 10, "invalid-token")
+	tests := []struct {
+		name  string
+		query domain.ListQuery
+	}{
+		{name: "other order", query: domain.ListQuery{Order: domain.SortCreatedAsc, Filter: issued.Filter}},
+		{name: "other filter", query: domain.ListQuery{Order: issued.Order, Filter: domain.ListFilter{Status: domain.StatusSent}}},
+		{name: "no filter", query: domain.ListQuery{Order: issued.Order}},
+	}
 
-	assert.NoError(t, err)
-	assert.Equal(t, 1, len(emails)) // Should return all emails since invalid token means start from beginning
-	assert.Empty(t, nextToken)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.query.PageSize = 1
+			tt.query.PageToken = first.NextPageToken
+
+			result, err := repo.List(context.Background(), tt.query)
+
+			assert.ErrorIs(t, err, pagination.ErrInvalidToken)
+			assert.Nil(t, result)
+		})
+	}
+
+	// The query it was issued for still accepts it
+	issued.PageToken = first.NextPageToken
+	next, err := repo.List(context.Background(), issued)
+	require.NoError(t, err)
+	assert.Len(t, next.Emails, 1)
+}
+
+func TestEmailRepository_List_PageTokenSurvivesDelete(t *testing.T) {
+	repo := createTestEmailRepository()
+	base := time.Now()
+	for i := 0; i < 4; i++ {
+		email := createTestEmail(fmt.Sprintf("user%d@example.com", i), "Subject", "Body")
+		email.ID = fmt.Sprintf("email-%d", i)
+		email.CreatedAt = base.Add(time.Duration(i) * time.Second)
+		require.NoError(t, repo.Save(context.Background(), email))
+	}
+
+	first, err := repo.List(context.Background(), domain.ListQuery{PageSize: 2})
+	require.NoError(t, err)
+
+	// Deleting the anchor and inserting before it must not shift the next page
+	require.NoError(t, repo.DeleteByID(context.Background(), first.Emails[1].ID))
+	early := createTestEmail("early@example.com", "Subject", "Body")
+	early.CreatedAt = base.Add(-time.Hour)
+	require.NoError(t, repo.Save(context.Background(), early))
+
+	second, err := repo.List(context.Background(), domain.ListQuery{PageSize: 2, PageToken: first.NextPageToken})
+	require.NoError(t, err)
+	require.Len(t, second.Emails, 2)
+	assert.Equal(t, "email-2", second.Emails[0].ID)
+	assert.Equal(t, "email-3", second.Emails[1].ID)
+}
+
+func TestEmailRepository_List_Filters(t *testing.T) {
+	base := time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC)
+	sentAt := base.Add(time.Hour)
+
+	newEmail := func(id, to, subject, status, tenant string, offset time.Duration, tags ...string) *domain.Email {
+		email := &domain.Email{
+			ID:        id,
+			To:        to,
+			Subject:   subject,
+			Status:    status,
+			CreatedAt: base.Add(offset),
+			Tenant:    tenant,
+			Tags:      tags,
+		}
+		if status == domain.StatusSent {
+			email.SentAt = &sentAt
+		}
+		return email
+	}
+
+	seed := []*domain.Email{
+		newEmail("a", "alice@example.com", "Welcome aboard", domain.StatusSent, "acme", 0, "onboarding"),
+		newEmail("b", "bob@example.com", "Weekly digest", domain.StatusFailed, "acme", time.Minute, "digest"),
+		newEmail("c", "Alice@Example.com", "Your invoice", domain.StatusPending, "globex", 2*time.Minute, "billing"),
+		newEmail("d", "carol@example.com", "Weekly digest", domain.StatusSent, "globex", 3*time.Minute, "digest"),
+	}
+
+	tests := []struct {
+		name     string
+		query    domain.ListQuery
+		expected []string
+	}{
+		{name: "no filter", query: domain.ListQuery{}, expected: []string{"a", "b", "c", "d"}},
+		{name: "newest first", query: domain.ListQuery{Order: domain.SortCreatedDesc}, expected: []string{"d", "c", "b", "a"}},
+		{name: "status", query: domain.ListQuery{Filter: domain.ListFilter{Status: domain.StatusSent}}, expected: []string{"a", "d"}},
+		{name: "recipient ignores case", query: domain.ListQuery{Filter: domain.ListFilter{To: "ALICE@example.com"}}, expected: []string{"a", "c"}},
+		{name: "subject substring", query: domain.ListQuery{Filter: domain.ListFilter{SubjectContains: "DIGEST"}}, expected: []string{"b", "d"}},
+		{name: "tenant and tag", query: domain.ListQuery{Filter: domain.ListFilter{Tenant: "globex", Tag: "digest"}}, expected: []string{"d"}},
+		{name: "unknown tag", query: domain.ListQuery{Filter: domain.ListFilter{Tag: "promo"}}, expected: nil},
+		{
+			name:     "created range",
+			query:    domain.ListQuery{Filter: domain.ListFilter{CreatedAfter: base.Add(time.Minute), CreatedBefore: base.Add(3 * time.Minute)}},
+			expected: []string{"b", "c"},
+		},
+		{
+			name:     "sent range skips unsent",
+			query:    domain.ListQuery{Filter: domain.ListFilter{SentAfter: base}, Order: domain.SortCreatedDesc},
+			expected: []string{"d", "a"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := createTestEmailRepository()
+			for _, email := range seed {
+				require.NoError(t, repo.Save(context.Background(), email))
+			}
+
+			result, err := repo.List(context.Background(), tt.query)
+
+			require.NoError(t, err)
+			var ids []string
+			for _, email := range result.Emails {
+				ids = append(ids, email.ID)
+			}
+			assert.Equal(t, tt.expected, ids)
+			assert.Equal(t, len(tt.expected), result.TotalCount)
+		})
+	}
+}
+
+func TestEmailRepository_List_IndexesFollowUpdates(t *testing.T) {
+	repo := createTestEmailRepository()
+	email := createTestEmail("old@example.com", "Subject", "Body")
+	require.NoError(t, repo.Save(context.Background(), email))
+
+	// Mutating and saving again must move the email between index buckets
+	email.To = "new@example.com"
+	require.NoError(t, repo.Save(context.Background(), email))
+	require.NoError(t, repo.UpdateStatus(context.Background(), email.ID, domain.StatusFailed, nil))
+
+	for _, tc := range []struct {
+		filter   domain.ListFilter
+		expected int
+	}{
+		{domain.ListFilter{To: "old@example.com"}, 0},
+		{domain.ListFilter{To: "new@example.com"}, 1},
+		{domain.ListFilter{Status: domain.StatusPending}, 0},
+		{domain.ListFilter{Status: domain.StatusFailed}, 1},
+	} {
+		result, err := repo.List(context.Background(), domain.ListQuery{Filter: tc.filter})
+		require.NoError(t, err)
+		assert.Equal(t, tc.expected, result.TotalCount, "filter %+v", tc.filter)
+	}
+
+	require.NoError(t, repo.DeleteByID(context.Background(), email.ID))
+	result, err := repo.List(context.Background(), domain.ListQuery{})
+	require.NoError(t, err)
+	assert.Zero(t, result.TotalCount)
+}
+
+func TestEmailRepository_List_PageTokenSurvivesStatusChange(t *testing.T) {
+	repo := createTestEmailRepository()
+	base := time.Now()
+	for i := 0; i < 4; i++ {
+		email := createTestEmail(fmt.Sprintf("user%d@example.com", i), "Subject", "Body")
+		email.ID = fmt.Sprintf("email-%d", i)
+		email.Status = domain.StatusFailed
+		email.CreatedAt = base.Add(time.Duration(i) * time.Second)
+		require.NoError(t, repo.Save(context.Background(), email))
+	}
+
+	query := domain.ListQuery{Filter: domain.ListFilter{Status: domain.StatusFailed}, PageSize: 2}
+	first, err := repo.List(context.Background(), query)
+	require.NoError(t, err)
+
+	// The anchor of the next page no longer matches the filter
+	for _, email := range first.Emails {
+		require.NoError(t, repo.UpdateStatus(context.Background(), email.ID, domain.StatusPending, nil))
+	}
+
+	query.PageToken = first.NextPageToken
+	second, err := repo.List(context.Background(), query)
+	require.NoError(t, err)
+	require.Len(t, second.Emails, 2)
+	assert.Equal(t, "email-2", second.Emails[0].ID)
+	assert.Equal(t, "email-3", second.Emails[1].ID)
+}
+
+func TestEmailRepository_List_Pages(t *testing.T) {
+	repo := createTestEmailRepository()
+	base := time.Now()
+	for i := 0; i < 10; i++ {
+		email := createTestEmail(fmt.Sprintf("user%d@example.com", i), "Subject", "Body")
+		email.ID = fmt.Sprintf("email-%d", i)
+		email.CreatedAt = base.Add(time.Duration(i) * time.Second)
+		email.Tags = []string{"all", "all"}
+		if i%2 == 0 {
+			email.Tags = append(email.Tags, "even")
+		}
+		require.NoError(t, repo.Save(context.Background(), email))
+	}
+
+	tests := []struct {
+		name     string
+		query    domain.ListQuery
+		expected []string
+	}{
+		{
+			name:     "duplicate tags",
+			query:    domain.ListQuery{Filter: domain.ListFilter{Tag: "all", CreatedBefore: base.Add(3 * time.Second)}},
+			expected: []string{"email-0", "email-1", "email-2"},
+		},
+		{
+			name:     "two conditions",
+			query:    domain.ListQuery{Filter: domain.ListFilter{Tag: "even", Status: domain.StatusPending}},
+			expected: []string{"email-0", "email-2", "email-4", "email-6", "email-8"},
+		},
+		{
+			name:     "two conditions newest first",
+			query:    domain.ListQuery{Filter: domain.ListFilter{Tag: "even", Status: domain.StatusPending}, Order: domain.SortCreatedDesc},
+			expected: []string{"email-8", "email-6", "email-4", "email-2", "email-0"},
+		},
+		{
+			name:     "created range newest first",
+			query:    domain.ListQuery{Filter: domain.ListFilter{CreatedAfter: base.Add(5 * time.Second)}, Order: domain.SortCreatedDesc},
+			expected: []string{"email-9", "email-8", "email-7", "email-6", "email-5"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := tt.query
+			query.PageSize = 2
+
+			var ids []string
+			for {
+				page, err := repo.List(context.Background(), query)
+				require.NoError(t, err)
+				assert.Equal(t, len(tt.expected), page.TotalCount)
+				for _, email := range page.Emails {
+					ids = append(ids, email.ID)
+				}
+				if page.NextPageToken == "" {
+					break
+				}
+				query.PageToken = page.NextPageToken
+			}
+
+			assert.Equal(t, tt.expected, ids)
+		})
+	}
 }
 
 func TestEmailRepository_DeleteByID_Success(t *testing.T) {
@@ -415,7 +720,7 @@ func TestEmailRepository_ConcurrentAccess(t *testing.T) {
 	}
 
 	// Verify all emails were saved
-	emails, _, err := repo.List(context.Background(), 20, "")
+	result, err := repo.List(context.Background(), domain.ListQuery{PageSize: 20})
 	require.NoError(t, err)
-	assert.Equal(t, 10, len(emails))
+	assert.Equal(t, 10, len(result.Emails))
 }
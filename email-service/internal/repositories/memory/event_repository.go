@@ -0,0 +1,46 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/popeskul/mailflow/common/logger"
+	"github.com/popeskul/mailflow/email-service/internal/domain"
+)
+
+type EventRepositoryContainer struct {
+	events map[string][]*domain.EmailEvent
+	mu     *sync.RWMutex
+	logger logger.Logger
+}
+
+func newEventRepository(logger logger.Logger) *EventRepositoryContainer {
+	return &EventRepositoryContainer{
+		events: make(map[string][]*domain.EmailEvent),
+		mu:     &sync.RWMutex{},
+		logger: logger.Named("event_repository"),
+	}
+}
+
+func (r *EventRepositoryContainer) Save(ctx context.Context, event *domain.EmailEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.events[event.EmailID] = append(r.events[event.EmailID], event)
+	return nil
+}
+
+func (r *EventRepositoryContainer) ListByEmailID(ctx context.Context, emailID string) ([]*domain.EmailEvent, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	events := make([]*domain.EmailEvent, len(r.events[emailID]))
+	copy(events, r.events[emailID])
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].OccurredAt.Before(events[j].OccurredAt)
+	})
+
+	return events, nil
+}
@@ -0,0 +1,63 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/popeskul/mailflow/common/logger"
+	"github.com/popeskul/mailflow/email-service/internal/domain"
+)
+
+func createTestEventRepository() *EventRepositoryContainer {
+	return newEventRepository(logger.NewZapLogger())
+}
+
+func TestEventRepository_ListByEmailID_Success(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name          string
+		events        []*domain.EmailEvent
+		emailID       string
+		expectedOrder []string
+	}{
+		{
+			name: "events are returned oldest first",
+			events: []*domain.EmailEvent{
+				{ID: "2", EmailID: "email-1", Type: domain.EventClick, OccurredAt: now.Add(time.Minute)},
+				{ID: "1", EmailID: "email-1", Type: domain.EventOpen, OccurredAt: now},
+				{ID: "3", EmailID: "email-2", Type: domain.EventOpen, OccurredAt: now},
+			},
+			emailID:       "email-1",
+			expectedOrder: []string{"1", "2"},
+		},
+		{
+			name:          "email without events",
+			emailID:       "email-1",
+			expectedOrder: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := createTestEventRepository()
+
+			for _, event := range tt.events {
+				require.NoError(t, repo.Save(context.Background(), event))
+			}
+
+			events, err := repo.ListByEmailID(context.Background(), tt.emailID)
+
+			assert.NoError(t, err)
+			ids := make([]string, 0, len(events))
+			for _, event := range events {
+				ids = append(ids, event.ID)
+			}
+			assert.Equal(t, tt.expectedOrder, ids)
+		})
+	}
+}
@@ -1,20 +1,36 @@
 package memory
 
 import (
+	"context"
+
 	"github.com/popeskul/mailflow/common/logger"
+	"github.com/popeskul/mailflow/common/pagination"
 	"github.com/popeskul/mailflow/email-service/internal/domain"
 )
 
 type Repositories struct {
-	email domain.EmailRepository
+	email  domain.EmailRepository
+	events domain.EmailEventRepository
 }
 
-func NewRepositories(logger logger.Logger) *Repositories {
+// NewRepositories creates the in-memory repositories. cursors signs the page
+// tokens handed out by List.
+func NewRepositories(cursors *pagination.Codec, logger logger.Logger) *Repositories {
 	return &Repositories{
-		email: newEmailRepository(logger),
+		email:  newEmailRepository(cursors, logger),
+		events: newEventRepository(logger),
 	}
 }
 
 func (r *Repositories) Email() domain.EmailRepository {
 	return r.email
 }
+
+func (r *Repositories) Events() domain.EmailEventRepository {
+	return r.events
+}
+
+// Ping reports whether the storage backend can be reached. Memory always can.
+func (r *Repositories) Ping(_ context.Context) error {
+	return nil
+}
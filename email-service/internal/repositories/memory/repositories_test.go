@@ -7,6 +7,8 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/popeskul/mailflow/common/logger"
+	"github.com/popeskul/mailflow/common/pagination"
+	"github.com/popeskul/mailflow/email-service/internal/domain"
 )
 
 func TestNewRepositories(t *testing.T) {
@@ -21,10 +23,11 @@ func TestNewRepositories(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			testLogger := logger.NewZapLogger()
-			repos := NewRepositories(testLogger)
+			repos := NewRepositories(pagination.NewCodec([]byte("test-secret")), testLogger)
 
 			assert.NotNil(t, repos)
 			assert.NotNil(t, repos.Email())
+			assert.NotNil(t, repos.Events())
 		})
 	}
 }
@@ -41,15 +44,21 @@ func TestRepositories_Email(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			testLogger := logger.NewZapLogger()
-			repos := NewRepositories(testLogger)
+			repos := NewRepositories(pagination.NewCodec([]byte("test-secret")), testLogger)
 
 			emailRepo := repos.Email()
 
 			assert.NotNil(t, emailRepo)
 			// Verify we can call methods on the repository
 			assert.NotPanics(t, func() {
-				_, _, _ = emailRepo.List(context.TODO(), 10, "")
+				_, _ = emailRepo.List(context.TODO(), domain.ListQuery{PageSize: 10})
 			})
 		})
 	}
 }
+
+func TestRepositories_Ping(t *testing.T) {
+	repos := NewRepositories(pagination.NewCodec([]byte("test-secret")), logger.NewZapLogger())
+
+	assert.NoError(t, repos.Ping(context.Background()))
+}
@@ -0,0 +1,313 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/popeskul/mailflow/common/logger"
+	"github.com/popeskul/mailflow/email-service/internal/config"
+	"github.com/popeskul/mailflow/email-service/internal/domain"
+)
+
+var (
+	// ErrQueueFull is returned when every worker is busy and the dispatch
+	// queue had no room left within the submit timeout.
+	ErrQueueFull = errors.New("dispatch queue is full")
+	// ErrDispatcherClosed is returned for emails submitted after shutdown began.
+	ErrDispatcherClosed = errors.New("dispatcher is shut down")
+)
+
+// drainGrace bounds how long shutdown waits for cancelled deliveries to
+// return once the deadline has passed.
+var drainGrace = time.Second
+
+// deliveryFunc performs a single delivery attempt for an accepted email.
+type deliveryFunc func(ctx context.Context, email *domain.Email)
+
+// job is a queued email together with the span that accepted it.
+type job struct {
+	email *domain.Email
+	link  trace.Link
+}
+
+// dispatcher hands accepted emails to a fixed pool of delivery workers.
+// The queue is bounded, so producers get ErrQueueFull instead of piling
+// up unbounded work when the workers cannot keep up.
+type dispatcher struct {
+	jobs          chan job
+	deliver       deliveryFunc
+	workers       int
+	submitTimeout time.Duration
+	metrics       Metrics
+	logger        logger.Logger
+
+	// mu guards closed. Submits register in senders under it, so jobs is
+	// only closed once every submit has stopped sending; done wakes the
+	// submits still waiting for room.
+	mu      sync.RWMutex
+	closed  bool
+	done    chan struct{}
+	senders sync.WaitGroup
+
+	// parkMu guards parked and leftover, which collect emails that were not
+	// delivered while draining
+	parkMu   sync.Mutex
+	parked   map[string]*domain.Email
+	leftover []*domain.Email
+
+	active  atomic.Int32
+	drained atomic.Int32
+	wg      sync.WaitGroup
+	ctx     context.Context
+	cancel  context.CancelFunc
+}
+
+func newDispatcher(
+	cfg config.DispatchConfig,
+	deliver deliveryFunc,
+	metrics Metrics,
+	l logger.Logger,
+) *dispatcher {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &dispatcher{
+		jobs:          make(chan job, cfg.QueueSize),
+		deliver:       deliver,
+		workers:       cfg.Workers,
+		submitTimeout: cfg.SubmitTimeout,
+		metrics:       metrics,
+		logger:        l.Named("dispatcher"),
+		parked:        make(map[string]*domain.Email),
+		done:          make(chan struct{}),
+		ctx:           ctx,
+		cancel:        cancel,
+	}
+}
+
+// start launches the worker goroutines
+func (d *dispatcher) start() {
+	d.logger.Info("starting dispatch workers",
+		logger.Field{Key: "workers", Value: d.workers},
+		logger.Field{Key: "queue_size", Value: cap(d.jobs)},
+	)
+
+	for i := 0; i < d.workers; i++ {
+		d.wg.Add(1)
+		go d.work()
+	}
+}
+
+func (d *dispatcher) work() {
+	defer d.wg.Done()
+
+	tracer := otel.GetTracerProvider().Tracer("email-service")
+
+	for j := range d.jobs {
+		d.metrics.SetQueueSize(len(d.jobs))
+
+		// Past the drain deadline the remaining jobs are only collected
+		if d.ctx.Err() != nil {
+			d.keep(j.email)
+			continue
+		}
+
+		d.metrics.SetActiveWorkers(int(d.active.Add(1)))
+
+		ctx, span := tracer.Start(d.ctx, "DeliverEmail",
+			trace.WithLinks(j.link),
+			trace.WithAttributes(attribute.String("email.id", j.email.ID)),
+		)
+		d.deliver(ctx, j.email)
+		span.End()
+
+		if d.isClosed() && !d.isParked(j.email.ID) {
+			d.drained.Add(1)
+		}
+
+		d.metrics.SetActiveWorkers(int(d.active.Add(-1)))
+	}
+}
+
+// submit queues an email, waiting up to the submit timeout for room.
+// The wait does not hold mu, so shutdown and the workers never block on it.
+func (d *dispatcher) submit(ctx context.Context, email *domain.Email) error {
+	d.mu.RLock()
+	if d.closed {
+		d.mu.RUnlock()
+		return ErrDispatcherClosed
+	}
+	d.senders.Add(1)
+	d.mu.RUnlock()
+	defer d.senders.Done()
+
+	j := job{
+		email: email,
+		link:  trace.LinkFromContext(ctx),
+	}
+
+	select {
+	case d.jobs <- j:
+		d.metrics.SetQueueSize(len(d.jobs))
+		return nil
+	default:
+	}
+
+	if d.submitTimeout <= 0 {
+		return ErrQueueFull
+	}
+
+	timer := time.NewTimer(d.submitTimeout)
+	defer timer.Stop()
+
+	select {
+	case d.jobs <- j:
+		d.metrics.SetQueueSize(len(d.jobs))
+		return nil
+	case <-timer.C:
+		return ErrQueueFull
+	case <-d.done:
+		return ErrDispatcherClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// offer queues an email without waiting. Workers use it for retries so
+// that a full queue never blocks the pool on itself. Once shutdown has
+// begun, retries are parked instead and handed back by shutdown.
+func (d *dispatcher) offer(email *domain.Email) error {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.closed {
+		d.park(email)
+		return nil
+	}
+
+	select {
+	case d.jobs <- job{email: email}:
+		d.metrics.SetQueueSize(len(d.jobs))
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// len returns the number of emails waiting for a worker
+func (d *dispatcher) len() int {
+	return len(d.jobs)
+}
+
+func (d *dispatcher) isClosed() bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return d.closed
+}
+
+func (d *dispatcher) park(email *domain.Email) {
+	d.parkMu.Lock()
+	defer d.parkMu.Unlock()
+
+	d.parked[email.ID] = email
+}
+
+func (d *dispatcher) isParked(id string) bool {
+	d.parkMu.Lock()
+	defer d.parkMu.Unlock()
+
+	_, ok := d.parked[id]
+	return ok
+}
+
+// keep records a queued email that will not be attempted before shutdown
+func (d *dispatcher) keep(email *domain.Email) {
+	d.parkMu.Lock()
+	defer d.parkMu.Unlock()
+
+	d.leftover = append(d.leftover, email)
+}
+
+// drainResult describes what the workers did with the queue during shutdown.
+type drainResult struct {
+	// drained counts emails whose delivery attempt finished while draining
+	drained int
+	// parked holds emails whose attempt failed while draining; they are
+	// not retried by this process
+	parked []*domain.Email
+	// leftover holds queued emails that were never attempted
+	leftover []*domain.Email
+	// abandoned counts deliveries still running after the drain grace period
+	abandoned int
+}
+
+// shutdown stops accepting emails and waits for the workers to drain the
+// queue. If ctx expires first, in-flight deliveries are cancelled, the
+// emails still queued are collected into the result and ctx.Err() is
+// returned.
+func (d *dispatcher) shutdown(ctx context.Context) (drainResult, error) {
+	d.mu.Lock()
+	first := !d.closed
+	if first {
+		d.closed = true
+		close(d.done)
+	}
+	d.mu.Unlock()
+
+	if first {
+		d.senders.Wait()
+		close(d.jobs)
+	}
+
+	d.logger.Info("draining dispatch queue",
+		logger.Field{Key: "queued", Value: len(d.jobs)},
+		logger.Field{Key: "in_flight", Value: d.active.Load()},
+	)
+
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+
+	var err error
+	select {
+	case <-done:
+		d.cancel()
+	case <-ctx.Done():
+		err = ctx.Err()
+		d.cancel()
+
+		// Collect what the workers have not picked up yet
+		for j := range d.jobs {
+			d.keep(j.email)
+		}
+
+		select {
+		case <-done:
+		case <-time.After(drainGrace):
+		}
+	}
+
+	d.parkMu.Lock()
+	defer d.parkMu.Unlock()
+
+	parked := make([]*domain.Email, 0, len(d.parked))
+	for _, email := range d.parked {
+		parked = append(parked, email)
+	}
+
+	return drainResult{
+		drained:   int(d.drained.Load()),
+		parked:    parked,
+		leftover:  d.leftover,
+		abandoned: int(d.active.Load()),
+	}, err
+}
@@ -0,0 +1,278 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/popeskul/mailflow/email-service/internal/config"
+	"github.com/popeskul/mailflow/email-service/internal/domain"
+	"github.com/popeskul/mailflow/email-service/internal/services/mocks"
+)
+
+func createTestDispatcher(cfg config.DispatchConfig, deliver deliveryFunc) *dispatcher {
+	return newDispatcher(cfg, deliver, &noOpMetrics{}, createTestLogger())
+}
+
+func TestDispatcher_Submit_Success(t *testing.T) {
+	tests := []struct {
+		name    string
+		workers int
+		emails  int
+	}{
+		{
+			name:    "single worker delivers every email",
+			workers: 1,
+			emails:  10,
+		},
+		{
+			name:    "worker pool delivers every email",
+			workers: 4,
+			emails:  100,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			delivered := make(map[string]bool)
+
+			d := createTestDispatcher(config.DispatchConfig{
+				Workers:   tt.workers,
+				QueueSize: tt.emails,
+			}, func(_ context.Context, email *domain.Email) {
+				mu.Lock()
+				delivered[email.ID] = true
+				mu.Unlock()
+			})
+			d.start()
+
+			for i := 0; i < tt.emails; i++ {
+				err := d.submit(context.Background(), domain.NewEmail("test@example.com", "Subject", "Body"))
+				require.NoError(t, err)
+			}
+
+			result, err := d.shutdown(context.Background())
+			require.NoError(t, err)
+			assert.Len(t, delivered, tt.emails)
+			assert.Empty(t, result.leftover)
+		})
+	}
+}
+
+func TestDispatcher_Submit_Fail(t *testing.T) {
+	tests := []struct {
+		name          string
+		cfg           config.DispatchConfig
+		prepare       func(*dispatcher) context.Context
+		expectedError error
+	}{
+		{
+			name: "queue full without submit timeout",
+			cfg: config.DispatchConfig{
+				Workers:   1,
+				QueueSize: 0,
+			},
+			prepare: func(_ *dispatcher) context.Context {
+				return context.Background()
+			},
+			expectedError: ErrQueueFull,
+		},
+		{
+			name: "queue full after submit timeout",
+			cfg: config.DispatchConfig{
+				Workers:       1,
+				QueueSize:     1,
+				SubmitTimeout: 10 * time.Millisecond,
+			},
+			prepare: func(d *dispatcher) context.Context {
+				_ = d.offer(domain.NewEmail("test@example.com", "Subject", "Body"))
+				return context.Background()
+			},
+			expectedError: ErrQueueFull,
+		},
+		{
+			name: "caller context cancelled while waiting",
+			cfg: config.DispatchConfig{
+				Workers:       1,
+				QueueSize:     0,
+				SubmitTimeout: time.Minute,
+			},
+			prepare: func(_ *dispatcher) context.Context {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+				return ctx
+			},
+			expectedError: context.Canceled,
+		},
+		{
+			name: "dispatcher already shut down",
+			cfg: config.DispatchConfig{
+				Workers:   1,
+				QueueSize: 10,
+			},
+			prepare: func(d *dispatcher) context.Context {
+				_, _ = d.shutdown(context.Background())
+				return context.Background()
+			},
+			expectedError: ErrDispatcherClosed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := createTestDispatcher(tt.cfg, func(context.Context, *domain.Email) {})
+			ctx := tt.prepare(d)
+
+			err := d.submit(ctx, domain.NewEmail("test@example.com", "Subject", "Body"))
+
+			assert.ErrorIs(t, err, tt.expectedError)
+		})
+	}
+}
+
+func TestDispatcher_Shutdown_Fail(t *testing.T) {
+	tests := []struct {
+		name    string
+		timeout time.Duration
+	}{
+		{
+			name:    "in-flight delivery outlives shutdown deadline",
+			timeout: 20 * time.Millisecond,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			started := make(chan struct{})
+			cancelled := make(chan struct{})
+
+			d := createTestDispatcher(config.DispatchConfig{
+				Workers:   1,
+				QueueSize: 1,
+			}, func(ctx context.Context, _ *domain.Email) {
+				close(started)
+				<-ctx.Done()
+				close(cancelled)
+			})
+			d.start()
+
+			require.NoError(t, d.submit(context.Background(), domain.NewEmail("test@example.com", "Subject", "Body")))
+			<-started
+
+			ctx, cancel := context.WithTimeout(context.Background(), tt.timeout)
+			defer cancel()
+
+			result, err := d.shutdown(ctx)
+
+			assert.ErrorIs(t, err, context.DeadlineExceeded)
+			assert.Zero(t, result.abandoned)
+
+			select {
+			case <-cancelled:
+			case <-time.After(time.Second):
+				t.Fatal("in-flight delivery was not cancelled")
+			}
+		})
+	}
+}
+
+func TestDispatcher_Shutdown_WaitingSubmit(t *testing.T) {
+	started := make(chan struct{}, 2)
+	release := make(chan struct{})
+
+	d := createTestDispatcher(config.DispatchConfig{
+		Workers:       1,
+		QueueSize:     1,
+		SubmitTimeout: time.Minute,
+	}, func(context.Context, *domain.Email) {
+		started <- struct{}{}
+		<-release
+	})
+	d.start()
+
+	require.NoError(t, d.submit(context.Background(), domain.NewEmail("test@example.com", "Subject", "Body")))
+	<-started
+	require.NoError(t, d.submit(context.Background(), domain.NewEmail("test@example.com", "Subject", "Body")))
+
+	// The queue is full, so this submit waits for room
+	submitted := make(chan error, 1)
+	go func() {
+		submitted <- d.submit(context.Background(), domain.NewEmail("test@example.com", "Subject", "Body"))
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	drained := make(chan drainResult, 1)
+	go func() {
+		result, _ := d.shutdown(context.Background())
+		drained <- result
+	}()
+
+	// The worker is still busy, so only shutdown can end the wait
+	select {
+	case err := <-submitted:
+		assert.ErrorIs(t, err, ErrDispatcherClosed)
+	case <-time.After(time.Second):
+		t.Fatal("waiting submit was not woken by shutdown")
+	}
+	close(release)
+
+	select {
+	case result := <-drained:
+		assert.Empty(t, result.leftover)
+	case <-time.After(time.Second):
+		t.Fatal("shutdown did not drain the queue")
+	}
+}
+
+// BenchmarkEmailService_Throughput measures end-to-end delivery throughput
+// against a sender with fixed SMTP latency for growing worker pools.
+func BenchmarkEmailService_Throughput(b *testing.B) {
+	const smtpLatency = time.Millisecond
+
+	for _, workers := range []int{1, 2, 4, 8, 16} {
+		b.Run(fmt.Sprintf("workers_%d", workers), func(b *testing.B) {
+			ctrl := gomock.NewController(b)
+
+			repo := mocks.NewMockEmailRepository(ctrl)
+			sender := mocks.NewMockEmailSender(ctrl)
+			limiter := mocks.NewMockLimiter(ctrl)
+
+			var sent atomic.Int64
+			repo.EXPECT().Save(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+			repo.EXPECT().UpdateStatus(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+			limiter.EXPECT().Wait(gomock.Any()).Return(nil).AnyTimes()
+			sender.EXPECT().Send(gomock.Any(), gomock.Any()).DoAndReturn(func(context.Context, *domain.Email) error {
+				time.Sleep(smtpLatency)
+				sent.Add(1)
+				return nil
+			}).AnyTimes()
+
+			svc := NewEmailService(repo, nil, sender, limiter, &noOpMetrics{}, config.DispatchConfig{
+				Workers:       workers,
+				QueueSize:     1024,
+				SubmitTimeout: time.Minute,
+			}, nil, createTestLogger())
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := svc.SendEmail(context.Background(), "test@example.com", "Subject", "Body", domain.SendOptions{}); err != nil {
+					b.Fatal(err)
+				}
+			}
+			if _, err := svc.Shutdown(context.Background()); err != nil {
+				b.Fatal(err)
+			}
+			b.StopTimer()
+
+			b.ReportMetric(float64(sent.Load())/b.Elapsed().Seconds(), "emails/s")
+		})
+	}
+}
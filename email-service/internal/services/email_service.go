@@ -11,40 +11,75 @@ import (
 	"go.opentelemetry.io/otel/trace"
 
 	"github.com/popeskul/mailflow/common/logger"
+	"github.com/popeskul/mailflow/email-service/internal/config"
 	"github.com/popeskul/mailflow/email-service/internal/domain"
 )
 
+// resendPageSize is how many failed emails ResendFailedEmails requeues per listing
+const resendPageSize = 100
+
 type emailService struct {
 	repo        EmailRepository
+	events      EmailEventRepository
 	sender      EmailSender
 	rateLimiter Limiter
 	metrics     Metrics
-	retryQueue  chan *domain.Email
+	dispatcher  *dispatcher
+	tracker     Tracker
+	spool       Spool
 	logger      logger.Logger
 }
 
+// Option configures the email service
+type Option func(*emailService)
+
+// WithSpool saves the emails not delivered at shutdown to spool and sends
+// them when the service starts again.
+func WithSpool(spool Spool) Option {
+	return func(s *emailService) {
+		s.spool = spool
+	}
+}
+
+// NewEmailService creates the email service. A nil tracker disables open
+// and click tracking regardless of what requests ask for.
 func NewEmailService(
 	repo EmailRepository,
+	events EmailEventRepository,
 	sender EmailSender,
 	limiter Limiter,
 	metrics Metrics,
+	dispatch config.DispatchConfig,
+	tracker Tracker,
 	l logger.Logger,
+	opts ...Option,
 ) EmailService {
 	svc := &emailService{
 		repo:        repo,
+		events:      events,
 		sender:      sender,
 		rateLimiter: limiter,
 		metrics:     metrics,
-		retryQueue:  make(chan *domain.Email, 1000),
+		tracker:     tracker,
 		logger:      l.Named("email_service"),
 	}
+	for _, opt := range opts {
+		opt(svc)
+	}
+
+	svc.dispatcher = newDispatcher(dispatch, svc.deliver, metrics, svc.logger)
+	svc.dispatcher.start()
 
-	go svc.processRetryQueue()
+	if svc.spool != nil {
+		svc.requeueSpooled()
+	}
 
 	return svc
 }
 
-func (s *emailService) SendEmail(ctx context.Context, to, subject, body string) (*domain.Email, error) {
+// SendEmail stores the email and hands it to the worker pool. It returns
+// as soon as the email is accepted; delivery happens asynchronously.
+func (s *emailService) SendEmail(ctx context.Context, to, subject, body string, opts domain.SendOptions) (*domain.Email, error) {
 	// Get tracer from global provider
 	tracer := otel.GetTracerProvider().Tracer("email-service")
 	ctx, span := tracer.Start(ctx, "SendEmail",
@@ -60,8 +95,21 @@ func (s *emailService) SendEmail(ctx context.Context, to, subject, body string)
 	})
 
 	email := domain.NewEmail(to, subject, body)
+	email.Category = opts.Category
+	email.UnsubscribeURL = opts.UnsubscribeURL
+	email.Tenant = opts.Tenant
+	email.Tags = opts.Tags
 	span.SetAttributes(attribute.String("email.id", email.ID))
 
+	// Tracking is opt-in per request but only honoured when enabled server-side
+	if s.tracker != nil {
+		email.Tracking = opts.Tracking
+	} else if opts.Tracking.Enabled() {
+		l.Debug("tracking requested but disabled, ignoring",
+			logger.Field{Key: "email_id", Value: email.ID},
+		)
+	}
+
 	// Save email to repository
 	saveCtx, saveSpan := tracer.Start(ctx, "SaveEmailToRepository")
 	l.Info("attempting to save email",
@@ -78,70 +126,182 @@ func (s *emailService) SendEmail(ctx context.Context, to, subject, body string)
 	}
 	saveSpan.End()
 
-	// Check rate limit
-	rateLimitCtx, rateLimitSpan := tracer.Start(ctx, "RateLimitCheck")
-	l.Info("attempting to send email")
-	if err := s.rateLimiter.Wait(rateLimitCtx); err != nil {
-		rateLimitSpan.RecordError(err)
-		rateLimitSpan.SetStatus(codes.Error, "rate limit exceeded")
-		rateLimitSpan.End()
-		l.Warn("rate limit exceeded, queueing email for retry",
+	// The email is handed to a worker, so the caller gets a snapshot
+	accepted := *email
+
+	// Hand over to the worker pool
+	dispatchCtx, dispatchSpan := tracer.Start(ctx, "DispatchEmail")
+	if err := s.dispatcher.submit(dispatchCtx, email); err != nil {
+		dispatchSpan.RecordError(err)
+		dispatchSpan.SetStatus(codes.Error, err.Error())
+		dispatchSpan.End()
+		l.Warn("failed to dispatch email",
 			logger.Field{Key: "error", Value: err},
 			logger.Field{Key: "email_id", Value: email.ID},
 		)
+		s.metrics.RecordEmailRejected()
+
+		if updateErr := s.repo.UpdateStatus(ctx, email.ID, domain.StatusFailed, nil); updateErr != nil {
+			l.Error("failed to update rejected email status",
+				logger.Field{Key: "error", Value: updateErr},
+			)
+		}
+		return nil, fmt.Errorf("failed to dispatch email: %w", err)
+	}
+	dispatchSpan.End()
+
+	s.metrics.RecordEmailQueued()
+	span.SetAttributes(attribute.Bool("email.queued", true))
+
+	l.Info("email accepted for delivery",
+		logger.Field{Key: "email_id", Value: email.ID},
+	)
+
+	return &accepted, nil
+}
+
+// deliver performs one delivery attempt on a dispatch worker
+func (s *emailService) deliver(ctx context.Context, email *domain.Email) {
+	l := s.logger.WithFields(logger.Fields{
+		"email_id": email.ID,
+		"to":       email.To,
+	})
+
+	if err := s.rateLimiter.Wait(ctx); err != nil {
+		l.Warn("rate limit exceeded, queueing email for retry",
+			logger.Field{Key: "error", Value: err},
+		)
 		s.metrics.RecordRateLimitDelay()
 		s.queueForRetry(email)
-		span.SetAttributes(attribute.Bool("email.queued", true))
-		return email, nil
+		return
+	}
+
+	outgoing := email
+	if s.tracker != nil {
+		outgoing = s.tracker.Instrument(email)
 	}
-	rateLimitSpan.End()
-
-	// Send email
-	sendCtx, sendSpan := tracer.Start(ctx, "SendEmailViaSMTP")
-	if err := s.sender.Send(sendCtx, email); err != nil {
-		sendSpan.RecordError(err)
-		sendSpan.SetStatus(codes.Error, err.Error())
-		sendSpan.End()
+
+	if err := s.sender.Send(ctx, outgoing); err != nil {
 		l.Error("failed to send email",
 			logger.Field{Key: "error", Value: err},
-			logger.Field{Key: "email_id", Value: email.ID},
 		)
 		s.metrics.RecordEmailFailed()
 		s.queueForRetry(email)
-		span.SetAttributes(attribute.Bool("email.failed", true))
-		return email, nil
+		return
 	}
-	sendSpan.End()
 
 	now := time.Now()
-	email.Status = domain.StatusSent
-	email.SentAt = &now
 
 	s.metrics.RecordEmailSent()
-	span.SetAttributes(
-		attribute.String("email.status", email.Status),
-		attribute.String("email.sent_at", now.Format(time.RFC3339)),
-	)
+	l.Info("email sent successfully")
 
-	l.Info("email sent successfully",
-		logger.Field{Key: "email_id", Value: email.ID},
-		logger.Field{Key: "metrics_sent", Value: true},
-	)
-
-	// Update status in repository
-	updateCtx, updateSpan := tracer.Start(ctx, "UpdateEmailStatus")
-	if err := s.repo.UpdateStatus(updateCtx, email.ID, email.Status, email.SentAt); err != nil {
-		updateSpan.RecordError(err)
-		updateSpan.SetStatus(codes.Error, err.Error())
-		updateSpan.End()
+	// The email is already out, so a failed status update must not resend it
+	if err := s.repo.UpdateStatus(ctx, email.ID, domain.StatusSent, &now); err != nil {
 		l.Error("failed to update email status",
 			logger.Field{Key: "error", Value: err},
 		)
-		return email, fmt.Errorf("failed to update email status: %w", err)
 	}
-	updateSpan.End()
+}
 
-	return email, nil
+func (s *emailService) Shutdown(ctx context.Context) (DrainReport, error) {
+	s.logger.Info("shutting down email service")
+
+	result, err := s.dispatcher.shutdown(ctx)
+
+	report := DrainReport{
+		Drained: result.drained,
+		Dropped: result.abandoned,
+	}
+
+	undelivered := make([]*domain.Email, 0, len(result.parked)+len(result.leftover))
+	undelivered = append(undelivered, result.parked...)
+	undelivered = append(undelivered, result.leftover...)
+
+	switch {
+	case len(undelivered) == 0:
+	case s.spool == nil:
+		s.logger.Warn("no spool configured, dropping undelivered emails",
+			logger.Field{Key: "count", Value: len(undelivered)},
+		)
+		report.Dropped += len(undelivered)
+	default:
+		// The deadline may have passed by now, so saving the emails must not
+		// inherit its cancellation
+		if spoolErr := s.spool.Persist(context.WithoutCancel(ctx), undelivered); spoolErr != nil {
+			s.logger.Error("failed to spool undelivered emails",
+				logger.Field{Key: "error", Value: spoolErr},
+				logger.Field{Key: "count", Value: len(undelivered)},
+			)
+			report.Dropped += len(undelivered)
+		} else {
+			report.Pending += len(undelivered)
+		}
+	}
+
+	fields := []logger.Field{
+		{Key: "drained", Value: report.Drained},
+		{Key: "pending", Value: report.Pending},
+		{Key: "dropped", Value: report.Dropped},
+	}
+
+	if err != nil {
+		s.logger.Warn("email dispatch did not drain before deadline",
+			append(fields, logger.Field{Key: "error", Value: err})...,
+		)
+		return report, fmt.Errorf("failed to drain dispatch queue: %w", err)
+	}
+
+	s.logger.Info("email dispatch drained", fields...)
+	return report, nil
+}
+
+// requeueSpooled hands the emails spooled at the last shutdown back to the
+// workers. Those that do not fit are spooled again for the next start.
+func (s *emailService) requeueSpooled() {
+	emails, err := s.spool.Load()
+	if err != nil {
+		s.logger.Error("failed to load spooled emails", logger.Field{Key: "error", Value: err})
+		return
+	}
+	if len(emails) == 0 {
+		return
+	}
+
+	ctx := context.Background()
+	var failed []*domain.Email
+	for _, email := range emails {
+		email.Status = domain.StatusPending
+		if err := s.repo.Save(ctx, email); err != nil {
+			s.logger.Error("failed to save spooled email",
+				logger.Field{Key: "error", Value: err},
+				logger.Field{Key: "email_id", Value: email.ID},
+			)
+			failed = append(failed, email)
+			continue
+		}
+		if err := s.dispatcher.offer(email); err != nil {
+			s.logger.Error("failed to requeue spooled email",
+				logger.Field{Key: "error", Value: err},
+				logger.Field{Key: "email_id", Value: email.ID},
+			)
+			failed = append(failed, email)
+		}
+	}
+
+	s.logger.Info("requeued spooled emails",
+		logger.Field{Key: "requeued", Value: len(emails) - len(failed)},
+		logger.Field{Key: "failed", Value: len(failed)},
+	)
+
+	if len(failed) == 0 {
+		return
+	}
+	if err := s.spool.Persist(ctx, failed); err != nil {
+		s.logger.Error("failed to spool emails that could not be requeued",
+			logger.Field{Key: "error", Value: err},
+			logger.Field{Key: "count", Value: len(failed)},
+		)
+	}
 }
 
 func (s *emailService) GetEmailStatus(ctx context.Context, id string) (*domain.Email, error) {
@@ -160,41 +320,87 @@ func (s *emailService) GetEmailStatus(ctx context.Context, id string) (*domain.E
 	return email, nil
 }
 
-func (s *emailService) ListEmails(ctx context.Context, pageSize int, pageToken string) ([]*domain.Email, string, error) {
+func (s *emailService) ListEmails(ctx context.Context, query domain.ListQuery) (*domain.ListResult, error) {
 	l := s.logger.WithFields(logger.Fields{
-		"page_size":  pageSize,
-		"page_token": pageToken,
+		"page_size":  query.PageSize,
+		"page_token": query.PageToken,
 	})
 
-	emails, nextToken, err := s.repo.List(ctx, pageSize, pageToken)
+	result, err := s.repo.List(ctx, query)
 	if err != nil {
 		l.Error("failed to list emails",
 			logger.Field{Key: "error", Value: err},
 		)
-		return nil, "", fmt.Errorf("failed to list emails: %w", err)
+		return nil, fmt.Errorf("failed to list emails: %w", err)
 	}
 
-	return emails, nextToken, nil
+	return result, nil
 }
 
-func (s *emailService) ResendFailedEmails(ctx context.Context) error {
+func (s *emailService) RecordEvent(ctx context.Context, event *domain.EmailEvent) error {
+	// Only events for emails we know about are kept
+	if _, err := s.repo.GetByID(ctx, event.EmailID); err != nil {
+		return fmt.Errorf("failed to get email: %w", err)
+	}
+
+	if err := s.events.Save(ctx, event); err != nil {
+		s.logger.Error("failed to save email event",
+			logger.Field{Key: "error", Value: err},
+			logger.Field{Key: "email_id", Value: event.EmailID},
+			logger.Field{Key: "type", Value: event.Type},
+		)
+		return fmt.Errorf("failed to save email event: %w", err)
+	}
+
+	return nil
+}
+
+func (s *emailService) GetEmailEvents(ctx context.Context, id string) ([]*domain.EmailEvent, error) {
 	l := s.logger.WithFields(logger.Fields{
-		"operation": "resend_failed",
+		"email_id": id,
 	})
 
-	l.Info("starting resend of failed emails")
+	if _, err := s.repo.GetByID(ctx, id); err != nil {
+		l.Error("failed to get email",
+			logger.Field{Key: "error", Value: err},
+		)
+		return nil, fmt.Errorf("failed to get email: %w", err)
+	}
 
-	emails, _, err := s.repo.List(ctx, 0, "")
+	events, err := s.events.ListByEmailID(ctx, id)
 	if err != nil {
-		l.Error("failed to list emails",
+		l.Error("failed to list email events",
 			logger.Field{Key: "error", Value: err},
 		)
-		return fmt.Errorf("failed to list emails: %w", err)
+		return nil, fmt.Errorf("failed to list email events: %w", err)
+	}
+
+	return events, nil
+}
+
+func (s *emailService) ResendFailedEmails(ctx context.Context) error {
+	l := s.logger.WithFields(logger.Fields{
+		"operation": "resend_failed",
+	})
+
+	l.Info("starting resend of failed emails")
+
+	query := domain.ListQuery{
+		Filter:   domain.ListFilter{Status: domain.StatusFailed},
+		PageSize: resendPageSize,
 	}
 
 	var resendCount int
-	for _, email := range emails {
-		if email.Status == domain.StatusFailed {
+	for {
+		page, err := s.repo.List(ctx, query)
+		if err != nil {
+			l.Error("failed to list emails",
+				logger.Field{Key: "error", Value: err},
+			)
+			return fmt.Errorf("failed to list emails: %w", err)
+		}
+
+		for _, email := range page.Emails {
 			l.Info("requeueing failed email",
 				logger.Field{Key: "email_id", Value: email.ID},
 				logger.Field{Key: "to", Value: email.To},
@@ -202,6 +408,11 @@ func (s *emailService) ResendFailedEmails(ctx context.Context) error {
 			s.queueForRetry(email)
 			resendCount++
 		}
+
+		if page.NextPageToken == "" {
+			break
+		}
+		query.PageToken = page.NextPageToken
 	}
 
 	l.Info("finished requeueing failed emails",
@@ -211,87 +422,34 @@ func (s *emailService) ResendFailedEmails(ctx context.Context) error {
 }
 
 func (s *emailService) queueForRetry(email *domain.Email) {
-	startTime := time.Now()
-
 	l := s.logger.WithFields(logger.Fields{
 		"email_id": email.ID,
 		"status":   email.Status,
 	})
 
-	email.Status = domain.StatusPending
 	s.metrics.RecordEmailQueued()
 
-	l.Info("email successfully queued for retry",
-		logger.Field{Key: "queue_time", Value: time.Since(startTime)},
-	)
-
-	select {
-	case s.retryQueue <- email:
-		l.Info("email successfully queued for retry")
-
-		if err := s.repo.UpdateStatus(context.Background(), email.ID, email.Status, nil); err != nil {
-			l.Error("failed to update email status after queuing",
-				logger.Field{Key: "error", Value: err},
-			)
-		}
-
-	default:
-		l.Warn("retry queue is full, marking email as failed")
+	if err := s.dispatcher.offer(email); err != nil {
+		l.Warn("retry queue unavailable, marking email as failed",
+			logger.Field{Key: "error", Value: err},
+		)
 
-		email.Status = domain.StatusFailed
-		if err := s.repo.UpdateStatus(context.Background(), email.ID, email.Status, nil); err != nil {
+		if err := s.repo.UpdateStatus(context.Background(), email.ID, domain.StatusFailed, nil); err != nil {
 			l.Error("failed to update email status when queue full",
 				logger.Field{Key: "error", Value: err},
 			)
 		}
 
 		s.metrics.RecordEmailFailed()
-		s.metrics.SetQueueSize(len(s.retryQueue))
+		s.metrics.SetQueueSize(s.dispatcher.len())
+		return
 	}
-}
-
-func (s *emailService) processRetryQueue() {
-	l := s.logger.Named("retry_queue")
-
-	for email := range s.retryQueue {
-		ctx := context.Background()
-		queueSize := len(s.retryQueue)
-		l := l.WithFields(logger.Fields{
-			"email_id":   email.ID,
-			"queue_size": queueSize,
-		})
-
-		l.Info("processing queued email")
-
-		if err := s.rateLimiter.Wait(ctx); err != nil {
-			l.Warn("rate limit still exceeded, requeueing email",
-				logger.Field{Key: "error", Value: err},
-			)
-			s.queueForRetry(email)
-			continue
-		}
 
-		if err := s.sender.Send(ctx, email); err != nil {
-			l.Error("failed to send queued email",
-				logger.Field{Key: "error", Value: err},
-			)
-			s.queueForRetry(email)
-			continue
-		}
+	l.Info("email successfully queued for retry")
 
-		now := time.Now()
-		email.Status = domain.StatusSent
-		email.SentAt = &now
-
-		l.Info("queued email sent successfully")
-		s.metrics.RecordEmailSent()
-
-		if err := s.repo.UpdateStatus(ctx, email.ID, email.Status, email.SentAt); err != nil {
-			l.Error("failed to update queued email status",
-				logger.Field{Key: "error", Value: err},
-			)
-			s.queueForRetry(email)
-			continue
-		}
+	if err := s.repo.UpdateStatus(context.Background(), email.ID, domain.StatusPending, nil); err != nil {
+		l.Error("failed to update email status after queuing",
+			logger.Field{Key: "error", Value: err},
+		)
 	}
 }
@@ -4,12 +4,17 @@ import (
 	"context"
 	"errors"
 	"io"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
 
 	"github.com/popeskul/mailflow/common/logger"
+	"github.com/popeskul/mailflow/common/spool"
+	"github.com/popeskul/mailflow/email-service/internal/config"
 	"github.com/popeskul/mailflow/email-service/internal/domain"
 	"github.com/popeskul/mailflow/email-service/internal/services/mocks"
 )
@@ -18,13 +23,29 @@ func createTestLogger() logger.Logger {
 	return logger.NewZapLogger(logger.WithOutputs(io.Discard))
 }
 
+var testDispatchConfig = config.DispatchConfig{
+	Workers:   1,
+	QueueSize: 1000,
+}
+
+// createTestEmailService builds a service whose dispatcher is not started,
+// so accepted emails stay in the queue where tests can inspect them.
 func createTestEmailService(repo EmailRepository, sender EmailSender, limiter Limiter, metrics Metrics) *emailService {
+	return createTestEmailServiceWithDispatch(repo, sender, limiter, metrics, testDispatchConfig)
+}
+
+func createTestEmailServiceWithDispatch(
+	repo EmailRepository,
+	sender EmailSender,
+	limiter Limiter,
+	metrics Metrics,
+	dispatch config.DispatchConfig,
+) *emailService {
 	service := &emailService{
 		repo:        repo,
 		sender:      sender,
 		rateLimiter: limiter,
 		metrics:     metrics,
-		retryQueue:  make(chan *domain.Email, 1000),
 		logger:      createTestLogger().Named("email_service"),
 	}
 
@@ -33,6 +54,8 @@ func createTestEmailService(repo EmailRepository, sender EmailSender, limiter Li
 		service.metrics = &noOpMetrics{}
 	}
 
+	service.dispatcher = newDispatcher(dispatch, service.deliver, service.metrics, service.logger)
+
 	return service
 }
 
@@ -42,11 +65,22 @@ type noOpMetrics struct{}
 func (n *noOpMetrics) RecordEmailSent()                           {}
 func (n *noOpMetrics) RecordEmailQueued()                         {}
 func (n *noOpMetrics) RecordEmailFailed()                         {}
+func (n *noOpMetrics) RecordEmailRejected()                       {}
 func (n *noOpMetrics) RecordRateLimitDelay()                      {}
 func (n *noOpMetrics) RecordDowntimePeriod()                      {}
 func (n *noOpMetrics) SetQueueSize(size int)                      {}
+func (n *noOpMetrics) SetActiveWorkers(count int)                 {}
 func (n *noOpMetrics) ObserveProcessingDuration(duration float64) {}
 
+// suffixTracker marks instrumented bodies so tests can see what was sent
+type suffixTracker struct{}
+
+func (suffixTracker) Instrument(email *domain.Email) *domain.Email {
+	instrumented := *email
+	instrumented.Body += " [tracked]"
+	return &instrumented
+}
+
 func TestNewEmailService_Success(t *testing.T) {
 	tests := []struct {
 		name string
@@ -74,52 +108,46 @@ func TestNewEmailService_Success(t *testing.T) {
 }
 
 func TestEmailService_SendEmail_Success(t *testing.T) {
+	acceptMocks := func(repo *mocks.MockEmailRepository, metrics *mocks.MockMetrics) {
+		repo.EXPECT().Save(gomock.Any(), gomock.Any()).Return(nil)
+		metrics.EXPECT().SetQueueSize(1)
+		metrics.EXPECT().RecordEmailQueued()
+	}
+
 	tests := []struct {
-		name       string
-		to         string
-		subject    string
-		body       string
-		setupMocks func(*mocks.MockEmailRepository, *mocks.MockEmailSender, *mocks.MockLimiter, *mocks.MockMetrics)
+		name             string
+		to               string
+		subject          string
+		body             string
+		tracking         domain.Tracking
+		tracker          Tracker
+		expectedTracking domain.Tracking
+		setupMocks       func(*mocks.MockEmailRepository, *mocks.MockMetrics)
 	}{
 		{
-			name:    "send email successfully",
-			to:      "test@example.com",
-			subject: "Test Subject",
-			body:    "Test Body",
-			setupMocks: func(repo *mocks.MockEmailRepository, sender *mocks.MockEmailSender, limiter *mocks.MockLimiter, metrics *mocks.MockMetrics) {
-				repo.EXPECT().Save(gomock.Any(), gomock.Any()).Return(nil)
-				limiter.EXPECT().Wait(gomock.Any()).Return(nil)
-				sender.EXPECT().Send(gomock.Any(), gomock.Any()).Return(nil)
-				metrics.EXPECT().RecordEmailSent()
-				repo.EXPECT().UpdateStatus(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
-			},
+			name:       "accept email for delivery",
+			to:         "test@example.com",
+			subject:    "Test Subject",
+			body:       "Test Body",
+			setupMocks: acceptMocks,
 		},
 		{
-			name:    "send email with rate limiting delay",
-			to:      "test@example.com",
-			subject: "Test Subject",
-			body:    "Test Body",
-			setupMocks: func(repo *mocks.MockEmailRepository, sender *mocks.MockEmailSender, limiter *mocks.MockLimiter, metrics *mocks.MockMetrics) {
-				repo.EXPECT().Save(gomock.Any(), gomock.Any()).Return(nil)
-				limiter.EXPECT().Wait(gomock.Any()).Return(context.DeadlineExceeded)
-				metrics.EXPECT().RecordRateLimitDelay()
-				metrics.EXPECT().RecordEmailQueued()
-				repo.EXPECT().UpdateStatus(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
-			},
+			name:             "tracking kept when enabled on the server",
+			to:               "test@example.com",
+			subject:          "Test Subject",
+			body:             "<p>Test Body</p>",
+			tracking:         domain.Tracking{Opens: true, Clicks: true},
+			tracker:          suffixTracker{},
+			expectedTracking: domain.Tracking{Opens: true, Clicks: true},
+			setupMocks:       acceptMocks,
 		},
 		{
-			name:    "send email with sender failure",
-			to:      "test@example.com",
-			subject: "Test Subject",
-			body:    "Test Body",
-			setupMocks: func(repo *mocks.MockEmailRepository, sender *mocks.MockEmailSender, limiter *mocks.MockLimiter, metrics *mocks.MockMetrics) {
-				repo.EXPECT().Save(gomock.Any(), gomock.Any()).Return(nil)
-				limiter.EXPECT().Wait(gomock.Any()).Return(nil)
-				sender.EXPECT().Send(gomock.Any(), gomock.Any()).Return(errors.New("send error"))
-				metrics.EXPECT().RecordEmailFailed()
-				metrics.EXPECT().RecordEmailQueued()
-				repo.EXPECT().UpdateStatus(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
-			},
+			name:       "tracking ignored when disabled on the server",
+			to:         "test@example.com",
+			subject:    "Test Subject",
+			body:       "<p>Test Body</p>",
+			tracking:   domain.Tracking{Opens: true},
+			setupMocks: acceptMocks,
 		},
 	}
 
@@ -133,17 +161,27 @@ func TestEmailService_SendEmail_Success(t *testing.T) {
 			limiter := mocks.NewMockLimiter(ctrl)
 			metrics := mocks.NewMockMetrics(ctrl)
 
-			tt.setupMocks(repo, sender, limiter, metrics)
+			tt.setupMocks(repo, metrics)
 
 			service := createTestEmailService(repo, sender, limiter, metrics)
+			service.tracker = tt.tracker
 
-			email, err := service.SendEmail(context.Background(), tt.to, tt.subject, tt.body)
+			email, err := service.SendEmail(context.Background(), tt.to, tt.subject, tt.body, domain.SendOptions{
+				Tracking:       tt.tracking,
+				Category:       "newsletter",
+				UnsubscribeURL: "https://example.com/unsubscribe?token=abc",
+			})
 
 			assert.NoError(t, err)
 			assert.NotNil(t, email)
 			assert.Equal(t, tt.to, email.To)
 			assert.Equal(t, tt.subject, email.Subject)
 			assert.Equal(t, tt.body, email.Body)
+			assert.Equal(t, domain.StatusPending, email.Status)
+			assert.Equal(t, tt.expectedTracking, email.Tracking)
+			assert.Equal(t, "newsletter", email.Category)
+			assert.Equal(t, "https://example.com/unsubscribe?token=abc", email.UnsubscribeURL)
+			assert.Equal(t, 1, service.dispatcher.len())
 		})
 	}
 }
@@ -154,32 +192,171 @@ func TestEmailService_SendEmail_Fail(t *testing.T) {
 		to            string
 		subject       string
 		body          string
-		setupMocks    func(*mocks.MockEmailRepository, *mocks.MockEmailSender, *mocks.MockLimiter, *mocks.MockMetrics)
-		expectedError string
+		dispatch      config.DispatchConfig
+		setupMocks    func(*mocks.MockEmailRepository, *mocks.MockMetrics)
+		expectedError error
+		expectedText  string
 	}{
 		{
-			name:    "repository save failure",
-			to:      "test@example.com",
-			subject: "Test Subject",
-			body:    "Test Body",
-			setupMocks: func(repo *mocks.MockEmailRepository, sender *mocks.MockEmailSender, limiter *mocks.MockLimiter, metrics *mocks.MockMetrics) {
+			name:     "repository save failure",
+			to:       "test@example.com",
+			subject:  "Test Subject",
+			body:     "Test Body",
+			dispatch: testDispatchConfig,
+			setupMocks: func(repo *mocks.MockEmailRepository, metrics *mocks.MockMetrics) {
 				repo.EXPECT().Save(gomock.Any(), gomock.Any()).Return(errors.New("database error"))
 			},
-			expectedError: "failed to save email",
+			expectedText: "failed to save email",
 		},
 		{
-			name:    "update status failure after successful send",
+			name:    "dispatch queue full",
 			to:      "test@example.com",
 			subject: "Test Subject",
 			body:    "Test Body",
-			setupMocks: func(repo *mocks.MockEmailRepository, sender *mocks.MockEmailSender, limiter *mocks.MockLimiter, metrics *mocks.MockMetrics) {
+			dispatch: config.DispatchConfig{
+				Workers:   1,
+				QueueSize: 0,
+			},
+			setupMocks: func(repo *mocks.MockEmailRepository, metrics *mocks.MockMetrics) {
 				repo.EXPECT().Save(gomock.Any(), gomock.Any()).Return(nil)
+				metrics.EXPECT().RecordEmailRejected()
+				repo.EXPECT().UpdateStatus(gomock.Any(), gomock.Any(), domain.StatusFailed, nil).Return(nil)
+			},
+			expectedError: ErrQueueFull,
+			expectedText:  "failed to dispatch email",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := mocks.NewMockEmailRepository(ctrl)
+			sender := mocks.NewMockEmailSender(ctrl)
+			limiter := mocks.NewMockLimiter(ctrl)
+			metrics := mocks.NewMockMetrics(ctrl)
+
+			tt.setupMocks(repo, metrics)
+
+			service := createTestEmailServiceWithDispatch(repo, sender, limiter, metrics, tt.dispatch)
+
+			email, err := service.SendEmail(context.Background(), tt.to, tt.subject, tt.body, domain.SendOptions{})
+
+			assert.Error(t, err)
+			assert.Nil(t, email)
+			assert.Contains(t, err.Error(), tt.expectedText)
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+			}
+		})
+	}
+}
+
+func TestEmailService_Deliver_Success(t *testing.T) {
+	tests := []struct {
+		name         string
+		email        *domain.Email
+		tracker      Tracker
+		expectedBody string
+	}{
+		{
+			name: "deliver email successfully",
+			email: &domain.Email{
+				ID:     "email-1",
+				To:     "test@example.com",
+				Body:   "Test Body",
+				Status: domain.StatusPending,
+			},
+			expectedBody: "Test Body",
+		},
+		{
+			name: "deliver instrumented body when tracking is enabled",
+			email: &domain.Email{
+				ID:       "email-1",
+				To:       "test@example.com",
+				Body:     "Test Body",
+				Status:   domain.StatusPending,
+				Tracking: domain.Tracking{Opens: true},
+			},
+			tracker:      suffixTracker{},
+			expectedBody: "Test Body [tracked]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := mocks.NewMockEmailRepository(ctrl)
+			sender := mocks.NewMockEmailSender(ctrl)
+			limiter := mocks.NewMockLimiter(ctrl)
+			metrics := mocks.NewMockMetrics(ctrl)
+
+			var sentBody string
+			limiter.EXPECT().Wait(gomock.Any()).Return(nil)
+			sender.EXPECT().Send(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, email *domain.Email) error {
+				sentBody = email.Body
+				return nil
+			})
+			metrics.EXPECT().RecordEmailSent()
+			repo.EXPECT().UpdateStatus(gomock.Any(), tt.email.ID, domain.StatusSent, gomock.Not(gomock.Nil())).Return(nil)
+
+			service := createTestEmailService(repo, sender, limiter, metrics)
+			service.tracker = tt.tracker
+
+			body := tt.email.Body
+			service.deliver(context.Background(), tt.email)
+
+			assert.Equal(t, tt.expectedBody, sentBody)
+			assert.Equal(t, body, tt.email.Body)
+			// State only changes through the repository
+			assert.Equal(t, domain.StatusPending, tt.email.Status)
+			assert.Nil(t, tt.email.SentAt)
+			assert.Equal(t, 0, service.dispatcher.len())
+		})
+	}
+}
+
+func TestEmailService_Deliver_Fail(t *testing.T) {
+	tests := []struct {
+		name       string
+		setupMocks func(*mocks.MockEmailRepository, *mocks.MockEmailSender, *mocks.MockLimiter, *mocks.MockMetrics)
+		requeued   bool
+	}{
+		{
+			name: "rate limit wait fails",
+			setupMocks: func(repo *mocks.MockEmailRepository, sender *mocks.MockEmailSender, limiter *mocks.MockLimiter, metrics *mocks.MockMetrics) {
+				limiter.EXPECT().Wait(gomock.Any()).Return(context.DeadlineExceeded)
+				metrics.EXPECT().RecordRateLimitDelay()
+				metrics.EXPECT().RecordEmailQueued()
+				metrics.EXPECT().SetQueueSize(1)
+				repo.EXPECT().UpdateStatus(gomock.Any(), gomock.Any(), domain.StatusPending, nil).Return(nil)
+			},
+			requeued: true,
+		},
+		{
+			name: "sender failure",
+			setupMocks: func(repo *mocks.MockEmailRepository, sender *mocks.MockEmailSender, limiter *mocks.MockLimiter, metrics *mocks.MockMetrics) {
+				limiter.EXPECT().Wait(gomock.Any()).Return(nil)
+				sender.EXPECT().Send(gomock.Any(), gomock.Any()).Return(errors.New("send error"))
+				metrics.EXPECT().RecordEmailFailed()
+				metrics.EXPECT().RecordEmailQueued()
+				metrics.EXPECT().SetQueueSize(1)
+				repo.EXPECT().UpdateStatus(gomock.Any(), gomock.Any(), domain.StatusPending, nil).Return(nil)
+			},
+			requeued: true,
+		},
+		{
+			name: "update status failure after successful send is not retried",
+			setupMocks: func(repo *mocks.MockEmailRepository, sender *mocks.MockEmailSender, limiter *mocks.MockLimiter, metrics *mocks.MockMetrics) {
 				limiter.EXPECT().Wait(gomock.Any()).Return(nil)
 				sender.EXPECT().Send(gomock.Any(), gomock.Any()).Return(nil)
 				metrics.EXPECT().RecordEmailSent()
-				repo.EXPECT().UpdateStatus(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(errors.New("update failed"))
+				repo.EXPECT().UpdateStatus(gomock.Any(), gomock.Any(), domain.StatusSent, gomock.Any()).Return(errors.New("update failed"))
 			},
-			expectedError: "failed to update email status",
+			requeued: false,
 		},
 	}
 
@@ -196,13 +373,15 @@ func TestEmailService_SendEmail_Fail(t *testing.T) {
 			tt.setupMocks(repo, sender, limiter, metrics)
 
 			service := createTestEmailService(repo, sender, limiter, metrics)
+			email := &domain.Email{ID: "email-1", To: "test@example.com", Status: domain.StatusPending}
 
-			email, err := service.SendEmail(context.Background(), tt.to, tt.subject, tt.body)
+			service.deliver(context.Background(), email)
 
-			assert.Error(t, err)
-			assert.Contains(t, err.Error(), tt.expectedError)
-			if tt.expectedError == "failed to save email" {
-				assert.Nil(t, email)
+			if tt.requeued {
+				assert.Equal(t, domain.StatusPending, email.Status)
+				assert.Equal(t, 1, service.dispatcher.len())
+			} else {
+				assert.Equal(t, 0, service.dispatcher.len())
 			}
 		})
 	}
@@ -283,28 +462,39 @@ func TestEmailService_GetEmailStatus_Fail(t *testing.T) {
 func TestEmailService_ListEmails_Success(t *testing.T) {
 	tests := []struct {
 		name              string
-		pageSize          int
-		pageToken         string
+		query             domain.ListQuery
 		expectedEmails    []*domain.Email
 		expectedNextToken string
+		expectedTotal     int
 	}{
 		{
-			name:      "list emails successfully",
-			pageSize:  10,
-			pageToken: "",
+			name:  "list emails successfully",
+			query: domain.ListQuery{PageSize: 10},
 			expectedEmails: []*domain.Email{
 				{ID: "1", To: "test1@example.com"},
 				{ID: "2", To: "test2@example.com"},
 			},
 			expectedNextToken: "next_token",
+			expectedTotal:     5,
 		},
 		{
 			name:              "list empty emails",
-			pageSize:          10,
-			pageToken:         "",
+			query:             domain.ListQuery{PageSize: 10},
 			expectedEmails:    []*domain.Email{},
 			expectedNextToken: "",
 		},
+		{
+			name: "filters are passed to the repository",
+			query: domain.ListQuery{
+				Filter:   domain.ListFilter{Status: domain.StatusFailed, Tenant: "acme"},
+				Order:    domain.SortCreatedDesc,
+				PageSize: 10,
+			},
+			expectedEmails: []*domain.Email{
+				{ID: "1", Status: domain.StatusFailed, Tenant: "acme"},
+			},
+			expectedTotal: 1,
+		},
 	}
 
 	for _, tt := range tests {
@@ -313,16 +503,21 @@ func TestEmailService_ListEmails_Success(t *testing.T) {
 			defer ctrl.Finish()
 
 			repo := mocks.NewMockEmailRepository(ctrl)
-			repo.EXPECT().List(gomock.Any(), tt.pageSize, tt.pageToken).Return(tt.expectedEmails, tt.expectedNextToken, nil)
+			repo.EXPECT().List(gomock.Any(), tt.query).Return(&domain.ListResult{
+				Emails:        tt.expectedEmails,
+				NextPageToken: tt.expectedNextToken,
+				TotalCount:    tt.expectedTotal,
+			}, nil)
 
 			service := createTestEmailService(repo, nil, nil, nil)
 
-			emails, nextToken, err := service.ListEmails(context.Background(), tt.pageSize, tt.pageToken)
+			result, err := service.ListEmails(context.Background(), tt.query)
 
 			assert.NoError(t, err)
-			assert.NotNil(t, emails)
-			assert.Equal(t, len(tt.expectedEmails), len(emails))
-			assert.Equal(t, tt.expectedNextToken, nextToken)
+			assert.NotNil(t, result.Emails)
+			assert.Equal(t, len(tt.expectedEmails), len(result.Emails))
+			assert.Equal(t, tt.expectedNextToken, result.NextPageToken)
+			assert.Equal(t, tt.expectedTotal, result.TotalCount)
 		})
 	}
 }
@@ -330,14 +525,12 @@ func TestEmailService_ListEmails_Success(t *testing.T) {
 func TestEmailService_ListEmails_Fail(t *testing.T) {
 	tests := []struct {
 		name          string
-		pageSize      int
-		pageToken     string
+		query         domain.ListQuery
 		expectedError string
 	}{
 		{
 			name:          "repository list failure",
-			pageSize:      10,
-			pageToken:     "",
+			query:         domain.ListQuery{PageSize: 10},
 			expectedError: "failed to list emails",
 		},
 	}
@@ -348,40 +541,46 @@ func TestEmailService_ListEmails_Fail(t *testing.T) {
 			defer ctrl.Finish()
 
 			repo := mocks.NewMockEmailRepository(ctrl)
-			repo.EXPECT().List(gomock.Any(), tt.pageSize, tt.pageToken).Return(nil, "", errors.New("database error"))
+			repo.EXPECT().List(gomock.Any(), tt.query).Return(nil, errors.New("database error"))
 
 			service := createTestEmailService(repo, nil, nil, nil)
 
-			emails, nextToken, err := service.ListEmails(context.Background(), tt.pageSize, tt.pageToken)
+			result, err := service.ListEmails(context.Background(), tt.query)
 
 			assert.Error(t, err)
-			assert.Nil(t, emails)
-			assert.Empty(t, nextToken)
+			assert.Nil(t, result)
 			assert.Contains(t, err.Error(), tt.expectedError)
 		})
 	}
 }
 
 func TestEmailService_ResendFailedEmails_Success(t *testing.T) {
+	failedOnly := domain.ListFilter{Status: domain.StatusFailed}
+
 	tests := []struct {
-		name         string
-		failedEmails []*domain.Email
+		name  string
+		pages []*domain.ListResult
 	}{
 		{
 			name: "resend failed emails successfully",
-			failedEmails: []*domain.Email{
-				{ID: "1", Status: domain.StatusFailed},
-				{ID: "2", Status: domain.StatusFailed},
-				{ID: "3", Status: domain.StatusSent},
+			pages: []*domain.ListResult{
+				{Emails: []*domain.Email{
+					{ID: "1", Status: domain.StatusFailed},
+					{ID: "2", Status: domain.StatusFailed},
+				}},
 			},
 		},
 		{
-			name: "no failed emails to resend",
-			failedEmails: []*domain.Email{
-				{ID: "1", Status: domain.StatusSent},
-				{ID: "2", Status: domain.StatusSent},
+			name: "resend failed emails across pages",
+			pages: []*domain.ListResult{
+				{Emails: []*domain.Email{{ID: "1", Status: domain.StatusFailed}}, NextPageToken: "1"},
+				{Emails: []*domain.Email{{ID: "2", Status: domain.StatusFailed}}},
 			},
 		},
+		{
+			name:  "no failed emails to resend",
+			pages: []*domain.ListResult{{}},
+		},
 	}
 
 	for _, tt := range tests {
@@ -390,21 +589,25 @@ func TestEmailService_ResendFailedEmails_Success(t *testing.T) {
 			defer ctrl.Finish()
 
 			repo := mocks.NewMockEmailRepository(ctrl)
-			repo.EXPECT().List(gomock.Any(), 0, "").Return(tt.failedEmails, "", nil)
 
-			// Add expectations for queueForRetry if there are failed emails
 			failedCount := 0
-			for _, email := range tt.failedEmails {
-				if email.Status == domain.StatusFailed {
-					failedCount++
-				}
+			pageToken := ""
+			for _, page := range tt.pages {
+				repo.EXPECT().List(gomock.Any(), domain.ListQuery{
+					Filter:    failedOnly,
+					PageSize:  resendPageSize,
+					PageToken: pageToken,
+				}).Return(page, nil)
+				pageToken = page.NextPageToken
+				failedCount += len(page.Emails)
 			}
+
+			// Add expectations for queueForRetry if there are failed emails
 			if failedCount > 0 {
 				repo.EXPECT().UpdateStatus(gomock.Any(), gomock.Any(), domain.StatusPending, nil).Times(failedCount)
 			}
 
 			service := createTestEmailService(repo, nil, nil, nil)
-			service.retryQueue = make(chan *domain.Email, 100)
 
 			err := service.ResendFailedEmails(context.Background())
 
@@ -430,7 +633,7 @@ func TestEmailService_ResendFailedEmails_Fail(t *testing.T) {
 			defer ctrl.Finish()
 
 			repo := mocks.NewMockEmailRepository(ctrl)
-			repo.EXPECT().List(gomock.Any(), 0, "").Return(nil, "", errors.New("database error"))
+			repo.EXPECT().List(gomock.Any(), gomock.Any()).Return(nil, errors.New("database error"))
 
 			service := createTestEmailService(repo, nil, nil, nil)
 
@@ -442,13 +645,178 @@ func TestEmailService_ResendFailedEmails_Fail(t *testing.T) {
 	}
 }
 
-func TestEmailService_ProcessQueue_Success(t *testing.T) {
+func TestEmailService_RecordEvent_Success(t *testing.T) {
+	tests := []struct {
+		name  string
+		event *domain.EmailEvent
+	}{
+		{
+			name:  "record open event",
+			event: domain.NewEmailEvent("email-1", domain.EventOpen, "", "Mozilla/5.0"),
+		},
+		{
+			name:  "record click event",
+			event: domain.NewEmailEvent("email-1", domain.EventClick, "https://example.com", "Mozilla/5.0"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := mocks.NewMockEmailRepository(ctrl)
+			events := mocks.NewMockEmailEventRepository(ctrl)
+
+			repo.EXPECT().GetByID(gomock.Any(), tt.event.EmailID).Return(&domain.Email{ID: tt.event.EmailID}, nil)
+			events.EXPECT().Save(gomock.Any(), tt.event).Return(nil)
+
+			service := createTestEmailService(repo, nil, nil, nil)
+			service.events = events
+
+			err := service.RecordEvent(context.Background(), tt.event)
+
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestEmailService_RecordEvent_Fail(t *testing.T) {
+	tests := []struct {
+		name          string
+		setupMocks    func(*mocks.MockEmailRepository, *mocks.MockEmailEventRepository)
+		expectedError string
+	}{
+		{
+			name: "unknown email",
+			setupMocks: func(repo *mocks.MockEmailRepository, events *mocks.MockEmailEventRepository) {
+				repo.EXPECT().GetByID(gomock.Any(), "email-1").Return(nil, errors.New("email not found"))
+			},
+			expectedError: "failed to get email",
+		},
+		{
+			name: "event save failure",
+			setupMocks: func(repo *mocks.MockEmailRepository, events *mocks.MockEmailEventRepository) {
+				repo.EXPECT().GetByID(gomock.Any(), "email-1").Return(&domain.Email{ID: "email-1"}, nil)
+				events.EXPECT().Save(gomock.Any(), gomock.Any()).Return(errors.New("database error"))
+			},
+			expectedError: "failed to save email event",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := mocks.NewMockEmailRepository(ctrl)
+			events := mocks.NewMockEmailEventRepository(ctrl)
+
+			tt.setupMocks(repo, events)
+
+			service := createTestEmailService(repo, nil, nil, nil)
+			service.events = events
+
+			err := service.RecordEvent(context.Background(), domain.NewEmailEvent("email-1", domain.EventOpen, "", ""))
+
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), tt.expectedError)
+		})
+	}
+}
+
+func TestEmailService_GetEmailEvents_Success(t *testing.T) {
+	tests := []struct {
+		name   string
+		events []*domain.EmailEvent
+	}{
+		{
+			name: "email with events",
+			events: []*domain.EmailEvent{
+				domain.NewEmailEvent("email-1", domain.EventOpen, "", ""),
+				domain.NewEmailEvent("email-1", domain.EventClick, "https://example.com", ""),
+			},
+		},
+		{
+			name:   "email without events",
+			events: []*domain.EmailEvent{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := mocks.NewMockEmailRepository(ctrl)
+			events := mocks.NewMockEmailEventRepository(ctrl)
+
+			repo.EXPECT().GetByID(gomock.Any(), "email-1").Return(&domain.Email{ID: "email-1"}, nil)
+			events.EXPECT().ListByEmailID(gomock.Any(), "email-1").Return(tt.events, nil)
+
+			service := createTestEmailService(repo, nil, nil, nil)
+			service.events = events
+
+			result, err := service.GetEmailEvents(context.Background(), "email-1")
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.events, result)
+		})
+	}
+}
+
+func TestEmailService_GetEmailEvents_Fail(t *testing.T) {
+	tests := []struct {
+		name          string
+		setupMocks    func(*mocks.MockEmailRepository, *mocks.MockEmailEventRepository)
+		expectedError string
+	}{
+		{
+			name: "unknown email",
+			setupMocks: func(repo *mocks.MockEmailRepository, events *mocks.MockEmailEventRepository) {
+				repo.EXPECT().GetByID(gomock.Any(), "email-1").Return(nil, errors.New("email not found"))
+			},
+			expectedError: "failed to get email",
+		},
+		{
+			name: "event listing failure",
+			setupMocks: func(repo *mocks.MockEmailRepository, events *mocks.MockEmailEventRepository) {
+				repo.EXPECT().GetByID(gomock.Any(), "email-1").Return(&domain.Email{ID: "email-1"}, nil)
+				events.EXPECT().ListByEmailID(gomock.Any(), "email-1").Return(nil, errors.New("database error"))
+			},
+			expectedError: "failed to list email events",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := mocks.NewMockEmailRepository(ctrl)
+			events := mocks.NewMockEmailEventRepository(ctrl)
+
+			tt.setupMocks(repo, events)
+
+			service := createTestEmailService(repo, nil, nil, nil)
+			service.events = events
+
+			result, err := service.GetEmailEvents(context.Background(), "email-1")
+
+			assert.Error(t, err)
+			assert.Nil(t, result)
+			assert.Contains(t, err.Error(), tt.expectedError)
+		})
+	}
+}
+
+func TestEmailService_Shutdown_Success(t *testing.T) {
 	tests := []struct {
 		name        string
 		queueEmails []*domain.Email
 	}{
 		{
-			name: "process queue with emails",
+			name: "shutdown drains queued emails",
 			queueEmails: []*domain.Email{
 				{ID: "1", To: "test1@example.com", Status: domain.StatusPending},
 				{ID: "2", To: "test2@example.com", Status: domain.StatusPending},
@@ -466,26 +834,161 @@ func TestEmailService_ProcessQueue_Success(t *testing.T) {
 			limiter := mocks.NewMockLimiter(ctrl)
 			metrics := mocks.NewMockMetrics(ctrl)
 
+			metrics.EXPECT().SetQueueSize(gomock.Any()).AnyTimes()
+			metrics.EXPECT().SetActiveWorkers(gomock.Any()).AnyTimes()
+			limiter.EXPECT().Wait(gomock.Any()).Return(nil).Times(len(tt.queueEmails))
+			sender.EXPECT().Send(gomock.Any(), gomock.Any()).Return(nil).Times(len(tt.queueEmails))
+			metrics.EXPECT().RecordEmailSent().Times(len(tt.queueEmails))
+			repo.EXPECT().UpdateStatus(gomock.Any(), gomock.Any(), domain.StatusSent, gomock.Any()).Return(nil).Times(len(tt.queueEmails))
+
 			service := createTestEmailService(repo, sender, limiter, metrics)
-			emailSvc := service
 
 			for _, email := range tt.queueEmails {
-				emailSvc.retryQueue <- email
+				assert.NoError(t, service.dispatcher.offer(email))
+			}
+
+			service.dispatcher.start()
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+
+			report, err := service.Shutdown(ctx)
+
+			assert.NoError(t, err)
+			assert.Equal(t, DrainReport{Drained: len(tt.queueEmails)}, report)
+			assert.Equal(t, 0, service.dispatcher.len())
+		})
+	}
+}
+
+func TestEmailService_Shutdown_Fail(t *testing.T) {
+	tests := []struct {
+		name           string
+		spoolPath      func(dir string) string
+		expectedReport DrainReport
+		expectedSpool  []string
+	}{
+		{
+			name:           "deadline spools queued emails and in-flight retry",
+			spoolPath:      func(dir string) string { return filepath.Join(dir, "spool.jsonl") },
+			expectedReport: DrainReport{Pending: 3},
+			expectedSpool:  []string{"in-flight", "queued-1", "queued-2"},
+		},
+		{
+			name:           "undelivered emails are dropped without a spool",
+			expectedReport: DrainReport{Dropped: 3},
+		},
+		{
+			name:           "emails that cannot be spooled are dropped",
+			spoolPath:      func(dir string) string { return filepath.Join(dir, "missing", "spool.jsonl") },
+			expectedReport: DrainReport{Dropped: 3},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := mocks.NewMockEmailRepository(ctrl)
+			sender := mocks.NewMockEmailSender(ctrl)
+			limiter := mocks.NewMockLimiter(ctrl)
+
+			started := make(chan struct{})
+			limiter.EXPECT().Wait(gomock.Any()).Return(nil)
+			sender.EXPECT().Send(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, _ *domain.Email) error {
+				close(started)
+				<-ctx.Done()
+				return ctx.Err()
+			})
+			// The cancelled attempt is parked for retry
+			repo.EXPECT().UpdateStatus(gomock.Any(), "in-flight", domain.StatusPending, gomock.Any()).Return(nil)
+
+			service := createTestEmailService(repo, sender, limiter, nil)
+
+			var emailSpool *spool.File[*domain.Email]
+			if tt.spoolPath != nil {
+				emailSpool = spool.NewFile[*domain.Email](tt.spoolPath(t.TempDir()))
+				service.spool = emailSpool
+			}
+
+			for _, id := range []string{"in-flight", "queued-1", "queued-2"} {
+				assert.NoError(t, service.dispatcher.offer(&domain.Email{ID: id, To: "test@example.com", Status: domain.StatusPending}))
 			}
-			close(emailSvc.retryQueue)
 
-			limiter.EXPECT().Wait(gomock.Any()).Return(nil).AnyTimes()
-			sender.EXPECT().Send(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
-			metrics.EXPECT().RecordEmailSent().AnyTimes()
-			repo.EXPECT().UpdateStatus(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+			service.dispatcher.start()
+			<-started
+
+			ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+			defer cancel()
+
+			report, err := service.Shutdown(ctx)
 
-			emailSvc.processRetryQueue()
+			assert.ErrorIs(t, err, context.DeadlineExceeded)
+			assert.Equal(t, tt.expectedReport, report)
 
-			assert.Equal(t, 0, len(emailSvc.retryQueue))
+			if tt.expectedSpool != nil {
+				spooled, err := emailSpool.Load()
+				require.NoError(t, err)
+
+				var ids []string
+				for _, email := range spooled {
+					ids = append(ids, email.ID)
+				}
+				assert.ElementsMatch(t, tt.expectedSpool, ids)
+			}
 		})
 	}
 }
 
+func TestEmailService_RequeueSpooled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mocks.NewMockEmailRepository(ctrl)
+	sender := mocks.NewMockEmailSender(ctrl)
+	limiter := mocks.NewMockLimiter(ctrl)
+
+	emailSpool := spool.NewFile[*domain.Email](filepath.Join(t.TempDir(), "spool.jsonl"))
+	spooled := []*domain.Email{
+		{ID: "spooled-1", To: "a@example.com", Category: "newsletter", UnsubscribeURL: "https://example.com/u/1", Status: domain.StatusFailed},
+		{ID: "spooled-2", To: "b@example.com", Status: domain.StatusPending},
+	}
+	require.NoError(t, emailSpool.Persist(context.Background(), spooled))
+
+	sent := make(chan *domain.Email, len(spooled))
+	repo.EXPECT().Save(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, email *domain.Email) error {
+		assert.Equal(t, domain.StatusPending, email.Status)
+		return nil
+	}).Times(len(spooled))
+	limiter.EXPECT().Wait(gomock.Any()).Return(nil).Times(len(spooled))
+	sender.EXPECT().Send(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, email *domain.Email) error {
+		sent <- email
+		return nil
+	}).Times(len(spooled))
+	repo.EXPECT().UpdateStatus(gomock.Any(), gomock.Any(), domain.StatusSent, gomock.Any()).Return(nil).Times(len(spooled))
+
+	service := NewEmailService(repo, nil, sender, limiter, &noOpMetrics{}, testDispatchConfig, nil, createTestLogger(), WithSpool(emailSpool))
+
+	report, err := service.Shutdown(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, DrainReport{Drained: len(spooled)}, report)
+
+	close(sent)
+	byID := make(map[string]*domain.Email)
+	for email := range sent {
+		byID[email.ID] = email
+	}
+	require.Len(t, byID, len(spooled))
+	assert.Equal(t, "newsletter", byID["spooled-1"].Category)
+	assert.Equal(t, "https://example.com/u/1", byID["spooled-1"].UnsubscribeURL)
+
+	// The spool was emptied when the emails were requeued
+	left, err := emailSpool.Load()
+	require.NoError(t, err)
+	assert.Empty(t, left)
+}
+
 func TestEmailService_QueueForRetry_Success(t *testing.T) {
 	tests := []struct {
 		name  string
@@ -520,8 +1023,8 @@ func TestEmailService_QueueForRetry_Success(t *testing.T) {
 
 			emailSvc.queueForRetry(tt.email)
 
-			assert.Equal(t, domain.StatusPending, tt.email.Status)
-			assert.Equal(t, 1, len(emailSvc.retryQueue))
+			assert.Equal(t, domain.StatusFailed, tt.email.Status)
+			assert.Equal(t, 1, emailSvc.dispatcher.len())
 		})
 	}
 }
@@ -556,11 +1059,12 @@ func TestEmailService_QueueForRetry_QueueFull_Fail(t *testing.T) {
 			metrics.EXPECT().SetQueueSize(gomock.Any()).AnyTimes()
 			repo.EXPECT().UpdateStatus(gomock.Any(), tt.email.ID, domain.StatusFailed, nil).Return(nil)
 
-			service := createTestEmailService(repo, sender, limiter, metrics)
+			service := createTestEmailServiceWithDispatch(repo, sender, limiter, metrics, config.DispatchConfig{
+				Workers:   1,
+				QueueSize: 0,
+			})
 			emailSvc := service
 
-			emailSvc.retryQueue = make(chan *domain.Email)
-
 			emailSvc.queueForRetry(tt.email)
 
 			assert.Equal(t, domain.StatusFailed, tt.email.Status)
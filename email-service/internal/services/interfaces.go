@@ -9,34 +9,71 @@ import (
 )
 
 type EmailService interface {
-	SendEmail(ctx context.Context, to, subject, body string) (*domain.Email, error)
+	SendEmail(ctx context.Context, to, subject, body string, opts domain.SendOptions) (*domain.Email, error)
 	GetEmailStatus(ctx context.Context, id string) (*domain.Email, error)
-	ListEmails(ctx context.Context, pageSize int, pageToken string) ([]*domain.Email, string, error)
+	ListEmails(ctx context.Context, query domain.ListQuery) (*domain.ListResult, error)
 	ResendFailedEmails(ctx context.Context) error
+	// RecordEvent stores an open or click reported by the tracking endpoint
+	RecordEvent(ctx context.Context, event *domain.EmailEvent) error
+	GetEmailEvents(ctx context.Context, id string) ([]*domain.EmailEvent, error)
+	// Shutdown stops accepting new emails and waits for queued and in-flight
+	// deliveries to finish or for ctx to expire, whichever comes first.
+	// Emails that were not delivered are saved to the spool, if there is one.
+	Shutdown(ctx context.Context) (DrainReport, error)
+}
+
+// DrainReport tells what happened to accepted emails during shutdown.
+type DrainReport struct {
+	// Drained is the number of deliveries completed while draining
+	Drained int
+	// Pending is the number of undelivered emails saved to the spool, which
+	// are sent when the service starts again
+	Pending int
+	// Dropped is the number of emails neither delivered nor spooled
+	Dropped int
+}
+
+// Spool keeps emails that were not delivered before shutdown for the next start
+type Spool interface {
+	Persist(ctx context.Context, emails []*domain.Email) error
+	Load() ([]*domain.Email, error)
 }
 
 type EmailRepository interface {
 	Save(ctx context.Context, email *domain.Email) error
 	GetByID(ctx context.Context, id string) (*domain.Email, error)
 	UpdateStatus(ctx context.Context, id string, status string, sentAt *time.Time) error
-	List(ctx context.Context, pageSize int, pageToken string) ([]*domain.Email, string, error)
+	List(ctx context.Context, query domain.ListQuery) (*domain.ListResult, error)
+}
+
+type EmailEventRepository interface {
+	Save(ctx context.Context, event *domain.EmailEvent) error
+	ListByEmailID(ctx context.Context, emailID string) ([]*domain.EmailEvent, error)
 }
 
 type Repositories interface {
 	Email() domain.EmailRepository
+	Events() domain.EmailEventRepository
 }
 
 type EmailSender interface {
 	Send(ctx context.Context, email *domain.Email) error
 }
 
+// Tracker instruments an email body for open and click tracking
+type Tracker interface {
+	Instrument(email *domain.Email) *domain.Email
+}
+
 type Metrics interface {
 	RecordEmailSent()
 	RecordEmailQueued()
 	RecordEmailFailed()
+	RecordEmailRejected()
 	RecordRateLimitDelay()
 	RecordDowntimePeriod()
 	SetQueueSize(size int)
+	SetActiveWorkers(count int)
 	ObserveProcessingDuration(duration float64)
 }
 
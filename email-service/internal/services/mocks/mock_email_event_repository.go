@@ -0,0 +1,71 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/popeskul/mailflow/email-service/internal/services (interfaces: EmailEventRepository)
+//
+// Generated by this command:
+//
+//	mockgen -destination=mocks/mock_email_event_repository.go -package=mocks github.com/popeskul/mailflow/email-service/internal/services EmailEventRepository
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	domain "github.com/popeskul/mailflow/email-service/internal/domain"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockEmailEventRepository is a mock of EmailEventRepository interface.
+type MockEmailEventRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockEmailEventRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockEmailEventRepositoryMockRecorder is the mock recorder for MockEmailEventRepository.
+type MockEmailEventRepositoryMockRecorder struct {
+	mock *MockEmailEventRepository
+}
+
+// NewMockEmailEventRepository creates a new mock instance.
+func NewMockEmailEventRepository(ctrl *gomock.Controller) *MockEmailEventRepository {
+	mock := &MockEmailEventRepository{ctrl: ctrl}
+	mock.recorder = &MockEmailEventRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockEmailEventRepository) EXPECT() *MockEmailEventRepositoryMockRecorder {
+	return m.recorder
+}
+
+// ListByEmailID mocks base method.
+func (m *MockEmailEventRepository) ListByEmailID(ctx context.Context, emailID string) ([]*domain.EmailEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListByEmailID", ctx, emailID)
+	ret0, _ := ret[0].([]*domain.EmailEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListByEmailID indicates an expected call of ListByEmailID.
+func (mr *MockEmailEventRepositoryMockRecorder) ListByEmailID(ctx, emailID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByEmailID", reflect.TypeOf((*MockEmailEventRepository)(nil).ListByEmailID), ctx, emailID)
+}
+
+// Save mocks base method.
+func (m *MockEmailEventRepository) Save(ctx context.Context, event *domain.EmailEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Save", ctx, event)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Save indicates an expected call of Save.
+func (mr *MockEmailEventRepositoryMockRecorder) Save(ctx, event any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockEmailEventRepository)(nil).Save), ctx, event)
+}
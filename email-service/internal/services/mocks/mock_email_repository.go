@@ -58,19 +58,18 @@ func (mr *MockEmailRepositoryMockRecorder) GetByID(ctx, id any) *gomock.Call {
 }
 
 // List mocks base method.
-func (m *MockEmailRepository) List(ctx context.Context, pageSize int, pageToken string) ([]*domain.Email, string, error) {
+func (m *MockEmailRepository) List(ctx context.Context, query domain.ListQuery) (*domain.ListResult, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "List", ctx, pageSize, pageToken)
-	ret0, _ := ret[0].([]*domain.Email)
-	ret1, _ := ret[1].(string)
-	ret2, _ := ret[2].(error)
-	return ret0, ret1, ret2
+	ret := m.ctrl.Call(m, "List", ctx, query)
+	ret0, _ := ret[0].(*domain.ListResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // List indicates an expected call of List.
-func (mr *MockEmailRepositoryMockRecorder) List(ctx, pageSize, pageToken any) *gomock.Call {
+func (mr *MockEmailRepositoryMockRecorder) List(ctx, query any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockEmailRepository)(nil).List), ctx, pageSize, pageToken)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockEmailRepository)(nil).List), ctx, query)
 }
 
 // Save mocks base method.
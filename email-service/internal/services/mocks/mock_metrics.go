@@ -87,6 +87,18 @@ func (mr *MockMetricsMockRecorder) RecordEmailQueued() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordEmailQueued", reflect.TypeOf((*MockMetrics)(nil).RecordEmailQueued))
 }
 
+// RecordEmailRejected mocks base method.
+func (m *MockMetrics) RecordEmailRejected() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "RecordEmailRejected")
+}
+
+// RecordEmailRejected indicates an expected call of RecordEmailRejected.
+func (mr *MockMetricsMockRecorder) RecordEmailRejected() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordEmailRejected", reflect.TypeOf((*MockMetrics)(nil).RecordEmailRejected))
+}
+
 // RecordEmailSent mocks base method.
 func (m *MockMetrics) RecordEmailSent() {
 	m.ctrl.T.Helper()
@@ -111,6 +123,18 @@ func (mr *MockMetricsMockRecorder) RecordRateLimitDelay() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordRateLimitDelay", reflect.TypeOf((*MockMetrics)(nil).RecordRateLimitDelay))
 }
 
+// SetActiveWorkers mocks base method.
+func (m *MockMetrics) SetActiveWorkers(count int) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetActiveWorkers", count)
+}
+
+// SetActiveWorkers indicates an expected call of SetActiveWorkers.
+func (mr *MockMetricsMockRecorder) SetActiveWorkers(count any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetActiveWorkers", reflect.TypeOf((*MockMetrics)(nil).SetActiveWorkers), count)
+}
+
 // SetQueueSize mocks base method.
 func (m *MockMetrics) SetQueueSize(size int) {
 	m.ctrl.T.Helper()
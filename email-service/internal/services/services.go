@@ -2,6 +2,7 @@ package services
 
 import (
 	"github.com/popeskul/mailflow/common/logger"
+	"github.com/popeskul/mailflow/email-service/internal/config"
 	"github.com/popeskul/mailflow/email-service/internal/metrics"
 )
 
@@ -14,10 +15,13 @@ func NewServices(
 	emailSender EmailSender,
 	limiter Limiter,
 	metrics *metrics.EmailMetrics,
+	dispatch config.DispatchConfig,
+	tracker Tracker,
 	logger logger.Logger,
+	opts ...Option,
 ) *ServiceContainer {
 	return &ServiceContainer{
-		email: NewEmailService(repos.Email(), emailSender, limiter, metrics, logger),
+		email: NewEmailService(repos.Email(), repos.Events(), emailSender, limiter, metrics, dispatch, tracker, logger, opts...),
 	}
 }
 
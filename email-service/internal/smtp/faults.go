@@ -0,0 +1,33 @@
+package smtp
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/popeskul/mailflow/common/fault"
+	"github.com/popeskul/mailflow/email-service/internal/domain"
+)
+
+// FaultTarget is the fault target for delivering email
+const FaultTarget = "smtp"
+
+// FaultSender injects the faults targeting FaultTarget into deliveries, so
+// SMTP failures can be tested without an SMTP server
+type FaultSender struct {
+	next   EmailSender
+	faults *fault.Injector
+}
+
+func NewFaultSender(next EmailSender, faults *fault.Injector) *FaultSender {
+	return &FaultSender{
+		next:   next,
+		faults: faults,
+	}
+}
+
+func (s *FaultSender) Send(ctx context.Context, email *domain.Email) error {
+	if err := s.faults.Inject(ctx, FaultTarget); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return s.next.Send(ctx, email)
+}
@@ -0,0 +1,57 @@
+package smtp
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/popeskul/mailflow/common/fault"
+	"github.com/popeskul/mailflow/email-service/internal/domain"
+)
+
+// countingSender counts the emails it is asked to send
+type countingSender struct {
+	sent int
+}
+
+func (s *countingSender) Send(context.Context, *domain.Email) error {
+	s.sent++
+	return nil
+}
+
+func TestFaultSender_Send(t *testing.T) {
+	tests := []struct {
+		name  string
+		fault fault.Fault
+		code  codes.Code
+		sent  int
+	}{
+		{
+			name:  "smtp fault",
+			fault: fault.Fault{Name: "smtp", Target: FaultTarget, Code: codes.Unavailable, Message: "421 try again later"},
+			code:  codes.Unavailable,
+		},
+		{
+			name:  "grpc faults do not reach delivery",
+			fault: fault.Fault{Name: "down", Target: fault.Any, Code: codes.Unavailable},
+			sent:  1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			faults := fault.NewInjector()
+			require.NoError(t, faults.Set(tt.fault))
+			next := &countingSender{}
+
+			err := NewFaultSender(next, faults).Send(context.Background(), &domain.Email{To: "to@example.com"})
+
+			assert.Equal(t, tt.code, status.Code(err))
+			assert.Equal(t, tt.sent, next.sent)
+		})
+	}
+}
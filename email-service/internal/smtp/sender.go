@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net/smtp"
+	"strings"
 
 	"github.com/popeskul/mailflow/common/logger"
 	"github.com/popeskul/mailflow/email-service/internal/config"
@@ -53,14 +54,10 @@ func (s *Sender) Send(ctx context.Context, email *domain.Email) error {
 
 	auth := smtp.PlainAuth("", s.username, s.password, s.host)
 
-	msg := fmt.Sprintf("From: %s\r\n"+
-		"To: %s\r\n"+
-		"Subject: %s\r\n"+
-		"\r\n"+
-		"%s\r\n", s.from, email.To, email.Subject, email.Body)
+	msg := buildMessage(s.from, email)
 
 	addr := s.host + ":" + s.port
-	if err := smtp.SendMail(addr, auth, s.from, []string{email.To}, []byte(msg)); err != nil {
+	if err := smtp.SendMail(addr, auth, s.from, []string{email.To}, msg); err != nil {
 		l.Error("failed to send email",
 			logger.Field{Key: "error", Value: err},
 			logger.Field{Key: "smtp_addr", Value: addr},
@@ -71,3 +68,25 @@ func (s *Sender) Send(ctx context.Context, email *domain.Email) error {
 	l.Info("email sent successfully")
 	return nil
 }
+
+// buildMessage renders the email as an RFC 5322 message. HTML bodies are
+// labelled as such, and emails carrying an unsubscribe URL get RFC 8058
+// one-click List-Unsubscribe headers.
+func buildMessage(from string, email *domain.Email) []byte {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", email.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", email.Subject)
+	if email.HTML {
+		b.WriteString("MIME-Version: 1.0\r\n")
+		b.WriteString("Content-Type: text/html; charset=UTF-8\r\n")
+	}
+	if email.UnsubscribeURL != "" {
+		fmt.Fprintf(&b, "List-Unsubscribe: <%s>\r\n", email.UnsubscribeURL)
+		b.WriteString("List-Unsubscribe-Post: List-Unsubscribe=One-Click\r\n")
+	}
+	fmt.Fprintf(&b, "\r\n%s\r\n", email.Body)
+
+	return []byte(b.String())
+}
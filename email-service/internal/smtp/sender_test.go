@@ -0,0 +1,70 @@
+package smtp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/popeskul/mailflow/email-service/internal/domain"
+)
+
+func TestBuildMessage_Success(t *testing.T) {
+	tests := []struct {
+		name     string
+		email    *domain.Email
+		expected string
+	}{
+		{
+			name: "plain message",
+			email: &domain.Email{
+				To:      "to@example.com",
+				Subject: "Hello",
+				Body:    "Body",
+			},
+			expected: "From: from@example.com\r\n" +
+				"To: to@example.com\r\n" +
+				"Subject: Hello\r\n" +
+				"\r\n" +
+				"Body\r\n",
+		},
+		{
+			name: "one-click unsubscribe headers",
+			email: &domain.Email{
+				To:             "to@example.com",
+				Subject:        "News",
+				Body:           "Body",
+				Category:       "newsletter",
+				UnsubscribeURL: "https://example.com/unsubscribe?token=abc",
+			},
+			expected: "From: from@example.com\r\n" +
+				"To: to@example.com\r\n" +
+				"Subject: News\r\n" +
+				"List-Unsubscribe: <https://example.com/unsubscribe?token=abc>\r\n" +
+				"List-Unsubscribe-Post: List-Unsubscribe=One-Click\r\n" +
+				"\r\n" +
+				"Body\r\n",
+		},
+		{
+			name: "html body",
+			email: &domain.Email{
+				To:      "to@example.com",
+				Subject: "Hello",
+				Body:    "<html><body>Body</body></html>",
+				HTML:    true,
+			},
+			expected: "From: from@example.com\r\n" +
+				"To: to@example.com\r\n" +
+				"Subject: Hello\r\n" +
+				"MIME-Version: 1.0\r\n" +
+				"Content-Type: text/html; charset=UTF-8\r\n" +
+				"\r\n" +
+				"<html><body>Body</body></html>\r\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, string(buildMessage("from@example.com", tt.email)))
+		})
+	}
+}
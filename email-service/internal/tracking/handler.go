@@ -0,0 +1,88 @@
+package tracking
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/popeskul/mailflow/common/logger"
+	"github.com/popeskul/mailflow/email-service/internal/domain"
+)
+
+// pixel is a transparent 1x1 GIF
+var pixel = []byte{
+	0x47, 0x49, 0x46, 0x38, 0x39, 0x61, 0x01, 0x00, 0x01, 0x00, 0x80, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0xff, 0xff, 0xff, 0x21, 0xf9, 0x04, 0x01, 0x00, 0x00, 0x00, 0x00, 0x2c, 0x00, 0x00, 0x00, 0x00,
+	0x01, 0x00, 0x01, 0x00, 0x00, 0x02, 0x02, 0x44, 0x01, 0x00, 0x3b,
+}
+
+// EventRecorder stores engagement events
+type EventRecorder interface {
+	RecordEvent(ctx context.Context, event *domain.EmailEvent) error
+}
+
+// NewHandler serves the open pixel and click redirects referenced by
+// instrumented emails. Recording failures never break the recipient's
+// experience: the pixel is still served and the click still redirects.
+func NewHandler(signer *Signer, recorder EventRecorder, l logger.Logger) http.Handler {
+	h := &handler{
+		signer:   signer,
+		recorder: recorder,
+		logger:   l.Named("tracking_handler"),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+openPath+"{token}", h.open)
+	mux.HandleFunc("GET "+clickPath+"{token}", h.click)
+	return mux
+}
+
+type handler struct {
+	signer   *Signer
+	recorder EventRecorder
+	logger   logger.Logger
+}
+
+func (h *handler) open(w http.ResponseWriter, r *http.Request) {
+	if claims, ok := h.verify(r, domain.EventOpen); ok {
+		h.record(r, claims)
+	}
+
+	w.Header().Set("Content-Type", "image/gif")
+	w.Header().Set("Cache-Control", "no-store, no-cache, must-revalidate")
+	_, _ = w.Write(pixel)
+}
+
+func (h *handler) click(w http.ResponseWriter, r *http.Request) {
+	claims, ok := h.verify(r, domain.EventClick)
+	if !ok {
+		// Never redirect to a target we did not sign
+		http.NotFound(w, r)
+		return
+	}
+
+	h.record(r, claims)
+	http.Redirect(w, r, claims.URL, http.StatusFound)
+}
+
+func (h *handler) verify(r *http.Request, eventType string) (Claims, bool) {
+	claims, err := h.signer.Verify(r.PathValue("token"))
+	if err != nil || claims.Type != eventType {
+		h.logger.Warn("rejected tracking token",
+			logger.Field{Key: "type", Value: eventType},
+			logger.Field{Key: "remote_addr", Value: r.RemoteAddr},
+		)
+		return Claims{}, false
+	}
+	return claims, true
+}
+
+func (h *handler) record(r *http.Request, c Claims) {
+	event := domain.NewEmailEvent(c.EmailID, c.Type, c.URL, r.UserAgent())
+	if err := h.recorder.RecordEvent(r.Context(), event); err != nil {
+		h.logger.Error("failed to record tracking event",
+			logger.Field{Key: "error", Value: err},
+			logger.Field{Key: "email_id", Value: c.EmailID},
+			logger.Field{Key: "type", Value: c.Type},
+		)
+	}
+}
@@ -0,0 +1,122 @@
+package tracking
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/popeskul/mailflow/common/logger"
+	"github.com/popeskul/mailflow/email-service/internal/domain"
+)
+
+type recorderFunc func(ctx context.Context, event *domain.EmailEvent) error
+
+func (f recorderFunc) RecordEvent(ctx context.Context, event *domain.EmailEvent) error {
+	return f(ctx, event)
+}
+
+func TestHandler_Success(t *testing.T) {
+	signer := NewSigner("secret")
+
+	tests := []struct {
+		name             string
+		path             string
+		recordErr        error
+		expectedStatus   int
+		expectedLocation string
+		expectedEvent    string
+	}{
+		{
+			name:           "open serves pixel",
+			path:           openPath + signer.Sign(Claims{EmailID: "email-1", Type: domain.EventOpen}),
+			expectedStatus: http.StatusOK,
+			expectedEvent:  domain.EventOpen,
+		},
+		{
+			name:             "click redirects to signed target",
+			path:             clickPath + signer.Sign(Claims{EmailID: "email-1", Type: domain.EventClick, URL: "https://example.com/x"}),
+			expectedStatus:   http.StatusFound,
+			expectedLocation: "https://example.com/x",
+			expectedEvent:    domain.EventClick,
+		},
+		{
+			name:             "click still redirects when recording fails",
+			path:             clickPath + signer.Sign(Claims{EmailID: "email-1", Type: domain.EventClick, URL: "https://example.com/x"}),
+			recordErr:        errors.New("email not found"),
+			expectedStatus:   http.StatusFound,
+			expectedLocation: "https://example.com/x",
+			expectedEvent:    domain.EventClick,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var recorded *domain.EmailEvent
+			h := NewHandler(signer, recorderFunc(func(_ context.Context, event *domain.EmailEvent) error {
+				recorded = event
+				return tt.recordErr
+			}), logger.NewZapLogger())
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Header.Set("User-Agent", "test-agent")
+			rec := httptest.NewRecorder()
+
+			h.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			assert.Equal(t, tt.expectedLocation, rec.Header().Get("Location"))
+			if assert.NotNil(t, recorded) {
+				assert.Equal(t, "email-1", recorded.EmailID)
+				assert.Equal(t, tt.expectedEvent, recorded.Type)
+				assert.Equal(t, "test-agent", recorded.UserAgent)
+			}
+		})
+	}
+}
+
+func TestHandler_Fail(t *testing.T) {
+	signer := NewSigner("secret")
+
+	tests := []struct {
+		name           string
+		path           string
+		expectedStatus int
+	}{
+		{
+			name:           "forged click token is not redirected",
+			path:           clickPath + NewSigner("other").Sign(Claims{EmailID: "email-1", Type: domain.EventClick, URL: "https://evil.test"}),
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "open token used as click",
+			path:           clickPath + signer.Sign(Claims{EmailID: "email-1", Type: domain.EventOpen}),
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "forged open token still gets pixel",
+			path:           openPath + "forged.token",
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorded := false
+			h := NewHandler(signer, recorderFunc(func(context.Context, *domain.EmailEvent) error {
+				recorded = true
+				return nil
+			}), logger.NewZapLogger())
+
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			assert.Empty(t, rec.Header().Get("Location"))
+			assert.False(t, recorded)
+		})
+	}
+}
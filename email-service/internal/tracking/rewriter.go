@@ -0,0 +1,71 @@
+package tracking
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/popeskul/mailflow/email-service/internal/domain"
+)
+
+const (
+	openPath  = "/t/o/"
+	clickPath = "/t/c/"
+)
+
+var (
+	htmlPattern = regexp.MustCompile(`(?i)<(html|body|a|p|div|table|br)[\s/>]`)
+	linkPattern = regexp.MustCompile(`(?i)(<a\s[^>]*?href\s*=\s*)(["'])(https?://[^"']+)(["'])`)
+	bodyClose   = regexp.MustCompile(`(?i)</body\s*>`)
+)
+
+// Rewriter instruments HTML email bodies with a tracking pixel and
+// redirecting links that point at the tracking endpoint.
+type Rewriter struct {
+	signer  *Signer
+	baseURL string
+}
+
+func NewRewriter(signer *Signer, baseURL string) *Rewriter {
+	return &Rewriter{
+		signer:  signer,
+		baseURL: strings.TrimRight(baseURL, "/"),
+	}
+}
+
+// Instrument returns a copy of the email with tracking applied to its body.
+// Plain-text bodies and emails without tracking are returned unchanged.
+func (r *Rewriter) Instrument(email *domain.Email) *domain.Email {
+	if !email.Tracking.Enabled() || !htmlPattern.MatchString(email.Body) {
+		return email
+	}
+
+	body := email.Body
+
+	if email.Tracking.Clicks {
+		body = linkPattern.ReplaceAllStringFunc(body, func(match string) string {
+			m := linkPattern.FindStringSubmatch(match)
+			target := html.UnescapeString(m[3])
+			token := r.signer.Sign(Claims{EmailID: email.ID, Type: domain.EventClick, URL: target})
+			return m[1] + m[2] + r.baseURL + clickPath + token + m[4]
+		})
+	}
+
+	if email.Tracking.Opens {
+		token := r.signer.Sign(Claims{EmailID: email.ID, Type: domain.EventOpen})
+		pixel := fmt.Sprintf(`<img src="%s%s%s" width="1" height="1" alt="" style="display:none">`,
+			r.baseURL, openPath, token)
+
+		if loc := bodyClose.FindStringIndex(body); loc != nil {
+			body = body[:loc[0]] + pixel + body[loc[0]:]
+		} else {
+			body += pixel
+		}
+	}
+
+	instrumented := *email
+	instrumented.Body = body
+	instrumented.HTML = true
+	return &instrumented
+}
@@ -0,0 +1,100 @@
+package tracking
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/popeskul/mailflow/email-service/internal/domain"
+)
+
+var trackedLink = regexp.MustCompile(`href="http://track\.test/t/c/([^"]+)"`)
+
+func TestRewriter_Instrument_Success(t *testing.T) {
+	tests := []struct {
+		name          string
+		body          string
+		tracking      domain.Tracking
+		expectPixel   bool
+		expectedLinks []string
+	}{
+		{
+			name:          "pixel inserted before closing body tag",
+			body:          "<html><body><p>Hi</p></body></html>",
+			tracking:      domain.Tracking{Opens: true},
+			expectPixel:   true,
+			expectedLinks: []string{},
+		},
+		{
+			name:          "links rewritten to signed redirects",
+			body:          `<p>Read <a href="https://example.com/a?x=1&amp;y=2">this</a> and <a class="btn" href="http://example.org">that</a></p>`,
+			tracking:      domain.Tracking{Clicks: true},
+			expectedLinks: []string{"https://example.com/a?x=1&y=2", "http://example.org"},
+		},
+		{
+			name:          "mailto links are left alone",
+			body:          `<p><a href="mailto:hi@example.com">mail</a></p>`,
+			tracking:      domain.Tracking{Clicks: true},
+			expectedLinks: []string{},
+		},
+	}
+
+	signer := NewSigner("secret")
+	rewriter := NewRewriter(signer, "http://track.test/")
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			email := &domain.Email{ID: "email-1", Body: tt.body, Tracking: tt.tracking}
+
+			result := rewriter.Instrument(email)
+
+			assert.Equal(t, tt.body, email.Body, "original email must not change")
+			assert.True(t, result.HTML)
+			assert.Equal(t, tt.expectPixel, regexp.MustCompile(`<img src="http://track\.test/t/o/[^"]+"[^>]*></body>`).MatchString(result.Body))
+
+			links := []string{}
+			for _, m := range trackedLink.FindAllStringSubmatch(result.Body, -1) {
+				claims, err := signer.Verify(m[1])
+				require.NoError(t, err)
+				assert.Equal(t, "email-1", claims.EmailID)
+				assert.Equal(t, domain.EventClick, claims.Type)
+				links = append(links, claims.URL)
+			}
+			assert.Equal(t, tt.expectedLinks, links)
+		})
+	}
+}
+
+func TestRewriter_Instrument_Unchanged(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		tracking domain.Tracking
+	}{
+		{
+			name:     "tracking not requested",
+			body:     `<p><a href="https://example.com">link</a></p>`,
+			tracking: domain.Tracking{},
+		},
+		{
+			name:     "plain text body",
+			body:     "Visit https://example.com",
+			tracking: domain.Tracking{Opens: true, Clicks: true},
+		},
+	}
+
+	rewriter := NewRewriter(NewSigner("secret"), "http://track.test")
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			email := &domain.Email{ID: "email-1", Body: tt.body, Tracking: tt.tracking}
+
+			result := rewriter.Instrument(email)
+
+			assert.Equal(t, tt.body, result.Body)
+			assert.False(t, result.HTML)
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package tracking
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/popeskul/mailflow/common/signed"
+)
+
+// ErrInvalidToken is returned for forged or malformed tracking tokens
+var ErrInvalidToken = errors.New("invalid tracking token")
+
+// Claims is what a tracking token vouches for
+type Claims struct {
+	EmailID string
+	Type    string
+	URL     string
+}
+
+// Signer issues and verifies HMAC-signed tracking tokens, so that the
+// public endpoint never records events or redirects for forged links.
+type Signer struct {
+	signer *signed.Signer
+}
+
+func NewSigner(secret string) *Signer {
+	return &Signer{signer: signed.NewSigner(secret)}
+}
+
+// Sign encodes the claims into a URL-safe token
+func (s *Signer) Sign(c Claims) string {
+	return s.signer.Sign([]byte(strings.Join([]string{c.EmailID, c.Type, c.URL}, "\n")))
+}
+
+// Verify checks the token signature and returns its claims
+func (s *Signer) Verify(token string) (Claims, error) {
+	payload, err := s.signer.Open(token)
+	if err != nil {
+		return Claims{}, ErrInvalidToken
+	}
+
+	parts := strings.SplitN(string(payload), "\n", 3)
+	if len(parts) != 3 {
+		return Claims{}, ErrInvalidToken
+	}
+
+	return Claims{EmailID: parts[0], Type: parts[1], URL: parts[2]}, nil
+}
@@ -0,0 +1,74 @@
+package tracking
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/popeskul/mailflow/email-service/internal/domain"
+)
+
+func TestSigner_Verify_Success(t *testing.T) {
+	tests := []struct {
+		name   string
+		claims Claims
+	}{
+		{
+			name:   "open token",
+			claims: Claims{EmailID: "email-1", Type: domain.EventOpen},
+		},
+		{
+			name:   "click token with query string",
+			claims: Claims{EmailID: "email-1", Type: domain.EventClick, URL: "https://example.com/a?b=c&d=e"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signer := NewSigner("secret")
+
+			claims, err := signer.Verify(signer.Sign(tt.claims))
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.claims, claims)
+		})
+	}
+}
+
+func TestSigner_Verify_Fail(t *testing.T) {
+	valid := NewSigner("secret").Sign(Claims{EmailID: "email-1", Type: domain.EventClick, URL: "https://example.com"})
+	payload, sig, _ := strings.Cut(valid, ".")
+	forged, _, _ := strings.Cut(NewSigner("secret").Sign(Claims{EmailID: "email-1", Type: domain.EventClick, URL: "https://evil.test"}), ".")
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{
+			name:  "missing signature",
+			token: payload,
+		},
+		{
+			name:  "signed with another secret",
+			token: NewSigner("other").Sign(Claims{EmailID: "email-1", Type: domain.EventOpen}),
+		},
+		{
+			name:  "tampered payload",
+			token: forged + "." + sig,
+		},
+		{
+			name:  "malformed encoding",
+			token: "!!!.???",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewSigner("secret").Verify(tt.token)
+
+			assert.ErrorIs(t, err, ErrInvalidToken)
+		})
+	}
+}
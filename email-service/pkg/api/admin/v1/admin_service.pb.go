@@ -0,0 +1,661 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: api/admin/v1/admin_service.proto
+
+package adminv1
+
+import (
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+
+	_ "google.golang.org/genproto/googleapis/api/annotations"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Schedule says when a fault is active, counted from when it was set. An
+// empty schedule is always active.
+type Schedule struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// after_ms delays the first time the fault is active.
+	AfterMs int64 `protobuf:"varint,1,opt,name=after_ms,json=afterMs,proto3" json:"after_ms,omitempty"`
+	// every_ms repeats the fault; for_ms must be set with it.
+	EveryMs int64 `protobuf:"varint,2,opt,name=every_ms,json=everyMs,proto3" json:"every_ms,omitempty"`
+	// for_ms is how long the fault stays active each time, until it is
+	// removed when 0.
+	ForMs         int64 `protobuf:"varint,3,opt,name=for_ms,json=forMs,proto3" json:"for_ms,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Schedule) Reset() {
+	*x = Schedule{}
+	mi := &file_api_admin_v1_admin_service_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Schedule) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Schedule) ProtoMessage() {}
+
+func (x *Schedule) ProtoReflect() protoreflect.Message {
+	mi := &file_api_admin_v1_admin_service_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Schedule.ProtoReflect.Descriptor instead.
+func (*Schedule) Descriptor() ([]byte, []int) {
+	return file_api_admin_v1_admin_service_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Schedule) GetAfterMs() int64 {
+	if x != nil {
+		return x.AfterMs
+	}
+	return 0
+}
+
+func (x *Schedule) GetEveryMs() int64 {
+	if x != nil {
+		return x.EveryMs
+	}
+	return 0
+}
+
+func (x *Schedule) GetForMs() int64 {
+	if x != nil {
+		return x.ForMs
+	}
+	return 0
+}
+
+// Fault is a failure injected into calls to a target.
+type Fault struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// name identifies the fault.
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// target is a full gRPC method name such as
+	// "/email.v1.EmailService/SendEmail", "*" for every method, or "smtp" for
+	// email delivery.
+	Target string `protobuf:"bytes,2,opt,name=target,proto3" json:"target,omitempty"`
+	// code is the name of the status code calls fail with, e.g.
+	// "Unavailable". With "OK" or no code calls only get the latency.
+	Code string `protobuf:"bytes,3,opt,name=code,proto3" json:"code,omitempty"`
+	// message is the status message.
+	Message string `protobuf:"bytes,4,opt,name=message,proto3" json:"message,omitempty"`
+	// latency_ms delays calls before they fail or go ahead.
+	LatencyMs int64 `protobuf:"varint,5,opt,name=latency_ms,json=latencyMs,proto3" json:"latency_ms,omitempty"`
+	// probability is the fraction of calls the fault applies to, from 0 to
+	// 1; 0 means every call.
+	Probability float64 `protobuf:"fixed64,6,opt,name=probability,proto3" json:"probability,omitempty"`
+	// schedule limits when the fault is active.
+	Schedule *Schedule `protobuf:"bytes,7,opt,name=schedule,proto3" json:"schedule,omitempty"`
+	// seed seeds the fault's random source, the service's fault seed when 0.
+	Seed int64 `protobuf:"varint,8,opt,name=seed,proto3" json:"seed,omitempty"`
+	// active is set while the fault's schedule is on.
+	Active bool `protobuf:"varint,9,opt,name=active,proto3" json:"active,omitempty"`
+	// injected is how many calls the fault was injected into.
+	Injected      uint64 `protobuf:"varint,10,opt,name=injected,proto3" json:"injected,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Fault) Reset() {
+	*x = Fault{}
+	mi := &file_api_admin_v1_admin_service_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Fault) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Fault) ProtoMessage() {}
+
+func (x *Fault) ProtoReflect() protoreflect.Message {
+	mi := &file_api_admin_v1_admin_service_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Fault.ProtoReflect.Descriptor instead.
+func (*Fault) Descriptor() ([]byte, []int) {
+	return file_api_admin_v1_admin_service_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Fault) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Fault) GetTarget() string {
+	if x != nil {
+		return x.Target
+	}
+	return ""
+}
+
+func (x *Fault) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+func (x *Fault) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *Fault) GetLatencyMs() int64 {
+	if x != nil {
+		return x.LatencyMs
+	}
+	return 0
+}
+
+func (x *Fault) GetProbability() float64 {
+	if x != nil {
+		return x.Probability
+	}
+	return 0
+}
+
+func (x *Fault) GetSchedule() *Schedule {
+	if x != nil {
+		return x.Schedule
+	}
+	return nil
+}
+
+func (x *Fault) GetSeed() int64 {
+	if x != nil {
+		return x.Seed
+	}
+	return 0
+}
+
+func (x *Fault) GetActive() bool {
+	if x != nil {
+		return x.Active
+	}
+	return false
+}
+
+func (x *Fault) GetInjected() uint64 {
+	if x != nil {
+		return x.Injected
+	}
+	return 0
+}
+
+type ListFaultsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListFaultsRequest) Reset() {
+	*x = ListFaultsRequest{}
+	mi := &file_api_admin_v1_admin_service_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListFaultsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListFaultsRequest) ProtoMessage() {}
+
+func (x *ListFaultsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_admin_v1_admin_service_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListFaultsRequest.ProtoReflect.Descriptor instead.
+func (*ListFaultsRequest) Descriptor() ([]byte, []int) {
+	return file_api_admin_v1_admin_service_proto_rawDescGZIP(), []int{2}
+}
+
+type ListFaultsResponse struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Faults []*Fault               `protobuf:"bytes,1,rep,name=faults,proto3" json:"faults,omitempty"`
+	// seed is the seed faults without one of their own use.
+	Seed          int64 `protobuf:"varint,2,opt,name=seed,proto3" json:"seed,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListFaultsResponse) Reset() {
+	*x = ListFaultsResponse{}
+	mi := &file_api_admin_v1_admin_service_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListFaultsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListFaultsResponse) ProtoMessage() {}
+
+func (x *ListFaultsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_admin_v1_admin_service_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListFaultsResponse.ProtoReflect.Descriptor instead.
+func (*ListFaultsResponse) Descriptor() ([]byte, []int) {
+	return file_api_admin_v1_admin_service_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ListFaultsResponse) GetFaults() []*Fault {
+	if x != nil {
+		return x.Faults
+	}
+	return nil
+}
+
+func (x *ListFaultsResponse) GetSeed() int64 {
+	if x != nil {
+		return x.Seed
+	}
+	return 0
+}
+
+type SetFaultRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Fault         *Fault                 `protobuf:"bytes,1,opt,name=fault,proto3" json:"fault,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetFaultRequest) Reset() {
+	*x = SetFaultRequest{}
+	mi := &file_api_admin_v1_admin_service_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetFaultRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetFaultRequest) ProtoMessage() {}
+
+func (x *SetFaultRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_admin_v1_admin_service_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetFaultRequest.ProtoReflect.Descriptor instead.
+func (*SetFaultRequest) Descriptor() ([]byte, []int) {
+	return file_api_admin_v1_admin_service_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *SetFaultRequest) GetFault() *Fault {
+	if x != nil {
+		return x.Fault
+	}
+	return nil
+}
+
+type SetFaultResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Fault         *Fault                 `protobuf:"bytes,1,opt,name=fault,proto3" json:"fault,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetFaultResponse) Reset() {
+	*x = SetFaultResponse{}
+	mi := &file_api_admin_v1_admin_service_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetFaultResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetFaultResponse) ProtoMessage() {}
+
+func (x *SetFaultResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_admin_v1_admin_service_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetFaultResponse.ProtoReflect.Descriptor instead.
+func (*SetFaultResponse) Descriptor() ([]byte, []int) {
+	return file_api_admin_v1_admin_service_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *SetFaultResponse) GetFault() *Fault {
+	if x != nil {
+		return x.Fault
+	}
+	return nil
+}
+
+type RemoveFaultRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RemoveFaultRequest) Reset() {
+	*x = RemoveFaultRequest{}
+	mi := &file_api_admin_v1_admin_service_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RemoveFaultRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RemoveFaultRequest) ProtoMessage() {}
+
+func (x *RemoveFaultRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_admin_v1_admin_service_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RemoveFaultRequest.ProtoReflect.Descriptor instead.
+func (*RemoveFaultRequest) Descriptor() ([]byte, []int) {
+	return file_api_admin_v1_admin_service_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *RemoveFaultRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type RemoveFaultResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RemoveFaultResponse) Reset() {
+	*x = RemoveFaultResponse{}
+	mi := &file_api_admin_v1_admin_service_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RemoveFaultResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RemoveFaultResponse) ProtoMessage() {}
+
+func (x *RemoveFaultResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_admin_v1_admin_service_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RemoveFaultResponse.ProtoReflect.Descriptor instead.
+func (*RemoveFaultResponse) Descriptor() ([]byte, []int) {
+	return file_api_admin_v1_admin_service_proto_rawDescGZIP(), []int{7}
+}
+
+type ClearFaultsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ClearFaultsRequest) Reset() {
+	*x = ClearFaultsRequest{}
+	mi := &file_api_admin_v1_admin_service_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ClearFaultsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClearFaultsRequest) ProtoMessage() {}
+
+func (x *ClearFaultsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_admin_v1_admin_service_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClearFaultsRequest.ProtoReflect.Descriptor instead.
+func (*ClearFaultsRequest) Descriptor() ([]byte, []int) {
+	return file_api_admin_v1_admin_service_proto_rawDescGZIP(), []int{8}
+}
+
+type ClearFaultsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ClearFaultsResponse) Reset() {
+	*x = ClearFaultsResponse{}
+	mi := &file_api_admin_v1_admin_service_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ClearFaultsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClearFaultsResponse) ProtoMessage() {}
+
+func (x *ClearFaultsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_admin_v1_admin_service_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClearFaultsResponse.ProtoReflect.Descriptor instead.
+func (*ClearFaultsResponse) Descriptor() ([]byte, []int) {
+	return file_api_admin_v1_admin_service_proto_rawDescGZIP(), []int{9}
+}
+
+var File_api_admin_v1_admin_service_proto protoreflect.FileDescriptor
+
+const file_api_admin_v1_admin_service_proto_rawDesc = "" +
+	"\n" +
+	" api/admin/v1/admin_service.proto\x12\badmin.v1\x1a\x1cgoogle/api/annotations.proto\x1a\x1fgoogle/api/field_behavior.proto\"W\n" +
+	"\bSchedule\x12\x19\n" +
+	"\bafter_ms\x18\x01 \x01(\x03R\aafterMs\x12\x19\n" +
+	"\bevery_ms\x18\x02 \x01(\x03R\aeveryMs\x12\x15\n" +
+	"\x06for_ms\x18\x03 \x01(\x03R\x05forMs\"\xae\x02\n" +
+	"\x05Fault\x12\x17\n" +
+	"\x04name\x18\x01 \x01(\tB\x03\xe0A\x02R\x04name\x12\x1b\n" +
+	"\x06target\x18\x02 \x01(\tB\x03\xe0A\x02R\x06target\x12\x12\n" +
+	"\x04code\x18\x03 \x01(\tR\x04code\x12\x18\n" +
+	"\amessage\x18\x04 \x01(\tR\amessage\x12\x1d\n" +
+	"\n" +
+	"latency_ms\x18\x05 \x01(\x03R\tlatencyMs\x12 \n" +
+	"\vprobability\x18\x06 \x01(\x01R\vprobability\x12.\n" +
+	"\bschedule\x18\a \x01(\v2\x12.admin.v1.ScheduleR\bschedule\x12\x12\n" +
+	"\x04seed\x18\b \x01(\x03R\x04seed\x12\x1b\n" +
+	"\x06active\x18\t \x01(\bB\x03\xe0A\x03R\x06active\x12\x1f\n" +
+	"\binjected\x18\n" +
+	" \x01(\x04B\x03\xe0A\x03R\binjected\"\x13\n" +
+	"\x11ListFaultsRequest\"Q\n" +
+	"\x12ListFaultsResponse\x12'\n" +
+	"\x06faults\x18\x01 \x03(\v2\x0f.admin.v1.FaultR\x06faults\x12\x12\n" +
+	"\x04seed\x18\x02 \x01(\x03R\x04seed\"=\n" +
+	"\x0fSetFaultRequest\x12*\n" +
+	"\x05fault\x18\x01 \x01(\v2\x0f.admin.v1.FaultB\x03\xe0A\x02R\x05fault\"9\n" +
+	"\x10SetFaultResponse\x12%\n" +
+	"\x05fault\x18\x01 \x01(\v2\x0f.admin.v1.FaultR\x05fault\"-\n" +
+	"\x12RemoveFaultRequest\x12\x17\n" +
+	"\x04name\x18\x01 \x01(\tB\x03\xe0A\x02R\x04name\"\x15\n" +
+	"\x13RemoveFaultResponse\"\x14\n" +
+	"\x12ClearFaultsRequest\"\x15\n" +
+	"\x13ClearFaultsResponse2\xce\x03\n" +
+	"\fAdminService\x12e\n" +
+	"\n" +
+	"ListFaults\x12\x1b.admin.v1.ListFaultsRequest\x1a\x1c.admin.v1.ListFaultsResponse\"\x1c\x82\xd3\xe4\x93\x02\x16\x12\x14/api/v1/admin/faults\x12s\n" +
+	"\bSetFault\x12\x19.admin.v1.SetFaultRequest\x1a\x1a.admin.v1.SetFaultResponse\"0\x82\xd3\xe4\x93\x02*:\x05fault\x1a!/api/v1/admin/faults/{fault.name}\x12o\n" +
+	"\vRemoveFault\x12\x1c.admin.v1.RemoveFaultRequest\x1a\x1d.admin.v1.RemoveFaultResponse\"#\x82\xd3\xe4\x93\x02\x1d*\x1b/api/v1/admin/faults/{name}\x12q\n" +
+	"\vClearFaults\x12\x1c.admin.v1.ClearFaultsRequest\x1a\x1d.admin.v1.ClearFaultsResponse\"%\x82\xd3\xe4\x93\x02\x1f:\x01*\"\x1a/api/v1/admin/faults:clearBEZCgithub.com/popeskul/mailflow/email-service/pkg/api/admin/v1;adminv1b\x06proto3"
+
+var (
+	file_api_admin_v1_admin_service_proto_rawDescOnce sync.Once
+	file_api_admin_v1_admin_service_proto_rawDescData []byte
+)
+
+func file_api_admin_v1_admin_service_proto_rawDescGZIP() []byte {
+	file_api_admin_v1_admin_service_proto_rawDescOnce.Do(func() {
+		file_api_admin_v1_admin_service_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_api_admin_v1_admin_service_proto_rawDesc), len(file_api_admin_v1_admin_service_proto_rawDesc)))
+	})
+	return file_api_admin_v1_admin_service_proto_rawDescData
+}
+
+var file_api_admin_v1_admin_service_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
+var file_api_admin_v1_admin_service_proto_goTypes = []any{
+	(*Schedule)(nil),            // 0: admin.v1.Schedule
+	(*Fault)(nil),               // 1: admin.v1.Fault
+	(*ListFaultsRequest)(nil),   // 2: admin.v1.ListFaultsRequest
+	(*ListFaultsResponse)(nil),  // 3: admin.v1.ListFaultsResponse
+	(*SetFaultRequest)(nil),     // 4: admin.v1.SetFaultRequest
+	(*SetFaultResponse)(nil),    // 5: admin.v1.SetFaultResponse
+	(*RemoveFaultRequest)(nil),  // 6: admin.v1.RemoveFaultRequest
+	(*RemoveFaultResponse)(nil), // 7: admin.v1.RemoveFaultResponse
+	(*ClearFaultsRequest)(nil),  // 8: admin.v1.ClearFaultsRequest
+	(*ClearFaultsResponse)(nil), // 9: admin.v1.ClearFaultsResponse
+}
+var file_api_admin_v1_admin_service_proto_depIdxs = []int32{
+	0, // 0: admin.v1.Fault.schedule:type_name -> admin.v1.Schedule
+	1, // 1: admin.v1.ListFaultsResponse.faults:type_name -> admin.v1.Fault
+	1, // 2: admin.v1.SetFaultRequest.fault:type_name -> admin.v1.Fault
+	1, // 3: admin.v1.SetFaultResponse.fault:type_name -> admin.v1.Fault
+	2, // 4: admin.v1.AdminService.ListFaults:input_type -> admin.v1.ListFaultsRequest
+	4, // 5: admin.v1.AdminService.SetFault:input_type -> admin.v1.SetFaultRequest
+	6, // 6: admin.v1.AdminService.RemoveFault:input_type -> admin.v1.RemoveFaultRequest
+	8, // 7: admin.v1.AdminService.ClearFaults:input_type -> admin.v1.ClearFaultsRequest
+	3, // 8: admin.v1.AdminService.ListFaults:output_type -> admin.v1.ListFaultsResponse
+	5, // 9: admin.v1.AdminService.SetFault:output_type -> admin.v1.SetFaultResponse
+	7, // 10: admin.v1.AdminService.RemoveFault:output_type -> admin.v1.RemoveFaultResponse
+	9, // 11: admin.v1.AdminService.ClearFaults:output_type -> admin.v1.ClearFaultsResponse
+	8, // [8:12] is the sub-list for method output_type
+	4, // [4:8] is the sub-list for method input_type
+	4, // [4:4] is the sub-list for extension type_name
+	4, // [4:4] is the sub-list for extension extendee
+	0, // [0:4] is the sub-list for field type_name
+}
+
+func init() { file_api_admin_v1_admin_service_proto_init() }
+func file_api_admin_v1_admin_service_proto_init() {
+	if File_api_admin_v1_admin_service_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_api_admin_v1_admin_service_proto_rawDesc), len(file_api_admin_v1_admin_service_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   10,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_api_admin_v1_admin_service_proto_goTypes,
+		DependencyIndexes: file_api_admin_v1_admin_service_proto_depIdxs,
+		MessageInfos:      file_api_admin_v1_admin_service_proto_msgTypes,
+	}.Build()
+	File_api_admin_v1_admin_service_proto = out.File
+	file_api_admin_v1_admin_service_proto_goTypes = nil
+	file_api_admin_v1_admin_service_proto_depIdxs = nil
+}
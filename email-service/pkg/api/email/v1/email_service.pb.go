@@ -24,6 +24,57 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+type SortOrder int32
+
+const (
+	SortOrder_SORT_ORDER_UNSPECIFIED SortOrder = 0
+	// Oldest first, the default
+	SortOrder_SORT_ORDER_CREATED_ASC SortOrder = 1
+	// Newest first
+	SortOrder_SORT_ORDER_CREATED_DESC SortOrder = 2
+)
+
+// Enum value maps for SortOrder.
+var (
+	SortOrder_name = map[int32]string{
+		0: "SORT_ORDER_UNSPECIFIED",
+		1: "SORT_ORDER_CREATED_ASC",
+		2: "SORT_ORDER_CREATED_DESC",
+	}
+	SortOrder_value = map[string]int32{
+		"SORT_ORDER_UNSPECIFIED":  0,
+		"SORT_ORDER_CREATED_ASC":  1,
+		"SORT_ORDER_CREATED_DESC": 2,
+	}
+)
+
+func (x SortOrder) Enum() *SortOrder {
+	p := new(SortOrder)
+	*p = x
+	return p
+}
+
+func (x SortOrder) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (SortOrder) Descriptor() protoreflect.EnumDescriptor {
+	return file_api_email_v1_email_service_proto_enumTypes[0].Descriptor()
+}
+
+func (SortOrder) Type() protoreflect.EnumType {
+	return &file_api_email_v1_email_service_proto_enumTypes[0]
+}
+
+func (x SortOrder) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use SortOrder.Descriptor instead.
+func (SortOrder) EnumDescriptor() ([]byte, []int) {
+	return file_api_email_v1_email_service_proto_rawDescGZIP(), []int{0}
+}
+
 type Email struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
@@ -33,6 +84,8 @@ type Email struct {
 	Status        string                 `protobuf:"bytes,5,opt,name=status,proto3" json:"status,omitempty"`
 	CreatedAt     string                 `protobuf:"bytes,6,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
 	SentAt        string                 `protobuf:"bytes,7,opt,name=sent_at,json=sentAt,proto3" json:"sent_at,omitempty"`
+	Tenant        string                 `protobuf:"bytes,8,opt,name=tenant,proto3" json:"tenant,omitempty"`
+	Tags          []string               `protobuf:"bytes,9,rep,name=tags,proto3" json:"tags,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -116,11 +169,35 @@ func (x *Email) GetSentAt() string {
 	return ""
 }
 
+func (x *Email) GetTenant() string {
+	if x != nil {
+		return x.Tenant
+	}
+	return ""
+}
+
+func (x *Email) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
 type SendEmailRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	To            string                 `protobuf:"bytes,1,opt,name=to,proto3" json:"to,omitempty"`
-	Subject       string                 `protobuf:"bytes,2,opt,name=subject,proto3" json:"subject,omitempty"`
-	Body          string                 `protobuf:"bytes,3,opt,name=body,proto3" json:"body,omitempty"`
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	To      string                 `protobuf:"bytes,1,opt,name=to,proto3" json:"to,omitempty"`
+	Subject string                 `protobuf:"bytes,2,opt,name=subject,proto3" json:"subject,omitempty"`
+	Body    string                 `protobuf:"bytes,3,opt,name=body,proto3" json:"body,omitempty"`
+	// Tracking is applied only when it is also enabled on the server
+	Tracking *TrackingOptions `protobuf:"bytes,4,opt,name=tracking,proto3" json:"tracking,omitempty"`
+	// Mailing category the email belongs to, e.g. "newsletter"
+	Category string `protobuf:"bytes,5,opt,name=category,proto3" json:"category,omitempty"`
+	// One-click unsubscribe endpoint sent as RFC 8058 List-Unsubscribe headers
+	UnsubscribeUrl string `protobuf:"bytes,6,opt,name=unsubscribe_url,json=unsubscribeUrl,proto3" json:"unsubscribe_url,omitempty"`
+	// Tenant the email is sent on behalf of, used to scope listings
+	Tenant string `protobuf:"bytes,7,opt,name=tenant,proto3" json:"tenant,omitempty"`
+	// Free-form labels that ListEmails can filter on
+	Tags          []string `protobuf:"bytes,8,rep,name=tags,proto3" json:"tags,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -176,6 +253,95 @@ func (x *SendEmailRequest) GetBody() string {
 	return ""
 }
 
+func (x *SendEmailRequest) GetTracking() *TrackingOptions {
+	if x != nil {
+		return x.Tracking
+	}
+	return nil
+}
+
+func (x *SendEmailRequest) GetCategory() string {
+	if x != nil {
+		return x.Category
+	}
+	return ""
+}
+
+func (x *SendEmailRequest) GetUnsubscribeUrl() string {
+	if x != nil {
+		return x.UnsubscribeUrl
+	}
+	return ""
+}
+
+func (x *SendEmailRequest) GetTenant() string {
+	if x != nil {
+		return x.Tenant
+	}
+	return ""
+}
+
+func (x *SendEmailRequest) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+type TrackingOptions struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Embed a tracking pixel to record opens
+	Opens bool `protobuf:"varint,1,opt,name=opens,proto3" json:"opens,omitempty"`
+	// Route links through the tracking endpoint to record clicks
+	Clicks        bool `protobuf:"varint,2,opt,name=clicks,proto3" json:"clicks,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TrackingOptions) Reset() {
+	*x = TrackingOptions{}
+	mi := &file_api_email_v1_email_service_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TrackingOptions) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TrackingOptions) ProtoMessage() {}
+
+func (x *TrackingOptions) ProtoReflect() protoreflect.Message {
+	mi := &file_api_email_v1_email_service_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TrackingOptions.ProtoReflect.Descriptor instead.
+func (*TrackingOptions) Descriptor() ([]byte, []int) {
+	return file_api_email_v1_email_service_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *TrackingOptions) GetOpens() bool {
+	if x != nil {
+		return x.Opens
+	}
+	return false
+}
+
+func (x *TrackingOptions) GetClicks() bool {
+	if x != nil {
+		return x.Clicks
+	}
+	return false
+}
+
 type SendEmailResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
@@ -186,7 +352,7 @@ type SendEmailResponse struct {
 
 func (x *SendEmailResponse) Reset() {
 	*x = SendEmailResponse{}
-	mi := &file_api_email_v1_email_service_proto_msgTypes[2]
+	mi := &file_api_email_v1_email_service_proto_msgTypes[3]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -198,7 +364,7 @@ func (x *SendEmailResponse) String() string {
 func (*SendEmailResponse) ProtoMessage() {}
 
 func (x *SendEmailResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_api_email_v1_email_service_proto_msgTypes[2]
+	mi := &file_api_email_v1_email_service_proto_msgTypes[3]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -211,7 +377,7 @@ func (x *SendEmailResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SendEmailResponse.ProtoReflect.Descriptor instead.
 func (*SendEmailResponse) Descriptor() ([]byte, []int) {
-	return file_api_email_v1_email_service_proto_rawDescGZIP(), []int{2}
+	return file_api_email_v1_email_service_proto_rawDescGZIP(), []int{3}
 }
 
 func (x *SendEmailResponse) GetId() string {
@@ -237,7 +403,7 @@ type GetEmailStatusRequest struct {
 
 func (x *GetEmailStatusRequest) Reset() {
 	*x = GetEmailStatusRequest{}
-	mi := &file_api_email_v1_email_service_proto_msgTypes[3]
+	mi := &file_api_email_v1_email_service_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -249,7 +415,7 @@ func (x *GetEmailStatusRequest) String() string {
 func (*GetEmailStatusRequest) ProtoMessage() {}
 
 func (x *GetEmailStatusRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_email_v1_email_service_proto_msgTypes[3]
+	mi := &file_api_email_v1_email_service_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -262,7 +428,7 @@ func (x *GetEmailStatusRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetEmailStatusRequest.ProtoReflect.Descriptor instead.
 func (*GetEmailStatusRequest) Descriptor() ([]byte, []int) {
-	return file_api_email_v1_email_service_proto_rawDescGZIP(), []int{3}
+	return file_api_email_v1_email_service_proto_rawDescGZIP(), []int{4}
 }
 
 func (x *GetEmailStatusRequest) GetId() string {
@@ -283,7 +449,7 @@ type GetEmailStatusResponse struct {
 
 func (x *GetEmailStatusResponse) Reset() {
 	*x = GetEmailStatusResponse{}
-	mi := &file_api_email_v1_email_service_proto_msgTypes[4]
+	mi := &file_api_email_v1_email_service_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -295,7 +461,7 @@ func (x *GetEmailStatusResponse) String() string {
 func (*GetEmailStatusResponse) ProtoMessage() {}
 
 func (x *GetEmailStatusResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_api_email_v1_email_service_proto_msgTypes[4]
+	mi := &file_api_email_v1_email_service_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -308,7 +474,7 @@ func (x *GetEmailStatusResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetEmailStatusResponse.ProtoReflect.Descriptor instead.
 func (*GetEmailStatusResponse) Descriptor() ([]byte, []int) {
-	return file_api_email_v1_email_service_proto_rawDescGZIP(), []int{4}
+	return file_api_email_v1_email_service_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *GetEmailStatusResponse) GetId() string {
@@ -333,16 +499,32 @@ func (x *GetEmailStatusResponse) GetSentAt() string {
 }
 
 type ListEmailsRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	PageSize      int32                  `protobuf:"varint,1,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
-	PageToken     string                 `protobuf:"bytes,2,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	PageSize  int32                  `protobuf:"varint,1,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	PageToken string                 `protobuf:"bytes,2,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	// One of "pending", "sent" or "failed"
+	Status string `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"`
+	// Recipient address, matched case-insensitively
+	To string `protobuf:"bytes,4,opt,name=to,proto3" json:"to,omitempty"`
+	// Case-insensitive substring of the subject
+	SubjectContains string `protobuf:"bytes,5,opt,name=subject_contains,json=subjectContains,proto3" json:"subject_contains,omitempty"`
+	// RFC 3339 bounds on created_at; after is inclusive, before is exclusive
+	CreatedAfter  string `protobuf:"bytes,6,opt,name=created_after,json=createdAfter,proto3" json:"created_after,omitempty"`
+	CreatedBefore string `protobuf:"bytes,7,opt,name=created_before,json=createdBefore,proto3" json:"created_before,omitempty"`
+	// RFC 3339 bounds on sent_at; emails not yet sent never match
+	SentAfter  string `protobuf:"bytes,8,opt,name=sent_after,json=sentAfter,proto3" json:"sent_after,omitempty"`
+	SentBefore string `protobuf:"bytes,9,opt,name=sent_before,json=sentBefore,proto3" json:"sent_before,omitempty"`
+	Tenant     string `protobuf:"bytes,10,opt,name=tenant,proto3" json:"tenant,omitempty"`
+	// Only emails carrying this tag
+	Tag           string    `protobuf:"bytes,11,opt,name=tag,proto3" json:"tag,omitempty"`
+	Order         SortOrder `protobuf:"varint,12,opt,name=order,proto3,enum=email.v1.SortOrder" json:"order,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *ListEmailsRequest) Reset() {
 	*x = ListEmailsRequest{}
-	mi := &file_api_email_v1_email_service_proto_msgTypes[5]
+	mi := &file_api_email_v1_email_service_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -354,7 +536,7 @@ func (x *ListEmailsRequest) String() string {
 func (*ListEmailsRequest) ProtoMessage() {}
 
 func (x *ListEmailsRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_email_v1_email_service_proto_msgTypes[5]
+	mi := &file_api_email_v1_email_service_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -367,7 +549,7 @@ func (x *ListEmailsRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ListEmailsRequest.ProtoReflect.Descriptor instead.
 func (*ListEmailsRequest) Descriptor() ([]byte, []int) {
-	return file_api_email_v1_email_service_proto_rawDescGZIP(), []int{5}
+	return file_api_email_v1_email_service_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *ListEmailsRequest) GetPageSize() int32 {
@@ -384,17 +566,89 @@ func (x *ListEmailsRequest) GetPageToken() string {
 	return ""
 }
 
+func (x *ListEmailsRequest) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *ListEmailsRequest) GetTo() string {
+	if x != nil {
+		return x.To
+	}
+	return ""
+}
+
+func (x *ListEmailsRequest) GetSubjectContains() string {
+	if x != nil {
+		return x.SubjectContains
+	}
+	return ""
+}
+
+func (x *ListEmailsRequest) GetCreatedAfter() string {
+	if x != nil {
+		return x.CreatedAfter
+	}
+	return ""
+}
+
+func (x *ListEmailsRequest) GetCreatedBefore() string {
+	if x != nil {
+		return x.CreatedBefore
+	}
+	return ""
+}
+
+func (x *ListEmailsRequest) GetSentAfter() string {
+	if x != nil {
+		return x.SentAfter
+	}
+	return ""
+}
+
+func (x *ListEmailsRequest) GetSentBefore() string {
+	if x != nil {
+		return x.SentBefore
+	}
+	return ""
+}
+
+func (x *ListEmailsRequest) GetTenant() string {
+	if x != nil {
+		return x.Tenant
+	}
+	return ""
+}
+
+func (x *ListEmailsRequest) GetTag() string {
+	if x != nil {
+		return x.Tag
+	}
+	return ""
+}
+
+func (x *ListEmailsRequest) GetOrder() SortOrder {
+	if x != nil {
+		return x.Order
+	}
+	return SortOrder_SORT_ORDER_UNSPECIFIED
+}
+
 type ListEmailsResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Emails        []*Email               `protobuf:"bytes,1,rep,name=emails,proto3" json:"emails,omitempty"`
 	NextPageToken string                 `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
+	// Number of emails matching the filters across all pages
+	TotalCount    int32 `protobuf:"varint,3,opt,name=total_count,json=totalCount,proto3" json:"total_count,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *ListEmailsResponse) Reset() {
 	*x = ListEmailsResponse{}
-	mi := &file_api_email_v1_email_service_proto_msgTypes[6]
+	mi := &file_api_email_v1_email_service_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -406,7 +660,7 @@ func (x *ListEmailsResponse) String() string {
 func (*ListEmailsResponse) ProtoMessage() {}
 
 func (x *ListEmailsResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_api_email_v1_email_service_proto_msgTypes[6]
+	mi := &file_api_email_v1_email_service_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -419,7 +673,7 @@ func (x *ListEmailsResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ListEmailsResponse.ProtoReflect.Descriptor instead.
 func (*ListEmailsResponse) Descriptor() ([]byte, []int) {
-	return file_api_email_v1_email_service_proto_rawDescGZIP(), []int{6}
+	return file_api_email_v1_email_service_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *ListEmailsResponse) GetEmails() []*Email {
@@ -436,11 +690,192 @@ func (x *ListEmailsResponse) GetNextPageToken() string {
 	return ""
 }
 
+func (x *ListEmailsResponse) GetTotalCount() int32 {
+	if x != nil {
+		return x.TotalCount
+	}
+	return 0
+}
+
+type EmailEvent struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Id      string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	EmailId string                 `protobuf:"bytes,2,opt,name=email_id,json=emailId,proto3" json:"email_id,omitempty"`
+	// Either "open" or "click"
+	Type string `protobuf:"bytes,3,opt,name=type,proto3" json:"type,omitempty"`
+	// Link target for click events
+	Url           string `protobuf:"bytes,4,opt,name=url,proto3" json:"url,omitempty"`
+	UserAgent     string `protobuf:"bytes,5,opt,name=user_agent,json=userAgent,proto3" json:"user_agent,omitempty"`
+	OccurredAt    string `protobuf:"bytes,6,opt,name=occurred_at,json=occurredAt,proto3" json:"occurred_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EmailEvent) Reset() {
+	*x = EmailEvent{}
+	mi := &file_api_email_v1_email_service_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EmailEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EmailEvent) ProtoMessage() {}
+
+func (x *EmailEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_api_email_v1_email_service_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EmailEvent.ProtoReflect.Descriptor instead.
+func (*EmailEvent) Descriptor() ([]byte, []int) {
+	return file_api_email_v1_email_service_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *EmailEvent) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *EmailEvent) GetEmailId() string {
+	if x != nil {
+		return x.EmailId
+	}
+	return ""
+}
+
+func (x *EmailEvent) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *EmailEvent) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *EmailEvent) GetUserAgent() string {
+	if x != nil {
+		return x.UserAgent
+	}
+	return ""
+}
+
+func (x *EmailEvent) GetOccurredAt() string {
+	if x != nil {
+		return x.OccurredAt
+	}
+	return ""
+}
+
+type GetEmailEventsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetEmailEventsRequest) Reset() {
+	*x = GetEmailEventsRequest{}
+	mi := &file_api_email_v1_email_service_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetEmailEventsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetEmailEventsRequest) ProtoMessage() {}
+
+func (x *GetEmailEventsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_email_v1_email_service_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetEmailEventsRequest.ProtoReflect.Descriptor instead.
+func (*GetEmailEventsRequest) Descriptor() ([]byte, []int) {
+	return file_api_email_v1_email_service_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *GetEmailEventsRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type GetEmailEventsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Events        []*EmailEvent          `protobuf:"bytes,1,rep,name=events,proto3" json:"events,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetEmailEventsResponse) Reset() {
+	*x = GetEmailEventsResponse{}
+	mi := &file_api_email_v1_email_service_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetEmailEventsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetEmailEventsResponse) ProtoMessage() {}
+
+func (x *GetEmailEventsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_email_v1_email_service_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetEmailEventsResponse.ProtoReflect.Descriptor instead.
+func (*GetEmailEventsResponse) Descriptor() ([]byte, []int) {
+	return file_api_email_v1_email_service_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *GetEmailEventsResponse) GetEvents() []*EmailEvent {
+	if x != nil {
+		return x.Events
+	}
+	return nil
+}
+
 var File_api_email_v1_email_service_proto protoreflect.FileDescriptor
 
 const file_api_email_v1_email_service_proto_rawDesc = "" +
 	"\n" +
-	" api/email/v1/email_service.proto\x12\bemail.v1\x1a\x1cgoogle/api/annotations.proto\x1a\x1fgoogle/api/field_behavior.proto\x1a.protoc-gen-openapiv2/options/annotations.proto\"\xb4\x01\n" +
+	" api/email/v1/email_service.proto\x12\bemail.v1\x1a\x1cgoogle/api/annotations.proto\x1a\x1fgoogle/api/field_behavior.proto\x1a.protoc-gen-openapiv2/options/annotations.proto\"\xe0\x01\n" +
 	"\x05Email\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12\x13\n" +
 	"\x02to\x18\x02 \x01(\tB\x03\xe0A\x02R\x02to\x12\x1d\n" +
@@ -449,11 +884,21 @@ const file_api_email_v1_email_service_proto_rawDesc = "" +
 	"\x06status\x18\x05 \x01(\tR\x06status\x12\x1d\n" +
 	"\n" +
 	"created_at\x18\x06 \x01(\tR\tcreatedAt\x12\x17\n" +
-	"\asent_at\x18\a \x01(\tR\x06sentAt\"_\n" +
+	"\asent_at\x18\a \x01(\tR\x06sentAt\x12\x16\n" +
+	"\x06tenant\x18\b \x01(\tR\x06tenant\x12\x12\n" +
+	"\x04tags\x18\t \x03(\tR\x04tags\"\x87\x02\n" +
 	"\x10SendEmailRequest\x12\x13\n" +
 	"\x02to\x18\x01 \x01(\tB\x03\xe0A\x02R\x02to\x12\x1d\n" +
 	"\asubject\x18\x02 \x01(\tB\x03\xe0A\x02R\asubject\x12\x17\n" +
-	"\x04body\x18\x03 \x01(\tB\x03\xe0A\x02R\x04body\";\n" +
+	"\x04body\x18\x03 \x01(\tB\x03\xe0A\x02R\x04body\x125\n" +
+	"\btracking\x18\x04 \x01(\v2\x19.email.v1.TrackingOptionsR\btracking\x12\x1a\n" +
+	"\bcategory\x18\x05 \x01(\tR\bcategory\x12'\n" +
+	"\x0funsubscribe_url\x18\x06 \x01(\tR\x0eunsubscribeUrl\x12\x16\n" +
+	"\x06tenant\x18\a \x01(\tR\x06tenant\x12\x12\n" +
+	"\x04tags\x18\b \x03(\tR\x04tags\"?\n" +
+	"\x0fTrackingOptions\x12\x14\n" +
+	"\x05opens\x18\x01 \x01(\bR\x05opens\x12\x16\n" +
+	"\x06clicks\x18\x02 \x01(\bR\x06clicks\";\n" +
 	"\x11SendEmailResponse\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12\x16\n" +
 	"\x06status\x18\x02 \x01(\tR\x06status\",\n" +
@@ -462,19 +907,53 @@ const file_api_email_v1_email_service_proto_rawDesc = "" +
 	"\x16GetEmailStatusResponse\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12\x16\n" +
 	"\x06status\x18\x02 \x01(\tR\x06status\x12\x17\n" +
-	"\asent_at\x18\x03 \x01(\tR\x06sentAt\"O\n" +
+	"\asent_at\x18\x03 \x01(\tR\x06sentAt\"\x83\x03\n" +
 	"\x11ListEmailsRequest\x12\x1b\n" +
 	"\tpage_size\x18\x01 \x01(\x05R\bpageSize\x12\x1d\n" +
 	"\n" +
-	"page_token\x18\x02 \x01(\tR\tpageToken\"e\n" +
+	"page_token\x18\x02 \x01(\tR\tpageToken\x12\x16\n" +
+	"\x06status\x18\x03 \x01(\tR\x06status\x12\x0e\n" +
+	"\x02to\x18\x04 \x01(\tR\x02to\x12)\n" +
+	"\x10subject_contains\x18\x05 \x01(\tR\x0fsubjectContains\x12#\n" +
+	"\rcreated_after\x18\x06 \x01(\tR\fcreatedAfter\x12%\n" +
+	"\x0ecreated_before\x18\a \x01(\tR\rcreatedBefore\x12\x1d\n" +
+	"\n" +
+	"sent_after\x18\b \x01(\tR\tsentAfter\x12\x1f\n" +
+	"\vsent_before\x18\t \x01(\tR\n" +
+	"sentBefore\x12\x16\n" +
+	"\x06tenant\x18\n" +
+	" \x01(\tR\x06tenant\x12\x10\n" +
+	"\x03tag\x18\v \x01(\tR\x03tag\x12)\n" +
+	"\x05order\x18\f \x01(\x0e2\x13.email.v1.SortOrderR\x05order\"\x86\x01\n" +
 	"\x12ListEmailsResponse\x12'\n" +
 	"\x06emails\x18\x01 \x03(\v2\x0f.email.v1.EmailR\x06emails\x12&\n" +
-	"\x0fnext_page_token\x18\x02 \x01(\tR\rnextPageToken2\xcb\x02\n" +
+	"\x0fnext_page_token\x18\x02 \x01(\tR\rnextPageToken\x12\x1f\n" +
+	"\vtotal_count\x18\x03 \x01(\x05R\n" +
+	"totalCount\"\x9d\x01\n" +
+	"\n" +
+	"EmailEvent\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x19\n" +
+	"\bemail_id\x18\x02 \x01(\tR\aemailId\x12\x12\n" +
+	"\x04type\x18\x03 \x01(\tR\x04type\x12\x10\n" +
+	"\x03url\x18\x04 \x01(\tR\x03url\x12\x1d\n" +
+	"\n" +
+	"user_agent\x18\x05 \x01(\tR\tuserAgent\x12\x1f\n" +
+	"\voccurred_at\x18\x06 \x01(\tR\n" +
+	"occurredAt\",\n" +
+	"\x15GetEmailEventsRequest\x12\x13\n" +
+	"\x02id\x18\x01 \x01(\tB\x03\xe0A\x02R\x02id\"F\n" +
+	"\x16GetEmailEventsResponse\x12,\n" +
+	"\x06events\x18\x01 \x03(\v2\x14.email.v1.EmailEventR\x06events*`\n" +
+	"\tSortOrder\x12\x1a\n" +
+	"\x16SORT_ORDER_UNSPECIFIED\x10\x00\x12\x1a\n" +
+	"\x16SORT_ORDER_CREATED_ASC\x10\x01\x12\x1b\n" +
+	"\x17SORT_ORDER_CREATED_DESC\x10\x022\xc3\x03\n" +
 	"\fEmailService\x12c\n" +
 	"\tSendEmail\x12\x1a.email.v1.SendEmailRequest\x1a\x1b.email.v1.SendEmailResponse\"\x1d\x82\xd3\xe4\x93\x02\x17:\x01*\"\x12/api/v1/email/send\x12v\n" +
 	"\x0eGetEmailStatus\x12\x1f.email.v1.GetEmailStatusRequest\x1a .email.v1.GetEmailStatusResponse\"!\x82\xd3\xe4\x93\x02\x1b\x12\x19/api/v1/email/{id}/status\x12^\n" +
 	"\n" +
-	"ListEmails\x12\x1b.email.v1.ListEmailsRequest\x1a\x1c.email.v1.ListEmailsResponse\"\x15\x82\xd3\xe4\x93\x02\x0f\x12\r/api/v1/emailBEZCgithub.com/popeskul/mailflow/email-service/pkg/api/email/v1;emailv1b\x06proto3"
+	"ListEmails\x12\x1b.email.v1.ListEmailsRequest\x1a\x1c.email.v1.ListEmailsResponse\"\x15\x82\xd3\xe4\x93\x02\x0f\x12\r/api/v1/email\x12v\n" +
+	"\x0eGetEmailEvents\x12\x1f.email.v1.GetEmailEventsRequest\x1a .email.v1.GetEmailEventsResponse\"!\x82\xd3\xe4\x93\x02\x1b\x12\x19/api/v1/email/{id}/eventsBEZCgithub.com/popeskul/mailflow/email-service/pkg/api/email/v1;emailv1b\x06proto3"
 
 var (
 	file_api_email_v1_email_service_proto_rawDescOnce sync.Once
@@ -488,29 +967,40 @@ func file_api_email_v1_email_service_proto_rawDescGZIP() []byte {
 	return file_api_email_v1_email_service_proto_rawDescData
 }
 
-var file_api_email_v1_email_service_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_api_email_v1_email_service_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_api_email_v1_email_service_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
 var file_api_email_v1_email_service_proto_goTypes = []any{
-	(*Email)(nil),                  // 0: email.v1.Email
-	(*SendEmailRequest)(nil),       // 1: email.v1.SendEmailRequest
-	(*SendEmailResponse)(nil),      // 2: email.v1.SendEmailResponse
-	(*GetEmailStatusRequest)(nil),  // 3: email.v1.GetEmailStatusRequest
-	(*GetEmailStatusResponse)(nil), // 4: email.v1.GetEmailStatusResponse
-	(*ListEmailsRequest)(nil),      // 5: email.v1.ListEmailsRequest
-	(*ListEmailsResponse)(nil),     // 6: email.v1.ListEmailsResponse
+	(SortOrder)(0),                 // 0: email.v1.SortOrder
+	(*Email)(nil),                  // 1: email.v1.Email
+	(*SendEmailRequest)(nil),       // 2: email.v1.SendEmailRequest
+	(*TrackingOptions)(nil),        // 3: email.v1.TrackingOptions
+	(*SendEmailResponse)(nil),      // 4: email.v1.SendEmailResponse
+	(*GetEmailStatusRequest)(nil),  // 5: email.v1.GetEmailStatusRequest
+	(*GetEmailStatusResponse)(nil), // 6: email.v1.GetEmailStatusResponse
+	(*ListEmailsRequest)(nil),      // 7: email.v1.ListEmailsRequest
+	(*ListEmailsResponse)(nil),     // 8: email.v1.ListEmailsResponse
+	(*EmailEvent)(nil),             // 9: email.v1.EmailEvent
+	(*GetEmailEventsRequest)(nil),  // 10: email.v1.GetEmailEventsRequest
+	(*GetEmailEventsResponse)(nil), // 11: email.v1.GetEmailEventsResponse
 }
 var file_api_email_v1_email_service_proto_depIdxs = []int32{
-	0, // 0: email.v1.ListEmailsResponse.emails:type_name -> email.v1.Email
-	1, // 1: email.v1.EmailService.SendEmail:input_type -> email.v1.SendEmailRequest
-	3, // 2: email.v1.EmailService.GetEmailStatus:input_type -> email.v1.GetEmailStatusRequest
-	5, // 3: email.v1.EmailService.ListEmails:input_type -> email.v1.ListEmailsRequest
-	2, // 4: email.v1.EmailService.SendEmail:output_type -> email.v1.SendEmailResponse
-	4, // 5: email.v1.EmailService.GetEmailStatus:output_type -> email.v1.GetEmailStatusResponse
-	6, // 6: email.v1.EmailService.ListEmails:output_type -> email.v1.ListEmailsResponse
-	4, // [4:7] is the sub-list for method output_type
-	1, // [1:4] is the sub-list for method input_type
-	1, // [1:1] is the sub-list for extension type_name
-	1, // [1:1] is the sub-list for extension extendee
-	0, // [0:1] is the sub-list for field type_name
+	3,  // 0: email.v1.SendEmailRequest.tracking:type_name -> email.v1.TrackingOptions
+	0,  // 1: email.v1.ListEmailsRequest.order:type_name -> email.v1.SortOrder
+	1,  // 2: email.v1.ListEmailsResponse.emails:type_name -> email.v1.Email
+	9,  // 3: email.v1.GetEmailEventsResponse.events:type_name -> email.v1.EmailEvent
+	2,  // 4: email.v1.EmailService.SendEmail:input_type -> email.v1.SendEmailRequest
+	5,  // 5: email.v1.EmailService.GetEmailStatus:input_type -> email.v1.GetEmailStatusRequest
+	7,  // 6: email.v1.EmailService.ListEmails:input_type -> email.v1.ListEmailsRequest
+	10, // 7: email.v1.EmailService.GetEmailEvents:input_type -> email.v1.GetEmailEventsRequest
+	4,  // 8: email.v1.EmailService.SendEmail:output_type -> email.v1.SendEmailResponse
+	6,  // 9: email.v1.EmailService.GetEmailStatus:output_type -> email.v1.GetEmailStatusResponse
+	8,  // 10: email.v1.EmailService.ListEmails:output_type -> email.v1.ListEmailsResponse
+	11, // 11: email.v1.EmailService.GetEmailEvents:output_type -> email.v1.GetEmailEventsResponse
+	8,  // [8:12] is the sub-list for method output_type
+	4,  // [4:8] is the sub-list for method input_type
+	4,  // [4:4] is the sub-list for extension type_name
+	4,  // [4:4] is the sub-list for extension extendee
+	0,  // [0:4] is the sub-list for field type_name
 }
 
 func init() { file_api_email_v1_email_service_proto_init() }
@@ -523,13 +1013,14 @@ func file_api_email_v1_email_service_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_api_email_v1_email_service_proto_rawDesc), len(file_api_email_v1_email_service_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   7,
+			NumEnums:      1,
+			NumMessages:   11,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_api_email_v1_email_service_proto_goTypes,
 		DependencyIndexes: file_api_email_v1_email_service_proto_depIdxs,
+		EnumInfos:         file_api_email_v1_email_service_proto_enumTypes,
 		MessageInfos:      file_api_email_v1_email_service_proto_msgTypes,
 	}.Build()
 	File_api_email_v1_email_service_proto = out.File
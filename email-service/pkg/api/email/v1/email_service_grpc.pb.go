@@ -23,6 +23,7 @@ const (
 	EmailService_SendEmail_FullMethodName      = "/email.v1.EmailService/SendEmail"
 	EmailService_GetEmailStatus_FullMethodName = "/email.v1.EmailService/GetEmailStatus"
 	EmailService_ListEmails_FullMethodName     = "/email.v1.EmailService/ListEmails"
+	EmailService_GetEmailEvents_FullMethodName = "/email.v1.EmailService/GetEmailEvents"
 )
 
 // EmailServiceClient is the client API for EmailService service.
@@ -32,6 +33,7 @@ type EmailServiceClient interface {
 	SendEmail(ctx context.Context, in *SendEmailRequest, opts ...grpc.CallOption) (*SendEmailResponse, error)
 	GetEmailStatus(ctx context.Context, in *GetEmailStatusRequest, opts ...grpc.CallOption) (*GetEmailStatusResponse, error)
 	ListEmails(ctx context.Context, in *ListEmailsRequest, opts ...grpc.CallOption) (*ListEmailsResponse, error)
+	GetEmailEvents(ctx context.Context, in *GetEmailEventsRequest, opts ...grpc.CallOption) (*GetEmailEventsResponse, error)
 }
 
 type emailServiceClient struct {
@@ -72,6 +74,16 @@ func (c *emailServiceClient) ListEmails(ctx context.Context, in *ListEmailsReque
 	return out, nil
 }
 
+func (c *emailServiceClient) GetEmailEvents(ctx context.Context, in *GetEmailEventsRequest, opts ...grpc.CallOption) (*GetEmailEventsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetEmailEventsResponse)
+	err := c.cc.Invoke(ctx, EmailService_GetEmailEvents_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // EmailServiceServer is the server API for EmailService service.
 // All implementations must embed UnimplementedEmailServiceServer
 // for forward compatibility.
@@ -79,6 +91,7 @@ type EmailServiceServer interface {
 	SendEmail(context.Context, *SendEmailRequest) (*SendEmailResponse, error)
 	GetEmailStatus(context.Context, *GetEmailStatusRequest) (*GetEmailStatusResponse, error)
 	ListEmails(context.Context, *ListEmailsRequest) (*ListEmailsResponse, error)
+	GetEmailEvents(context.Context, *GetEmailEventsRequest) (*GetEmailEventsResponse, error)
 	mustEmbedUnimplementedEmailServiceServer()
 }
 
@@ -98,6 +111,9 @@ func (UnimplementedEmailServiceServer) GetEmailStatus(context.Context, *GetEmail
 func (UnimplementedEmailServiceServer) ListEmails(context.Context, *ListEmailsRequest) (*ListEmailsResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ListEmails not implemented")
 }
+func (UnimplementedEmailServiceServer) GetEmailEvents(context.Context, *GetEmailEventsRequest) (*GetEmailEventsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetEmailEvents not implemented")
+}
 func (UnimplementedEmailServiceServer) mustEmbedUnimplementedEmailServiceServer() {}
 func (UnimplementedEmailServiceServer) testEmbeddedByValue()                      {}
 
@@ -173,6 +189,24 @@ func _EmailService_ListEmails_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _EmailService_GetEmailEvents_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetEmailEventsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EmailServiceServer).GetEmailEvents(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EmailService_GetEmailEvents_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EmailServiceServer).GetEmailEvents(ctx, req.(*GetEmailEventsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // EmailService_ServiceDesc is the grpc.ServiceDesc for EmailService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -192,6 +226,10 @@ var EmailService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ListEmails",
 			Handler:    _EmailService_ListEmails_Handler,
 		},
+		{
+			MethodName: "GetEmailEvents",
+			Handler:    _EmailService_GetEmailEvents_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "api/email/v1/email_service.proto",
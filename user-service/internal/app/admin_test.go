@@ -0,0 +1,181 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/popeskul/mailflow/user-service/internal/circuitbreaker"
+	pb "github.com/popeskul/mailflow/user-service/pkg/api/user/v1"
+)
+
+// login signs up a user with a verified address and returns their access
+// token
+func login(t *testing.T, a *App, email string) string {
+	t.Helper()
+
+	createUser(t, a, email)
+	verifyUser(t, a, email)
+	return accessToken(t, a, email)
+}
+
+// verifyUser marks a user's address verified as following the link would
+func verifyUser(t *testing.T, a *App, email string) {
+	t.Helper()
+
+	users := a.repos.User()
+	user, err := users.GetByEmail(context.Background(), email)
+	require.NoError(t, err)
+	user.Verified = true
+	require.NoError(t, users.Update(context.Background(), user))
+}
+
+// accessToken logs an existing user in and returns their access token
+func accessToken(t *testing.T, a *App, email string) string {
+	t.Helper()
+
+	conn, err := grpc.NewClient(a.GRPCAddr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	resp, err := pb.NewUserServiceClient(conn).Login(ctx, &pb.LoginRequest{
+		Email:    email,
+		Password: "correct horse battery staple",
+	})
+	require.NoError(t, err)
+
+	return resp.GetTokens().GetAccessToken()
+}
+
+// adminRequest calls the admin API through the HTTP gateway
+func adminRequest(t *testing.T, a *App, method, path, token string) (int, map[string]any) {
+	t.Helper()
+
+	req, err := http.NewRequest(method, "http://"+a.HTTPAddr().String()+path, nil)
+	require.NoError(t, err)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var body map[string]any
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+
+	return resp.StatusCode, body
+}
+
+func TestApp_Admin_Success(t *testing.T) {
+	tests := []struct {
+		name   string
+		action string
+		state  circuitbreaker.State
+		forced bool
+	}{
+		{name: "force open", action: "force-open", state: circuitbreaker.StateOpen, forced: true},
+		{name: "force close", action: "force-close", state: circuitbreaker.StateClosed, forced: true},
+		{name: "reset", action: "reset", state: circuitbreaker.StateClosed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, emailAddr := startEmailService(t)
+			cfg := testConfig(emailAddr)
+			cfg.Auth.Admins = []string{"admin@example.com"}
+			a, _ := startApp(t, cfg)
+			token := login(t, a, "admin@example.com")
+
+			code, body := adminRequest(t, a, http.MethodPost,
+				"/api/v1/admin/circuit-breakers/"+emailBreaker+":"+tt.action, token)
+
+			require.Equal(t, http.StatusOK, code, body)
+			breaker := body["circuitBreaker"].(map[string]any)
+			assert.Equal(t, emailBreaker, breaker["name"])
+			assert.Equal(t, tt.state.String(), breaker["state"])
+			assert.Equal(t, tt.state, a.breaker.GetState())
+			assert.Equal(t, tt.forced, a.breaker.GetMetrics().Forced)
+
+			code, body = adminRequest(t, a, http.MethodGet, "/api/v1/admin/circuit-breakers", token)
+
+			require.Equal(t, http.StatusOK, code, body)
+			breakers := body["circuitBreakers"].([]any)
+			require.Len(t, breakers, 1)
+			assert.Equal(t, tt.state.String(), breakers[0].(map[string]any)["state"])
+		})
+	}
+}
+
+func TestApp_Admin_Fail(t *testing.T) {
+	tests := []struct {
+		name  string
+		path  string
+		token func(t *testing.T, a *App) string
+		code  int
+	}{
+		{
+			name:  "missing token",
+			path:  "/api/v1/admin/circuit-breakers/" + emailBreaker + ":force-open",
+			token: func(*testing.T, *App) string { return "" },
+			code:  http.StatusUnauthorized,
+		},
+		{
+			name:  "not an admin",
+			path:  "/api/v1/admin/circuit-breakers/" + emailBreaker + ":force-open",
+			token: func(t *testing.T, a *App) string { return login(t, a, "alice@example.com") },
+			code:  http.StatusForbidden,
+		},
+		{
+			name: "unverified admin address",
+			path: "/api/v1/admin/circuit-breakers/" + emailBreaker + ":force-open",
+			token: func(t *testing.T, a *App) string {
+				createUser(t, a, "admin@example.com")
+				return accessToken(t, a, "admin@example.com")
+			},
+			code: http.StatusForbidden,
+		},
+		{
+			name:  "unknown circuit breaker",
+			path:  "/api/v1/admin/circuit-breakers/billing-service:force-open",
+			token: func(t *testing.T, a *App) string { return login(t, a, "admin@example.com") },
+			code:  http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, emailAddr := startEmailService(t)
+			cfg := testConfig(emailAddr)
+			cfg.Auth.Admins = []string{"admin@example.com"}
+			a, _ := startApp(t, cfg)
+
+			code, _ := adminRequest(t, a, http.MethodPost, tt.path, tt.token(t, a))
+
+			assert.Equal(t, tt.code, code)
+			assert.Equal(t, circuitbreaker.StateClosed, a.breaker.GetState())
+		})
+	}
+}
+
+func TestApp_Admin_AuthDisabled(t *testing.T) {
+	_, _, emailAddr := startEmailService(t)
+	cfg := testConfig(emailAddr)
+	cfg.Auth.Secret = ""
+	a, _ := startApp(t, cfg)
+
+	code, _ := adminRequest(t, a, http.MethodPost, "/api/v1/admin/circuit-breakers/"+emailBreaker+":force-open", "")
+
+	assert.Equal(t, http.StatusNotFound, code)
+	assert.Equal(t, circuitbreaker.StateClosed, a.breaker.GetState())
+}
@@ -0,0 +1,513 @@
+// Package app is the composition root of the user service: it builds every
+// component from the configuration and runs the servers.
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	grpchealth "google.golang.org/grpc/health"
+	healthgrpc "google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/popeskul/mailflow/common/auth"
+	"github.com/popeskul/mailflow/common/concurrency"
+	"github.com/popeskul/mailflow/common/health"
+	"github.com/popeskul/mailflow/common/logger"
+	"github.com/popeskul/mailflow/common/mtls"
+	"github.com/popeskul/mailflow/common/pagination"
+	emailv1 "github.com/popeskul/mailflow/email-service/pkg/api/email/v1"
+	"github.com/popeskul/mailflow/user-service/internal/circuitbreaker"
+	"github.com/popeskul/mailflow/user-service/internal/config"
+	"github.com/popeskul/mailflow/user-service/internal/domain"
+	grpcserver "github.com/popeskul/mailflow/user-service/internal/grpc"
+	"github.com/popeskul/mailflow/user-service/internal/metrics"
+	"github.com/popeskul/mailflow/user-service/internal/queue"
+	"github.com/popeskul/mailflow/user-service/internal/repositories/memory"
+	"github.com/popeskul/mailflow/user-service/internal/retry"
+	"github.com/popeskul/mailflow/user-service/internal/services"
+	"github.com/popeskul/mailflow/user-service/internal/unsubscribe"
+	"github.com/popeskul/mailflow/user-service/internal/verification"
+	adminv1 "github.com/popeskul/mailflow/user-service/pkg/api/admin/v1"
+	healthpb "github.com/popeskul/mailflow/user-service/pkg/api/health"
+	pb "github.com/popeskul/mailflow/user-service/pkg/api/user/v1"
+)
+
+const (
+	// serviceName identifies the service to the services it calls
+	serviceName = "user-service"
+	// metricsNamespace prefixes the service's own metrics
+	metricsNamespace = "user_service"
+	// emailBreaker names the circuit breaker guarding the email service
+	emailBreaker = "email-service"
+	// maxRetryDelay caps the backoff between email send attempts
+	maxRetryDelay = 30 * time.Second
+)
+
+// App is the assembled user service
+type App struct {
+	cfg      *config.Config
+	logger   logger.Logger
+	services *services.Services
+	certs    *mtls.Reloader
+	email    *services.EmailClientWrapper
+	spool    *queue.FileSpool
+	links    *unsubscribe.Links
+	repos    *memory.Repositories
+	breakers *circuitbreaker.Registry
+	breaker  *circuitbreaker.CircuitBreaker
+	queue    *queue.EmailQueue
+
+	checks *health.Registry
+	health *grpchealth.Server
+
+	emailConn   *grpc.ClientConn
+	gatewayConn *grpc.ClientConn
+
+	grpcServer    *grpc.Server
+	httpServer    *http.Server
+	metricsServer *http.Server
+
+	grpcLis    net.Listener
+	httpLis    net.Listener
+	metricsLis net.Listener
+}
+
+// New builds the service and opens its listeners. queueLogger is used by
+// the email retry queue, which logs with zap directly.
+func New(cfg *config.Config, l logger.Logger, queueLogger *zap.Logger) (_ *App, err error) {
+	a := &App{
+		cfg:    cfg,
+		logger: l.Named("app"),
+	}
+	defer func() {
+		if err != nil {
+			a.close()
+		}
+	}()
+
+	// gRPC traffic is only encrypted when a certificate is configured
+	if cfg.TLS.Enabled() {
+		if a.certs, err = mtls.NewReloader(cfg.TLS.Config, l); err != nil {
+			return nil, fmt.Errorf("failed to load TLS certificates: %w", err)
+		}
+	} else {
+		a.logger.Warn("tls is not configured, grpc traffic is not encrypted")
+	}
+
+	// Logins are only possible when a token signing secret is configured
+	var tokens *auth.Tokens
+	if cfg.Auth.Secret != "" {
+		tokens = auth.NewTokens(cfg.Auth.Secret, cfg.Auth.AccessTokenTTL)
+	} else {
+		a.logger.Warn("auth.secret is not set, logins are disabled and requests are not authenticated")
+	}
+
+	if a.repos, err = newRepositories(cfg, a.logger); err != nil {
+		return nil, err
+	}
+
+	if err := a.newEmailClient(cfg.Client.EmailService, tokens, queueLogger); err != nil {
+		return nil, err
+	}
+
+	a.links = a.unsubscribeLinks()
+	a.services = services.NewServicesWithWrapper(a.repos, a.email, a.links, a.verificationLinks(), services.AuthOptions{
+		Tokens:       tokens,
+		RefreshTTL:   cfg.Auth.RefreshTokenTTL,
+		Admins:       cfg.Auth.Admins,
+		ResetTTL:     cfg.Reset.TTL,
+		ResetLimit:   cfg.Reset.MaxRequests,
+		ResetWindow:  cfg.Reset.Window,
+		ResendLimit:  cfg.Verification.MaxResends,
+		ResendWindow: cfg.Verification.ResendWindow,
+	}, l)
+
+	a.checks = a.newChecks()
+
+	if err := a.newGRPCServer(tokens); err != nil {
+		return nil, err
+	}
+	if err := a.newHTTPServer(); err != nil {
+		return nil, err
+	}
+	if err := a.newMetricsServer(); err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
+
+// GRPCAddr returns the address the gRPC server listens on
+func (a *App) GRPCAddr() net.Addr {
+	return a.grpcLis.Addr()
+}
+
+// HTTPAddr returns the address the HTTP gateway listens on
+func (a *App) HTTPAddr() net.Addr {
+	return a.httpLis.Addr()
+}
+
+// Run serves until ctx is done or a server fails, then shuts down
+// gracefully within the configured shutdown timeout.
+func (a *App) Run(ctx context.Context) error {
+	// Background work outlives ctx so the queue can still be drained
+	workers, stopWorkers := context.WithCancel(context.WithoutCancel(ctx))
+	defer stopWorkers()
+
+	if a.certs != nil {
+		go a.certs.Run(workers)
+	}
+	if a.email != nil {
+		a.requeueSpooled()
+		a.email.ProcessQueue(workers)
+	}
+	go grpcserver.WatchHealth(workers, a.checks, a.health, a.cfg.Health.Interval)
+
+	errs := make(chan error, 3)
+	go func() {
+		a.logger.Info("starting grpc server", logger.Field{Key: "addr", Value: a.grpcLis.Addr().String()})
+		errs <- a.grpcServer.Serve(a.grpcLis)
+	}()
+	go func() {
+		a.logger.Info("starting http server", logger.Field{Key: "addr", Value: a.httpLis.Addr().String()})
+		errs <- serveHTTP(a.httpServer, a.httpLis)
+	}()
+	go func() {
+		a.logger.Info("starting metrics server", logger.Field{Key: "addr", Value: a.metricsLis.Addr().String()})
+		errs <- serveHTTP(a.metricsServer, a.metricsLis)
+	}()
+
+	var err error
+	select {
+	case <-ctx.Done():
+	case err = <-errs:
+		a.logger.Error("server failed, shutting down",
+			logger.Field{Key: "error", Value: err},
+		)
+	}
+
+	a.shutdown()
+
+	return err
+}
+
+func (a *App) shutdown() {
+	a.logger.Info("shutting down servers")
+
+	// Tell load balancers to stop sending traffic before the servers stop
+	a.health.Shutdown()
+
+	ctx, cancel := context.WithTimeout(context.Background(), a.cfg.Server.ShutdownTimeout)
+	defer cancel()
+
+	if err := a.httpServer.Shutdown(ctx); err != nil {
+		a.logger.Error("http server shutdown failed", logger.Field{Key: "error", Value: err})
+	}
+	if err := a.metricsServer.Shutdown(ctx); err != nil {
+		a.logger.Error("metrics server shutdown failed", logger.Field{Key: "error", Value: err})
+	}
+
+	// Calls still running at the deadline are cut off
+	stopped := make(chan struct{})
+	go func() {
+		a.grpcServer.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		a.logger.Warn("grpc server did not stop before deadline, closing open calls")
+		a.grpcServer.Stop()
+	}
+
+	// Requests are finished, so nothing new reaches the email queue now
+	report := a.services.Shutdown(ctx)
+	a.logger.Info("email queue drained",
+		logger.Field{Key: "drained", Value: report.Drained},
+		logger.Field{Key: "persisted", Value: report.Persisted},
+		logger.Field{Key: "dropped", Value: report.Dropped},
+	)
+
+	a.close()
+
+	a.logger.Info("all servers stopped")
+}
+
+// close releases connections and listeners that are still open
+func (a *App) close() {
+	for _, conn := range []*grpc.ClientConn{a.gatewayConn, a.emailConn} {
+		if conn == nil {
+			continue
+		}
+		if err := conn.Close(); err != nil {
+			a.logger.Warn("failed to close grpc connection", logger.Field{Key: "error", Value: err})
+		}
+	}
+	for _, lis := range []net.Listener{a.grpcLis, a.httpLis, a.metricsLis} {
+		if lis != nil {
+			_ = lis.Close() // Already closed by a server that served on it
+		}
+	}
+}
+
+func newRepositories(cfg *config.Config, l logger.Logger) (*memory.Repositories, error) {
+	cursors := pagination.NewCodec([]byte(cfg.Pagination.CursorSecret))
+	if cfg.Pagination.CursorSecret == "" {
+		l.Warn("pagination.cursor_secret is not set, page tokens will not survive a restart")
+		var err error
+		if cursors, err = pagination.NewEphemeralCodec(); err != nil {
+			return nil, fmt.Errorf("failed to create cursor codec: %w", err)
+		}
+	}
+
+	return memory.NewRepositories(cursors, l), nil
+}
+
+// unsubscribeLinks are only put into emails when a signing secret is configured
+func (a *App) unsubscribeLinks() *unsubscribe.Links {
+	if a.cfg.Unsubscribe.Secret == "" {
+		a.logger.Warn("unsubscribe.secret is not set, emails are sent without List-Unsubscribe headers")
+		return nil
+	}
+	return unsubscribe.NewLinks(a.cfg.Unsubscribe.BaseURL, a.cfg.Unsubscribe.Secret)
+}
+
+// verificationLinks are only required when a verification secret is configured
+func (a *App) verificationLinks() *verification.Links {
+	if a.cfg.Verification.Secret == "" {
+		a.logger.Warn("verification.secret is not set, email addresses are not verified")
+		return nil
+	}
+	return verification.NewLinks(a.cfg.Verification.BaseURL, a.cfg.Verification.Secret, a.cfg.Verification.TTL)
+}
+
+// newEmailClient dials the email service and wraps the client in the
+// circuit breaker, retries and the retry queue
+func (a *App) newEmailClient(cfg config.EmailServiceConfig, tokens *auth.Tokens, queueLogger *zap.Logger) error {
+	opts := []grpc.DialOption{
+		grpc.WithTransportCredentials(a.clientCredentials(cfg.SPIFFEID)),
+	}
+	// The email service only accepts mail from service principals
+	if tokens != nil {
+		opts = append(opts, grpc.WithPerRPCCredentials(auth.NewServiceCredentials(tokens, serviceName, a.certs != nil)))
+	}
+
+	conn, err := grpc.NewClient(cfg.Address, opts...)
+	if err != nil {
+		return fmt.Errorf("failed to create email service client: %w", err)
+	}
+	a.emailConn = conn
+
+	var queueOpts []queue.Option
+	if cfg.SpoolPath != "" {
+		a.spool = queue.NewFileSpool(cfg.SpoolPath)
+		queueOpts = append(queueOpts, queue.WithPersister(a.spool))
+	}
+
+	a.breakers = circuitbreaker.NewRegistry(&cfg.CircuitBreaker)
+	a.breaker = a.breakers.Get(emailBreaker)
+	a.queue = queue.NewEmailQueue(cfg.QueueSize, queueLogger, queueOpts...)
+
+	metrics.NewCircuitBreakerCollector(metricsNamespace, a.breaker)
+	metrics.NewQueueCollector(metricsNamespace, a.queue)
+	retries := metrics.NewRetryCollector(metricsNamespace)
+
+	strategy := retry.NewStrategy(cfg.RetryStrategy, cfg.RetryDelay, maxRetryDelay, cfg.RetryAttempts)
+	if cfg.RetryMaxElapsed > 0 {
+		strategy = &retry.Deadline{Strategy: strategy, MaxElapsed: cfg.RetryMaxElapsed}
+	}
+
+	wrapperOpts := []services.WrapperOption{
+		services.WithTimeout(cfg.Timeout),
+		services.WithRetryStrategy(strategy),
+		services.WithRetryOptions(retry.WithOnDone(retries.Observe)),
+		services.WithRetryBudget(retry.NewBudget(cfg.RetryBudget.MaxTokens, cfg.RetryBudget.TokenRatio)),
+	}
+	if cfg.Hedging.Enabled() {
+		wrapperOpts = append(wrapperOpts, services.WithHedging(
+			retry.WithHedgeDelay(cfg.Hedging.Delay),
+			retry.WithHedgePercentile(cfg.Hedging.Percentile),
+			retry.WithHedgeBudget(retry.NewBudget(cfg.Hedging.Budget.MaxTokens, cfg.Hedging.Budget.TokenRatio)),
+		))
+	}
+
+	a.email = services.NewEmailClientWrapper(emailv1.NewEmailServiceClient(conn), a.breaker, a.queue, a.logger, wrapperOpts...)
+
+	return nil
+}
+
+// requeueSpooled puts emails persisted at the last shutdown back in the
+// queue. Those that do not fit are spooled again for the next start.
+func (a *App) requeueSpooled() {
+	if a.spool == nil {
+		return
+	}
+
+	emails, err := a.spool.Load()
+	if err != nil {
+		a.logger.Error("failed to load spooled emails", logger.Field{Key: "error", Value: err})
+		return
+	}
+
+	var failed []*domain.Email
+	for _, email := range emails {
+		if err := a.email.Requeue(email); err != nil {
+			a.logger.Error("failed to requeue spooled email",
+				logger.Field{Key: "email_id", Value: email.ID},
+				logger.Field{Key: "error", Value: err},
+			)
+			failed = append(failed, email)
+		}
+	}
+	if len(failed) == 0 {
+		return
+	}
+
+	if err := a.spool.Persist(context.Background(), failed); err != nil {
+		a.logger.Error("failed to spool emails that could not be requeued",
+			logger.Field{Key: "count", Value: len(failed)},
+			logger.Field{Key: "error", Value: err},
+		)
+	}
+}
+
+func (a *App) newGRPCServer(tokens *auth.Tokens) error {
+	var opts []grpc.ServerOption
+	if a.certs != nil {
+		// The gateway calls the server with the service's own certificate
+		allowed := a.cfg.TLS.AllowedClients
+		if len(allowed) > 0 && a.certs.ID() != "" {
+			allowed = append(slices.Clone(allowed), a.certs.ID())
+		}
+		opts = append(opts, grpc.Creds(credentials.NewTLS(a.certs.ServerTLS(allowed...))))
+	}
+	// Recovery comes first so that a panic anywhere in the chain is an error
+	interceptors := []grpc.UnaryServerInterceptor{grpcserver.RecoveryInterceptor(a.logger)}
+	if limit := a.cfg.Server.ConcurrencyLimit; limit.Enabled() {
+		calls := concurrency.New(limit)
+		metrics.Registry.MustRegister(concurrency.NewCollector(metricsNamespace, calls))
+		// Health checks must answer however busy the server is
+		interceptors = append(interceptors, concurrency.UnaryServerInterceptor(calls,
+			healthpb.HealthService_Check_FullMethodName,
+			healthpb.HealthService_Liveness_FullMethodName,
+			healthpb.HealthService_Readiness_FullMethodName,
+			healthpb.HealthService_Healthz_FullMethodName,
+			healthgrpc.Health_Check_FullMethodName,
+		))
+	}
+	if tokens != nil {
+		interceptors = append(interceptors, grpcserver.AuthInterceptor(a.services.Auth(), grpcserver.Policy))
+	}
+	opts = append(opts, grpc.ChainUnaryInterceptor(interceptors...))
+
+	a.grpcServer = grpc.NewServer(opts...)
+	pb.RegisterUserServiceServer(a.grpcServer, grpcserver.NewUserServer(a.services, a.logger))
+	healthpb.RegisterHealthServiceServer(a.grpcServer, grpcserver.NewHealthServer(a.checks, a.logger))
+	// Without auth anyone could force the breakers, so there is no admin API
+	if tokens != nil {
+		adminv1.RegisterAdminServiceServer(a.grpcServer, grpcserver.NewAdminServer(a.breakers, a.logger))
+	}
+
+	// Not serving until the first readiness report
+	a.health = grpchealth.NewServer()
+	a.health.SetServingStatus("", healthgrpc.HealthCheckResponse_NOT_SERVING)
+	healthgrpc.RegisterHealthServer(a.grpcServer, a.health)
+
+	lis, err := net.Listen("tcp", a.cfg.Server.GRPCPort)
+	if err != nil {
+		return fmt.Errorf("failed to listen on grpc port: %w", err)
+	}
+	a.grpcLis = lis
+
+	return nil
+}
+
+func (a *App) newHTTPServer() error {
+	_, port, err := net.SplitHostPort(a.grpcLis.Addr().String())
+	if err != nil {
+		return fmt.Errorf("failed to parse grpc address: %w", err)
+	}
+
+	var selfID string
+	if a.certs != nil {
+		selfID = a.certs.ID()
+	}
+
+	conn, err := grpc.NewClient(net.JoinHostPort("localhost", port),
+		grpc.WithTransportCredentials(a.clientCredentials(selfID)),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to connect to grpc server: %w", err)
+	}
+	a.gatewayConn = conn
+
+	mux := runtime.NewServeMux()
+	if err := pb.RegisterUserServiceHandler(context.Background(), mux, conn); err != nil {
+		return fmt.Errorf("failed to register grpc-gateway handler: %w", err)
+	}
+	if err := healthpb.RegisterHealthServiceHandler(context.Background(), mux, conn); err != nil {
+		return fmt.Errorf("failed to register health grpc-gateway handler: %w", err)
+	}
+	if a.cfg.Auth.Secret != "" {
+		if err := adminv1.RegisterAdminServiceHandler(context.Background(), mux, conn); err != nil {
+			return fmt.Errorf("failed to register admin grpc-gateway handler: %w", err)
+		}
+	}
+
+	httpMux := http.NewServeMux()
+	httpMux.Handle("/", mux)
+	if a.links != nil {
+		httpMux.Handle(unsubscribe.Path, unsubscribe.NewHandler(a.links, a.services.Subscriptions(), a.logger))
+	}
+
+	lis, err := net.Listen("tcp", a.cfg.Server.HTTPPort)
+	if err != nil {
+		return fmt.Errorf("failed to listen on http port: %w", err)
+	}
+	a.httpLis = lis
+	a.httpServer = &http.Server{Handler: httpMux}
+
+	return nil
+}
+
+func (a *App) newMetricsServer() error {
+	metricsMux := http.NewServeMux()
+	metricsMux.Handle("/metrics", promhttp.HandlerFor(metrics.Registry, promhttp.HandlerOpts{}))
+
+	lis, err := net.Listen("tcp", a.cfg.Monitor.MetricsPort)
+	if err != nil {
+		return fmt.Errorf("failed to listen on metrics port: %w", err)
+	}
+	a.metricsLis = lis
+	a.metricsServer = &http.Server{Handler: metricsMux}
+
+	return nil
+}
+
+// clientCredentials secure a connection with the service's certificate and
+// expect the server to present serverID. Without certificates the
+// connection is plaintext.
+func (a *App) clientCredentials(serverID string) credentials.TransportCredentials {
+	if a.certs == nil {
+		return insecure.NewCredentials()
+	}
+	return credentials.NewTLS(a.certs.ClientTLS(serverID))
+}
+
+func serveHTTP(server *http.Server, lis net.Listener) error {
+	if err := server.Serve(lis); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,392 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	grpchealth "google.golang.org/grpc/health"
+	healthgrpc "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/popeskul/mailflow/common/auth"
+	"github.com/popeskul/mailflow/common/concurrency"
+	"github.com/popeskul/mailflow/common/fault"
+	"github.com/popeskul/mailflow/common/logger"
+	emailv1 "github.com/popeskul/mailflow/email-service/pkg/api/email/v1"
+	"github.com/popeskul/mailflow/user-service/internal/circuitbreaker"
+	"github.com/popeskul/mailflow/user-service/internal/config"
+	"github.com/popeskul/mailflow/user-service/internal/domain"
+	"github.com/popeskul/mailflow/user-service/internal/metrics"
+	"github.com/popeskul/mailflow/user-service/internal/queue"
+	pb "github.com/popeskul/mailflow/user-service/pkg/api/user/v1"
+)
+
+const testSecret = "test-secret"
+
+// outage takes the fake email service down
+var outage = fault.Fault{Name: "outage", Target: fault.Any, Code: codes.Unavailable, Message: "email service down"}
+
+// emailService stands in for the email service. It accepts mail from
+// service principals only and fails with the faults set in faults.
+type emailService struct {
+	emailv1.UnimplementedEmailServiceServer
+
+	tokens *auth.Tokens
+	faults *fault.Injector
+
+	mu       sync.Mutex
+	attempts int
+	sent     []*emailv1.SendEmailRequest
+}
+
+func (s *emailService) SendEmail(ctx context.Context, req *emailv1.SendEmailRequest) (*emailv1.SendEmailResponse, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	header := md.Get("authorization")
+	if len(header) != 1 {
+		return nil, status.Error(codes.Unauthenticated, "missing token")
+	}
+	principal, err := s.tokens.Parse(strings.TrimPrefix(header[0], "Bearer "))
+	if err != nil || principal.Role != auth.RoleService {
+		return nil, status.Error(codes.PermissionDenied, "not a service")
+	}
+
+	s.mu.Lock()
+	s.attempts++
+	s.mu.Unlock()
+
+	if err := s.faults.Inject(ctx, emailv1.EmailService_SendEmail_FullMethodName); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sent = append(s.sent, req)
+
+	return &emailv1.SendEmailResponse{Id: "email-1", Status: "sent"}, nil
+}
+
+func (s *emailService) stats() (int, []*emailv1.SendEmailRequest) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.attempts, append([]*emailv1.SendEmailRequest(nil), s.sent...)
+}
+
+// startEmailService serves a fake email service on a loopback port. Its
+// health server reports it serving.
+func startEmailService(t *testing.T) (*emailService, *grpchealth.Server, string) {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	fake := &emailService{
+		tokens: auth.NewTokens(testSecret, 0),
+		faults: fault.NewInjector(fault.WithSeed(1)),
+	}
+	server := grpc.NewServer()
+	emailv1.RegisterEmailServiceServer(server, fake)
+	healthServer := grpchealth.NewServer()
+	healthServer.SetServingStatus(emailv1.EmailService_ServiceDesc.ServiceName, healthgrpc.HealthCheckResponse_SERVING)
+	healthgrpc.RegisterHealthServer(server, healthServer)
+	go func() {
+		_ = server.Serve(lis)
+	}()
+	t.Cleanup(server.Stop)
+
+	return fake, healthServer, lis.Addr().String()
+}
+
+func testConfig(emailAddr string) *config.Config {
+	cfg := &config.Config{}
+	cfg.Server.GRPCPort = "127.0.0.1:0"
+	cfg.Server.HTTPPort = "127.0.0.1:0"
+	cfg.Server.ShutdownTimeout = 5 * time.Second
+	cfg.Server.ConcurrencyLimit = concurrency.Config{
+		Algorithm:    concurrency.KindGradient,
+		InitialLimit: 20,
+		MinLimit:     5,
+		MaxLimit:     200,
+	}
+	cfg.Monitor.MetricsPort = "127.0.0.1:0"
+	cfg.Client.EmailService = config.EmailServiceConfig{
+		Address:        emailAddr,
+		Timeout:        time.Second,
+		RetryAttempts:  2,
+		RetryDelay:     10 * time.Millisecond,
+		QueueSize:      10,
+		CircuitBreaker: *circuitbreaker.DefaultConfig(),
+		RetryBudget:    config.RetryBudgetConfig{MaxTokens: 10, TokenRatio: 0.1},
+	}
+	cfg.Auth = config.AuthConfig{
+		Secret:          testSecret,
+		AccessTokenTTL:  time.Minute,
+		RefreshTokenTTL: time.Hour,
+	}
+	cfg.Reset.TTL = time.Hour
+	cfg.Reset.MaxRequests = 3
+	cfg.Reset.Window = time.Hour
+	cfg.Health = config.HealthConfig{
+		CheckTimeout:    time.Second,
+		Interval:        10 * time.Millisecond,
+		QueueSaturation: 0.9,
+	}
+	return cfg
+}
+
+// startApp runs the user service until the returned function stops it
+func startApp(t *testing.T, cfg *config.Config) (*App, func() error) {
+	t.Helper()
+
+	// The collectors register themselves with the global registry
+	registry := metrics.Registry
+	metrics.Registry = prometheus.NewRegistry()
+	t.Cleanup(func() { metrics.Registry = registry })
+
+	a, err := New(cfg, logger.NewZapLogger(logger.WithOutputs(io.Discard)), zap.NewNop())
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- a.Run(ctx)
+	}()
+
+	var once sync.Once
+	var runErr error
+	stop := func() error {
+		once.Do(func() {
+			cancel()
+			runErr = <-done
+		})
+		return runErr
+	}
+	t.Cleanup(func() { _ = stop() })
+
+	return a, stop
+}
+
+func createUser(t *testing.T, a *App, email string) {
+	t.Helper()
+
+	conn, err := grpc.NewClient(a.GRPCAddr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err = pb.NewUserServiceClient(conn).CreateUser(ctx, &pb.CreateUserRequest{
+		Email:    email,
+		Username: "alice",
+		Password: "correct horse battery staple",
+	})
+	require.NoError(t, err)
+}
+
+func TestApp_Run_Success(t *testing.T) {
+	tests := []struct {
+		name string
+		// unavailable makes the email service fail until the app shuts down
+		unavailable bool
+	}{
+		{
+			name: "welcome email is sent on sign-up",
+		},
+		{
+			name:        "welcome email is queued while the email service is down and drained at shutdown",
+			unavailable: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake, _, emailAddr := startEmailService(t)
+			if tt.unavailable {
+				require.NoError(t, fake.faults.Set(outage))
+			}
+
+			a, stop := startApp(t, testConfig(emailAddr))
+
+			createUser(t, a, "alice@example.com")
+
+			if tt.unavailable {
+				attempts, sent := fake.stats()
+				assert.GreaterOrEqual(t, attempts, 1)
+				assert.Empty(t, sent)
+
+				require.NoError(t, fake.faults.Remove(outage.Name))
+			}
+
+			require.NoError(t, stop())
+
+			_, sent := fake.stats()
+			require.Len(t, sent, 1)
+			assert.Equal(t, "alice@example.com", sent[0].To)
+			assert.Equal(t, "Welcome to our service!", sent[0].Subject)
+		})
+	}
+}
+
+func TestApp_Run_FlakyEmailService(t *testing.T) {
+	fake, _, emailAddr := startEmailService(t)
+	// With this seed the first two calls fail and the rest succeed, so the
+	// sign-up's attempt and its retry fail and the queue delivers the email
+	require.NoError(t, fake.faults.Set(fault.Fault{
+		Name:        "flaky",
+		Target:      emailv1.EmailService_SendEmail_FullMethodName,
+		Code:        codes.Unavailable,
+		Probability: 0.5,
+		Seed:        12,
+	}))
+
+	a, stop := startApp(t, testConfig(emailAddr))
+
+	createUser(t, a, "alice@example.com")
+	require.NoError(t, stop())
+
+	attempts, sent := fake.stats()
+	assert.Equal(t, 3, attempts)
+	require.Len(t, sent, 1)
+	assert.Equal(t, "alice@example.com", sent[0].To)
+
+	flaky, err := fake.faults.Get("flaky")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), flaky.Injected)
+}
+
+func TestApp_Run_RequeueSpooled(t *testing.T) {
+	fake, _, emailAddr := startEmailService(t)
+	require.NoError(t, fake.faults.Set(outage))
+
+	// Two more emails than the queue holds were spooled at the last shutdown
+	cfg := testConfig(emailAddr)
+	cfg.Client.EmailService.SpoolPath = filepath.Join(t.TempDir(), "spool.jsonl")
+	spooled := make([]*domain.Email, cfg.Client.EmailService.QueueSize+2)
+	for i := range spooled {
+		spooled[i] = &domain.Email{ID: fmt.Sprintf("email-%d", i), To: "alice@example.com", Status: domain.EmailStatusPending}
+	}
+	require.NoError(t, queue.NewFileSpool(cfg.Client.EmailService.SpoolPath).Persist(context.Background(), spooled))
+
+	_, stop := startApp(t, cfg)
+	require.NoError(t, stop())
+
+	// Those that did not fit are spooled again, the rest at shutdown
+	emails, err := queue.NewFileSpool(cfg.Client.EmailService.SpoolPath).Load()
+	require.NoError(t, err)
+	var ids []string
+	for _, email := range emails {
+		ids = append(ids, email.ID)
+	}
+	for _, email := range spooled {
+		assert.Contains(t, ids, email.ID)
+	}
+}
+
+func TestApp_Run_ShutdownTimeout(t *testing.T) {
+	fake, _, emailAddr := startEmailService(t)
+	// Sign-ups hang on the welcome email well past the shutdown deadline
+	require.NoError(t, fake.faults.Set(fault.Fault{
+		Name:    "slow",
+		Target:  emailv1.EmailService_SendEmail_FullMethodName,
+		Latency: time.Minute,
+	}))
+
+	cfg := testConfig(emailAddr)
+	cfg.Server.ShutdownTimeout = 100 * time.Millisecond
+	cfg.Client.EmailService.Timeout = time.Minute
+
+	a, stop := startApp(t, cfg)
+
+	conn, err := grpc.NewClient(a.GRPCAddr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	go func() {
+		_, _ = pb.NewUserServiceClient(conn).CreateUser(context.Background(), &pb.CreateUserRequest{
+			Email:    "alice@example.com",
+			Username: "alice",
+			Password: "correct horse battery staple",
+		})
+	}()
+	require.Eventually(t, func() bool {
+		attempts, _ := fake.stats()
+		return attempts > 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	start := time.Now()
+	_ = stop()
+
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestApp_Metrics_Success(t *testing.T) {
+	_, _, emailAddr := startEmailService(t)
+	cfg := testConfig(emailAddr)
+
+	a, _ := startApp(t, cfg)
+
+	resp, err := http.Get("http://" + a.metricsLis.Addr().String() + "/metrics")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, string(body), "user_service_circuit_breaker_state")
+	assert.Contains(t, string(body), "user_service_queue_size")
+	assert.Contains(t, string(body), "user_service_concurrency_limiter_limit")
+}
+
+func TestNew_Fail(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(cfg *config.Config)
+	}{
+		{
+			name: "missing certificate files",
+			mutate: func(cfg *config.Config) {
+				cfg.TLS.CertFile = "missing.crt"
+				cfg.TLS.KeyFile = "missing.key"
+			},
+		},
+		{
+			name: "invalid grpc port",
+			mutate: func(cfg *config.Config) {
+				cfg.Server.GRPCPort = "invalid"
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := metrics.Registry
+			metrics.Registry = prometheus.NewRegistry()
+			defer func() { metrics.Registry = registry }()
+
+			cfg := testConfig("127.0.0.1:1")
+			tt.mutate(cfg)
+
+			a, err := New(cfg, logger.NewZapLogger(logger.WithOutputs(io.Discard)), zap.NewNop())
+
+			assert.Error(t, err)
+			assert.Nil(t, a)
+		})
+	}
+}
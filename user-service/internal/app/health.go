@@ -0,0 +1,64 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"google.golang.org/grpc/codes"
+	healthgrpc "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+
+	"github.com/popeskul/mailflow/common/health"
+	emailv1 "github.com/popeskul/mailflow/email-service/pkg/api/email/v1"
+	"github.com/popeskul/mailflow/user-service/internal/circuitbreaker"
+)
+
+// newChecks registers what the service needs to serve requests: its
+// repositories, and a working path to the email service
+func (a *App) newChecks() *health.Registry {
+	checks := health.NewRegistry(a.cfg.Health.CheckTimeout)
+
+	checks.AddReadiness("repository", a.repos.Ping)
+	checks.AddReadiness("email_circuit_breaker", a.checkCircuitBreaker)
+	checks.AddReadiness("email_queue", a.checkQueue)
+	checks.AddReadiness("email_service", a.checkEmailService)
+
+	return checks
+}
+
+// checkCircuitBreaker fails while calls to the email service are rejected
+func (a *App) checkCircuitBreaker(_ context.Context) error {
+	if a.breaker.GetState() == circuitbreaker.StateOpen {
+		return errors.New("circuit to the email service is open")
+	}
+	return nil
+}
+
+// checkQueue fails when the email retry queue is close to dropping emails
+func (a *App) checkQueue(_ context.Context) error {
+	size, capacity := a.queue.Size(), a.queue.Capacity()
+	if float64(size) >= a.cfg.Health.QueueSaturation*float64(capacity) {
+		return fmt.Errorf("email queue is saturated: %d of %d", size, capacity)
+	}
+	return nil
+}
+
+// checkEmailService asks the email service whether it is serving, which it
+// is not while in maintenance mode
+func (a *App) checkEmailService(ctx context.Context) error {
+	resp, err := healthgrpc.NewHealthClient(a.emailConn).Check(ctx, &healthgrpc.HealthCheckRequest{
+		Service: emailv1.EmailService_ServiceDesc.ServiceName,
+	})
+	if status.Code(err) == codes.Unimplemented {
+		// An email service without health checks can only be judged by its calls
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to check email service: %w", err)
+	}
+	if resp.GetStatus() != healthgrpc.HealthCheckResponse_SERVING {
+		return fmt.Errorf("email service is %s", resp.GetStatus())
+	}
+	return nil
+}
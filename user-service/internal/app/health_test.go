@@ -0,0 +1,144 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthgrpc "google.golang.org/grpc/health/grpc_health_v1"
+
+	emailv1 "github.com/popeskul/mailflow/email-service/pkg/api/email/v1"
+	"github.com/popeskul/mailflow/user-service/internal/circuitbreaker"
+	"github.com/popeskul/mailflow/user-service/internal/config"
+	"github.com/popeskul/mailflow/user-service/internal/domain"
+	"github.com/popeskul/mailflow/user-service/internal/queue"
+)
+
+// servingStatus polls the app's grpc.health.v1 server until it reports want
+func servingStatus(t *testing.T, a *App, want healthgrpc.HealthCheckResponse_ServingStatus) {
+	t.Helper()
+
+	conn, err := grpc.NewClient(a.GRPCAddr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	client := healthgrpc.NewHealthClient(conn)
+	assert.Eventually(t, func() bool {
+		resp, err := client.Check(context.Background(), &healthgrpc.HealthCheckRequest{})
+		return err == nil && resp.GetStatus() == want
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func getHealth(t *testing.T, a *App, path string) (int, string) {
+	t.Helper()
+
+	resp, err := http.Get("http://" + a.HTTPAddr().String() + path)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	return resp.StatusCode, string(body)
+}
+
+func TestApp_Health_Success(t *testing.T) {
+	tests := []struct {
+		path   string
+		status string
+	}{
+		{path: "/v1/health", status: "HEALTHY"},
+		{path: "/v1/healthz", status: "HEALTHY"},
+		{path: "/v1/liveness", status: "ALIVE"},
+		{path: "/v1/readiness", status: "READY"},
+	}
+
+	_, _, emailAddr := startEmailService(t)
+	a, _ := startApp(t, testConfig(emailAddr))
+
+	servingStatus(t, a, healthgrpc.HealthCheckResponse_SERVING)
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			code, body := getHealth(t, a, tt.path)
+
+			assert.Equal(t, http.StatusOK, code)
+			assert.Contains(t, body, tt.status)
+		})
+	}
+}
+
+func TestApp_Health_Fail(t *testing.T) {
+	_, emailHealth, emailAddr := startEmailService(t)
+	a, _ := startApp(t, testConfig(emailAddr))
+
+	servingStatus(t, a, healthgrpc.HealthCheckResponse_SERVING)
+
+	// The email service enters maintenance mode
+	emailHealth.SetServingStatus(emailv1.EmailService_ServiceDesc.ServiceName, healthgrpc.HealthCheckResponse_NOT_SERVING)
+
+	servingStatus(t, a, healthgrpc.HealthCheckResponse_NOT_SERVING)
+
+	code, body := getHealth(t, a, "/v1/readiness")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Contains(t, body, "email service is NOT_SERVING")
+
+	// Liveness does not depend on the email service
+	code, _ = getHealth(t, a, "/v1/liveness")
+	assert.Equal(t, http.StatusOK, code)
+}
+
+func TestApp_Checks_Fail(t *testing.T) {
+	tests := []struct {
+		name  string
+		check func(a *App) func(context.Context) error
+		setup func(t *testing.T, a *App)
+		err   string
+	}{
+		{
+			name:  "circuit breaker open",
+			check: func(a *App) func(context.Context) error { return a.checkCircuitBreaker },
+			setup: func(_ *testing.T, a *App) {
+				for range circuitbreaker.DefaultConfig().FailureThreshold {
+					_ = a.breaker.Execute(context.Background(), func(context.Context) error {
+						return errors.New("unavailable")
+					})
+				}
+			},
+			err: "circuit to the email service is open",
+		},
+		{
+			name:  "queue saturated",
+			check: func(a *App) func(context.Context) error { return a.checkQueue },
+			setup: func(t *testing.T, a *App) {
+				for range 9 {
+					require.NoError(t, a.queue.Enqueue(&domain.Email{To: "alice@example.com"}))
+				}
+			},
+			err: "email queue is saturated: 9 of 10",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &App{
+				cfg:     &config.Config{Health: config.HealthConfig{QueueSaturation: 0.9}},
+				breaker: circuitbreaker.New(circuitbreaker.DefaultConfig()),
+				queue:   queue.NewEmailQueue(10, zap.NewNop()),
+			}
+			require.NoError(t, tt.check(a)(context.Background()))
+
+			tt.setup(t, a)
+
+			assert.EqualError(t, tt.check(a)(context.Background()), tt.err)
+		})
+	}
+}
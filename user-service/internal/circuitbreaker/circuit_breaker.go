@@ -6,6 +6,11 @@ import (
 	"sync"
 	"sync/atomic"
 	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 // State represents the state of the circuit breaker
@@ -17,6 +22,48 @@ const (
 	StateHalfOpen
 )
 
+// String returns the state's name as used in logs and metrics
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// StateChangeFunc is called after the circuit moves from one state to another
+type StateChangeFunc func(from, to State)
+
+// transition is a state change waiting to be delivered to the listeners
+type transition struct {
+	from, to State
+}
+
+func (t transition) changed() bool {
+	return t.from != t.to
+}
+
+// Mode selects how a closed circuit decides to open
+type Mode string
+
+const (
+	// ModeConsecutive opens after FailureThreshold failures in a row
+	ModeConsecutive Mode = "consecutive"
+	// ModeWindow opens when the failure or slow call rate over the last
+	// WindowSize crosses its threshold
+	ModeWindow Mode = "window"
+)
+
+// Valid reports whether m is a known mode. The empty mode is ModeConsecutive.
+func (m Mode) Valid() bool {
+	return m == "" || m == ModeConsecutive || m == ModeWindow
+}
+
 var (
 	ErrCircuitOpen     = errors.New("circuit breaker is open")
 	ErrTooManyRequests = errors.New("too many requests in half-open state")
@@ -24,14 +71,42 @@ var (
 
 // Config holds the configuration for the circuit breaker
 type Config struct {
+	// Mode selects how the circuit opens; ModeConsecutive when empty
+	Mode Mode `mapstructure:"mode"`
 	// FailureThreshold is the number of failures before opening the circuit
-	FailureThreshold int
+	FailureThreshold int `mapstructure:"failure_threshold"`
 	// SuccessThreshold is the number of successes in half-open state before closing the circuit
-	SuccessThreshold int
+	SuccessThreshold int `mapstructure:"success_threshold"`
 	// Timeout is the duration the circuit stays open before switching to half-open
-	Timeout time.Duration
+	Timeout time.Duration `mapstructure:"timeout"`
 	// MaxRequests is the maximum number of requests allowed in half-open state
-	MaxRequests int
+	MaxRequests int `mapstructure:"max_requests"`
+	// IsFailure decides whether an error counts against the service; other
+	// errors count as successes. DefaultIsFailure is used when nil.
+	IsFailure func(error) bool `mapstructure:"-"`
+	// IgnoreErrors are neither failures nor successes, matched with
+	// errors.Is or, for context errors, by gRPC status code. When nil
+	// context.Canceled is ignored, as the caller gave up, not the service.
+	IgnoreErrors []error `mapstructure:"-"`
+
+	// The settings below are only used in ModeWindow
+
+	// WindowSize is how far back calls are counted
+	WindowSize time.Duration `mapstructure:"window_size"`
+	// WindowBuckets is how many slices the window is split into; calls
+	// expire a slice at a time
+	WindowBuckets int `mapstructure:"window_buckets"`
+	// MinRequests is how many calls the window must hold before the rates
+	// are considered, so a few early failures do not open the circuit
+	MinRequests int `mapstructure:"min_requests"`
+	// FailureRateThreshold is the share of failed calls, between 0 and 1,
+	// that opens the circuit. Zero disables it.
+	FailureRateThreshold float64 `mapstructure:"failure_rate_threshold"`
+	// SlowCallDuration is how long a call may take before it counts as slow
+	SlowCallDuration time.Duration `mapstructure:"slow_call_duration"`
+	// SlowCallRateThreshold is the share of slow calls, between 0 and 1,
+	// that opens the circuit. Zero disables it.
+	SlowCallRateThreshold float64 `mapstructure:"slow_call_rate_threshold"`
 }
 
 // DefaultConfig returns default circuit breaker configuration
@@ -41,6 +116,40 @@ func DefaultConfig() *Config {
 		SuccessThreshold: 2,
 		Timeout:          30 * time.Second,
 		MaxRequests:      3,
+
+		WindowSize:            time.Minute,
+		WindowBuckets:         10,
+		MinRequests:           20,
+		FailureRateThreshold:  0.5,
+		SlowCallDuration:      5 * time.Second,
+		SlowCallRateThreshold: 0,
+	}
+}
+
+// defaultIgnoreErrors are ignored when Config.IgnoreErrors is nil
+var defaultIgnoreErrors = []error{context.Canceled}
+
+// DefaultIsFailure counts gRPC errors caused by the request, such as
+// InvalidArgument or NotFound, as successes: the service answered. Every
+// other error is a failure.
+func DefaultIsFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	switch status.Code(err) {
+	case codes.OK,
+		codes.Canceled,
+		codes.InvalidArgument,
+		codes.NotFound,
+		codes.AlreadyExists,
+		codes.PermissionDenied,
+		codes.Unauthenticated,
+		codes.FailedPrecondition,
+		codes.OutOfRange:
+		return false
+	default:
+		return true
 	}
 }
 
@@ -49,11 +158,27 @@ type CircuitBreaker struct {
 	config *Config
 	state  atomic.Value // State
 	mu     sync.Mutex
+	now    func() time.Time
+
+	// window counts recent calls in ModeWindow, nil otherwise
+	window *window
 
 	failures        int
 	successes       int
 	lastFailureTime time.Time
 	halfOpenReqs    int
+	// forced holds the circuit in its state until Reset
+	forced bool
+
+	// listeners are keyed by subscription so they can be removed
+	listeners map[int]StateChangeFunc
+	nextID    int
+	// pending transitions are delivered in order by notify, outside mu so
+	// listeners may call back into the breaker
+	pending []transition
+	// notifying is set while a goroutine delivers pending, so changes made
+	// meanwhile, including by listeners, are queued for it to deliver
+	notifying bool
 }
 
 // New creates a new circuit breaker
@@ -63,7 +188,12 @@ func New(config *Config) *CircuitBreaker {
 	}
 
 	cb := &CircuitBreaker{
-		config: config,
+		config:    config,
+		now:       time.Now,
+		listeners: make(map[int]StateChangeFunc),
+	}
+	if config.Mode == ModeWindow {
+		cb.window = newWindow(config.WindowSize, config.WindowBuckets)
 	}
 	cb.state.Store(StateClosed)
 	return cb
@@ -71,15 +201,96 @@ func New(config *Config) *CircuitBreaker {
 
 // Execute runs the given function with circuit breaker protection
 func (cb *CircuitBreaker) Execute(ctx context.Context, fn func(context.Context) error) error {
-	if err := cb.canExecute(); err != nil {
+	t, err := cb.canExecute()
+	cb.changed(ctx, t)
+	if err != nil {
 		return err
 	}
 
-	err := fn(ctx)
-	cb.recordResult(err)
+	start := cb.now()
+	err = fn(ctx)
+	cb.changed(ctx, cb.recordResult(err, cb.now().Sub(start)))
 	return err
 }
 
+// OnStateChange registers fn to be called after every state change and
+// returns a function that removes it. Listeners run one at a time in the
+// order the changes happened, so they should return quickly. They run on
+// the goroutine that caused the change, or on the one already delivering
+// earlier changes, and may change the breaker's state themselves.
+func (cb *CircuitBreaker) OnStateChange(fn StateChangeFunc) (unsubscribe func()) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	id := cb.nextID
+	cb.nextID++
+	cb.listeners[id] = fn
+
+	return func() {
+		cb.mu.Lock()
+		defer cb.mu.Unlock()
+		delete(cb.listeners, id)
+	}
+}
+
+// changed records t on the span in ctx and notifies the listeners
+func (cb *CircuitBreaker) changed(ctx context.Context, t transition) {
+	if !t.changed() {
+		return
+	}
+
+	trace.SpanFromContext(ctx).AddEvent("circuit_breaker.state_change", trace.WithAttributes(
+		attribute.String("circuit_breaker.from", t.from.String()),
+		attribute.String("circuit_breaker.to", t.to.String()),
+	))
+	cb.notify()
+}
+
+// notify delivers the pending transitions to the listeners, unless another
+// goroutine is delivering them already, until none are left
+func (cb *CircuitBreaker) notify() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if cb.notifying {
+		return
+	}
+	cb.notifying = true
+	defer func() { cb.notifying = false }()
+
+	for len(cb.pending) > 0 {
+		t := cb.pending[0]
+		cb.pending = cb.pending[1:]
+		listeners := make([]StateChangeFunc, 0, len(cb.listeners))
+		for _, fn := range cb.listeners {
+			listeners = append(listeners, fn)
+		}
+		cb.deliver(listeners, t)
+	}
+}
+
+// deliver calls the listeners with t. It must be called with mu held,
+// which it releases meanwhile.
+func (cb *CircuitBreaker) deliver(listeners []StateChangeFunc, t transition) {
+	cb.mu.Unlock()
+	defer cb.mu.Lock()
+
+	for _, fn := range listeners {
+		fn(t.from, t.to)
+	}
+}
+
+// setState moves the circuit to state and queues the change for the
+// listeners. It must be called with mu held.
+func (cb *CircuitBreaker) setState(state State) transition {
+	t := transition{from: cb.state.Load().(State), to: state}
+	if t.changed() {
+		cb.state.Store(state)
+		cb.pending = append(cb.pending, t)
+	}
+	return t
+}
+
 // GetState returns the current state of the circuit breaker
 func (cb *CircuitBreaker) GetState() State {
 	return cb.state.Load().(State)
@@ -88,15 +299,47 @@ func (cb *CircuitBreaker) GetState() State {
 // Reset resets the circuit breaker to closed state
 func (cb *CircuitBreaker) Reset() {
 	cb.mu.Lock()
-	defer cb.mu.Unlock()
+	cb.forced = false
+	cb.closeLocked()
+	cb.mu.Unlock()
 
-	cb.state.Store(StateClosed)
+	cb.notify()
+}
+
+// ForceOpen opens the circuit and keeps it open, rejecting every call,
+// until ForceClose or Reset
+func (cb *CircuitBreaker) ForceOpen() {
+	cb.mu.Lock()
+	cb.forced = true
+	cb.open()
+	cb.mu.Unlock()
+
+	cb.notify()
+}
+
+// ForceClose closes the circuit and keeps it closed, whatever the calls
+// return, until ForceOpen or Reset
+func (cb *CircuitBreaker) ForceClose() {
+	cb.mu.Lock()
+	cb.forced = true
+	cb.closeLocked()
+	cb.mu.Unlock()
+
+	cb.notify()
+}
+
+// closeLocked closes the circuit and clears its counters
+func (cb *CircuitBreaker) closeLocked() {
+	cb.setState(StateClosed)
 	cb.failures = 0
 	cb.successes = 0
 	cb.halfOpenReqs = 0
+	if cb.window != nil {
+		cb.window.reset()
+	}
 }
 
-func (cb *CircuitBreaker) canExecute() error {
+func (cb *CircuitBreaker) canExecute() (transition, error) {
 	cb.mu.Lock()
 	defer cb.mu.Unlock()
 
@@ -104,69 +347,157 @@ func (cb *CircuitBreaker) canExecute() error {
 
 	switch state {
 	case StateClosed:
-		return nil
+		return transition{}, nil
 
 	case StateOpen:
-		if time.Since(cb.lastFailureTime) > cb.config.Timeout {
-			cb.state.Store(StateHalfOpen)
+		if !cb.forced && cb.now().Sub(cb.lastFailureTime) > cb.config.Timeout {
+			t := cb.setState(StateHalfOpen)
 			cb.halfOpenReqs = 1 // Count this request
 			cb.successes = 0
-			return nil
+			return t, nil
 		}
-		return ErrCircuitOpen
+		return transition{}, ErrCircuitOpen
 
 	case StateHalfOpen:
 		if cb.halfOpenReqs >= cb.config.MaxRequests {
-			return ErrTooManyRequests
+			return transition{}, ErrTooManyRequests
 		}
 		cb.halfOpenReqs++
-		return nil
+		return transition{}, nil
 
 	default:
-		return nil
+		return transition{}, nil
 	}
 }
 
-func (cb *CircuitBreaker) recordResult(err error) {
+// recordResult counts the outcome of a call and returns the state change
+// it caused, if any
+func (cb *CircuitBreaker) recordResult(err error, took time.Duration) (t transition) {
 	cb.mu.Lock()
 	defer cb.mu.Unlock()
 
 	state := cb.state.Load().(State)
 
+	if cb.forced {
+		return t
+	}
+
+	if cb.ignored(err) {
+		// Free the probe slot so ignored calls cannot wedge the half-open state
+		if state == StateHalfOpen && cb.halfOpenReqs > 0 {
+			cb.halfOpenReqs--
+		}
+		return t
+	}
+
+	failed := cb.isFailure(err)
+	slow := cb.window != nil && cb.config.SlowCallDuration > 0 && took >= cb.config.SlowCallDuration
+
 	switch state {
 	case StateClosed:
-		if err != nil {
+		if cb.window != nil {
+			return cb.recordWindow(failed, slow)
+		}
+
+		if failed {
 			cb.failures++
 			if cb.failures >= cb.config.FailureThreshold {
-				cb.state.Store(StateOpen)
-				cb.lastFailureTime = time.Now()
+				t = cb.open()
 			}
 		} else {
 			cb.failures = 0
 		}
 
 	case StateHalfOpen:
-		if err != nil {
-			cb.state.Store(StateOpen)
-			cb.lastFailureTime = time.Now()
+		// A slow call is no better than a failed one when probing recovery
+		if failed || (slow && cb.config.SlowCallRateThreshold > 0) {
+			t = cb.open()
 			cb.failures = cb.config.FailureThreshold
 		} else {
 			cb.successes++
 			if cb.successes >= cb.config.SuccessThreshold {
-				cb.state.Store(StateClosed)
+				t = cb.setState(StateClosed)
 				cb.failures = 0
+				if cb.window != nil {
+					cb.window.reset()
+				}
 			}
 		}
 	}
+	return t
+}
+
+func (cb *CircuitBreaker) isFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+	if cb.config.IsFailure != nil {
+		return cb.config.IsFailure(err)
+	}
+	return DefaultIsFailure(err)
+}
+
+func (cb *CircuitBreaker) ignored(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	ignore := cb.config.IgnoreErrors
+	if ignore == nil {
+		ignore = defaultIgnoreErrors
+	}
+
+	for _, target := range ignore {
+		if errors.Is(err, target) {
+			return true
+		}
+		// A gRPC call reports its context's error as a status
+		if code := status.FromContextError(target).Code(); code != codes.Unknown && status.Code(err) == code {
+			return true
+		}
+	}
+	return false
+}
+
+// recordWindow counts a call in the window and opens the circuit once
+// enough calls were made and either rate crosses its threshold
+func (cb *CircuitBreaker) recordWindow(failed, slow bool) transition {
+	now := cb.now()
+	cb.window.record(now, failed, slow)
+
+	calls, failures, slowCalls := cb.window.totals(now)
+	cb.failures = failures
+	if calls == 0 || calls < cb.config.MinRequests {
+		return transition{}
+	}
+
+	failureRate := float64(failures) / float64(calls)
+	slowRate := float64(slowCalls) / float64(calls)
+	if (cb.config.FailureRateThreshold > 0 && failureRate >= cb.config.FailureRateThreshold) ||
+		(cb.config.SlowCallRateThreshold > 0 && slowRate >= cb.config.SlowCallRateThreshold) {
+		t := cb.open()
+		cb.window.reset()
+		return t
+	}
+	return transition{}
+}
+
+func (cb *CircuitBreaker) open() transition {
+	cb.lastFailureTime = cb.now()
+	return cb.setState(StateOpen)
 }
 
 // Metrics represents circuit breaker metrics
 type Metrics struct {
-	State           string
+	State string
+	// Failures are the consecutive failures, or in ModeWindow the failures
+	// within the window
 	Failures        int
 	Successes       int
 	LastFailureTime time.Time
 	HalfOpenReqs    int
+	// Forced is set while the state was forced by ForceOpen or ForceClose
+	Forced bool
 }
 
 // GetMetrics returns current circuit breaker metrics
@@ -174,22 +505,12 @@ func (cb *CircuitBreaker) GetMetrics() Metrics {
 	cb.mu.Lock()
 	defer cb.mu.Unlock()
 
-	state := cb.state.Load().(State)
-	var stateStr string
-	switch state {
-	case StateClosed:
-		stateStr = "closed"
-	case StateOpen:
-		stateStr = "open"
-	case StateHalfOpen:
-		stateStr = "half-open"
-	}
-
 	return Metrics{
-		State:           stateStr,
+		State:           cb.state.Load().(State).String(),
 		Failures:        cb.failures,
 		Successes:       cb.successes,
 		LastFailureTime: cb.lastFailureTime,
 		HalfOpenReqs:    cb.halfOpenReqs,
+		Forced:          cb.forced,
 	}
 }
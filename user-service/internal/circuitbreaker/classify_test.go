@@ -0,0 +1,153 @@
+package circuitbreaker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var errPermanent = errors.New("permanent")
+
+func TestDefaultIsFailure(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		failure bool
+	}{
+		{name: "nil", err: nil, failure: false},
+		{name: "invalid argument", err: status.Error(codes.InvalidArgument, "bad request"), failure: false},
+		{name: "not found", err: status.Error(codes.NotFound, "missing"), failure: false},
+		{name: "permission denied", err: status.Error(codes.PermissionDenied, "denied"), failure: false},
+		{name: "unavailable", err: status.Error(codes.Unavailable, "down"), failure: true},
+		{name: "deadline exceeded", err: status.Error(codes.DeadlineExceeded, "slow"), failure: true},
+		{name: "internal", err: status.Error(codes.Internal, "bug"), failure: true},
+		{name: "plain error", err: errors.New("connection reset"), failure: true},
+		{name: "wrapped status", err: fmt.Errorf("send: %w", status.Error(codes.InvalidArgument, "bad")), failure: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.failure, DefaultIsFailure(tt.err))
+		})
+	}
+}
+
+func TestCircuitBreaker_Classify_Success(t *testing.T) {
+	tests := []struct {
+		name   string
+		config func(c *Config)
+		err    error
+	}{
+		{
+			name: "client errors do not open the circuit",
+			err:  status.Error(codes.InvalidArgument, "recipient email is required"),
+		},
+		{
+			name: "caller cancellations are ignored",
+			err:  context.Canceled,
+		},
+		{
+			name: "cancellations reported by grpc are ignored",
+			err:  status.Error(codes.Canceled, "context canceled"),
+		},
+		{
+			name: "custom classifier",
+			config: func(c *Config) {
+				c.IsFailure = func(err error) bool { return !errors.Is(err, errPermanent) }
+			},
+			err: fmt.Errorf("send: %w", errPermanent),
+		},
+		{
+			name: "custom ignore list",
+			config: func(c *Config) {
+				c.IgnoreErrors = []error{errPermanent}
+			},
+			err: errPermanent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &Config{FailureThreshold: 2, SuccessThreshold: 1, Timeout: time.Minute, MaxRequests: 1}
+			if tt.config != nil {
+				tt.config(config)
+			}
+			cb := New(config)
+
+			for range 5 {
+				err := cb.Execute(context.Background(), func(context.Context) error { return tt.err })
+				assert.Equal(t, tt.err, err)
+			}
+
+			assert.Equal(t, StateClosed, cb.GetState())
+		})
+	}
+}
+
+func TestCircuitBreaker_Classify_Fail(t *testing.T) {
+	tests := []struct {
+		name   string
+		config func(c *Config)
+		err    error
+	}{
+		{
+			name: "unavailable opens the circuit",
+			err:  status.Error(codes.Unavailable, "down"),
+		},
+		{
+			name: "cancellations count when not ignored",
+			config: func(c *Config) {
+				c.IgnoreErrors = []error{}
+			},
+			err: context.Canceled,
+		},
+		{
+			name: "custom classifier",
+			config: func(c *Config) {
+				c.IsFailure = func(error) bool { return true }
+			},
+			err: status.Error(codes.InvalidArgument, "bad request"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &Config{FailureThreshold: 2, SuccessThreshold: 1, Timeout: time.Minute, MaxRequests: 1}
+			if tt.config != nil {
+				tt.config(config)
+			}
+			cb := New(config)
+
+			for range 2 {
+				_ = cb.Execute(context.Background(), func(context.Context) error { return tt.err })
+			}
+
+			assert.Equal(t, StateOpen, cb.GetState())
+		})
+	}
+}
+
+func TestCircuitBreaker_Classify_HalfOpen(t *testing.T) {
+	c := newTestClock()
+	cb := New(&Config{FailureThreshold: 1, SuccessThreshold: 1, Timeout: time.Minute, MaxRequests: 1})
+	cb.now = c.Now
+
+	_ = cb.Execute(context.Background(), func(context.Context) error { return errors.New("down") })
+	assert.Equal(t, StateOpen, cb.GetState())
+
+	// An ignored probe leaves the circuit half-open with its slot free
+	c.Advance(2 * time.Minute)
+	err := cb.Execute(context.Background(), func(context.Context) error { return context.Canceled })
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, StateHalfOpen, cb.GetState())
+
+	err = cb.Execute(context.Background(), func(context.Context) error { return nil })
+	assert.NoError(t, err)
+	assert.Equal(t, StateClosed, cb.GetState())
+}
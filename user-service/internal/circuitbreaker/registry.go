@@ -0,0 +1,130 @@
+package circuitbreaker
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+var (
+	ErrUnknownBreaker = errors.New("unknown circuit breaker")
+	ErrBreakerExists  = errors.New("circuit breaker already registered")
+)
+
+// Registry manages named circuit breakers, one per downstream service or
+// per RPC method, so one failing dependency does not trip calls to another
+type Registry struct {
+	defaults *Config
+
+	mu       sync.RWMutex
+	breakers map[string]*CircuitBreaker
+}
+
+// NewRegistry creates a registry whose breakers use defaults unless they
+// are registered with their own configuration
+func NewRegistry(defaults *Config) *Registry {
+	if defaults == nil {
+		defaults = DefaultConfig()
+	}
+
+	return &Registry{
+		defaults: defaults,
+		breakers: make(map[string]*CircuitBreaker),
+	}
+}
+
+// Register creates the breaker called name with config
+func (r *Registry) Register(name string, config *Config) (*CircuitBreaker, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.breakers[name]; ok {
+		return nil, fmt.Errorf("%w: %s", ErrBreakerExists, name)
+	}
+
+	cb := New(config)
+	r.breakers[name] = cb
+	return cb, nil
+}
+
+// Get returns the breaker called name, creating it with the default
+// configuration on first use
+func (r *Registry) Get(name string) *CircuitBreaker {
+	r.mu.RLock()
+	cb, ok := r.breakers[name]
+	r.mu.RUnlock()
+	if ok {
+		return cb
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if cb, ok := r.breakers[name]; ok {
+		return cb
+	}
+	cb = New(r.defaults)
+	r.breakers[name] = cb
+	return cb
+}
+
+// Lookup returns the breaker called name if it exists
+func (r *Registry) Lookup(name string) (*CircuitBreaker, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	cb, ok := r.breakers[name]
+	return cb, ok
+}
+
+// Snapshot is a named breaker's metrics at one point in time
+type Snapshot struct {
+	Name string
+	Metrics
+}
+
+// List returns the metrics of every breaker, sorted by name
+func (r *Registry) List() []Snapshot {
+	r.mu.RLock()
+	names := make([]string, 0, len(r.breakers))
+	for name := range r.breakers {
+		names = append(names, name)
+	}
+	r.mu.RUnlock()
+	slices.Sort(names)
+
+	snapshots := make([]Snapshot, 0, len(names))
+	for _, name := range names {
+		cb, _ := r.Lookup(name)
+		snapshots = append(snapshots, Snapshot{Name: name, Metrics: cb.GetMetrics()})
+	}
+	return snapshots
+}
+
+// ForceOpen holds the breaker called name open until it is reset
+func (r *Registry) ForceOpen(name string) (Snapshot, error) {
+	return r.apply(name, (*CircuitBreaker).ForceOpen)
+}
+
+// ForceClose holds the breaker called name closed until it is reset
+func (r *Registry) ForceClose(name string) (Snapshot, error) {
+	return r.apply(name, (*CircuitBreaker).ForceClose)
+}
+
+// Reset closes the breaker called name and lets it trip again
+func (r *Registry) Reset(name string) (Snapshot, error) {
+	return r.apply(name, (*CircuitBreaker).Reset)
+}
+
+// apply runs action on an existing breaker; unknown names are an error so
+// a typo does not create a breaker nothing uses
+func (r *Registry) apply(name string, action func(*CircuitBreaker)) (Snapshot, error) {
+	cb, ok := r.Lookup(name)
+	if !ok {
+		return Snapshot{}, fmt.Errorf("%w: %s", ErrUnknownBreaker, name)
+	}
+
+	action(cb)
+	return Snapshot{Name: name, Metrics: cb.GetMetrics()}, nil
+}
@@ -0,0 +1,125 @@
+package circuitbreaker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry_Get_Success(t *testing.T) {
+	r := NewRegistry(&Config{FailureThreshold: 1, SuccessThreshold: 1, Timeout: time.Minute, MaxRequests: 1})
+
+	email := r.Get("email-service")
+	assert.Same(t, email, r.Get("email-service"))
+
+	// Breakers trip independently
+	_ = email.Execute(context.Background(), fail)
+	assert.Equal(t, StateOpen, email.GetState())
+	assert.Equal(t, StateClosed, r.Get("billing-service").GetState())
+}
+
+func TestRegistry_Register_Success(t *testing.T) {
+	r := NewRegistry(nil)
+
+	cb, err := r.Register("email-service", &Config{FailureThreshold: 1, SuccessThreshold: 1, Timeout: time.Minute, MaxRequests: 1})
+	require.NoError(t, err)
+	assert.Same(t, cb, r.Get("email-service"))
+
+	_ = cb.Execute(context.Background(), fail)
+	assert.Equal(t, StateOpen, cb.GetState())
+}
+
+func TestRegistry_Register_Fail(t *testing.T) {
+	r := NewRegistry(nil)
+	r.Get("email-service")
+
+	_, err := r.Register("email-service", DefaultConfig())
+
+	assert.ErrorIs(t, err, ErrBreakerExists)
+}
+
+func TestRegistry_List_Success(t *testing.T) {
+	r := NewRegistry(nil)
+	r.Get("email-service")
+	r.Get("billing-service")
+	_, err := r.ForceOpen("email-service")
+	require.NoError(t, err)
+
+	list := r.List()
+
+	require.Len(t, list, 2)
+	assert.Equal(t, "billing-service", list[0].Name)
+	assert.Equal(t, "closed", list[0].State)
+	assert.Equal(t, "email-service", list[1].Name)
+	assert.Equal(t, "open", list[1].State)
+	assert.True(t, list[1].Forced)
+}
+
+func TestRegistry_Actions_Success(t *testing.T) {
+	tests := []struct {
+		name   string
+		action func(r *Registry, name string) (Snapshot, error)
+		state  string
+		forced bool
+	}{
+		{name: "force open", action: (*Registry).ForceOpen, state: "open", forced: true},
+		{name: "force close", action: (*Registry).ForceClose, state: "closed", forced: true},
+		{name: "reset", action: (*Registry).Reset, state: "closed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRegistry(nil)
+			r.Get("email-service")
+
+			snapshot, err := tt.action(r, "email-service")
+
+			require.NoError(t, err)
+			assert.Equal(t, "email-service", snapshot.Name)
+			assert.Equal(t, tt.state, snapshot.State)
+			assert.Equal(t, tt.forced, snapshot.Forced)
+		})
+	}
+}
+
+func TestRegistry_Actions_Fail(t *testing.T) {
+	r := NewRegistry(nil)
+
+	for _, action := range []func(*Registry, string) (Snapshot, error){
+		(*Registry).ForceOpen, (*Registry).ForceClose, (*Registry).Reset,
+	} {
+		_, err := action(r, "missing")
+		assert.ErrorIs(t, err, ErrUnknownBreaker)
+	}
+
+	// A failed action does not create the breaker
+	assert.Empty(t, r.List())
+}
+
+func TestCircuitBreaker_Force_Success(t *testing.T) {
+	c := newTestClock()
+	cb := New(&Config{FailureThreshold: 1, SuccessThreshold: 1, Timeout: time.Minute, MaxRequests: 1})
+	cb.now = c.Now
+
+	// A forced open circuit does not probe after the timeout
+	cb.ForceOpen()
+	c.Advance(2 * time.Minute)
+	assert.ErrorIs(t, cb.Execute(context.Background(), succeed), ErrCircuitOpen)
+	assert.Equal(t, StateOpen, cb.GetState())
+
+	// A forced closed circuit does not open on failures
+	cb.ForceClose()
+	for range 3 {
+		_ = cb.Execute(context.Background(), fail)
+	}
+	assert.Equal(t, StateClosed, cb.GetState())
+
+	// Reset lets the circuit trip again
+	cb.Reset()
+	_ = cb.Execute(context.Background(), fail)
+	assert.Equal(t, StateOpen, cb.GetState())
+	assert.False(t, cb.GetMetrics().Forced)
+}
@@ -0,0 +1,200 @@
+package circuitbreaker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// transitions records the state changes a listener sees
+type transitions struct {
+	mu  sync.Mutex
+	got []transition
+}
+
+func (r *transitions) record(from, to State) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.got = append(r.got, transition{from: from, to: to})
+}
+
+func (r *transitions) list() []transition {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]transition(nil), r.got...)
+}
+
+func fail(context.Context) error { return errors.New("service error") }
+
+func succeed(context.Context) error { return nil }
+
+func TestState_String(t *testing.T) {
+	assert.Equal(t, "closed", StateClosed.String())
+	assert.Equal(t, "open", StateOpen.String())
+	assert.Equal(t, "half-open", StateHalfOpen.String())
+	assert.Equal(t, "unknown", State(42).String())
+}
+
+func TestCircuitBreaker_OnStateChange_Success(t *testing.T) {
+	tests := []struct {
+		name  string
+		calls func(cb *CircuitBreaker, c *clock)
+		want  []transition
+	}{
+		{
+			name: "no transition while closed",
+			calls: func(cb *CircuitBreaker, c *clock) {
+				_ = cb.Execute(context.Background(), fail)
+				_ = cb.Execute(context.Background(), succeed)
+			},
+		},
+		{
+			name: "open, probe and close",
+			calls: func(cb *CircuitBreaker, c *clock) {
+				_ = cb.Execute(context.Background(), fail)
+				_ = cb.Execute(context.Background(), fail)
+				c.Advance(2 * time.Minute)
+				_ = cb.Execute(context.Background(), succeed)
+			},
+			want: []transition{
+				{from: StateClosed, to: StateOpen},
+				{from: StateOpen, to: StateHalfOpen},
+				{from: StateHalfOpen, to: StateClosed},
+			},
+		},
+		{
+			name: "failed probe reopens",
+			calls: func(cb *CircuitBreaker, c *clock) {
+				_ = cb.Execute(context.Background(), fail)
+				_ = cb.Execute(context.Background(), fail)
+				c.Advance(2 * time.Minute)
+				_ = cb.Execute(context.Background(), fail)
+			},
+			want: []transition{
+				{from: StateClosed, to: StateOpen},
+				{from: StateOpen, to: StateHalfOpen},
+				{from: StateHalfOpen, to: StateOpen},
+			},
+		},
+		{
+			name: "reset closes an open circuit",
+			calls: func(cb *CircuitBreaker, c *clock) {
+				_ = cb.Execute(context.Background(), fail)
+				_ = cb.Execute(context.Background(), fail)
+				cb.Reset()
+				cb.Reset()
+			},
+			want: []transition{
+				{from: StateClosed, to: StateOpen},
+				{from: StateOpen, to: StateClosed},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClock()
+			cb := New(&Config{FailureThreshold: 2, SuccessThreshold: 1, Timeout: time.Minute, MaxRequests: 1})
+			cb.now = c.Now
+
+			var got transitions
+			cb.OnStateChange(got.record)
+
+			tt.calls(cb, c)
+
+			assert.Equal(t, tt.want, got.list())
+		})
+	}
+}
+
+func TestCircuitBreaker_OnStateChange_Unsubscribe(t *testing.T) {
+	cb := New(&Config{FailureThreshold: 1, SuccessThreshold: 1, Timeout: time.Minute, MaxRequests: 1})
+
+	var kept, removed transitions
+	cb.OnStateChange(kept.record)
+	unsubscribe := cb.OnStateChange(removed.record)
+	unsubscribe()
+
+	_ = cb.Execute(context.Background(), fail)
+
+	assert.Len(t, kept.list(), 1)
+	assert.Empty(t, removed.list())
+}
+
+func TestCircuitBreaker_OnStateChange_Reentrant(t *testing.T) {
+	cb := New(&Config{FailureThreshold: 1, SuccessThreshold: 1, Timeout: time.Minute, MaxRequests: 1})
+
+	// Listeners run outside the breaker's lock and may query it
+	var seen State
+	cb.OnStateChange(func(_, _ State) {
+		seen = cb.GetState()
+		_ = cb.GetMetrics()
+	})
+
+	_ = cb.Execute(context.Background(), fail)
+
+	assert.Equal(t, StateOpen, seen)
+}
+
+func TestCircuitBreaker_OnStateChange_ChangesState(t *testing.T) {
+	cb := New(&Config{FailureThreshold: 1, SuccessThreshold: 1, Timeout: time.Minute, MaxRequests: 1})
+
+	// A listener changing the state has its change delivered after its own
+	var got transitions
+	cb.OnStateChange(func(from, to State) {
+		got.record(from, to)
+		if to == StateOpen {
+			cb.Reset()
+		}
+	})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		cb.ForceOpen()
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("ForceOpen deadlocked on its listener")
+	}
+	assert.Equal(t, StateClosed, cb.GetState())
+	assert.Equal(t, []transition{
+		{from: StateClosed, to: StateOpen},
+		{from: StateOpen, to: StateClosed},
+	}, got.list())
+}
+
+func TestCircuitBreaker_Execute_SpanEvent(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+
+	cb := New(&Config{FailureThreshold: 1, SuccessThreshold: 1, Timeout: time.Minute, MaxRequests: 1})
+
+	ctx, span := tracer.Start(context.Background(), "send")
+	_ = cb.Execute(ctx, fail)
+	span.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	events := spans[0].Events()
+	require.Len(t, events, 1)
+	assert.Equal(t, "circuit_breaker.state_change", events[0].Name)
+
+	attrs := map[string]string{}
+	for _, kv := range events[0].Attributes {
+		attrs[string(kv.Key)] = kv.Value.AsString()
+	}
+	assert.Equal(t, map[string]string{
+		"circuit_breaker.from": "closed",
+		"circuit_breaker.to":   "open",
+	}, attrs)
+}
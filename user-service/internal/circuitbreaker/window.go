@@ -0,0 +1,72 @@
+package circuitbreaker
+
+import "time"
+
+// bucket counts the calls that ended in one slice of the window
+type bucket struct {
+	start    time.Time
+	calls    int
+	failures int
+	slow     int
+}
+
+// window counts calls over the last size, split into buckets so old calls
+// expire a bucket at a time instead of being kept individually
+type window struct {
+	size    time.Duration
+	width   time.Duration
+	buckets []bucket
+}
+
+func newWindow(size time.Duration, buckets int) *window {
+	if buckets < 1 {
+		buckets = 1
+	}
+	width := size / time.Duration(buckets)
+	if width <= 0 {
+		width = 1
+	}
+
+	return &window{
+		size:    width * time.Duration(buckets),
+		width:   width,
+		buckets: make([]bucket, buckets),
+	}
+}
+
+// record counts a call that ended at now
+func (w *window) record(now time.Time, failed, slow bool) {
+	start := now.Truncate(w.width)
+	b := &w.buckets[int(start.UnixNano()/int64(w.width))%len(w.buckets)]
+
+	// The bucket last held an earlier slice of time
+	if !b.start.Equal(start) {
+		*b = bucket{start: start}
+	}
+
+	b.calls++
+	if failed {
+		b.failures++
+	}
+	if slow {
+		b.slow++
+	}
+}
+
+// totals sums the calls that ended within the window before now
+func (w *window) totals(now time.Time) (calls, failures, slow int) {
+	oldest := now.Truncate(w.width).Add(-w.size)
+	for _, b := range w.buckets {
+		if !b.start.After(oldest) {
+			continue
+		}
+		calls += b.calls
+		failures += b.failures
+		slow += b.slow
+	}
+	return calls, failures, slow
+}
+
+func (w *window) reset() {
+	clear(w.buckets)
+}
@@ -0,0 +1,216 @@
+package circuitbreaker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// clock is a manual time source for the circuit breaker
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time { return c.now }
+
+func (c *clock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func newTestClock() *clock {
+	return &clock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func windowConfig() *Config {
+	return &Config{
+		Mode:                  ModeWindow,
+		SuccessThreshold:      1,
+		Timeout:               time.Minute,
+		MaxRequests:           1,
+		WindowSize:            10 * time.Second,
+		WindowBuckets:         10,
+		MinRequests:           10,
+		FailureRateThreshold:  0.5,
+		SlowCallDuration:      time.Second,
+		SlowCallRateThreshold: 0.8,
+	}
+}
+
+// call runs one call through cb that fails or succeeds and takes took
+func call(cb *CircuitBreaker, c *clock, fail bool, took time.Duration) error {
+	return cb.Execute(context.Background(), func(context.Context) error {
+		c.Advance(took)
+		if fail {
+			return errors.New("service error")
+		}
+		return nil
+	})
+}
+
+func TestWindow_Totals_Success(t *testing.T) {
+	tests := []struct {
+		name     string
+		advance  time.Duration
+		calls    int
+		failures int
+		slow     int
+	}{
+		{name: "calls within the window count", advance: 5 * time.Second, calls: 3, failures: 2, slow: 1},
+		{name: "calls in the oldest bucket still count", advance: 9 * time.Second, calls: 3, failures: 2, slow: 1},
+		{name: "calls older than the window expire", advance: 10 * time.Second, calls: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClock()
+			w := newWindow(10*time.Second, 10)
+
+			w.record(c.Now(), true, false)
+			w.record(c.Now(), true, true)
+			w.record(c.Now(), false, false)
+			c.Advance(tt.advance)
+
+			calls, failures, slow := w.totals(c.Now())
+			assert.Equal(t, tt.calls, calls)
+			assert.Equal(t, tt.failures, failures)
+			assert.Equal(t, tt.slow, slow)
+		})
+	}
+}
+
+func TestWindow_Record_Success(t *testing.T) {
+	c := newTestClock()
+	w := newWindow(10*time.Second, 10)
+
+	w.record(c.Now(), true, false)
+	// The same bucket comes round again a window later and starts over
+	c.Advance(10 * time.Second)
+	w.record(c.Now(), false, false)
+
+	calls, failures, _ := w.totals(c.Now())
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, 0, failures)
+}
+
+func TestCircuitBreaker_WindowMode_Success(t *testing.T) {
+	tests := []struct {
+		name  string
+		calls func(cb *CircuitBreaker, c *clock)
+	}{
+		{
+			name: "failure rate below threshold",
+			calls: func(cb *CircuitBreaker, c *clock) {
+				for i := range 20 {
+					_ = call(cb, c, i%3 == 0, 0)
+				}
+			},
+		},
+		{
+			name: "too few calls to judge",
+			calls: func(cb *CircuitBreaker, c *clock) {
+				for range 9 {
+					_ = call(cb, c, true, 0)
+				}
+			},
+		},
+		{
+			name: "failures expire from the window",
+			calls: func(cb *CircuitBreaker, c *clock) {
+				for range 9 {
+					_ = call(cb, c, true, 0)
+				}
+				c.Advance(10 * time.Second)
+				for range 9 {
+					_ = call(cb, c, false, 0)
+				}
+				_ = call(cb, c, true, 0)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClock()
+			cb := New(windowConfig())
+			cb.now = c.Now
+
+			tt.calls(cb, c)
+
+			assert.Equal(t, StateClosed, cb.GetState())
+		})
+	}
+}
+
+func TestCircuitBreaker_WindowMode_Fail(t *testing.T) {
+	tests := []struct {
+		name  string
+		calls func(cb *CircuitBreaker, c *clock)
+	}{
+		{
+			name: "every other call fails",
+			calls: func(cb *CircuitBreaker, c *clock) {
+				for i := range 10 {
+					_ = call(cb, c, i%2 == 0, 0)
+				}
+			},
+		},
+		{
+			name: "calls are slow",
+			calls: func(cb *CircuitBreaker, c *clock) {
+				for range 10 {
+					_ = call(cb, c, false, time.Second)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClock()
+			cb := New(windowConfig())
+			cb.now = c.Now
+
+			tt.calls(cb, c)
+
+			assert.Equal(t, StateOpen, cb.GetState())
+			assert.Equal(t, ErrCircuitOpen, call(cb, c, false, 0))
+		})
+	}
+}
+
+func TestCircuitBreaker_WindowMode_HalfOpen(t *testing.T) {
+	tests := []struct {
+		name  string
+		fail  bool
+		took  time.Duration
+		state State
+	}{
+		{name: "fast success closes", state: StateClosed},
+		{name: "failure reopens", fail: true, state: StateOpen},
+		{name: "slow success reopens", took: 2 * time.Second, state: StateOpen},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClock()
+			cb := New(windowConfig())
+			cb.now = c.Now
+
+			for range 10 {
+				_ = call(cb, c, true, 0)
+			}
+			assert.Equal(t, StateOpen, cb.GetState())
+
+			c.Advance(time.Minute + time.Second)
+			_ = call(cb, c, tt.fail, tt.took)
+
+			assert.Equal(t, tt.state, cb.GetState())
+			// Closing starts the window afresh
+			if tt.state == StateClosed {
+				_ = call(cb, c, true, 0)
+				assert.Equal(t, StateClosed, cb.GetState())
+			}
+		})
+	}
+}
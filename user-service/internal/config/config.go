@@ -8,21 +8,34 @@ import (
 
 	"github.com/spf13/viper"
 
+	"github.com/popeskul/mailflow/common/concurrency"
 	"github.com/popeskul/mailflow/common/logger"
+	"github.com/popeskul/mailflow/common/mtls"
+	"github.com/popeskul/mailflow/user-service/internal/circuitbreaker"
+	"github.com/popeskul/mailflow/user-service/internal/retry"
 )
 
 type Config struct {
-	Server  ServerConfig           `mapstructure:"server"`
-	Client  ClientConfig           `mapstructure:"client"`
-	Monitor MonitorConfig          `mapstructure:"monitor"`
-	Trace   TraceConfig            `mapstructure:"trace"`
-	Log     logger.UnmarshalConfig `mapstructure:"logger"`
+	Server       ServerConfig           `mapstructure:"server"`
+	Client       ClientConfig           `mapstructure:"client"`
+	Monitor      MonitorConfig          `mapstructure:"monitor"`
+	Trace        TraceConfig            `mapstructure:"trace"`
+	Unsubscribe  UnsubscribeConfig      `mapstructure:"unsubscribe"`
+	Verification VerificationConfig     `mapstructure:"verification"`
+	Auth         AuthConfig             `mapstructure:"auth"`
+	Reset        PasswordResetConfig    `mapstructure:"password_reset"`
+	Pagination   PaginationConfig       `mapstructure:"pagination"`
+	TLS          TLSConfig              `mapstructure:"tls"`
+	Health       HealthConfig           `mapstructure:"health"`
+	Log          logger.UnmarshalConfig `mapstructure:"logger"`
 }
 
 type ServerConfig struct {
 	GRPCPort        string        `mapstructure:"grpc_port"`
 	HTTPPort        string        `mapstructure:"http_port"`
 	ShutdownTimeout time.Duration `mapstructure:"shutdown_timeout"`
+	// ConcurrencyLimit rejects calls beyond an adaptive concurrency limit
+	ConcurrencyLimit concurrency.Config `mapstructure:"concurrency_limit"`
 }
 
 type ClientConfig struct {
@@ -34,12 +47,130 @@ type EmailServiceConfig struct {
 	Timeout       time.Duration `mapstructure:"timeout"`
 	RetryAttempts int           `mapstructure:"retry_attempts"`
 	RetryDelay    time.Duration `mapstructure:"retry_delay"`
+	// RetryStrategy picks how the delay between attempts grows
+	RetryStrategy retry.Kind `mapstructure:"retry_strategy"`
+	// RetryMaxElapsed caps the time a send spends retrying; zero does not
+	RetryMaxElapsed time.Duration `mapstructure:"retry_max_elapsed"`
+	// SPIFFEID is the identity the email service must present when TLS
+	// has a trust domain. When empty any ID in the trust domain is accepted.
+	SPIFFEID string `mapstructure:"spiffe_id"`
+	// QueueSize is how many emails are held for resending while the email
+	// service is unavailable
+	QueueSize int `mapstructure:"queue_size"`
+	// SpoolPath is the file queued emails are saved to at shutdown and
+	// resent from at startup. When empty they are dropped.
+	SpoolPath string `mapstructure:"spool_path"`
+	// CircuitBreaker decides when calls stop being made and are queued instead
+	CircuitBreaker circuitbreaker.Config `mapstructure:"circuit_breaker"`
+	// RetryBudget limits retries across all sends
+	RetryBudget RetryBudgetConfig `mapstructure:"retry_budget"`
+	// Hedging sends another read when one is slow
+	Hedging HedgingConfig `mapstructure:"hedging"`
+}
+
+// RetryBudgetConfig sizes the token bucket shared by retries. Each failed
+// attempt takes a token and each successful send returns TokenRatio of one;
+// sends are not retried while less than half of MaxTokens is left.
+type RetryBudgetConfig struct {
+	MaxTokens  float64 `mapstructure:"max_tokens"`
+	TokenRatio float64 `mapstructure:"token_ratio"`
+}
+
+// HedgingConfig decides when reads from the email service are hedged.
+// Reads are hedged after Delay, or once enough have been made after the
+// Percentile of their latencies; with neither they are not hedged.
+type HedgingConfig struct {
+	Delay      time.Duration `mapstructure:"delay"`
+	Percentile float64       `mapstructure:"percentile"`
+	// Budget limits hedges as RetryBudget limits retries: each hedge takes
+	// a token and each read returns TokenRatio of one
+	Budget RetryBudgetConfig `mapstructure:"budget"`
+}
+
+// Enabled reports whether reads are hedged
+func (c HedgingConfig) Enabled() bool {
+	return c.Delay > 0 || c.Percentile > 0
+}
+
+// TLSConfig secures the gRPC server, the gateway's connection to it and
+// the email client. Connections are plaintext unless it is enabled.
+type TLSConfig struct {
+	mtls.Config `mapstructure:",squash"`
+	// AllowedClients are the SPIFFE IDs that may call the gRPC server. When
+	// empty any ID in the trust domain is accepted.
+	AllowedClients []string `mapstructure:"allowed_clients"`
+}
+
+// HealthConfig controls the readiness checks
+type HealthConfig struct {
+	// CheckTimeout is how long a single check may take
+	CheckTimeout time.Duration `mapstructure:"check_timeout"`
+	// Interval is how often the grpc.health.v1 status is refreshed
+	Interval time.Duration `mapstructure:"interval"`
+	// QueueSaturation is the fill ratio of the email retry queue, between 0
+	// and 1, from which the service reports itself not ready
+	QueueSaturation float64 `mapstructure:"queue_saturation"`
 }
 
 type MonitorConfig struct {
 	MetricsPort string `mapstructure:"metrics_port"`
 }
 
+// UnsubscribeConfig controls the signed one-click unsubscribe links put
+// into outgoing emails. Links are only generated when Secret is set.
+type UnsubscribeConfig struct {
+	// BaseURL is the public address of the HTTP server
+	BaseURL string `mapstructure:"base_url"`
+	// Secret signs the unsubscribe tokens
+	Secret string `mapstructure:"secret"`
+}
+
+// VerificationConfig controls double opt-in. New users must follow a signed
+// link before they get the welcome email; it is only required when Secret is set.
+type VerificationConfig struct {
+	// BaseURL is the public address of the HTTP gateway
+	BaseURL string `mapstructure:"base_url"`
+	// Secret signs the verification tokens
+	Secret string `mapstructure:"secret"`
+	// TTL is how long a verification link stays valid
+	TTL time.Duration `mapstructure:"ttl"`
+	// MaxResends verification emails may be resent to one address per ResendWindow
+	MaxResends   int           `mapstructure:"max_resends"`
+	ResendWindow time.Duration `mapstructure:"resend_window"`
+}
+
+// AuthConfig controls password logins. Login and the auth interceptors are
+// only enabled when Secret is set.
+type AuthConfig struct {
+	// Secret signs the access tokens. The email service checks the same
+	// tokens, so it must be configured with the same secret.
+	Secret string `mapstructure:"secret"`
+	// AccessTokenTTL is how long an access token is accepted. Keep it short:
+	// it is the longest a token keeps working after its session ends.
+	AccessTokenTTL time.Duration `mapstructure:"access_token_ttl"`
+	// RefreshTokenTTL is how long an unused session stays alive
+	RefreshTokenTTL time.Duration `mapstructure:"refresh_token_ttl"`
+	// Admins are the email addresses of users who log in as admins once
+	// they verified them, so they need verification enabled
+	Admins []string `mapstructure:"admins"`
+}
+
+// PasswordResetConfig controls the password reset emails
+type PasswordResetConfig struct {
+	// TTL is how long a reset token stays valid
+	TTL time.Duration `mapstructure:"ttl"`
+	// MaxRequests resets may be requested for one address per Window
+	MaxRequests int           `mapstructure:"max_requests"`
+	Window      time.Duration `mapstructure:"window"`
+}
+
+// PaginationConfig controls the page tokens returned by list APIs
+type PaginationConfig struct {
+	// CursorSecret signs page tokens. When empty a random key is used, so
+	// tokens stop working after a restart.
+	CursorSecret string `mapstructure:"cursor_secret"`
+}
+
 type TraceConfig struct {
 	ServiceName string `mapstructure:"service_name"`
 	JaegerURL   string `mapstructure:"jaeger_url"` // Keep for backwards compatibility with config
@@ -69,12 +200,35 @@ func setDefaultConfig() {
 	viper.SetDefault("server.grpc_port", ":50051")
 	viper.SetDefault("server.http_port", ":8080")
 	viper.SetDefault("server.shutdown_timeout", "30s")
+	viper.SetDefault("server.concurrency_limit.algorithm", string(concurrency.KindGradient))
+	viper.SetDefault("server.concurrency_limit.initial_limit", 20)
+	viper.SetDefault("server.concurrency_limit.min_limit", 5)
+	viper.SetDefault("server.concurrency_limit.max_limit", 200)
+	viper.SetDefault("server.concurrency_limit.timeout", "1s")
 
 	// Client defaults
 	viper.SetDefault("client.email_service.address", "email-service:50052")
 	viper.SetDefault("client.email_service.timeout", "5s")
 	viper.SetDefault("client.email_service.retry_attempts", 3)
 	viper.SetDefault("client.email_service.retry_delay", "1s")
+	viper.SetDefault("client.email_service.retry_strategy", string(retry.KindExponential))
+	viper.SetDefault("client.email_service.queue_size", 1000)
+	viper.SetDefault("client.email_service.retry_budget.max_tokens", 10)
+	viper.SetDefault("client.email_service.retry_budget.token_ratio", 0.1)
+	viper.SetDefault("client.email_service.hedging.delay", "100ms")
+	viper.SetDefault("client.email_service.hedging.percentile", 0.95)
+	viper.SetDefault("client.email_service.hedging.budget.max_tokens", 10)
+	viper.SetDefault("client.email_service.hedging.budget.token_ratio", 0.1)
+	viper.SetDefault("client.email_service.circuit_breaker.mode", string(circuitbreaker.ModeConsecutive))
+	viper.SetDefault("client.email_service.circuit_breaker.failure_threshold", 5)
+	viper.SetDefault("client.email_service.circuit_breaker.success_threshold", 2)
+	viper.SetDefault("client.email_service.circuit_breaker.timeout", "30s")
+	viper.SetDefault("client.email_service.circuit_breaker.max_requests", 3)
+	viper.SetDefault("client.email_service.circuit_breaker.window_size", "1m")
+	viper.SetDefault("client.email_service.circuit_breaker.window_buckets", 10)
+	viper.SetDefault("client.email_service.circuit_breaker.min_requests", 20)
+	viper.SetDefault("client.email_service.circuit_breaker.failure_rate_threshold", 0.5)
+	viper.SetDefault("client.email_service.circuit_breaker.slow_call_duration", "5s")
 
 	// Monitor defaults
 	viper.SetDefault("monitor.metrics_port", ":9101")
@@ -84,6 +238,32 @@ func setDefaultConfig() {
 	viper.SetDefault("logger.encoding", "json")
 	viper.SetDefault("logger.output_path", "stdout")
 
+	// Unsubscribe defaults
+	viper.SetDefault("unsubscribe.base_url", "http://localhost:8080")
+
+	// Verification defaults
+	viper.SetDefault("verification.base_url", "http://localhost:8080")
+	viper.SetDefault("verification.ttl", "24h")
+	viper.SetDefault("verification.max_resends", 3)
+	viper.SetDefault("verification.resend_window", "1h")
+
+	// Auth defaults
+	viper.SetDefault("auth.access_token_ttl", "15m")
+	viper.SetDefault("auth.refresh_token_ttl", "720h")
+
+	// Password reset defaults
+	viper.SetDefault("password_reset.ttl", "1h")
+	viper.SetDefault("password_reset.max_requests", 3)
+	viper.SetDefault("password_reset.window", "1h")
+
+	// TLS defaults
+	viper.SetDefault("tls.reload_interval", "30s")
+
+	// Health defaults
+	viper.SetDefault("health.check_timeout", "2s")
+	viper.SetDefault("health.interval", "5s")
+	viper.SetDefault("health.queue_saturation", 0.9)
+
 	// Trace defaults
 	viper.SetDefault("trace.service_name", "user-service")
 	viper.SetDefault("trace.version", "1.0.0")
@@ -102,6 +282,9 @@ func validateConfig(config *Config) error {
 	if config.Server.HTTPPort == "" {
 		errors = append(errors, "server.http_port is required")
 	}
+	if err := config.Server.ConcurrencyLimit.Validate(); err != nil {
+		errors = append(errors, fmt.Sprintf("server.concurrency_limit: %v", err))
+	}
 
 	// Validate Client config
 	if config.Client.EmailService.Address == "" {
@@ -116,6 +299,127 @@ func validateConfig(config *Config) error {
 	if config.Client.EmailService.RetryDelay <= 0 {
 		errors = append(errors, "client.email_service.retry_delay must be greater than 0")
 	}
+	if kind := config.Client.EmailService.RetryStrategy; !kind.Valid() {
+		errors = append(errors, fmt.Sprintf("client.email_service.retry_strategy %q is not a known strategy", kind))
+	}
+	if config.Client.EmailService.RetryMaxElapsed < 0 {
+		errors = append(errors, "client.email_service.retry_max_elapsed must not be negative")
+	}
+	if config.Client.EmailService.QueueSize <= 0 {
+		errors = append(errors, "client.email_service.queue_size must be greater than 0")
+	}
+	if budget := config.Client.EmailService.RetryBudget; budget.MaxTokens <= 0 || budget.TokenRatio <= 0 {
+		errors = append(errors, "client.email_service.retry_budget max_tokens and token_ratio must be greater than 0")
+	}
+	if hedging := config.Client.EmailService.Hedging; hedging.Enabled() {
+		if hedging.Delay < 0 {
+			errors = append(errors, "client.email_service.hedging.delay must not be negative")
+		}
+		if hedging.Percentile < 0 || hedging.Percentile >= 1 {
+			errors = append(errors, "client.email_service.hedging.percentile must be between 0 and 1")
+		}
+		if hedging.Budget.MaxTokens <= 0 || hedging.Budget.TokenRatio <= 0 {
+			errors = append(errors, "client.email_service.hedging.budget max_tokens and token_ratio must be greater than 0")
+		}
+	}
+
+	// Validate Circuit breaker config
+	cb := config.Client.EmailService.CircuitBreaker
+	if !cb.Mode.Valid() {
+		errors = append(errors, fmt.Sprintf("client.email_service.circuit_breaker.mode %q is not consecutive or window", cb.Mode))
+	}
+	if cb.SuccessThreshold <= 0 {
+		errors = append(errors, "client.email_service.circuit_breaker.success_threshold must be greater than 0")
+	}
+	if cb.Timeout <= 0 {
+		errors = append(errors, "client.email_service.circuit_breaker.timeout must be greater than 0")
+	}
+	if cb.MaxRequests < cb.SuccessThreshold {
+		errors = append(errors, "client.email_service.circuit_breaker.max_requests must be at least success_threshold")
+	}
+	if cb.Mode == circuitbreaker.ModeWindow {
+		if cb.WindowSize <= 0 || cb.WindowBuckets <= 0 {
+			errors = append(errors, "client.email_service.circuit_breaker.window_size and window_buckets must be greater than 0")
+		}
+		if cb.FailureRateThreshold < 0 || cb.FailureRateThreshold > 1 || cb.SlowCallRateThreshold < 0 || cb.SlowCallRateThreshold > 1 {
+			errors = append(errors, "client.email_service.circuit_breaker rate thresholds must be between 0 and 1")
+		}
+		if cb.FailureRateThreshold == 0 && cb.SlowCallRateThreshold == 0 {
+			errors = append(errors, "client.email_service.circuit_breaker needs a failure or slow call rate threshold in window mode")
+		}
+		if cb.SlowCallRateThreshold > 0 && cb.SlowCallDuration <= 0 {
+			errors = append(errors, "client.email_service.circuit_breaker.slow_call_duration must be greater than 0")
+		}
+	} else if cb.FailureThreshold <= 0 {
+		errors = append(errors, "client.email_service.circuit_breaker.failure_threshold must be greater than 0")
+	}
+
+	// Validate Unsubscribe config
+	if config.Unsubscribe.Secret != "" && config.Unsubscribe.BaseURL == "" {
+		errors = append(errors, "unsubscribe.base_url is required when unsubscribe.secret is set")
+	}
+
+	// Validate Verification config
+	if config.Verification.Secret != "" {
+		if config.Verification.BaseURL == "" {
+			errors = append(errors, "verification.base_url is required when verification.secret is set")
+		}
+		if config.Verification.TTL <= 0 {
+			errors = append(errors, "verification.ttl must be greater than 0")
+		}
+		if config.Verification.MaxResends <= 0 {
+			errors = append(errors, "verification.max_resends must be greater than 0")
+		}
+		if config.Verification.ResendWindow <= 0 {
+			errors = append(errors, "verification.resend_window must be greater than 0")
+		}
+	}
+
+	// Validate Auth config
+	if config.Auth.Secret != "" {
+		if config.Auth.AccessTokenTTL <= 0 {
+			errors = append(errors, "auth.access_token_ttl must be greater than 0")
+		}
+		if config.Auth.RefreshTokenTTL <= config.Auth.AccessTokenTTL {
+			errors = append(errors, "auth.refresh_token_ttl must be greater than auth.access_token_ttl")
+		}
+	}
+	if len(config.Auth.Admins) > 0 && config.Verification.Secret == "" {
+		errors = append(errors, "auth.admins requires verification.secret, as only verified addresses become admins")
+	}
+
+	// Validate Password reset config
+	if config.Reset.TTL <= 0 {
+		errors = append(errors, "password_reset.ttl must be greater than 0")
+	}
+	if config.Reset.MaxRequests <= 0 {
+		errors = append(errors, "password_reset.max_requests must be greater than 0")
+	}
+	if config.Reset.Window <= 0 {
+		errors = append(errors, "password_reset.window must be greater than 0")
+	}
+
+	// Validate TLS config
+	if (config.TLS.CertFile == "") != (config.TLS.KeyFile == "") {
+		errors = append(errors, "tls.cert_file and tls.key_file must be set together")
+	}
+	if config.TLS.Enabled() && config.TLS.ReloadInterval <= 0 {
+		errors = append(errors, "tls.reload_interval must be greater than 0")
+	}
+	if config.TLS.TrustDomain != "" && config.TLS.CAFile == "" {
+		errors = append(errors, "tls.ca_file is required when tls.trust_domain is set")
+	}
+
+	// Validate Health config
+	if config.Health.CheckTimeout <= 0 {
+		errors = append(errors, "health.check_timeout must be greater than 0")
+	}
+	if config.Health.Interval <= 0 {
+		errors = append(errors, "health.interval must be greater than 0")
+	}
+	if config.Health.QueueSaturation <= 0 || config.Health.QueueSaturation > 1 {
+		errors = append(errors, "health.queue_saturation must be greater than 0 and at most 1")
+	}
 
 	// Validate Monitor config
 	if config.Monitor.MetricsPort == "" {
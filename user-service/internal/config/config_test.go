@@ -7,6 +7,11 @@ import (
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/popeskul/mailflow/common/concurrency"
+	"github.com/popeskul/mailflow/common/mtls"
+	"github.com/popeskul/mailflow/user-service/internal/circuitbreaker"
+	"github.com/popeskul/mailflow/user-service/internal/retry"
 )
 
 func TestLoadConfig_Default(t *testing.T) {
@@ -22,16 +27,63 @@ func TestLoadConfig_Default(t *testing.T) {
 	assert.Equal(t, ":50051", config.Server.GRPCPort)
 	assert.Equal(t, ":8080", config.Server.HTTPPort)
 	assert.Equal(t, 30*time.Second, config.Server.ShutdownTimeout)
+	assert.Equal(t, concurrency.Config{
+		Algorithm:    concurrency.KindGradient,
+		InitialLimit: 20,
+		MinLimit:     5,
+		MaxLimit:     200,
+		Timeout:      time.Second,
+	}, config.Server.ConcurrencyLimit)
 
 	// Check default client config
 	assert.Equal(t, "email-service:50052", config.Client.EmailService.Address)
 	assert.Equal(t, 5*time.Second, config.Client.EmailService.Timeout)
 	assert.Equal(t, 3, config.Client.EmailService.RetryAttempts)
 	assert.Equal(t, 1*time.Second, config.Client.EmailService.RetryDelay)
+	assert.Equal(t, retry.KindExponential, config.Client.EmailService.RetryStrategy)
+	assert.Zero(t, config.Client.EmailService.RetryMaxElapsed)
+	assert.Equal(t, 1000, config.Client.EmailService.QueueSize)
+	assert.Empty(t, config.Client.EmailService.SpoolPath)
+	assert.Equal(t, RetryBudgetConfig{MaxTokens: 10, TokenRatio: 0.1}, config.Client.EmailService.RetryBudget)
+	assert.Equal(t, HedgingConfig{
+		Delay:      100 * time.Millisecond,
+		Percentile: 0.95,
+		Budget:     RetryBudgetConfig{MaxTokens: 10, TokenRatio: 0.1},
+	}, config.Client.EmailService.Hedging)
+	assert.Equal(t, circuitbreaker.ModeConsecutive, config.Client.EmailService.CircuitBreaker.Mode)
+	assert.Equal(t, 5, config.Client.EmailService.CircuitBreaker.FailureThreshold)
+	assert.Equal(t, 30*time.Second, config.Client.EmailService.CircuitBreaker.Timeout)
+	assert.Equal(t, time.Minute, config.Client.EmailService.CircuitBreaker.WindowSize)
+	assert.Equal(t, 0.5, config.Client.EmailService.CircuitBreaker.FailureRateThreshold)
 
 	// Check default monitor config
 	assert.Equal(t, ":9101", config.Monitor.MetricsPort)
 
+	// Check default unsubscribe config
+	assert.Equal(t, "http://localhost:8080", config.Unsubscribe.BaseURL)
+	assert.Empty(t, config.Unsubscribe.Secret)
+
+	// Verification is off until a secret is configured
+	assert.Empty(t, config.Verification.Secret)
+	assert.Equal(t, 24*time.Hour, config.Verification.TTL)
+	assert.Equal(t, 3, config.Verification.MaxResends)
+	assert.Equal(t, time.Hour, config.Verification.ResendWindow)
+
+	// Logins are off until a secret is configured
+	assert.Empty(t, config.Auth.Secret)
+	assert.Equal(t, 15*time.Minute, config.Auth.AccessTokenTTL)
+	assert.Equal(t, 720*time.Hour, config.Auth.RefreshTokenTTL)
+
+	// Check default password reset config
+	assert.Equal(t, time.Hour, config.Reset.TTL)
+	assert.Equal(t, 3, config.Reset.MaxRequests)
+	assert.Equal(t, time.Hour, config.Reset.Window)
+
+	// Check default health config
+	assert.Equal(t, 2*time.Second, config.Health.CheckTimeout)
+	assert.Equal(t, 5*time.Second, config.Health.Interval)
+	assert.Equal(t, 0.9, config.Health.QueueSaturation)
+
 	// Check default trace config
 	assert.Equal(t, "user-service", config.Trace.ServiceName)
 	assert.Equal(t, "1.0.0", config.Trace.Version)
@@ -58,15 +110,28 @@ func TestValidateConfig_Success(t *testing.T) {
 				},
 				Client: ClientConfig{
 					EmailService: EmailServiceConfig{
-						Address:       "email-service:50052",
-						Timeout:       5 * time.Second,
-						RetryAttempts: 3,
-						RetryDelay:    1 * time.Second,
+						Address:        "email-service:50052",
+						Timeout:        5 * time.Second,
+						RetryAttempts:  3,
+						RetryDelay:     1 * time.Second,
+						QueueSize:      1000,
+						CircuitBreaker: *circuitbreaker.DefaultConfig(),
+						RetryBudget:    RetryBudgetConfig{MaxTokens: 10, TokenRatio: 0.1},
 					},
 				},
 				Monitor: MonitorConfig{
 					MetricsPort: ":9101",
 				},
+				Reset: PasswordResetConfig{
+					TTL:         time.Hour,
+					MaxRequests: 3,
+					Window:      time.Hour,
+				},
+				Health: HealthConfig{
+					CheckTimeout:    2 * time.Second,
+					Interval:        5 * time.Second,
+					QueueSaturation: 0.9,
+				},
 			},
 		},
 	}
@@ -97,6 +162,7 @@ func TestValidateConfig_Fail(t *testing.T) {
 						Timeout:       5 * time.Second,
 						RetryAttempts: 3,
 						RetryDelay:    1 * time.Second,
+						QueueSize:     1000,
 					},
 				},
 				Monitor: MonitorConfig{
@@ -117,6 +183,7 @@ func TestValidateConfig_Fail(t *testing.T) {
 						Timeout:       5 * time.Second,
 						RetryAttempts: 3,
 						RetryDelay:    1 * time.Second,
+						QueueSize:     1000,
 					},
 				},
 				Monitor: MonitorConfig{
@@ -137,6 +204,7 @@ func TestValidateConfig_Fail(t *testing.T) {
 						Timeout:       5 * time.Second,
 						RetryAttempts: 3,
 						RetryDelay:    1 * time.Second,
+						QueueSize:     1000,
 					},
 				},
 				Monitor: MonitorConfig{
@@ -158,6 +226,7 @@ func TestValidateConfig_Fail(t *testing.T) {
 						Timeout:       0,
 						RetryAttempts: 3,
 						RetryDelay:    1 * time.Second,
+						QueueSize:     1000,
 					},
 				},
 				Monitor: MonitorConfig{
@@ -179,6 +248,7 @@ func TestValidateConfig_Fail(t *testing.T) {
 						Timeout:       5 * time.Second,
 						RetryAttempts: 0,
 						RetryDelay:    1 * time.Second,
+						QueueSize:     1000,
 					},
 				},
 				Monitor: MonitorConfig{
@@ -200,6 +270,7 @@ func TestValidateConfig_Fail(t *testing.T) {
 						Timeout:       5 * time.Second,
 						RetryAttempts: 3,
 						RetryDelay:    0,
+						QueueSize:     1000,
 					},
 				},
 				Monitor: MonitorConfig{
@@ -208,6 +279,214 @@ func TestValidateConfig_Fail(t *testing.T) {
 			},
 			expectedError: "client.email_service.retry_delay must be greater than 0",
 		},
+		{
+			name: "invalid queue size",
+			config: &Config{
+				Server: ServerConfig{
+					GRPCPort: ":50051",
+					HTTPPort: ":8080",
+				},
+				Client: ClientConfig{
+					EmailService: EmailServiceConfig{
+						Address:       "email-service:50052",
+						Timeout:       5 * time.Second,
+						RetryAttempts: 3,
+						RetryDelay:    1 * time.Second,
+					},
+				},
+				Monitor: MonitorConfig{
+					MetricsPort: ":9101",
+				},
+			},
+			expectedError: "client.email_service.queue_size must be greater than 0",
+		},
+		{
+			name: "window circuit breaker without thresholds",
+			config: &Config{
+				Server: ServerConfig{
+					GRPCPort: ":50051",
+					HTTPPort: ":8080",
+				},
+				Client: ClientConfig{
+					EmailService: EmailServiceConfig{
+						Address:       "email-service:50052",
+						Timeout:       5 * time.Second,
+						RetryAttempts: 3,
+						RetryDelay:    1 * time.Second,
+						QueueSize:     1000,
+						CircuitBreaker: circuitbreaker.Config{
+							Mode:             circuitbreaker.ModeWindow,
+							SuccessThreshold: 2,
+							Timeout:          30 * time.Second,
+							MaxRequests:      3,
+							WindowSize:       time.Minute,
+							WindowBuckets:    10,
+						},
+					},
+				},
+				Monitor: MonitorConfig{
+					MetricsPort: ":9101",
+				},
+			},
+			expectedError: "client.email_service.circuit_breaker needs a failure or slow call rate threshold in window mode",
+		},
+		{
+			name: "unknown circuit breaker mode",
+			config: &Config{
+				Server: ServerConfig{
+					GRPCPort: ":50051",
+					HTTPPort: ":8080",
+				},
+				Client: ClientConfig{
+					EmailService: EmailServiceConfig{
+						Address:        "email-service:50052",
+						Timeout:        5 * time.Second,
+						RetryAttempts:  3,
+						RetryDelay:     1 * time.Second,
+						QueueSize:      1000,
+						CircuitBreaker: circuitbreaker.Config{Mode: "sliding"},
+					},
+				},
+				Monitor: MonitorConfig{
+					MetricsPort: ":9101",
+				},
+			},
+			expectedError: `client.email_service.circuit_breaker.mode "sliding" is not consecutive or window`,
+		},
+		{
+			name: "concurrency limit above its maximum",
+			config: &Config{
+				Server: ServerConfig{
+					GRPCPort: ":50051",
+					HTTPPort: ":8080",
+					ConcurrencyLimit: concurrency.Config{
+						Algorithm:    concurrency.KindAIMD,
+						InitialLimit: 500,
+						MinLimit:     5,
+						MaxLimit:     200,
+					},
+				},
+				Client: ClientConfig{
+					EmailService: EmailServiceConfig{
+						Address:        "email-service:50052",
+						Timeout:        5 * time.Second,
+						RetryAttempts:  3,
+						RetryDelay:     1 * time.Second,
+						QueueSize:      1000,
+						CircuitBreaker: *circuitbreaker.DefaultConfig(),
+						RetryBudget:    RetryBudgetConfig{MaxTokens: 10, TokenRatio: 0.1},
+					},
+				},
+				Monitor: MonitorConfig{
+					MetricsPort: ":9101",
+				},
+			},
+			expectedError: "server.concurrency_limit: initial_limit must be between min_limit and max_limit",
+		},
+		{
+			name: "unknown retry strategy",
+			config: &Config{
+				Server: ServerConfig{
+					GRPCPort: ":50051",
+					HTTPPort: ":8080",
+				},
+				Client: ClientConfig{
+					EmailService: EmailServiceConfig{
+						Address:        "email-service:50052",
+						Timeout:        5 * time.Second,
+						RetryAttempts:  3,
+						RetryDelay:     1 * time.Second,
+						RetryStrategy:  "linear",
+						QueueSize:      1000,
+						CircuitBreaker: *circuitbreaker.DefaultConfig(),
+						RetryBudget:    RetryBudgetConfig{MaxTokens: 10, TokenRatio: 0.1},
+					},
+				},
+				Monitor: MonitorConfig{
+					MetricsPort: ":9101",
+				},
+			},
+			expectedError: `client.email_service.retry_strategy "linear" is not a known strategy`,
+		},
+		{
+			name: "hedging percentile of one",
+			config: &Config{
+				Server: ServerConfig{
+					GRPCPort: ":50051",
+					HTTPPort: ":8080",
+				},
+				Client: ClientConfig{
+					EmailService: EmailServiceConfig{
+						Address:        "email-service:50052",
+						Timeout:        5 * time.Second,
+						RetryAttempts:  3,
+						RetryDelay:     1 * time.Second,
+						QueueSize:      1000,
+						CircuitBreaker: *circuitbreaker.DefaultConfig(),
+						RetryBudget:    RetryBudgetConfig{MaxTokens: 10, TokenRatio: 0.1},
+						Hedging: HedgingConfig{
+							Percentile: 1,
+							Budget:     RetryBudgetConfig{MaxTokens: 10, TokenRatio: 0.1},
+						},
+					},
+				},
+				Monitor: MonitorConfig{
+					MetricsPort: ":9101",
+				},
+			},
+			expectedError: "client.email_service.hedging.percentile must be between 0 and 1",
+		},
+		{
+			name: "empty retry budget",
+			config: &Config{
+				Server: ServerConfig{
+					GRPCPort: ":50051",
+					HTTPPort: ":8080",
+				},
+				Client: ClientConfig{
+					EmailService: EmailServiceConfig{
+						Address:        "email-service:50052",
+						Timeout:        5 * time.Second,
+						RetryAttempts:  3,
+						RetryDelay:     1 * time.Second,
+						QueueSize:      1000,
+						CircuitBreaker: *circuitbreaker.DefaultConfig(),
+						RetryBudget:    RetryBudgetConfig{MaxTokens: 10},
+					},
+				},
+				Monitor: MonitorConfig{
+					MetricsPort: ":9101",
+				},
+			},
+			expectedError: "client.email_service.retry_budget max_tokens and token_ratio must be greater than 0",
+		},
+		{
+			name: "queue saturation above one",
+			config: &Config{
+				Server: ServerConfig{
+					GRPCPort: ":50051",
+					HTTPPort: ":8080",
+				},
+				Client: ClientConfig{
+					EmailService: EmailServiceConfig{
+						Address:       "email-service:50052",
+						Timeout:       5 * time.Second,
+						RetryAttempts: 3,
+						RetryDelay:    1 * time.Second,
+						QueueSize:     1000,
+					},
+				},
+				Monitor: MonitorConfig{
+					MetricsPort: ":9101",
+				},
+				Health: HealthConfig{
+					CheckTimeout:    2 * time.Second,
+					Interval:        5 * time.Second,
+					QueueSaturation: 1.5,
+				},
+			},
+			expectedError: "health.queue_saturation must be greater than 0 and at most 1",
+		},
 		{
 			name: "missing metrics port",
 			config: &Config{
@@ -221,12 +500,231 @@ func TestValidateConfig_Fail(t *testing.T) {
 						Timeout:       5 * time.Second,
 						RetryAttempts: 3,
 						RetryDelay:    1 * time.Second,
+						QueueSize:     1000,
 					},
 				},
 				Monitor: MonitorConfig{},
 			},
 			expectedError: "monitor.metrics_port is required",
 		},
+		{
+			name: "unsubscribe secret without base url",
+			config: &Config{
+				Server: ServerConfig{
+					GRPCPort: ":50051",
+					HTTPPort: ":8080",
+				},
+				Client: ClientConfig{
+					EmailService: EmailServiceConfig{
+						Address:       "email-service:50052",
+						Timeout:       5 * time.Second,
+						RetryAttempts: 3,
+						RetryDelay:    1 * time.Second,
+						QueueSize:     1000,
+					},
+				},
+				Monitor: MonitorConfig{
+					MetricsPort: ":9101",
+				},
+				Unsubscribe: UnsubscribeConfig{
+					Secret: "secret",
+				},
+			},
+			expectedError: "unsubscribe.base_url is required when unsubscribe.secret is set",
+		},
+		{
+			name: "verification secret without ttl",
+			config: &Config{
+				Server: ServerConfig{
+					GRPCPort: ":50051",
+					HTTPPort: ":8080",
+				},
+				Client: ClientConfig{
+					EmailService: EmailServiceConfig{
+						Address:       "email-service:50052",
+						Timeout:       5 * time.Second,
+						RetryAttempts: 3,
+						RetryDelay:    1 * time.Second,
+						QueueSize:     1000,
+					},
+				},
+				Monitor: MonitorConfig{
+					MetricsPort: ":9101",
+				},
+				Verification: VerificationConfig{
+					BaseURL: "http://localhost:8080",
+					Secret:  "secret",
+				},
+			},
+			expectedError: "verification.ttl must be greater than 0",
+		},
+		{
+			name: "verification resends not rate limited",
+			config: &Config{
+				Server: ServerConfig{
+					GRPCPort: ":50051",
+					HTTPPort: ":8080",
+				},
+				Client: ClientConfig{
+					EmailService: EmailServiceConfig{
+						Address:       "email-service:50052",
+						Timeout:       5 * time.Second,
+						RetryAttempts: 3,
+						RetryDelay:    1 * time.Second,
+						QueueSize:     1000,
+					},
+				},
+				Monitor: MonitorConfig{
+					MetricsPort: ":9101",
+				},
+				Verification: VerificationConfig{
+					BaseURL:      "http://localhost:8080",
+					Secret:       "secret",
+					TTL:          time.Hour,
+					ResendWindow: time.Hour,
+				},
+			},
+			expectedError: "verification.max_resends must be greater than 0",
+		},
+		{
+			name: "verification resend window missing",
+			config: &Config{
+				Server: ServerConfig{
+					GRPCPort: ":50051",
+					HTTPPort: ":8080",
+				},
+				Client: ClientConfig{
+					EmailService: EmailServiceConfig{
+						Address:       "email-service:50052",
+						Timeout:       5 * time.Second,
+						RetryAttempts: 3,
+						RetryDelay:    1 * time.Second,
+						QueueSize:     1000,
+					},
+				},
+				Monitor: MonitorConfig{
+					MetricsPort: ":9101",
+				},
+				Verification: VerificationConfig{
+					BaseURL:    "http://localhost:8080",
+					Secret:     "secret",
+					TTL:        time.Hour,
+					MaxResends: 3,
+				},
+			},
+			expectedError: "verification.resend_window must be greater than 0",
+		},
+		{
+			name: "refresh tokens expiring before access tokens",
+			config: &Config{
+				Server: ServerConfig{
+					GRPCPort: ":50051",
+					HTTPPort: ":8080",
+				},
+				Client: ClientConfig{
+					EmailService: EmailServiceConfig{
+						Address:       "email-service:50052",
+						Timeout:       5 * time.Second,
+						RetryAttempts: 3,
+						RetryDelay:    1 * time.Second,
+						QueueSize:     1000,
+					},
+				},
+				Monitor: MonitorConfig{
+					MetricsPort: ":9101",
+				},
+				Auth: AuthConfig{
+					Secret:          "secret",
+					AccessTokenTTL:  time.Hour,
+					RefreshTokenTTL: time.Minute,
+				},
+			},
+			expectedError: "auth.refresh_token_ttl must be greater than auth.access_token_ttl",
+		},
+		{
+			name: "admins without verification",
+			config: &Config{
+				Server: ServerConfig{
+					GRPCPort: ":50051",
+					HTTPPort: ":8080",
+				},
+				Client: ClientConfig{
+					EmailService: EmailServiceConfig{
+						Address:       "email-service:50052",
+						Timeout:       5 * time.Second,
+						RetryAttempts: 3,
+						RetryDelay:    1 * time.Second,
+						QueueSize:     1000,
+					},
+				},
+				Monitor: MonitorConfig{
+					MetricsPort: ":9101",
+				},
+				Auth: AuthConfig{
+					Secret:          "secret",
+					AccessTokenTTL:  time.Minute,
+					RefreshTokenTTL: time.Hour,
+					Admins:          []string{"admin@example.com"},
+				},
+			},
+			expectedError: "auth.admins requires verification.secret, as only verified addresses become admins",
+		},
+		{
+			name: "password resets not rate limited",
+			config: &Config{
+				Server: ServerConfig{
+					GRPCPort: ":50051",
+					HTTPPort: ":8080",
+				},
+				Client: ClientConfig{
+					EmailService: EmailServiceConfig{
+						Address:       "email-service:50052",
+						Timeout:       5 * time.Second,
+						RetryAttempts: 3,
+						RetryDelay:    1 * time.Second,
+						QueueSize:     1000,
+					},
+				},
+				Monitor: MonitorConfig{
+					MetricsPort: ":9101",
+				},
+				Reset: PasswordResetConfig{
+					TTL:    time.Hour,
+					Window: time.Hour,
+				},
+			},
+			expectedError: "password_reset.max_requests must be greater than 0",
+		},
+		{
+			name: "tls certificate without key",
+			config: &Config{
+				Server: ServerConfig{
+					GRPCPort: ":50051",
+					HTTPPort: ":8080",
+				},
+				Client: ClientConfig{
+					EmailService: EmailServiceConfig{
+						Address:       "email-service:50052",
+						Timeout:       5 * time.Second,
+						RetryAttempts: 3,
+						RetryDelay:    1 * time.Second,
+						QueueSize:     1000,
+					},
+				},
+				Monitor: MonitorConfig{
+					MetricsPort: ":9101",
+				},
+				Reset: PasswordResetConfig{
+					TTL:         time.Hour,
+					MaxRequests: 3,
+					Window:      time.Hour,
+				},
+				TLS: TLSConfig{
+					Config: mtls.Config{CertFile: "/etc/tls/cert.pem", ReloadInterval: time.Minute},
+				},
+			},
+			expectedError: "tls.cert_file and tls.key_file must be set together",
+		},
 	}
 
 	for _, tt := range tests {
@@ -256,6 +754,7 @@ func TestSetDefaultConfig(t *testing.T) {
 	assert.Equal(t, "info", viper.GetString("logger.level"))
 	assert.Equal(t, "json", viper.GetString("logger.encoding"))
 	assert.Equal(t, "stdout", viper.GetString("logger.output_path"))
+	assert.Equal(t, "30s", viper.GetString("tls.reload_interval"))
 	assert.Equal(t, "user-service", viper.GetString("trace.service_name"))
 	assert.Equal(t, "1.0.0", viper.GetString("trace.version"))
 	assert.Equal(t, "http://jaeger:14268/api/traces", viper.GetString("trace.jaeger_url"))
@@ -15,6 +15,24 @@ type Email struct {
 	CreatedAt time.Time
 	SentAt    *time.Time
 	Status    EmailStatus
+	// Tracking, when set, asks the email service to record opens or clicks
+	Tracking *EmailTracking
+	// Category is the mailing category recipients can unsubscribe from
+	Category string
+	// UnsubscribeURL is the one-click unsubscribe endpoint for the recipient
+	UnsubscribeURL string
+	// Tenant the email is sent on behalf of
+	Tenant string
+	Tags   []string
+	// Sensitive emails carry secrets, such as reset codes, and are never
+	// persisted when the queue shuts down
+	Sensitive bool
+}
+
+// EmailTracking selects which engagement events are recorded for an email
+type EmailTracking struct {
+	Opens  bool
+	Clicks bool
 }
 
 // EmailStatus represents the status of an email
@@ -0,0 +1,23 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrInvalidResetToken = errors.New("invalid or expired password reset token")
+	// ErrResetRateLimited is returned when an address asked for too many resets
+	ErrResetRateLimited = errors.New("too many password reset requests")
+	// ErrPasswordResetDisabled is returned when there is no way to send reset emails
+	ErrPasswordResetDisabled = errors.New("password reset is not enabled")
+)
+
+// PasswordReset is an outstanding reset request. Only the SHA-256 of the
+// token is stored, so a leaked store does not leak usable tokens.
+type PasswordReset struct {
+	TokenHash []byte
+	UserID    string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
@@ -1,11 +1,47 @@
 package domain
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
+// UserRepository stores users. Email addresses are unique after
+// NormalizeAddress; Create and Update fail with ErrEmailTaken otherwise.
+// Update and Delete compare versions: they fail with ErrVersionConflict
+// unless the stored version matches the one given, where 0 matches any.
 type UserRepository interface {
 	Create(ctx context.Context, user *User) error
 	GetByID(ctx context.Context, id string) (*User, error)
+	GetByEmail(ctx context.Context, email string) (*User, error)
 	Update(ctx context.Context, user *User) error
-	Delete(ctx context.Context, id string) error
+	Delete(ctx context.Context, id string, version int64) error
 	List(ctx context.Context, pageSize int, pageToken string) ([]*User, string, error)
 }
+
+// SubscriptionRepository keeps a per-category opt-out list of addresses
+type SubscriptionRepository interface {
+	OptOut(ctx context.Context, category, address string) error
+	OptIn(ctx context.Context, category, address string) error
+	IsOptedOut(ctx context.Context, category, address string) (bool, error)
+}
+
+// SessionRepository stores login sessions. Rotate swaps the refresh token
+// hash only if the stored one still equals oldHash, so a refresh token can
+// be redeemed once even under concurrent requests.
+type SessionRepository interface {
+	Create(ctx context.Context, session *Session) error
+	Get(ctx context.Context, id string) (*Session, error)
+	Rotate(ctx context.Context, id string, oldHash, newHash []byte, expiresAt time.Time) error
+	Delete(ctx context.Context, id string) error
+	// DeleteByUser ends every session of a user except the one named by keep
+	DeleteByUser(ctx context.Context, userID, keep string) error
+}
+
+// PasswordResetRepository stores outstanding password resets, at most one
+// per user: Create replaces the user's previous reset. Consume removes and
+// returns a reset, so each token works once; unknown tokens fail with
+// ErrInvalidResetToken.
+type PasswordResetRepository interface {
+	Create(ctx context.Context, reset *PasswordReset) error
+	Consume(ctx context.Context, tokenHash []byte) (*PasswordReset, error)
+}
@@ -1,9 +1,38 @@
 package domain
 
-import "context"
+import (
+	"context"
+
+	"github.com/popeskul/mailflow/common/auth"
+)
 
 type UserService interface {
-	Create(ctx context.Context, email, username string) (*User, error)
+	Create(ctx context.Context, email, username, password string) (*User, error)
 	Get(ctx context.Context, id string) (*User, error)
+	GetByEmail(ctx context.Context, email string) (*User, error)
+	Update(ctx context.Context, id string, update UserUpdate) (*User, error)
+	Delete(ctx context.Context, id, etag string) error
+	VerifyEmail(ctx context.Context, token string) (*User, error)
+	ResendVerification(ctx context.Context, email string) error
 	List(ctx context.Context, pageSize int, pageToken string) ([]*User, string, error)
 }
+
+type SubscriptionService interface {
+	GetPreferences(ctx context.Context, userID string) ([]CategoryPreference, error)
+	UpdatePreferences(ctx context.Context, userID string, prefs []CategoryPreference) ([]CategoryPreference, error)
+	Unsubscribe(ctx context.Context, address, category string) error
+}
+
+type AuthService interface {
+	Login(ctx context.Context, email, password string) (*User, *TokenPair, error)
+	Refresh(ctx context.Context, refreshToken string) (*TokenPair, error)
+	Logout(ctx context.Context, sessionID string) error
+	ChangePassword(ctx context.Context, userID, sessionID, currentPassword, newPassword string) error
+	// Authenticate checks an access token and returns who it was issued to
+	Authenticate(ctx context.Context, accessToken string) (*auth.Principal, error)
+}
+
+type PasswordResetService interface {
+	RequestPasswordReset(ctx context.Context, email string) error
+	ConfirmPasswordReset(ctx context.Context, token, newPassword string) error
+}
@@ -0,0 +1,35 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// ErrInvalidCredentials is returned for unknown users and wrong
+	// passwords alike, so logins do not reveal which addresses exist
+	ErrInvalidCredentials  = errors.New("invalid email or password")
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	ErrSessionNotFound     = errors.New("session not found")
+	// ErrAuthDisabled is returned when no token signing secret is configured
+	ErrAuthDisabled = errors.New("authentication is not enabled")
+)
+
+// Session is a login. It lives as long as its refresh token keeps being
+// rotated, and ends on logout, password change or refresh token reuse.
+type Session struct {
+	ID     string
+	UserID string
+	// RefreshTokenHash is the SHA-256 of the current refresh token secret
+	RefreshTokenHash []byte
+	CreatedAt        time.Time
+	ExpiresAt        time.Time
+}
+
+// TokenPair is what a login or refresh hands back to the client
+type TokenPair struct {
+	AccessToken           string
+	AccessTokenExpiresAt  time.Time
+	RefreshToken          string
+	RefreshTokenExpiresAt time.Time
+}
@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+)
+
+// Mailing categories recipients can unsubscribe from
+const (
+	CategoryOnboarding     = "onboarding"
+	CategoryNewsletter     = "newsletter"
+	CategoryProductUpdates = "product_updates"
+)
+
+// Categories lists every known mailing category
+var Categories = []string{
+	CategoryOnboarding,
+	CategoryNewsletter,
+	CategoryProductUpdates,
+}
+
+var ErrUnknownCategory = errors.New("unknown subscription category")
+
+// CategoryPreference tells whether a user receives mail of a category
+type CategoryPreference struct {
+	Category   string
+	Subscribed bool
+}
+
+func IsValidCategory(category string) bool {
+	for _, c := range Categories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
+
+// NormalizeAddress returns the form email addresses are compared in
+func NormalizeAddress(address string) string {
+	return strings.ToLower(strings.TrimSpace(address))
+}
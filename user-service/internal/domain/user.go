@@ -1,17 +1,58 @@
 package domain
 
 import (
+	"errors"
+	"fmt"
+	"strconv"
 	"time"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
 )
 
+var (
+	ErrUserNotFound = errors.New("user not found")
+	// ErrEmailTaken is returned when another user already has the address
+	ErrEmailTaken = errors.New("email address already registered")
+	// ErrVersionConflict is returned when a user changed since the caller read it
+	ErrVersionConflict = errors.New("user was modified concurrently")
+	ErrInvalidETag     = errors.New("invalid etag")
+	// ErrVerificationDisabled is returned when no verification secret is configured
+	ErrVerificationDisabled = errors.New("email verification is not enabled")
+	// ErrResendRateLimited is returned when an address asked for too many
+	// verification emails
+	ErrResendRateLimited = errors.New("too many verification email requests")
+	ErrWeakPassword      = fmt.Errorf("password must be between %d and %d characters", MinPasswordLength, MaxPasswordLength)
+)
+
+// Password length limits. The maximum bounds the work a login costs.
+const (
+	MinPasswordLength = 8
+	MaxPasswordLength = 128
+)
+
 type User struct {
 	ID        string
 	Email     string
 	Name      string
 	CreatedAt time.Time
 	UpdatedAt time.Time
+	// Verified is set once the user follows the link sent to Email
+	Verified   bool
+	VerifiedAt *time.Time
+	// PasswordHash is an argon2id or bcrypt hash; empty when the user
+	// has no password and cannot log in
+	PasswordHash string
+	// Version is bumped by the repository on every write, starting at 1
+	Version int64
+}
+
+// UserUpdate describes a partial update; nil fields are left unchanged
+type UserUpdate struct {
+	Email *string
+	Name  *string
+	// ETag, when set, must match the stored user for the update to apply
+	ETag string
 }
 
 func NewUser(email, name string) *User {
@@ -24,3 +65,29 @@ func NewUser(email, name string) *User {
 		UpdatedAt: now,
 	}
 }
+
+// ETag identifies this revision of the user for optimistic concurrency
+func (u *User) ETag() string {
+	return strconv.FormatInt(u.Version, 10)
+}
+
+// VersionFromETag returns the version an etag refers to. An empty etag
+// yields 0, which repositories treat as "any version".
+func VersionFromETag(etag string) (int64, error) {
+	if etag == "" {
+		return 0, nil
+	}
+	version, err := strconv.ParseInt(etag, 10, 64)
+	if err != nil || version <= 0 {
+		return 0, ErrInvalidETag
+	}
+	return version, nil
+}
+
+// ValidatePassword checks a new password against the length limits
+func ValidatePassword(password string) error {
+	if n := utf8.RuneCountInString(password); n < MinPasswordLength || n > MaxPasswordLength {
+		return ErrWeakPassword
+	}
+	return nil
+}
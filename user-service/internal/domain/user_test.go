@@ -249,3 +249,33 @@ func TestUser_Validation_Success(t *testing.T) {
 		})
 	}
 }
+
+func TestVersionFromETag_Success(t *testing.T) {
+	tests := []struct {
+		name     string
+		etag     string
+		expected int64
+	}{
+		{name: "empty etag matches any version", etag: "", expected: 0},
+		{name: "round trip", etag: (&User{Version: 7}).ETag(), expected: 7},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			version, err := VersionFromETag(tt.etag)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, version)
+		})
+	}
+}
+
+func TestVersionFromETag_Fail(t *testing.T) {
+	for _, etag := range []string{"abc", "0", "-1"} {
+		t.Run(etag, func(t *testing.T) {
+			_, err := VersionFromETag(etag)
+
+			assert.ErrorIs(t, err, ErrInvalidETag)
+		})
+	}
+}
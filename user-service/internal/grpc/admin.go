@@ -0,0 +1,128 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/popeskul/mailflow/common/auth"
+	"github.com/popeskul/mailflow/common/logger"
+	"github.com/popeskul/mailflow/user-service/internal/circuitbreaker"
+	adminv1 "github.com/popeskul/mailflow/user-service/pkg/api/admin/v1"
+)
+
+// AdminServer lets admins inspect and override the circuit breakers in a
+// registry during incidents
+type AdminServer struct {
+	adminv1.UnimplementedAdminServiceServer
+	breakers *circuitbreaker.Registry
+	logger   logger.Logger
+}
+
+func NewAdminServer(breakers *circuitbreaker.Registry, l logger.Logger) *AdminServer {
+	return &AdminServer{
+		breakers: breakers,
+		logger:   l.Named("admin_server"),
+	}
+}
+
+func (s *AdminServer) ListCircuitBreakers(
+	_ context.Context,
+	_ *adminv1.ListCircuitBreakersRequest,
+) (*adminv1.ListCircuitBreakersResponse, error) {
+	snapshots := s.breakers.List()
+
+	breakers := make([]*adminv1.CircuitBreaker, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		breakers = append(breakers, toProtoCircuitBreaker(snapshot))
+	}
+
+	return &adminv1.ListCircuitBreakersResponse{CircuitBreakers: breakers}, nil
+}
+
+func (s *AdminServer) ForceOpenCircuitBreaker(
+	ctx context.Context,
+	req *adminv1.ForceOpenCircuitBreakerRequest,
+) (*adminv1.ForceOpenCircuitBreakerResponse, error) {
+	snapshot, err := s.override(ctx, "force_open", req.GetName(), s.breakers.ForceOpen)
+	if err != nil {
+		return nil, err
+	}
+
+	return &adminv1.ForceOpenCircuitBreakerResponse{CircuitBreaker: toProtoCircuitBreaker(snapshot)}, nil
+}
+
+func (s *AdminServer) ForceCloseCircuitBreaker(
+	ctx context.Context,
+	req *adminv1.ForceCloseCircuitBreakerRequest,
+) (*adminv1.ForceCloseCircuitBreakerResponse, error) {
+	snapshot, err := s.override(ctx, "force_close", req.GetName(), s.breakers.ForceClose)
+	if err != nil {
+		return nil, err
+	}
+
+	return &adminv1.ForceCloseCircuitBreakerResponse{CircuitBreaker: toProtoCircuitBreaker(snapshot)}, nil
+}
+
+func (s *AdminServer) ResetCircuitBreaker(
+	ctx context.Context,
+	req *adminv1.ResetCircuitBreakerRequest,
+) (*adminv1.ResetCircuitBreakerResponse, error) {
+	snapshot, err := s.override(ctx, "reset", req.GetName(), s.breakers.Reset)
+	if err != nil {
+		return nil, err
+	}
+
+	return &adminv1.ResetCircuitBreakerResponse{CircuitBreaker: toProtoCircuitBreaker(snapshot)}, nil
+}
+
+// override applies action to the named breaker and logs who did it, as
+// manual overrides are worth finding in the logs after an incident
+func (s *AdminServer) override(
+	ctx context.Context,
+	action, name string,
+	apply func(string) (circuitbreaker.Snapshot, error),
+) (circuitbreaker.Snapshot, error) {
+	if name == "" {
+		return circuitbreaker.Snapshot{}, status.Error(codes.InvalidArgument, "circuit breaker name is required")
+	}
+
+	snapshot, err := apply(name)
+	if err != nil {
+		if errors.Is(err, circuitbreaker.ErrUnknownBreaker) {
+			return snapshot, status.Errorf(codes.NotFound, "circuit breaker %q not found", name)
+		}
+		return snapshot, status.Error(codes.Internal, "failed to update circuit breaker")
+	}
+
+	var caller string
+	if principal, ok := auth.FromContext(ctx); ok {
+		caller = principal.UserID
+	}
+	s.logger.Warn("circuit breaker overridden",
+		logger.Field{Key: "circuit_breaker", Value: name},
+		logger.Field{Key: "action", Value: action},
+		logger.Field{Key: "state", Value: snapshot.State},
+		logger.Field{Key: "caller", Value: caller},
+	)
+
+	return snapshot, nil
+}
+
+func toProtoCircuitBreaker(snapshot circuitbreaker.Snapshot) *adminv1.CircuitBreaker {
+	result := &adminv1.CircuitBreaker{
+		Name:             snapshot.Name,
+		State:            snapshot.State,
+		Forced:           snapshot.Forced,
+		Failures:         int32(snapshot.Failures),
+		Successes:        int32(snapshot.Successes),
+		HalfOpenRequests: int32(snapshot.HalfOpenReqs),
+	}
+	if !snapshot.LastFailureTime.IsZero() {
+		result.LastFailureTime = snapshot.LastFailureTime.Format(time.RFC3339)
+	}
+	return result
+}
@@ -0,0 +1,51 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/popeskul/mailflow/common/auth"
+	"github.com/popeskul/mailflow/user-service/internal/domain"
+)
+
+// Authenticator checks access tokens
+type Authenticator interface {
+	Authenticate(ctx context.Context, accessToken string) (*auth.Principal, error)
+}
+
+// AuthInterceptor requires a valid bearer access token for every method
+// but the policy's public ones, checks the caller's role against policy
+// and puts the caller's principal into the context.
+func AuthInterceptor(authenticator Authenticator, policy auth.Policy) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		var principal *auth.Principal
+		if rule, ok := policy[info.FullMethod]; !ok || !rule.Public {
+			token, ok := auth.BearerToken(ctx)
+			if !ok {
+				return nil, status.Error(codes.Unauthenticated, "missing access token")
+			}
+
+			var err error
+			principal, err = authenticator.Authenticate(ctx, token)
+			switch {
+			case errors.Is(err, auth.ErrTokenExpired):
+				return nil, status.Error(codes.Unauthenticated, "access token expired")
+			case errors.Is(err, auth.ErrInvalidToken), errors.Is(err, domain.ErrSessionNotFound):
+				return nil, status.Error(codes.Unauthenticated, "invalid access token")
+			case err != nil:
+				return nil, status.Error(codes.Internal, "failed to authenticate")
+			}
+			ctx = auth.NewContext(ctx, principal)
+		}
+
+		if err := policy.Authorize(info.FullMethod, principal, req); err != nil {
+			return nil, status.Error(codes.PermissionDenied, "permission denied")
+		}
+
+		return handler(ctx, req)
+	}
+}
@@ -0,0 +1,149 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthgrpc "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/popeskul/mailflow/common/auth"
+	"github.com/popeskul/mailflow/user-service/internal/domain"
+	adminv1 "github.com/popeskul/mailflow/user-service/pkg/api/admin/v1"
+	pb "github.com/popeskul/mailflow/user-service/pkg/api/user/v1"
+)
+
+// ownerID is the user every request in the tests is about
+const ownerID = "user-1"
+
+// fakeAuthenticator maps access tokens to principals or errors
+type fakeAuthenticator map[string]any
+
+func (f fakeAuthenticator) Authenticate(_ context.Context, token string) (*auth.Principal, error) {
+	switch v := f[token].(type) {
+	case *auth.Principal:
+		return v, nil
+	case error:
+		return nil, v
+	default:
+		return nil, auth.ErrInvalidToken
+	}
+}
+
+var authenticator = fakeAuthenticator{
+	"admin":   &auth.Principal{UserID: "admin-1", SessionID: "s-admin", Role: auth.RoleAdmin},
+	"service": &auth.Principal{UserID: "email-service", Role: auth.RoleService},
+	"owner":   &auth.Principal{UserID: ownerID, SessionID: "s-owner", Role: auth.RoleUser},
+	"other":   &auth.Principal{UserID: "user-2", SessionID: "s-other", Role: auth.RoleUser},
+	"expired": auth.ErrTokenExpired,
+	"revoked": domain.ErrSessionNotFound,
+	"broken":  errors.New("database error"),
+}
+
+// callAs calls method through the interceptor with token as the bearer
+// token, or without one when token is empty
+func callAs(interceptor grpc.UnaryServerInterceptor, method, token string, req any) (*auth.Principal, error) {
+	ctx := context.Background()
+	if token != "" {
+		ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", "Bearer "+token))
+	}
+
+	var principal *auth.Principal
+	_, err := interceptor(ctx, req, &grpc.UnaryServerInfo{FullMethod: method}, func(ctx context.Context, _ any) (any, error) {
+		principal, _ = auth.FromContext(ctx)
+		return "ok", nil
+	})
+	return principal, err
+}
+
+func TestAuthInterceptor_Policy(t *testing.T) {
+	const (
+		OK     = codes.OK
+		Unauth = codes.Unauthenticated
+		Denied = codes.PermissionDenied
+	)
+
+	// Each method is called by every kind of caller: no token, an admin, a
+	// service, the user the request is about and another user
+	tests := []struct {
+		method string
+		req    any
+		none   codes.Code
+		admin  codes.Code
+		svc    codes.Code
+		owner  codes.Code
+		other  codes.Code
+	}{
+		{pb.UserService_CreateUser_FullMethodName, &pb.CreateUserRequest{}, OK, OK, OK, OK, OK},
+		{pb.UserService_Login_FullMethodName, &pb.LoginRequest{}, OK, OK, OK, OK, OK},
+		{pb.UserService_RequestPasswordReset_FullMethodName, &pb.RequestPasswordResetRequest{}, OK, OK, OK, OK, OK},
+		{pb.UserService_GetUser_FullMethodName, &pb.GetUserRequest{Id: ownerID}, Unauth, OK, OK, OK, Denied},
+		{pb.UserService_GetUserByEmail_FullMethodName, &pb.GetUserByEmailRequest{}, Unauth, OK, OK, Denied, Denied},
+		{pb.UserService_ListUsers_FullMethodName, &pb.ListUsersRequest{}, Unauth, OK, OK, Denied, Denied},
+		{pb.UserService_UpdateUser_FullMethodName, &pb.UpdateUserRequest{User: &pb.User{Id: ownerID}}, Unauth, OK, Denied, OK, Denied},
+		{pb.UserService_DeleteUser_FullMethodName, &pb.DeleteUserRequest{Id: ownerID}, Unauth, OK, Denied, OK, Denied},
+		{pb.UserService_Logout_FullMethodName, &pb.LogoutRequest{}, Unauth, OK, Denied, OK, OK},
+		{pb.UserService_ChangePassword_FullMethodName, &pb.ChangePasswordRequest{}, Unauth, OK, Denied, OK, OK},
+		{pb.UserService_GetSubscriptionPreferences_FullMethodName, &pb.GetSubscriptionPreferencesRequest{UserId: ownerID}, Unauth, OK, Denied, OK, Denied},
+		{pb.UserService_UpdateSubscriptionPreferences_FullMethodName, &pb.UpdateSubscriptionPreferencesRequest{UserId: ownerID}, Unauth, OK, Denied, OK, Denied},
+		{adminv1.AdminService_ListCircuitBreakers_FullMethodName, &adminv1.ListCircuitBreakersRequest{}, Unauth, OK, Denied, Denied, Denied},
+		{adminv1.AdminService_ForceOpenCircuitBreaker_FullMethodName, &adminv1.ForceOpenCircuitBreakerRequest{}, Unauth, OK, Denied, Denied, Denied},
+		{healthgrpc.Health_Check_FullMethodName, nil, OK, OK, OK, OK, OK},
+		{"/user.v1.UserService/Unknown", nil, Unauth, Denied, Denied, Denied, Denied},
+	}
+
+	interceptor := AuthInterceptor(authenticator, Policy)
+	for _, tt := range tests {
+		callers := []struct {
+			token string
+			want  codes.Code
+		}{
+			{"", tt.none},
+			{"admin", tt.admin},
+			{"service", tt.svc},
+			{"owner", tt.owner},
+			{"other", tt.other},
+		}
+		for _, caller := range callers {
+			name := caller.token
+			if name == "" {
+				name = "anonymous"
+			}
+			t.Run(tt.method+"/"+name, func(t *testing.T) {
+				principal, err := callAs(interceptor, tt.method, caller.token, tt.req)
+
+				assert.Equal(t, caller.want, status.Code(err))
+				if err == nil && caller.token != "" && !Policy[tt.method].Public {
+					assert.Equal(t, authenticator[caller.token], principal, "handler sees the caller")
+				}
+			})
+		}
+	}
+}
+
+func TestAuthInterceptor_Tokens(t *testing.T) {
+	tests := []struct {
+		name  string
+		token string
+		code  codes.Code
+	}{
+		{name: "expired token", token: "expired", code: codes.Unauthenticated},
+		{name: "ended session", token: "revoked", code: codes.Unauthenticated},
+		{name: "invalid token", token: "forged", code: codes.Unauthenticated},
+		{name: "authenticator failure", token: "broken", code: codes.Internal},
+	}
+
+	interceptor := AuthInterceptor(authenticator, Policy)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := callAs(interceptor, pb.UserService_GetUser_FullMethodName, tt.token, &pb.GetUserRequest{Id: ownerID})
+
+			assert.Equal(t, tt.code, status.Code(err))
+		})
+	}
+}
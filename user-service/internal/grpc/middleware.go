@@ -2,6 +2,7 @@ package grpc
 
 import (
 	"context"
+	"runtime/debug"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -10,6 +11,8 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+
+	"github.com/popeskul/mailflow/common/logger"
 )
 
 // LoggingInterceptor logs gRPC requests
@@ -57,8 +60,9 @@ func MetricsInterceptor(registry *prometheus.Registry) grpc.UnaryServerIntercept
 	}
 }
 
-// RecoveryInterceptor recovers from panics in gRPC handlers
-func RecoveryInterceptor(logger *zap.Logger) grpc.UnaryServerInterceptor {
+// RecoveryInterceptor turns a panic in a gRPC handler into an Internal
+// error, so one bad request cannot take the server down
+func RecoveryInterceptor(l logger.Logger) grpc.UnaryServerInterceptor {
 	return func(
 		ctx context.Context,
 		req interface{},
@@ -67,9 +71,10 @@ func RecoveryInterceptor(logger *zap.Logger) grpc.UnaryServerInterceptor {
 	) (resp interface{}, err error) {
 		defer func() {
 			if r := recover(); r != nil {
-				logger.Error("gRPC panic recovered",
-					zap.String("method", info.FullMethod),
-					zap.Any("panic", r),
+				l.Error("gRPC panic recovered",
+					logger.Field{Key: "method", Value: info.FullMethod},
+					logger.Field{Key: "panic", Value: r},
+					logger.Field{Key: "stack", Value: string(debug.Stack())},
 				)
 				err = status.Errorf(codes.Internal, "internal server error")
 			}
@@ -0,0 +1,75 @@
+package grpc
+
+import (
+	healthgrpc "google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/popeskul/mailflow/common/auth"
+	adminv1 "github.com/popeskul/mailflow/user-service/pkg/api/admin/v1"
+	healthpb "github.com/popeskul/mailflow/user-service/pkg/api/health"
+	pb "github.com/popeskul/mailflow/user-service/pkg/api/user/v1"
+)
+
+// Policy says who may call each method. Users may only read and change
+// their own account, admins any account; services may look users up.
+var Policy = auth.Policy{
+	pb.UserService_CreateUser_FullMethodName:           {Public: true},
+	pb.UserService_Login_FullMethodName:                {Public: true},
+	pb.UserService_RefreshToken_FullMethodName:         {Public: true},
+	pb.UserService_VerifyEmail_FullMethodName:          {Public: true},
+	pb.UserService_ResendVerification_FullMethodName:   {Public: true},
+	pb.UserService_RequestPasswordReset_FullMethodName: {Public: true},
+	pb.UserService_ConfirmPasswordReset_FullMethodName: {Public: true},
+
+	pb.UserService_GetUser_FullMethodName: {
+		Roles: []auth.Role{auth.RoleAdmin, auth.RoleService},
+		Owner: ownedBy(func(r *pb.GetUserRequest) string { return r.GetId() }),
+	},
+	pb.UserService_GetUserByEmail_FullMethodName: {Roles: []auth.Role{auth.RoleAdmin, auth.RoleService}},
+	pb.UserService_ListUsers_FullMethodName:      {Roles: []auth.Role{auth.RoleAdmin, auth.RoleService}},
+	pb.UserService_UpdateUser_FullMethodName: {
+		Roles: []auth.Role{auth.RoleAdmin},
+		Owner: ownedBy(func(r *pb.UpdateUserRequest) string { return r.GetUser().GetId() }),
+	},
+	pb.UserService_DeleteUser_FullMethodName: {
+		Roles: []auth.Role{auth.RoleAdmin},
+		Owner: ownedBy(func(r *pb.DeleteUserRequest) string { return r.GetId() }),
+	},
+
+	// Session methods act on the caller's own session, which services lack
+	pb.UserService_Logout_FullMethodName:         {Roles: []auth.Role{auth.RoleAdmin, auth.RoleUser}},
+	pb.UserService_ChangePassword_FullMethodName: {Roles: []auth.Role{auth.RoleAdmin, auth.RoleUser}},
+
+	pb.UserService_GetSubscriptionPreferences_FullMethodName: {
+		Roles: []auth.Role{auth.RoleAdmin},
+		Owner: ownedBy(func(r *pb.GetSubscriptionPreferencesRequest) string { return r.GetUserId() }),
+	},
+	pb.UserService_UpdateSubscriptionPreferences_FullMethodName: {
+		Roles: []auth.Role{auth.RoleAdmin},
+		Owner: ownedBy(func(r *pb.UpdateSubscriptionPreferencesRequest) string { return r.GetUserId() }),
+	},
+
+	// Overriding circuit breakers is for incident response
+	adminv1.AdminService_ListCircuitBreakers_FullMethodName:      {Roles: []auth.Role{auth.RoleAdmin}},
+	adminv1.AdminService_ForceOpenCircuitBreaker_FullMethodName:  {Roles: []auth.Role{auth.RoleAdmin}},
+	adminv1.AdminService_ForceCloseCircuitBreaker_FullMethodName: {Roles: []auth.Role{auth.RoleAdmin}},
+	adminv1.AdminService_ResetCircuitBreaker_FullMethodName:      {Roles: []auth.Role{auth.RoleAdmin}},
+
+	// Probes come from load balancers and orchestrators, which have no token
+	healthpb.HealthService_Check_FullMethodName:     {Public: true},
+	healthpb.HealthService_Liveness_FullMethodName:  {Public: true},
+	healthpb.HealthService_Readiness_FullMethodName: {Public: true},
+	healthpb.HealthService_Healthz_FullMethodName:   {Public: true},
+	healthgrpc.Health_Check_FullMethodName:          {Public: true},
+	healthgrpc.Health_Watch_FullMethodName:          {Public: true},
+}
+
+// ownedBy adapts a getter for the user a request is about to auth.Rule.Owner
+func ownedBy[T any](userID func(T) string) func(any) string {
+	return func(req any) string {
+		r, ok := req.(T)
+		if !ok {
+			return ""
+		}
+		return userID(r)
+	}
+}
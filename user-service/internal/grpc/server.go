@@ -2,30 +2,43 @@ package grpc
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
+	"github.com/popeskul/mailflow/common/auth"
 	"github.com/popeskul/mailflow/common/logger"
+	"github.com/popeskul/mailflow/common/pagination"
 	"github.com/popeskul/mailflow/user-service/internal/domain"
+	"github.com/popeskul/mailflow/user-service/internal/verification"
 	pb "github.com/popeskul/mailflow/user-service/pkg/api/user/v1"
 )
 
 type Services interface {
 	User() domain.UserService
+	Subscriptions() domain.SubscriptionService
+	Auth() domain.AuthService
+	PasswordResets() domain.PasswordResetService
 }
 
 type UserServer struct {
 	pb.UnimplementedUserServiceServer
-	userService domain.UserService
-	logger      logger.Logger
+	userService         domain.UserService
+	subscriptionService domain.SubscriptionService
+	authService         domain.AuthService
+	resetService        domain.PasswordResetService
+	logger              logger.Logger
 }
 
 func NewUserServer(userService Services, logger logger.Logger) *UserServer {
 	return &UserServer{
-		userService: userService.User(),
-		logger:      logger.Named("user_server"),
+		userService:         userService.User(),
+		subscriptionService: userService.Subscriptions(),
+		authService:         userService.Auth(),
+		resetService:        userService.PasswordResets(),
+		logger:              logger.Named("user_server"),
 	}
 }
 
@@ -34,9 +47,9 @@ func (s *UserServer) CreateUser(ctx context.Context, req *pb.CreateUserRequest)
 		return nil, status.Error(codes.InvalidArgument, "email and username are required")
 	}
 
-	user, err := s.userService.Create(ctx, req.GetEmail(), req.GetUsername())
+	user, err := s.userService.Create(ctx, req.GetEmail(), req.GetUsername(), req.GetPassword())
 	if err != nil {
-		return nil, status.Error(codes.Internal, "failed to create user")
+		return nil, userStatus(err, "failed to create user")
 	}
 
 	return &pb.CreateUserResponse{
@@ -60,9 +73,27 @@ func (s *UserServer) GetUser(ctx context.Context, req *pb.GetUserRequest) (*pb.G
 	}, nil
 }
 
+func (s *UserServer) GetUserByEmail(ctx context.Context, req *pb.GetUserByEmailRequest) (*pb.GetUserByEmailResponse, error) {
+	if req.GetEmail() == "" {
+		return nil, status.Error(codes.InvalidArgument, "email is required")
+	}
+
+	user, err := s.userService.GetByEmail(ctx, req.GetEmail())
+	if err != nil {
+		return nil, userStatus(err, "failed to get user")
+	}
+
+	return &pb.GetUserByEmailResponse{
+		User: toProtoUser(user),
+	}, nil
+}
+
 func (s *UserServer) ListUsers(ctx context.Context, req *pb.ListUsersRequest) (*pb.ListUsersResponse, error) {
 	users, nextPageToken, err := s.userService.List(ctx, int(req.GetPageSize()), req.GetPageToken())
 	if err != nil {
+		if errors.Is(err, pagination.ErrInvalidToken) {
+			return nil, status.Error(codes.InvalidArgument, "invalid page token")
+		}
 		return nil, status.Error(codes.Internal, "failed to list users")
 	}
 
@@ -77,11 +108,320 @@ func (s *UserServer) ListUsers(ctx context.Context, req *pb.ListUsersRequest) (*
 	}, nil
 }
 
+func (s *UserServer) UpdateUser(ctx context.Context, req *pb.UpdateUserRequest) (*pb.UpdateUserResponse, error) {
+	user := req.GetUser()
+	if user.GetId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "user id is required")
+	}
+
+	update, err := toUserUpdate(user, req.GetUpdateMask().GetPaths())
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := s.userService.Update(ctx, user.GetId(), update)
+	if err != nil {
+		return nil, userStatus(err, "failed to update user")
+	}
+
+	return &pb.UpdateUserResponse{
+		User: toProtoUser(updated),
+	}, nil
+}
+
+func (s *UserServer) DeleteUser(ctx context.Context, req *pb.DeleteUserRequest) (*pb.DeleteUserResponse, error) {
+	if req.GetId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "user id is required")
+	}
+
+	if err := s.userService.Delete(ctx, req.GetId(), req.GetEtag()); err != nil {
+		return nil, userStatus(err, "failed to delete user")
+	}
+
+	return &pb.DeleteUserResponse{}, nil
+}
+
+func (s *UserServer) VerifyEmail(ctx context.Context, req *pb.VerifyEmailRequest) (*pb.VerifyEmailResponse, error) {
+	if req.GetToken() == "" {
+		return nil, status.Error(codes.InvalidArgument, "token is required")
+	}
+
+	user, err := s.userService.VerifyEmail(ctx, req.GetToken())
+	if err != nil {
+		return nil, userStatus(err, "failed to verify email")
+	}
+
+	return &pb.VerifyEmailResponse{
+		User: toProtoUser(user),
+	}, nil
+}
+
+func (s *UserServer) ResendVerification(
+	ctx context.Context,
+	req *pb.ResendVerificationRequest,
+) (*pb.ResendVerificationResponse, error) {
+	if req.GetEmail() == "" {
+		return nil, status.Error(codes.InvalidArgument, "email is required")
+	}
+
+	if err := s.userService.ResendVerification(ctx, req.GetEmail()); err != nil {
+		return nil, userStatus(err, "failed to resend verification email")
+	}
+
+	return &pb.ResendVerificationResponse{}, nil
+}
+
+func (s *UserServer) Login(ctx context.Context, req *pb.LoginRequest) (*pb.LoginResponse, error) {
+	if req.GetEmail() == "" || req.GetPassword() == "" {
+		return nil, status.Error(codes.InvalidArgument, "email and password are required")
+	}
+
+	user, tokens, err := s.authService.Login(ctx, req.GetEmail(), req.GetPassword())
+	if err != nil {
+		return nil, userStatus(err, "failed to log in")
+	}
+
+	return &pb.LoginResponse{
+		Tokens: toProtoTokens(tokens),
+		User:   toProtoUser(user),
+	}, nil
+}
+
+func (s *UserServer) Logout(ctx context.Context, _ *pb.LogoutRequest) (*pb.LogoutResponse, error) {
+	principal, ok := auth.FromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "missing access token")
+	}
+
+	if err := s.authService.Logout(ctx, principal.SessionID); err != nil {
+		return nil, userStatus(err, "failed to log out")
+	}
+
+	return &pb.LogoutResponse{}, nil
+}
+
+func (s *UserServer) RefreshToken(ctx context.Context, req *pb.RefreshTokenRequest) (*pb.RefreshTokenResponse, error) {
+	if req.GetRefreshToken() == "" {
+		return nil, status.Error(codes.InvalidArgument, "refresh token is required")
+	}
+
+	tokens, err := s.authService.Refresh(ctx, req.GetRefreshToken())
+	if err != nil {
+		return nil, userStatus(err, "failed to refresh token")
+	}
+
+	return &pb.RefreshTokenResponse{
+		Tokens: toProtoTokens(tokens),
+	}, nil
+}
+
+func (s *UserServer) ChangePassword(ctx context.Context, req *pb.ChangePasswordRequest) (*pb.ChangePasswordResponse, error) {
+	principal, ok := auth.FromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "missing access token")
+	}
+	if req.GetCurrentPassword() == "" || req.GetNewPassword() == "" {
+		return nil, status.Error(codes.InvalidArgument, "current and new password are required")
+	}
+
+	err := s.authService.ChangePassword(ctx, principal.UserID, principal.SessionID, req.GetCurrentPassword(), req.GetNewPassword())
+	if err != nil {
+		return nil, userStatus(err, "failed to change password")
+	}
+
+	return &pb.ChangePasswordResponse{}, nil
+}
+
+func (s *UserServer) RequestPasswordReset(
+	ctx context.Context,
+	req *pb.RequestPasswordResetRequest,
+) (*pb.RequestPasswordResetResponse, error) {
+	if req.GetEmail() == "" {
+		return nil, status.Error(codes.InvalidArgument, "email is required")
+	}
+
+	if err := s.resetService.RequestPasswordReset(ctx, req.GetEmail()); err != nil {
+		return nil, userStatus(err, "failed to request password reset")
+	}
+
+	return &pb.RequestPasswordResetResponse{}, nil
+}
+
+func (s *UserServer) ConfirmPasswordReset(
+	ctx context.Context,
+	req *pb.ConfirmPasswordResetRequest,
+) (*pb.ConfirmPasswordResetResponse, error) {
+	if req.GetToken() == "" || req.GetNewPassword() == "" {
+		return nil, status.Error(codes.InvalidArgument, "token and new password are required")
+	}
+
+	if err := s.resetService.ConfirmPasswordReset(ctx, req.GetToken(), req.GetNewPassword()); err != nil {
+		return nil, userStatus(err, "failed to reset password")
+	}
+
+	return &pb.ConfirmPasswordResetResponse{}, nil
+}
+
+func (s *UserServer) GetSubscriptionPreferences(
+	ctx context.Context,
+	req *pb.GetSubscriptionPreferencesRequest,
+) (*pb.GetSubscriptionPreferencesResponse, error) {
+	if req.GetUserId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "user id is required")
+	}
+
+	prefs, err := s.subscriptionService.GetPreferences(ctx, req.GetUserId())
+	if err != nil {
+		return nil, status.Error(codes.NotFound, "user not found")
+	}
+
+	return &pb.GetSubscriptionPreferencesResponse{
+		Preferences: toProtoPreferences(prefs),
+	}, nil
+}
+
+func (s *UserServer) UpdateSubscriptionPreferences(
+	ctx context.Context,
+	req *pb.UpdateSubscriptionPreferencesRequest,
+) (*pb.UpdateSubscriptionPreferencesResponse, error) {
+	if req.GetUserId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "user id is required")
+	}
+
+	updates := make([]domain.CategoryPreference, 0, len(req.GetPreferences()))
+	for _, pref := range req.GetPreferences() {
+		updates = append(updates, domain.CategoryPreference{
+			Category:   pref.GetCategory(),
+			Subscribed: pref.GetSubscribed(),
+		})
+	}
+
+	prefs, err := s.subscriptionService.UpdatePreferences(ctx, req.GetUserId(), updates)
+	if err != nil {
+		if errors.Is(err, domain.ErrUnknownCategory) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		return nil, status.Error(codes.NotFound, "user not found")
+	}
+
+	return &pb.UpdateSubscriptionPreferencesResponse{
+		Preferences: toProtoPreferences(prefs),
+	}, nil
+}
+
+func toProtoPreferences(prefs []domain.CategoryPreference) []*pb.SubscriptionPreference {
+	result := make([]*pb.SubscriptionPreference, 0, len(prefs))
+	for _, pref := range prefs {
+		result = append(result, &pb.SubscriptionPreference{
+			Category:   pref.Category,
+			Subscribed: pref.Subscribed,
+		})
+	}
+	return result
+}
+
 func toProtoUser(user *domain.User) *pb.User {
-	return &pb.User{
+	result := &pb.User{
 		Id:        user.ID,
 		Email:     user.Email,
 		Username:  user.Name,
 		CreatedAt: user.CreatedAt.Format(time.RFC3339),
+		UpdatedAt: user.UpdatedAt.Format(time.RFC3339),
+		Etag:      user.ETag(),
+		Verified:  user.Verified,
+	}
+	if user.VerifiedAt != nil {
+		result.VerifiedAt = user.VerifiedAt.Format(time.RFC3339)
+	}
+	return result
+}
+
+func toProtoTokens(tokens *domain.TokenPair) *pb.Tokens {
+	return &pb.Tokens{
+		AccessToken:           tokens.AccessToken,
+		AccessTokenExpiresAt:  tokens.AccessTokenExpiresAt.Format(time.RFC3339),
+		RefreshToken:          tokens.RefreshToken,
+		RefreshTokenExpiresAt: tokens.RefreshTokenExpiresAt.Format(time.RFC3339),
+		TokenType:             "Bearer",
+	}
+}
+
+// toUserUpdate picks the fields named by the update mask, or every non-empty
+// updatable field when the mask is empty. The gateway derives masks from the
+// request body, so "id" and "etag" are accepted and ignored.
+func toUserUpdate(user *pb.User, paths []string) (domain.UserUpdate, error) {
+	update := domain.UserUpdate{ETag: user.GetEtag()}
+
+	if len(paths) == 0 {
+		if user.GetEmail() != "" {
+			paths = append(paths, "email")
+		}
+		if user.GetUsername() != "" {
+			paths = append(paths, "username")
+		}
+	}
+
+	for _, path := range paths {
+		switch path {
+		case "email":
+			email := user.GetEmail()
+			if email == "" {
+				return update, status.Error(codes.InvalidArgument, "email cannot be empty")
+			}
+			update.Email = &email
+		case "username":
+			name := user.GetUsername()
+			if name == "" {
+				return update, status.Error(codes.InvalidArgument, "username cannot be empty")
+			}
+			update.Name = &name
+		case "id", "etag":
+		default:
+			return update, status.Errorf(codes.InvalidArgument, "field %q cannot be updated", path)
+		}
+	}
+
+	if update.Email == nil && update.Name == nil {
+		return update, status.Error(codes.InvalidArgument, "no fields to update")
+	}
+
+	return update, nil
+}
+
+// userStatus maps user service errors to gRPC statuses
+func userStatus(err error, msg string) error {
+	switch {
+	case errors.Is(err, domain.ErrUserNotFound):
+		return status.Error(codes.NotFound, "user not found")
+	case errors.Is(err, domain.ErrEmailTaken):
+		return status.Error(codes.AlreadyExists, "email address already registered")
+	case errors.Is(err, domain.ErrVersionConflict):
+		return status.Error(codes.Aborted, "user was modified concurrently, read it again and retry")
+	case errors.Is(err, domain.ErrInvalidETag):
+		return status.Error(codes.InvalidArgument, "invalid etag")
+	case errors.Is(err, verification.ErrInvalidToken):
+		return status.Error(codes.InvalidArgument, "invalid verification link")
+	case errors.Is(err, verification.ErrTokenExpired):
+		return status.Error(codes.FailedPrecondition, "verification link expired, request a new one")
+	case errors.Is(err, domain.ErrVerificationDisabled):
+		return status.Error(codes.FailedPrecondition, "email verification is not enabled")
+	case errors.Is(err, domain.ErrResendRateLimited):
+		return status.Error(codes.ResourceExhausted, "too many verification email requests, try again later")
+	case errors.Is(err, domain.ErrWeakPassword):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, domain.ErrInvalidCredentials):
+		return status.Error(codes.Unauthenticated, "invalid email or password")
+	case errors.Is(err, domain.ErrInvalidRefreshToken):
+		return status.Error(codes.Unauthenticated, "invalid refresh token")
+	case errors.Is(err, domain.ErrAuthDisabled):
+		return status.Error(codes.FailedPrecondition, "authentication is not enabled")
+	case errors.Is(err, domain.ErrInvalidResetToken):
+		return status.Error(codes.InvalidArgument, "invalid or expired password reset token")
+	case errors.Is(err, domain.ErrResetRateLimited):
+		return status.Error(codes.ResourceExhausted, "too many password reset requests, try again later")
+	case errors.Is(err, domain.ErrPasswordResetDisabled):
+		return status.Error(codes.FailedPrecondition, "password reset is not enabled")
+	default:
+		return status.Error(codes.Internal, msg)
 	}
 }
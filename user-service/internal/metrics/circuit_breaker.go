@@ -14,6 +14,7 @@ type CircuitBreakerCollector struct {
 	failuresGauge     prometheus.Gauge
 	successesGauge    prometheus.Gauge
 	halfOpenReqsGauge prometheus.Gauge
+	transitions       *prometheus.CounterVec
 }
 
 // NewCircuitBreakerCollector creates a new circuit breaker collector
@@ -53,8 +54,22 @@ func NewCircuitBreakerCollector(namespace string, cb *circuitbreaker.CircuitBrea
 				Help:      "Number of requests in half-open state",
 			},
 		),
+		transitions: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Subsystem: "circuit_breaker",
+				Name:      "transitions_total",
+				Help:      "Total number of circuit breaker state changes",
+			},
+			[]string{"from", "to"},
+		),
 	}
 
+	// Count transitions as they happen, polling would miss short-lived states
+	cb.OnStateChange(func(from, to circuitbreaker.State) {
+		collector.transitions.WithLabelValues(stateLabel(from), stateLabel(to)).Inc()
+	})
+
 	// Register the collector with our custom registry
 	Registry.MustRegister(collector)
 
@@ -67,6 +82,7 @@ func (c *CircuitBreakerCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- c.failuresGauge.Desc()
 	ch <- c.successesGauge.Desc()
 	ch <- c.halfOpenReqsGauge.Desc()
+	c.transitions.Describe(ch)
 }
 
 // Collect implements prometheus.Collector
@@ -101,6 +117,15 @@ func (c *CircuitBreakerCollector) Collect(ch chan<- prometheus.Metric) {
 	ch <- c.failuresGauge
 	ch <- c.successesGauge
 	ch <- c.halfOpenReqsGauge
+	c.transitions.Collect(ch)
+}
+
+// stateLabel returns the label value the state gauge uses for s
+func stateLabel(s circuitbreaker.State) string {
+	if s == circuitbreaker.StateHalfOpen {
+		return "half_open"
+	}
+	return s.String()
 }
 
 // QueueCollector collects queue metrics
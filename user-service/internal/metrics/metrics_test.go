@@ -2,7 +2,9 @@ package metrics
 
 import (
 	"context"
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/stretchr/testify/assert"
@@ -12,6 +14,7 @@ import (
 	"github.com/popeskul/mailflow/user-service/internal/circuitbreaker"
 	"github.com/popeskul/mailflow/user-service/internal/domain"
 	"github.com/popeskul/mailflow/user-service/internal/queue"
+	"github.com/popeskul/mailflow/user-service/internal/retry"
 )
 
 func TestRegistry_Init(t *testing.T) {
@@ -67,6 +70,7 @@ func TestNewCircuitBreakerCollector(t *testing.T) {
 			assert.NotNil(t, collector.failuresGauge)
 			assert.NotNil(t, collector.successesGauge)
 			assert.NotNil(t, collector.halfOpenReqsGauge)
+			assert.NotNil(t, collector.transitions)
 		})
 	}
 }
@@ -104,7 +108,7 @@ func TestCircuitBreakerCollector_Describe(t *testing.T) {
 				descs = append(descs, desc)
 			}
 
-			assert.Equal(t, 4, len(descs)) // state gauge (1) + failures + successes + half_open_reqs
+			assert.Equal(t, 5, len(descs)) // state gauge (1) + failures + successes + half_open_reqs + transitions
 		})
 	}
 }
@@ -148,6 +152,109 @@ func TestCircuitBreakerCollector_Collect(t *testing.T) {
 	}
 }
 
+func TestCircuitBreakerCollector_Transitions(t *testing.T) {
+	tests := []struct {
+		name  string
+		calls func(cb *circuitbreaker.CircuitBreaker)
+		want  map[[2]string]float64
+	}{
+		{
+			name:  "no transitions",
+			calls: func(cb *circuitbreaker.CircuitBreaker) {},
+			want:  map[[2]string]float64{},
+		},
+		{
+			name: "open and reset",
+			calls: func(cb *circuitbreaker.CircuitBreaker) {
+				_ = cb.Execute(context.Background(), func(context.Context) error { return errors.New("down") })
+				cb.Reset()
+				_ = cb.Execute(context.Background(), func(context.Context) error { return errors.New("down") })
+			},
+			want: map[[2]string]float64{
+				{"closed", "open"}: 2,
+				{"open", "closed"}: 1,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Create a test registry to avoid conflicts
+			testRegistry := prometheus.NewRegistry()
+
+			// Temporarily replace global registry
+			originalRegistry := Registry
+			Registry = testRegistry
+			defer func() {
+				Registry = originalRegistry
+			}()
+
+			cb := circuitbreaker.New(&circuitbreaker.Config{FailureThreshold: 1, SuccessThreshold: 1, Timeout: time.Minute, MaxRequests: 1})
+			NewCircuitBreakerCollector("test", cb)
+
+			tt.calls(cb)
+
+			families, err := testRegistry.Gather()
+			require.NoError(t, err)
+
+			got := map[[2]string]float64{}
+			for _, family := range families {
+				if family.GetName() != "test_circuit_breaker_transitions_total" {
+					continue
+				}
+				for _, m := range family.GetMetric() {
+					labels := map[string]string{}
+					for _, l := range m.GetLabel() {
+						labels[l.GetName()] = l.GetValue()
+					}
+					got[[2]string{labels["from"], labels["to"]}] = m.GetCounter().GetValue()
+				}
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestRetryCollector_Observe(t *testing.T) {
+	// Create a test registry to avoid conflicts
+	testRegistry := prometheus.NewRegistry()
+
+	// Temporarily replace global registry
+	originalRegistry := Registry
+	Registry = testRegistry
+	defer func() {
+		Registry = originalRegistry
+	}()
+
+	collector := NewRetryCollector("test")
+	collector.Observe(context.Background(), retry.Result{Attempts: 1})
+	collector.Observe(context.Background(), retry.Result{Attempts: 3, Delay: 300 * time.Millisecond})
+	collector.Observe(context.Background(), retry.Result{Attempts: 5, Delay: 2 * time.Second, Err: errors.New("down")})
+
+	families, err := testRegistry.Gather()
+	require.NoError(t, err)
+
+	type observed struct {
+		count uint64
+		sum   float64
+	}
+	got := map[string]observed{}
+	for _, family := range families {
+		for _, m := range family.GetMetric() {
+			got[family.GetName()+"/"+m.GetLabel()[0].GetValue()] = observed{
+				count: m.GetHistogram().GetSampleCount(),
+				sum:   m.GetHistogram().GetSampleSum(),
+			}
+		}
+	}
+	assert.Equal(t, map[string]observed{
+		"test_retry_attempts/success":      {count: 2, sum: 4},
+		"test_retry_attempts/failure":      {count: 1, sum: 5},
+		"test_retry_delay_seconds/success": {count: 2, sum: 0.3},
+		"test_retry_delay_seconds/failure": {count: 1, sum: 2},
+	}, got)
+}
+
 func TestNewQueueCollector(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -315,4 +422,5 @@ type mockQueue struct{}
 func (m *mockQueue) Enqueue(email *domain.Email) error                              { return nil }
 func (m *mockQueue) Start(ctx context.Context, processor func(*domain.Email) error) {}
 func (m *mockQueue) Stop()                                                          {}
+func (m *mockQueue) Shutdown(ctx context.Context) queue.DrainReport                 { return queue.DrainReport{} }
 func (m *mockQueue) Size() int                                                      { return 0 }
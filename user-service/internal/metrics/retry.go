@@ -0,0 +1,71 @@
+package metrics
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/popeskul/mailflow/user-service/internal/retry"
+)
+
+// RetryCollector records how many attempts retried calls take and how long
+// they wait between them
+type RetryCollector struct {
+	attempts *prometheus.HistogramVec
+	delay    *prometheus.HistogramVec
+}
+
+// NewRetryCollector creates a new retry collector. Pass its Observe method
+// to retry.WithOnDone.
+func NewRetryCollector(namespace string) *RetryCollector {
+	collector := &RetryCollector{
+		attempts: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Subsystem: "retry",
+				Name:      "attempts",
+				Help:      "Number of attempts per call",
+				Buckets:   prometheus.LinearBuckets(1, 1, 10),
+			},
+			[]string{"result"},
+		),
+		delay: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Subsystem: "retry",
+				Name:      "delay_seconds",
+				Help:      "Total time per call spent waiting between attempts",
+				Buckets:   prometheus.ExponentialBuckets(0.01, 2, 13),
+			},
+			[]string{"result"},
+		),
+	}
+
+	// Register the collector with our custom registry
+	Registry.MustRegister(collector)
+
+	return collector
+}
+
+// Observe records a finished call
+func (c *RetryCollector) Observe(_ context.Context, result retry.Result) {
+	label := "success"
+	if result.Err != nil {
+		label = "failure"
+	}
+
+	c.attempts.WithLabelValues(label).Observe(float64(result.Attempts))
+	c.delay.WithLabelValues(label).Observe(result.Delay.Seconds())
+}
+
+// Describe implements prometheus.Collector
+func (c *RetryCollector) Describe(ch chan<- *prometheus.Desc) {
+	c.attempts.Describe(ch)
+	c.delay.Describe(ch)
+}
+
+// Collect implements prometheus.Collector
+func (c *RetryCollector) Collect(ch chan<- prometheus.Metric) {
+	c.attempts.Collect(ch)
+	c.delay.Collect(ch)
+}
@@ -0,0 +1,128 @@
+// Package passhash hashes and verifies user passwords.
+package passhash
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ErrInvalidHash is returned for stored hashes in an unknown format
+var ErrInvalidHash = errors.New("invalid password hash")
+
+// Argon2Params tunes argon2id. Changing them makes NeedsRehash report
+// existing hashes so they are upgraded on the next login.
+type Argon2Params struct {
+	Memory  uint32 // KiB
+	Time    uint32
+	Threads uint8
+	SaltLen uint32
+	KeyLen  uint32
+}
+
+// DefaultParams follow the second recommended option of RFC 9106
+var DefaultParams = Argon2Params{
+	Memory:  64 * 1024,
+	Time:    3,
+	Threads: 4,
+	SaltLen: 16,
+	KeyLen:  32,
+}
+
+// Hash hashes a password with argon2id into the PHC string format,
+// e.g. "$argon2id$v=19$m=65536,t=3,p=4$<salt>$<key>".
+func Hash(password string) (string, error) {
+	p := DefaultParams
+
+	salt := make([]byte, p.SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	key := argon2.IDKey([]byte(password), salt, p.Time, p.Memory, p.Threads, p.KeyLen)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, p.Memory, p.Time, p.Threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// Verify reports whether password matches encoded, which is either
+// an argon2id hash from Hash or a bcrypt hash from an older system.
+func Verify(encoded, password string) (bool, error) {
+	if isBcrypt(encoded) {
+		err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, nil
+		}
+		if err != nil {
+			return false, ErrInvalidHash
+		}
+		return true, nil
+	}
+
+	p, salt, key, err := decodeArgon2(encoded)
+	if err != nil {
+		return false, err
+	}
+
+	other := argon2.IDKey([]byte(password), salt, p.Time, p.Memory, p.Threads, uint32(len(key)))
+	return subtle.ConstantTimeCompare(key, other) == 1, nil
+}
+
+// NeedsRehash reports whether encoded was made with another algorithm or
+// other parameters than Hash uses now
+func NeedsRehash(encoded string) bool {
+	p, salt, key, err := decodeArgon2(encoded)
+	if err != nil {
+		return true
+	}
+
+	return p.Memory != DefaultParams.Memory ||
+		p.Time != DefaultParams.Time ||
+		p.Threads != DefaultParams.Threads ||
+		uint32(len(salt)) != DefaultParams.SaltLen ||
+		uint32(len(key)) != DefaultParams.KeyLen
+}
+
+func isBcrypt(encoded string) bool {
+	return strings.HasPrefix(encoded, "$2a$") ||
+		strings.HasPrefix(encoded, "$2b$") ||
+		strings.HasPrefix(encoded, "$2y$")
+}
+
+func decodeArgon2(encoded string) (Argon2Params, []byte, []byte, error) {
+	var p Argon2Params
+
+	// "", "argon2id", "v=19", "m=..,t=..,p=..", salt, key
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return p, nil, nil, ErrInvalidHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return p, nil, nil, ErrInvalidHash
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.Memory, &p.Time, &p.Threads); err != nil {
+		return p, nil, nil, ErrInvalidHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return p, nil, nil, ErrInvalidHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return p, nil, nil, ErrInvalidHash
+	}
+
+	return p, salt, key, nil
+}
@@ -0,0 +1,70 @@
+package passhash
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestVerify_Success(t *testing.T) {
+	argon, err := Hash("correct horse")
+	require.NoError(t, err)
+	legacy, err := bcrypt.GenerateFromPassword([]byte("correct horse"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	defaults := DefaultParams
+	DefaultParams.Time = 1
+	outdated, err := Hash("correct horse")
+	DefaultParams = defaults
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		hash     string
+		password string
+		match    bool
+		rehash   bool
+	}{
+		{name: "argon2id match", hash: argon, password: "correct horse", match: true},
+		{name: "argon2id mismatch", hash: argon, password: "wrong horse", match: false},
+		{name: "bcrypt match needs rehash", hash: string(legacy), password: "correct horse", match: true, rehash: true},
+		{name: "bcrypt mismatch", hash: string(legacy), password: "wrong horse", match: false, rehash: true},
+		{name: "outdated argon2id parameters need rehash", hash: outdated, password: "correct horse", match: true, rehash: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, err := Verify(tt.hash, tt.password)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.match, ok)
+			assert.Equal(t, tt.rehash, NeedsRehash(tt.hash))
+		})
+	}
+}
+
+func TestVerify_Fail(t *testing.T) {
+	tests := []struct {
+		name string
+		hash string
+	}{
+		{name: "empty", hash: ""},
+		{name: "unknown algorithm", hash: "$scrypt$ln=16,r=8,p=1$c2FsdA$a2V5"},
+		{name: "wrong version", hash: "$argon2id$v=16$m=65536,t=3,p=4$c2FsdHNhbHRzYWx0$a2V5a2V5"},
+		{name: "bad parameters", hash: "$argon2id$v=19$m=x$c2FsdHNhbHRzYWx0$a2V5a2V5"},
+		{name: "bad salt", hash: "$argon2id$v=19$m=65536,t=3,p=4$!!!$a2V5a2V5"},
+		{name: "truncated bcrypt", hash: "$2a$10$short"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, err := Verify(tt.hash, "password")
+
+			assert.ErrorIs(t, err, ErrInvalidHash)
+			assert.False(t, ok)
+			assert.True(t, NeedsRehash(tt.hash))
+		})
+	}
+}
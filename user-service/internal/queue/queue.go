@@ -14,33 +14,87 @@ import (
 // ErrQueueEmpty indicates that the queue is empty
 var ErrQueueEmpty = fmt.Errorf("queue is empty")
 
+// ErrQueueClosed indicates that the queue no longer accepts emails
+var ErrQueueClosed = fmt.Errorf("queue is closed")
+
+// retryDelay is how long the processor waits before re-enqueueing a failed email
+const retryDelay = 5 * time.Second
+
 // Queue defines the interface for email queue operations
 type Queue interface {
 	Enqueue(email *domain.Email) error
 	Start(ctx context.Context, processor func(*domain.Email) error)
 	Stop()
+	Shutdown(ctx context.Context) DrainReport
 	Size() int
 }
 
+// DrainReport tells what happened to queued emails during shutdown
+type DrainReport struct {
+	// Drained is the number of emails processed successfully while draining
+	Drained int
+	// Persisted is the number of emails handed to the persister
+	Persisted int
+	// Dropped is the number of emails that were neither processed nor persisted
+	Dropped int
+}
+
+// Persister stores emails that could not be processed before shutdown
+type Persister interface {
+	Persist(ctx context.Context, emails []*domain.Email) error
+}
+
+// Option configures an EmailQueue
+type Option func(*EmailQueue)
+
+// WithPersister sets where Shutdown stores emails it could not process
+func WithPersister(p Persister) Option {
+	return func(q *EmailQueue) {
+		q.persister = p
+	}
+}
+
 // EmailQueue represents an email queue for retry logic
 type EmailQueue struct {
-	queue  chan *domain.Email
-	logger *zap.Logger
-	done   chan struct{}
-	wg     sync.WaitGroup
+	queue     chan *domain.Email
+	logger    *zap.Logger
+	done      chan struct{}
+	wake      chan struct{}
+	wg        sync.WaitGroup
+	stopOnce  sync.Once
+	persister Persister
+
+	// mu guards closed and processor
+	mu        sync.RWMutex
+	closed    bool
+	processor func(*domain.Email) error
 }
 
 // NewEmailQueue creates a new email queue
-func NewEmailQueue(bufferSize int, logger *zap.Logger) *EmailQueue {
-	return &EmailQueue{
+func NewEmailQueue(bufferSize int, logger *zap.Logger, opts ...Option) *EmailQueue {
+	q := &EmailQueue{
 		queue:  make(chan *domain.Email, bufferSize),
 		logger: logger,
 		done:   make(chan struct{}),
+		wake:   make(chan struct{}, 1),
 	}
+
+	for _, opt := range opts {
+		opt(q)
+	}
+
+	return q
 }
 
 // Enqueue adds an email to the retry queue
 func (q *EmailQueue) Enqueue(email *domain.Email) error {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	if q.closed {
+		return ErrQueueClosed
+	}
+
 	select {
 	case q.queue <- email:
 		q.logger.Debug("Email enqueued for retry", zap.String("email_id", email.ID))
@@ -52,10 +106,23 @@ func (q *EmailQueue) Enqueue(email *domain.Email) error {
 
 // Start begins processing the queue
 func (q *EmailQueue) Start(ctx context.Context, processor func(*domain.Email) error) {
+	q.mu.Lock()
+	q.processor = processor
+	q.mu.Unlock()
+
 	q.wg.Add(1)
 	go func() {
 		defer q.wg.Done()
 		for {
+			// Stopping wins over a ready email, so nothing is taken after Stop
+			select {
+			case <-ctx.Done():
+				return
+			case <-q.done:
+				return
+			default:
+			}
+
 			select {
 			case <-ctx.Done():
 				return
@@ -67,8 +134,11 @@ func (q *EmailQueue) Start(ctx context.Context, processor func(*domain.Email) er
 						q.logger.Error("Failed to process email from queue",
 							zap.String("email_id", email.ID),
 							zap.Error(err))
-						// Retry after delay
-						time.Sleep(5 * time.Second)
+						// Retry after delay, handing the email back if stopped meanwhile
+						if !q.wait(ctx, retryDelay) {
+							q.putBack(email)
+							return
+						}
 						if retryErr := q.Enqueue(email); retryErr != nil {
 							q.logger.Error("Failed to re-enqueue email",
 								zap.String("email_id", email.ID),
@@ -81,17 +151,166 @@ func (q *EmailQueue) Start(ctx context.Context, processor func(*domain.Email) er
 	}()
 }
 
-// Stop stops the queue processing
+// Flush cuts the processor's current retry delay short, so queued emails
+// are sent right away, e.g. once the service they go to has recovered
+func (q *EmailQueue) Flush() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// wait sleeps for d, or until Flush, and reports whether the queue is
+// still running
+func (q *EmailQueue) wait(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	// A flush from before this delay started is stale
+	select {
+	case <-q.wake:
+	default:
+	}
+
+	select {
+	case <-timer.C:
+		return true
+	case <-q.wake:
+		return true
+	case <-ctx.Done():
+		return false
+	case <-q.done:
+		return false
+	}
+}
+
+// putBack returns an email taken by the processor so Shutdown can drain it
+func (q *EmailQueue) putBack(email *domain.Email) {
+	select {
+	case q.queue <- email:
+	default:
+		q.logger.Error("Failed to return email to queue, dropping it",
+			zap.String("email_id", email.ID))
+	}
+}
+
+// Stop stops the queue processing without draining queued emails
 func (q *EmailQueue) Stop() {
-	close(q.done)
+	q.stopOnce.Do(func() {
+		close(q.done)
+	})
 	q.wg.Wait()
 }
 
+// Shutdown stops accepting emails and the background processor, then
+// processes what is still queued until ctx expires. Emails that fail or
+// are left over are handed to the persister; without one they are dropped.
+func (q *EmailQueue) Shutdown(ctx context.Context) DrainReport {
+	q.mu.Lock()
+	q.closed = true
+	processor := q.processor
+	q.mu.Unlock()
+
+	stopped := make(chan struct{})
+	go func() {
+		q.Stop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+	}
+
+	var (
+		report  DrainReport
+		pending []*domain.Email
+	)
+
+drain:
+	for ctx.Err() == nil {
+		select {
+		case email := <-q.queue:
+			if processor == nil {
+				pending = append(pending, email)
+				continue
+			}
+			if err := processor(email); err != nil {
+				q.logger.Warn("Failed to process email while draining",
+					zap.String("email_id", email.ID),
+					zap.Error(err))
+				pending = append(pending, email)
+				continue
+			}
+			report.Drained++
+		default:
+			break drain
+		}
+	}
+
+	// Whatever is still queued after the deadline goes to the persister
+	for len(q.queue) > 0 {
+		pending = append(pending, <-q.queue)
+	}
+
+	if len(pending) > 0 {
+		report.Persisted, report.Dropped = q.persist(ctx, pending)
+	}
+
+	q.logger.Info("Email queue shut down",
+		zap.Int("drained", report.Drained),
+		zap.Int("persisted", report.Persisted),
+		zap.Int("dropped", report.Dropped))
+
+	return report
+}
+
+// persist stores pending emails and returns how many were persisted and
+// dropped. Sensitive emails are always dropped.
+func (q *EmailQueue) persist(ctx context.Context, pending []*domain.Email) (int, int) {
+	kept := pending[:0]
+	for _, email := range pending {
+		if !email.Sensitive {
+			kept = append(kept, email)
+		}
+	}
+	sensitive := len(pending) - len(kept)
+	if sensitive > 0 {
+		q.logger.Warn("Dropping sensitive queued emails instead of persisting them",
+			zap.Int("count", sensitive))
+	}
+	if len(kept) == 0 {
+		return 0, sensitive
+	}
+	pending = kept
+
+	if q.persister == nil {
+		q.logger.Warn("No persister configured, dropping queued emails",
+			zap.Int("count", len(pending)))
+		return 0, sensitive + len(pending)
+	}
+
+	// The deadline may have passed already; persisting must still run
+	if err := q.persister.Persist(context.WithoutCancel(ctx), pending); err != nil {
+		q.logger.Error("Failed to persist queued emails",
+			zap.Int("count", len(pending)),
+			zap.Error(err))
+		return 0, sensitive + len(pending)
+	}
+
+	return len(pending), sensitive
+}
+
 // Size returns the current queue size
 func (q *EmailQueue) Size() int {
 	return len(q.queue)
 }
 
+// Capacity returns how many emails the queue holds when full
+func (q *EmailQueue) Capacity() int {
+	return cap(q.queue)
+}
+
 // MockEmailQueue for testing
 type MockEmailQueue struct {
 	emails []*domain.Email
@@ -120,6 +339,11 @@ func (m *MockEmailQueue) Stop() {
 	// Mock implementation - does nothing
 }
 
+// Shutdown reports every queued email as dropped for mock
+func (m *MockEmailQueue) Shutdown(_ context.Context) DrainReport {
+	return DrainReport{Dropped: len(m.emails)}
+}
+
 // Size returns the mock queue size
 func (m *MockEmailQueue) Size() int {
 	return len(m.emails)
@@ -2,6 +2,9 @@ package queue_test
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -114,3 +117,205 @@ func TestEmailQueue_StartStop(t *testing.T) {
 	// Stop the queue
 	q.Stop()
 }
+
+func TestEmailQueue_Flush(t *testing.T) {
+	q := queue.NewEmailQueue(10, zap.NewNop())
+
+	attempts := make(chan int, 2)
+	count := 0
+	q.Start(context.Background(), func(email *domain.Email) error {
+		count++
+		attempts <- count
+		if count == 1 {
+			return errors.New("service unavailable")
+		}
+		return nil
+	})
+	defer q.Stop()
+
+	if err := q.Enqueue(&domain.Email{ID: "flush"}); err != nil {
+		t.Fatalf("Failed to enqueue: %v", err)
+	}
+	<-attempts
+
+	// Flush until the retry delay is cut short; well before it would expire
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	deadline := time.After(time.Second)
+	for {
+		select {
+		case <-ticker.C:
+			q.Flush()
+		case n := <-attempts:
+			if n != 2 {
+				t.Errorf("Expected second attempt, got %d", n)
+			}
+			return
+		case <-deadline:
+			t.Fatal("Email was not retried after Flush")
+		}
+	}
+}
+
+func TestEmailQueue_EnqueueAfterShutdown(t *testing.T) {
+	q := queue.NewEmailQueue(10, zap.NewNop())
+	q.Shutdown(context.Background())
+
+	err := q.Enqueue(&domain.Email{ID: "late"})
+	if !errors.Is(err, queue.ErrQueueClosed) {
+		t.Errorf("Expected ErrQueueClosed, got %v", err)
+	}
+}
+
+func TestEmailQueue_Shutdown(t *testing.T) {
+	tests := []struct {
+		name      string
+		emails    int
+		failIDs   map[string]bool
+		sensitive map[string]bool
+		start     bool
+		persister *recordingPersister
+		expected  queue.DrainReport
+	}{
+		{
+			name:     "drains every queued email",
+			emails:   3,
+			start:    true,
+			expected: queue.DrainReport{Drained: 3},
+		},
+		{
+			name:      "persists emails that fail while draining",
+			emails:    3,
+			failIDs:   map[string]bool{"email-1": true},
+			start:     true,
+			persister: &recordingPersister{},
+			expected:  queue.DrainReport{Drained: 2, Persisted: 1},
+		},
+		{
+			name:      "persists everything when never started",
+			emails:    2,
+			persister: &recordingPersister{},
+			expected:  queue.DrainReport{Persisted: 2},
+		},
+		{
+			name:      "drops sensitive emails instead of persisting them",
+			emails:    3,
+			sensitive: map[string]bool{"email-0": true, "email-2": true},
+			persister: &recordingPersister{},
+			expected:  queue.DrainReport{Persisted: 1, Dropped: 2},
+		},
+		{
+			name:      "persists nothing when every email is sensitive",
+			emails:    1,
+			sensitive: map[string]bool{"email-0": true},
+			persister: &recordingPersister{},
+			expected:  queue.DrainReport{Dropped: 1},
+		},
+		{
+			name:     "drops leftovers without a persister",
+			emails:   2,
+			expected: queue.DrainReport{Dropped: 2},
+		},
+		{
+			name:      "drops leftovers the persister rejects",
+			emails:    2,
+			persister: &recordingPersister{err: errors.New("disk full")},
+			expected:  queue.DrainReport{Dropped: 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts []queue.Option
+			if tt.persister != nil {
+				opts = append(opts, queue.WithPersister(tt.persister))
+			}
+			q := queue.NewEmailQueue(10, zap.NewNop(), opts...)
+
+			// Fill the queue before starting so the drain sees every email
+			for i := 0; i < tt.emails; i++ {
+				id := fmt.Sprintf("email-%d", i)
+				if err := q.Enqueue(&domain.Email{ID: id, Sensitive: tt.sensitive[id]}); err != nil {
+					t.Fatalf("Failed to enqueue: %v", err)
+				}
+			}
+
+			if tt.start {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+				q.Start(ctx, func(email *domain.Email) error {
+					if tt.failIDs[email.ID] {
+						return errors.New("send failed")
+					}
+					return nil
+				})
+			}
+
+			report := q.Shutdown(context.Background())
+
+			if report != tt.expected {
+				t.Errorf("Expected report %+v, got %+v", tt.expected, report)
+			}
+			if tt.persister != nil && tt.persister.err == nil && len(tt.persister.emails) != tt.expected.Persisted {
+				t.Errorf("Expected %d persisted emails, got %d", tt.expected.Persisted, len(tt.persister.emails))
+			}
+			if tt.persister != nil {
+				for _, email := range tt.persister.emails {
+					if email.Sensitive {
+						t.Errorf("Expected sensitive email %s not to be persisted", email.ID)
+					}
+				}
+			}
+			if size := q.Size(); size != 0 {
+				t.Errorf("Expected empty queue after shutdown, got %d", size)
+			}
+		})
+	}
+}
+
+func TestFileSpool_PersistAndLoad(t *testing.T) {
+	spool := queue.NewFileSpool(filepath.Join(t.TempDir(), "spool.jsonl"))
+
+	emails := []*domain.Email{
+		{ID: "spool-1", To: "a@example.com", Subject: "A", Status: domain.EmailStatusPending},
+		{ID: "spool-2", To: "b@example.com", Subject: "B", Status: domain.EmailStatusPending},
+	}
+	if err := spool.Persist(context.Background(), emails); err != nil {
+		t.Fatalf("Failed to persist: %v", err)
+	}
+
+	loaded, err := spool.Load()
+	if err != nil {
+		t.Fatalf("Failed to load: %v", err)
+	}
+	if len(loaded) != len(emails) {
+		t.Fatalf("Expected %d emails, got %d", len(emails), len(loaded))
+	}
+	for i, email := range loaded {
+		if email.ID != emails[i].ID || email.To != emails[i].To {
+			t.Errorf("Expected email %+v, got %+v", emails[i], email)
+		}
+	}
+
+	// Loading empties the spool
+	loaded, err = spool.Load()
+	if err != nil {
+		t.Fatalf("Failed to load empty spool: %v", err)
+	}
+	if len(loaded) != 0 {
+		t.Errorf("Expected empty spool, got %d emails", len(loaded))
+	}
+}
+
+type recordingPersister struct {
+	emails []*domain.Email
+	err    error
+}
+
+func (p *recordingPersister) Persist(_ context.Context, emails []*domain.Email) error {
+	if p.err != nil {
+		return p.err
+	}
+	p.emails = append(p.emails, emails...)
+	return nil
+}
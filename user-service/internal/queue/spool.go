@@ -0,0 +1,14 @@
+package queue
+
+import (
+	"github.com/popeskul/mailflow/common/spool"
+	"github.com/popeskul/mailflow/user-service/internal/domain"
+)
+
+// FileSpool persists emails as JSON lines in a local file so they survive a restart
+type FileSpool = spool.File[*domain.Email]
+
+// NewFileSpool creates a spool backed by the file at path
+func NewFileSpool(path string) *FileSpool {
+	return spool.NewFile[*domain.Email](path)
+}
@@ -0,0 +1,74 @@
+// Package ratelimit limits how often something may happen per key, e.g.
+// how many emails of a kind one address may be sent.
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// Keyed allows at most limit events per key within any sliding window.
+// Keys with no events left in the window are forgotten.
+type Keyed struct {
+	limit     int
+	window    time.Duration
+	events    map[string][]time.Time
+	lastSweep time.Time
+	mu        sync.Mutex
+	now       func() time.Time
+}
+
+func NewKeyed(limit int, window time.Duration) *Keyed {
+	return &Keyed{
+		limit:     limit,
+		window:    window,
+		events:    make(map[string][]time.Time),
+		lastSweep: time.Now(),
+		now:       time.Now,
+	}
+}
+
+// Allow records an event for key and reports whether it is within the
+// limit. Rejected events are not recorded, so retrying does not extend
+// the wait.
+func (k *Keyed) Allow(key string) bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	now := k.now()
+	since := now.Add(-k.window)
+
+	if now.Sub(k.lastSweep) >= k.window {
+		k.sweep(since)
+		k.lastSweep = now
+	}
+
+	events := prune(k.events[key], since)
+	if len(events) >= k.limit {
+		k.events[key] = events
+		return false
+	}
+
+	k.events[key] = append(events, now)
+	return true
+}
+
+// sweep forgets keys whose events are all older than since
+func (k *Keyed) sweep(since time.Time) {
+	for key, events := range k.events {
+		if events = prune(events, since); len(events) == 0 {
+			delete(k.events, key)
+		} else {
+			k.events[key] = events
+		}
+	}
+}
+
+// prune drops the events before since; events are in time order
+func prune(events []time.Time, since time.Time) []time.Time {
+	i := 0
+	for i < len(events) && events[i].Before(since) {
+		i++
+	}
+	return events[i:]
+}
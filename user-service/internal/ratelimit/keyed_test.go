@@ -0,0 +1,24 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyed_Allow(t *testing.T) {
+	now := time.Now()
+	limiter := NewKeyed(2, time.Hour)
+	limiter.now = func() time.Time { return now }
+
+	assert.True(t, limiter.Allow("a@example.com"))
+	assert.True(t, limiter.Allow("a@example.com"))
+	assert.False(t, limiter.Allow("a@example.com"), "third event within the window")
+	assert.True(t, limiter.Allow("b@example.com"), "keys are limited separately")
+
+	// The first two events slide out of the window; rejected ones never counted
+	now = now.Add(time.Hour + time.Second)
+	assert.True(t, limiter.Allow("a@example.com"))
+	assert.Len(t, limiter.events, 1, "idle keys are swept")
+}
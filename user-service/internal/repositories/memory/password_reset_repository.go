@@ -0,0 +1,59 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/popeskul/mailflow/common/logger"
+	"github.com/popeskul/mailflow/user-service/internal/domain"
+)
+
+type PasswordResetRepository struct {
+	// resets are keyed by token hash
+	resets map[string]*domain.PasswordReset
+	// byUser maps user IDs to the token hash of their outstanding reset
+	byUser map[string]string
+	mu     *sync.Mutex
+	logger logger.Logger
+}
+
+func newPasswordResetRepository(logger logger.Logger) *PasswordResetRepository {
+	return &PasswordResetRepository{
+		resets: make(map[string]*domain.PasswordReset),
+		byUser: make(map[string]string),
+		mu:     &sync.Mutex{},
+		logger: logger.Named("password_reset_repository"),
+	}
+}
+
+func (r *PasswordResetRepository) Create(ctx context.Context, reset *domain.PasswordReset) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if previous, exists := r.byUser[reset.UserID]; exists {
+		delete(r.resets, previous)
+	}
+
+	stored := *reset
+	key := string(reset.TokenHash)
+	r.resets[key] = &stored
+	r.byUser[reset.UserID] = key
+
+	return nil
+}
+
+func (r *PasswordResetRepository) Consume(ctx context.Context, tokenHash []byte) (*domain.PasswordReset, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := string(tokenHash)
+	reset, exists := r.resets[key]
+	if !exists {
+		return nil, domain.ErrInvalidResetToken
+	}
+
+	delete(r.resets, key)
+	delete(r.byUser, reset.UserID)
+
+	return reset, nil
+}
@@ -0,0 +1,65 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/popeskul/mailflow/common/logger"
+	"github.com/popeskul/mailflow/user-service/internal/domain"
+)
+
+func TestPasswordResetRepository_Consume_Success(t *testing.T) {
+	repo := newPasswordResetRepository(logger.NewZapLogger())
+	reset := &domain.PasswordReset{TokenHash: []byte("hash-1"), UserID: "user-1", ExpiresAt: time.Now().Add(time.Hour)}
+	require.NoError(t, repo.Create(context.Background(), reset))
+
+	consumed, err := repo.Consume(context.Background(), []byte("hash-1"))
+
+	require.NoError(t, err)
+	assert.Equal(t, reset, consumed)
+}
+
+func TestPasswordResetRepository_Consume_Fail(t *testing.T) {
+	tests := []struct {
+		name  string
+		hash  string
+		setup func(*PasswordResetRepository)
+	}{
+		{
+			name:  "unknown token",
+			hash:  "hash-9",
+			setup: func(*PasswordResetRepository) {},
+		},
+		{
+			name: "already consumed",
+			hash: "hash-1",
+			setup: func(repo *PasswordResetRepository) {
+				_, _ = repo.Consume(context.Background(), []byte("hash-1"))
+			},
+		},
+		{
+			name: "replaced by a newer reset",
+			hash: "hash-1",
+			setup: func(repo *PasswordResetRepository) {
+				_ = repo.Create(context.Background(), &domain.PasswordReset{TokenHash: []byte("hash-2"), UserID: "user-1"})
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newPasswordResetRepository(logger.NewZapLogger())
+			require.NoError(t, repo.Create(context.Background(), &domain.PasswordReset{TokenHash: []byte("hash-1"), UserID: "user-1"}))
+			tt.setup(repo)
+
+			reset, err := repo.Consume(context.Background(), []byte(tt.hash))
+
+			assert.ErrorIs(t, err, domain.ErrInvalidResetToken)
+			assert.Nil(t, reset)
+		})
+	}
+}
@@ -1,20 +1,48 @@
 package memory
 
 import (
+	"context"
+
 	"github.com/popeskul/mailflow/common/logger"
+	"github.com/popeskul/mailflow/common/pagination"
 	"github.com/popeskul/mailflow/user-service/internal/domain"
 )
 
 type Repositories struct {
-	user domain.UserRepository
+	user          domain.UserRepository
+	subscriptions domain.SubscriptionRepository
+	sessions      domain.SessionRepository
+	resets        domain.PasswordResetRepository
 }
 
-func NewRepositories(logger logger.Logger) *Repositories {
+// NewRepositories creates the in-memory repositories. cursors signs the page
+// tokens handed out by List.
+func NewRepositories(cursors *pagination.Codec, logger logger.Logger) *Repositories {
 	return &Repositories{
-		user: newUserRepository(logger),
+		user:          newUserRepository(cursors, logger),
+		subscriptions: newSubscriptionRepository(logger),
+		sessions:      newSessionRepository(logger),
+		resets:        newPasswordResetRepository(logger),
 	}
 }
 
 func (r Repositories) User() domain.UserRepository {
 	return r.user
 }
+
+func (r Repositories) Subscriptions() domain.SubscriptionRepository {
+	return r.subscriptions
+}
+
+func (r Repositories) Sessions() domain.SessionRepository {
+	return r.sessions
+}
+
+func (r Repositories) PasswordResets() domain.PasswordResetRepository {
+	return r.resets
+}
+
+// Ping reports whether the storage backend can be reached. Memory always can.
+func (r Repositories) Ping(_ context.Context) error {
+	return nil
+}
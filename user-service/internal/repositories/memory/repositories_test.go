@@ -7,6 +7,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/popeskul/mailflow/common/logger"
+	"github.com/popeskul/mailflow/common/pagination"
 )
 
 func TestNewRepositories(t *testing.T) {
@@ -21,10 +22,13 @@ func TestNewRepositories(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			testLogger := logger.NewZapLogger()
-			repos := NewRepositories(testLogger)
+			repos := NewRepositories(pagination.NewCodec([]byte("test-secret")), testLogger)
 
 			assert.NotNil(t, repos)
 			assert.NotNil(t, repos.User())
+			assert.NotNil(t, repos.Subscriptions())
+			assert.NotNil(t, repos.Sessions())
+			assert.NotNil(t, repos.PasswordResets())
 		})
 	}
 }
@@ -41,7 +45,7 @@ func TestRepositories_User(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			testLogger := logger.NewZapLogger()
-			repos := NewRepositories(testLogger)
+			repos := NewRepositories(pagination.NewCodec([]byte("test-secret")), testLogger)
 
 			userRepo := repos.User()
 
@@ -53,3 +57,9 @@ func TestRepositories_User(t *testing.T) {
 		})
 	}
 }
+
+func TestRepositories_Ping(t *testing.T) {
+	repos := NewRepositories(pagination.NewCodec([]byte("test-secret")), logger.NewZapLogger())
+
+	assert.NoError(t, repos.Ping(context.Background()))
+}
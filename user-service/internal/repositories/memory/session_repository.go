@@ -0,0 +1,111 @@
+package memory
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/popeskul/mailflow/common/logger"
+	"github.com/popeskul/mailflow/user-service/internal/domain"
+)
+
+type SessionRepository struct {
+	sessions map[string]*domain.Session
+	// byUser maps user IDs to the IDs of their sessions
+	byUser map[string]map[string]struct{}
+	mu     *sync.RWMutex
+	logger logger.Logger
+}
+
+func newSessionRepository(logger logger.Logger) *SessionRepository {
+	return &SessionRepository{
+		sessions: make(map[string]*domain.Session),
+		byUser:   make(map[string]map[string]struct{}),
+		mu:       &sync.RWMutex{},
+		logger:   logger.Named("session_repository"),
+	}
+}
+
+func (r *SessionRepository) Create(ctx context.Context, session *domain.Session) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.sessions[session.ID]; exists {
+		return errors.New("session already exists")
+	}
+
+	stored := *session
+	r.sessions[session.ID] = &stored
+	if r.byUser[session.UserID] == nil {
+		r.byUser[session.UserID] = make(map[string]struct{})
+	}
+	r.byUser[session.UserID][session.ID] = struct{}{}
+
+	return nil
+}
+
+func (r *SessionRepository) Get(ctx context.Context, id string) (*domain.Session, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	session, exists := r.sessions[id]
+	if !exists {
+		return nil, domain.ErrSessionNotFound
+	}
+
+	found := *session
+	return &found, nil
+}
+
+func (r *SessionRepository) Rotate(ctx context.Context, id string, oldHash, newHash []byte, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	session, exists := r.sessions[id]
+	if !exists {
+		return domain.ErrSessionNotFound
+	}
+	if subtle.ConstantTimeCompare(session.RefreshTokenHash, oldHash) != 1 {
+		return domain.ErrInvalidRefreshToken
+	}
+
+	session.RefreshTokenHash = newHash
+	session.ExpiresAt = expiresAt
+	return nil
+}
+
+func (r *SessionRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	session, exists := r.sessions[id]
+	if !exists {
+		return domain.ErrSessionNotFound
+	}
+
+	r.remove(session)
+	return nil
+}
+
+func (r *SessionRepository) DeleteByUser(ctx context.Context, userID, keep string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id := range r.byUser[userID] {
+		if id != keep {
+			r.remove(r.sessions[id])
+		}
+	}
+
+	return nil
+}
+
+func (r *SessionRepository) remove(session *domain.Session) {
+	delete(r.sessions, session.ID)
+	delete(r.byUser[session.UserID], session.ID)
+	if len(r.byUser[session.UserID]) == 0 {
+		delete(r.byUser, session.UserID)
+	}
+}
@@ -0,0 +1,89 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/popeskul/mailflow/common/logger"
+	"github.com/popeskul/mailflow/user-service/internal/domain"
+)
+
+func newTestSession(id, userID string) *domain.Session {
+	return &domain.Session{
+		ID:               id,
+		UserID:           userID,
+		RefreshTokenHash: []byte("hash-1"),
+		CreatedAt:        time.Now(),
+		ExpiresAt:        time.Now().Add(time.Hour),
+	}
+}
+
+func TestSessionRepository_Rotate_Success(t *testing.T) {
+	repo := newSessionRepository(logger.NewZapLogger())
+	require.NoError(t, repo.Create(context.Background(), newTestSession("session-1", "user-1")))
+	expiresAt := time.Now().Add(2 * time.Hour)
+
+	err := repo.Rotate(context.Background(), "session-1", []byte("hash-1"), []byte("hash-2"), expiresAt)
+
+	require.NoError(t, err)
+	session, err := repo.Get(context.Background(), "session-1")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("hash-2"), session.RefreshTokenHash)
+	assert.Equal(t, expiresAt, session.ExpiresAt)
+}
+
+func TestSessionRepository_Rotate_Fail(t *testing.T) {
+	tests := []struct {
+		name        string
+		id          string
+		oldHash     string
+		expectedErr error
+	}{
+		{name: "unknown session", id: "session-2", oldHash: "hash-1", expectedErr: domain.ErrSessionNotFound},
+		{name: "already rotated", id: "session-1", oldHash: "hash-0", expectedErr: domain.ErrInvalidRefreshToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newSessionRepository(logger.NewZapLogger())
+			require.NoError(t, repo.Create(context.Background(), newTestSession("session-1", "user-1")))
+
+			err := repo.Rotate(context.Background(), tt.id, []byte(tt.oldHash), []byte("hash-2"), time.Now())
+
+			assert.ErrorIs(t, err, tt.expectedErr)
+		})
+	}
+}
+
+func TestSessionRepository_DeleteByUser_Success(t *testing.T) {
+	repo := newSessionRepository(logger.NewZapLogger())
+	for _, session := range []*domain.Session{
+		newTestSession("session-1", "user-1"),
+		newTestSession("session-2", "user-1"),
+		newTestSession("session-3", "user-1"),
+		newTestSession("session-4", "user-2"),
+	} {
+		require.NoError(t, repo.Create(context.Background(), session))
+	}
+
+	err := repo.DeleteByUser(context.Background(), "user-1", "session-2")
+
+	require.NoError(t, err)
+	for id, exists := range map[string]bool{
+		"session-1": false,
+		"session-2": true,
+		"session-3": false,
+		"session-4": true,
+	} {
+		_, err := repo.Get(context.Background(), id)
+		if exists {
+			assert.NoError(t, err, id)
+		} else {
+			assert.ErrorIs(t, err, domain.ErrSessionNotFound, id)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/popeskul/mailflow/common/logger"
+	"github.com/popeskul/mailflow/user-service/internal/domain"
+)
+
+type SubscriptionRepository struct {
+	optOuts map[string]map[string]struct{}
+	mu      *sync.RWMutex
+	logger  logger.Logger
+}
+
+func newSubscriptionRepository(logger logger.Logger) *SubscriptionRepository {
+	return &SubscriptionRepository{
+		optOuts: make(map[string]map[string]struct{}),
+		mu:      &sync.RWMutex{},
+		logger:  logger.Named("subscription_repository"),
+	}
+}
+
+func (r *SubscriptionRepository) OptOut(ctx context.Context, category, address string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.optOuts[category] == nil {
+		r.optOuts[category] = make(map[string]struct{})
+	}
+	r.optOuts[category][domain.NormalizeAddress(address)] = struct{}{}
+
+	return nil
+}
+
+func (r *SubscriptionRepository) OptIn(ctx context.Context, category, address string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.optOuts[category], domain.NormalizeAddress(address))
+
+	return nil
+}
+
+func (r *SubscriptionRepository) IsOptedOut(ctx context.Context, category, address string) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	_, exists := r.optOuts[category][domain.NormalizeAddress(address)]
+	return exists, nil
+}
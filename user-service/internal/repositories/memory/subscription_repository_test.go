@@ -0,0 +1,70 @@
+package memory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/popeskul/mailflow/common/logger"
+	"github.com/popeskul/mailflow/user-service/internal/domain"
+)
+
+func TestSubscriptionRepository_IsOptedOut_Success(t *testing.T) {
+	tests := []struct {
+		name     string
+		optOut   []string
+		optIn    []string
+		category string
+		address  string
+		optedOut bool
+	}{
+		{
+			name:     "address never opted out",
+			category: domain.CategoryNewsletter,
+			address:  "test@example.com",
+			optedOut: false,
+		},
+		{
+			name:     "opt out matches regardless of case and spaces",
+			optOut:   []string{" Test@Example.com "},
+			category: domain.CategoryNewsletter,
+			address:  "test@example.com",
+			optedOut: true,
+		},
+		{
+			name:     "opt out is scoped to its category",
+			optOut:   []string{"test@example.com"},
+			category: domain.CategoryProductUpdates,
+			address:  "test@example.com",
+			optedOut: false,
+		},
+		{
+			name:     "opt in removes opt out",
+			optOut:   []string{"test@example.com"},
+			optIn:    []string{"test@example.com"},
+			category: domain.CategoryNewsletter,
+			address:  "test@example.com",
+			optedOut: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newSubscriptionRepository(logger.NewZapLogger())
+
+			for _, address := range tt.optOut {
+				require.NoError(t, repo.OptOut(context.Background(), domain.CategoryNewsletter, address))
+			}
+			for _, address := range tt.optIn {
+				require.NoError(t, repo.OptIn(context.Background(), domain.CategoryNewsletter, address))
+			}
+
+			optedOut, err := repo.IsOptedOut(context.Background(), tt.category, tt.address)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.optedOut, optedOut)
+		})
+	}
+}
@@ -3,28 +3,37 @@ package memory
 import (
 	"context"
 	"errors"
+	"slices"
 	"sort"
 	"sync"
 
 	"github.com/popeskul/mailflow/common/logger"
+	"github.com/popeskul/mailflow/common/pagination"
 	"github.com/popeskul/mailflow/user-service/internal/domain"
 )
 
 type UserRepository struct {
 	users       map[string]*domain.User
 	sortedUsers []*domain.User
-	mu          *sync.RWMutex
-	logger      logger.Logger
+	// byEmail maps normalized addresses to user IDs
+	byEmail map[string]string
+	cursors *pagination.Codec
+	mu      *sync.RWMutex
+	logger  logger.Logger
 }
 
-func newUserRepository(logger logger.Logger) *UserRepository {
+func newUserRepository(cursors *pagination.Codec, logger logger.Logger) *UserRepository {
 	return &UserRepository{
-		users:  make(map[string]*domain.User),
-		mu:     &sync.RWMutex{},
-		logger: logger.Named("user_repository"),
+		users:   make(map[string]*domain.User),
+		byEmail: make(map[string]string),
+		cursors: cursors,
+		mu:      &sync.RWMutex{},
+		logger:  logger.Named("user_repository"),
 	}
 }
 
+// Create stores a copy of user at version 1. Users are copied in and out of
+// the repository so a caller can only change stored state through Update.
 func (r *UserRepository) Create(ctx context.Context, user *domain.User) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
@@ -32,9 +41,16 @@ func (r *UserRepository) Create(ctx context.Context, user *domain.User) error {
 	if _, exists := r.users[user.ID]; exists {
 		return errors.New("user already exists")
 	}
+	email := domain.NormalizeAddress(user.Email)
+	if _, taken := r.byEmail[email]; taken {
+		return domain.ErrEmailTaken
+	}
 
-	r.users[user.ID] = user
-	r.sortedUsers = append(r.sortedUsers, user)
+	user.Version = 1
+	stored := *user
+	r.users[user.ID] = &stored
+	r.byEmail[email] = user.ID
+	r.sortedUsers = append(r.sortedUsers, &stored)
 	sort.Slice(r.sortedUsers, r.sortUsers)
 
 	return nil
@@ -47,54 +63,125 @@ func (r *UserRepository) sortUsers(i, j int) bool {
 	return r.sortedUsers[i].CreatedAt.Before(r.sortedUsers[j].CreatedAt)
 }
 
+// position returns the index of stored in sortedUsers, or -1
+func (r *UserRepository) position(stored *domain.User) int {
+	i := sort.Search(len(r.sortedUsers), func(i int) bool {
+		u := r.sortedUsers[i]
+		if u.CreatedAt.Equal(stored.CreatedAt) {
+			return u.ID >= stored.ID
+		}
+		return u.CreatedAt.After(stored.CreatedAt)
+	})
+	if i < len(r.sortedUsers) && r.sortedUsers[i].ID == stored.ID {
+		return i
+	}
+	return -1
+}
+
 func (r *UserRepository) GetByID(ctx context.Context, id string) (*domain.User, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
 	user, exists := r.users[id]
 	if !exists {
-		return nil, errors.New("email not found")
+		return nil, domain.ErrUserNotFound
+	}
+
+	found := *user
+	return &found, nil
+}
+
+// GetByEmail finds a user by address, ignoring case and surrounding spaces
+func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	id, exists := r.byEmail[domain.NormalizeAddress(email)]
+	if !exists {
+		return nil, domain.ErrUserNotFound
 	}
 
-	return user, nil
+	found := *r.users[id]
+	return &found, nil
 }
 
+// Update replaces the stored user if its version still matches user.Version
+// and bumps the version on both.
 func (r *UserRepository) Update(ctx context.Context, user *domain.User) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	if _, exists := r.users[user.ID]; !exists {
-		return errors.New("email not found")
+	current, exists := r.users[user.ID]
+	if !exists {
+		return domain.ErrUserNotFound
+	}
+	if user.Version != 0 && user.Version != current.Version {
+		return domain.ErrVersionConflict
 	}
 
-	r.users[user.ID] = user
+	oldEmail, newEmail := domain.NormalizeAddress(current.Email), domain.NormalizeAddress(user.Email)
+	if oldEmail != newEmail {
+		if _, taken := r.byEmail[newEmail]; taken {
+			return domain.ErrEmailTaken
+		}
+		delete(r.byEmail, oldEmail)
+		r.byEmail[newEmail] = user.ID
+	}
+
+	i := r.position(current)
+	user.Version = current.Version + 1
+	user.CreatedAt = current.CreatedAt
+	stored := *user
+	r.users[user.ID] = &stored
+	if i >= 0 {
+		r.sortedUsers[i] = &stored
+	}
 	return nil
 }
 
-func (r *UserRepository) Delete(ctx context.Context, id string) error {
+// Delete removes the user from every index if version matches
+func (r *UserRepository) Delete(ctx context.Context, id string, version int64) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	if _, exists := r.users[id]; !exists {
-		return errors.New("email not found")
+	current, exists := r.users[id]
+	if !exists {
+		return domain.ErrUserNotFound
+	}
+	if version != 0 && version != current.Version {
+		return domain.ErrVersionConflict
 	}
 
+	if i := r.position(current); i >= 0 {
+		r.sortedUsers = slices.Delete(r.sortedUsers, i, i+1)
+	}
+	delete(r.byEmail, domain.NormalizeAddress(current.Email))
 	delete(r.users, id)
 	return nil
 }
 
+// listScope keeps user page tokens from being used on other listings
+var listScope = pagination.NewScope("users")
+
 func (r *UserRepository) List(ctx context.Context, pageSize int, pageToken string) ([]*domain.User, string, error) {
+	if pageSize <= 0 {
+		pageSize = 10
+	}
+
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
+	// The cursor carries the sort key of the last user returned, so the next
+	// page starts at the right place even if that user has since been deleted
 	startIndex := 0
 	if pageToken != "" {
-		for i, user := range r.sortedUsers {
-			if user.ID == pageToken {
-				startIndex = i + 1
-				break
-			}
+		cursor, err := r.cursors.Decode(listScope, pageToken)
+		if err != nil {
+			return nil, "", err
 		}
+		startIndex = sort.Search(len(r.sortedUsers), func(i int) bool {
+			return cursor.After(r.sortedUsers[i].CreatedAt, r.sortedUsers[i].ID)
+		})
 	}
 
 	if startIndex >= len(r.sortedUsers) {
@@ -106,11 +193,16 @@ func (r *UserRepository) List(ctx context.Context, pageSize int, pageToken strin
 		endIndex = len(r.sortedUsers)
 	}
 
-	result := r.sortedUsers[startIndex:endIndex]
+	result := make([]*domain.User, 0, endIndex-startIndex)
+	for _, user := range r.sortedUsers[startIndex:endIndex] {
+		found := *user
+		result = append(result, &found)
+	}
 
 	var nextPageToken string
 	if endIndex < len(r.sortedUsers) {
-		nextPageToken = r.sortedUsers[endIndex-1].ID
+		last := r.sortedUsers[endIndex-1]
+		nextPageToken = r.cursors.Encode(listScope, pagination.Cursor{CreatedAt: last.CreatedAt, ID: last.ID})
 	}
 
 	return result, nextPageToken, nil
@@ -10,12 +10,13 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/popeskul/mailflow/common/logger"
+	"github.com/popeskul/mailflow/common/pagination"
 	"github.com/popeskul/mailflow/user-service/internal/domain"
 )
 
 func createTestUserRepository() *UserRepository {
 	testLogger := logger.NewZapLogger()
-	return newUserRepository(testLogger)
+	return newUserRepository(pagination.NewCodec([]byte("test-secret")), testLogger)
 }
 
 func createTestUser(email, name string) *domain.User {
@@ -83,6 +84,72 @@ func TestUserRepository_Create_Fail(t *testing.T) {
 	}
 }
 
+func TestUserRepository_Create_EmailTaken(t *testing.T) {
+	tests := []struct {
+		name  string
+		email string
+	}{
+		{name: "same address", email: "test@example.com"},
+		{name: "different case", email: "Test@Example.COM"},
+		{name: "surrounding spaces", email: "  test@example.com "},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := createTestUserRepository()
+			require.NoError(t, repo.Create(context.Background(), createTestUser("test@example.com", "First")))
+
+			err := repo.Create(context.Background(), createTestUser(tt.email, "Second"))
+
+			assert.ErrorIs(t, err, domain.ErrEmailTaken)
+		})
+	}
+}
+
+func TestUserRepository_GetByEmail_Success(t *testing.T) {
+	repo := createTestUserRepository()
+	user := createTestUser("Test@Example.com", "Test User")
+	require.NoError(t, repo.Create(context.Background(), user))
+
+	found, err := repo.GetByEmail(context.Background(), "test@example.COM")
+
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, found.ID)
+	assert.Equal(t, "Test@Example.com", found.Email)
+}
+
+func TestUserRepository_GetByEmail_Fail(t *testing.T) {
+	repo := createTestUserRepository()
+
+	user, err := repo.GetByEmail(context.Background(), "missing@example.com")
+
+	assert.ErrorIs(t, err, domain.ErrUserNotFound)
+	assert.Nil(t, user)
+}
+
+func TestUserRepository_EmailIndexFollowsWrites(t *testing.T) {
+	repo := createTestUserRepository()
+	alice := createTestUser("alice@example.com", "Alice")
+	bob := createTestUser("bob@example.com", "Bob")
+	require.NoError(t, repo.Create(context.Background(), alice))
+	require.NoError(t, repo.Create(context.Background(), bob))
+
+	// Taking another user's address is rejected
+	bob.Email = "ALICE@example.com"
+	assert.ErrorIs(t, repo.Update(context.Background(), bob), domain.ErrEmailTaken)
+
+	// Changing address frees the old one
+	alice.Email = "alice@new.example.com"
+	require.NoError(t, repo.Update(context.Background(), alice))
+	_, err := repo.GetByEmail(context.Background(), "alice@example.com")
+	assert.ErrorIs(t, err, domain.ErrUserNotFound)
+	require.NoError(t, repo.Update(context.Background(), bob))
+
+	// Deleting frees the address for new sign-ups
+	require.NoError(t, repo.Delete(context.Background(), bob.ID, 0))
+	assert.NoError(t, repo.Create(context.Background(), createTestUser("alice@example.com", "New")))
+}
+
 func TestUserRepository_GetByID_Success(t *testing.T) {
 	tests := []struct {
 		name string
@@ -133,7 +200,7 @@ func TestUserRepository_GetByID_Fail(t *testing.T) {
 
 			assert.Error(t, err)
 			assert.Nil(t, user)
-			assert.Contains(t, err.Error(), "email not found")
+			assert.ErrorIs(t, err, domain.ErrUserNotFound)
 		})
 	}
 }
@@ -191,11 +258,56 @@ func TestUserRepository_Update_Fail(t *testing.T) {
 			err := repo.Update(context.Background(), tt.user)
 
 			assert.Error(t, err)
-			assert.Contains(t, err.Error(), "email not found")
+			assert.ErrorIs(t, err, domain.ErrUserNotFound)
 		})
 	}
 }
 
+func TestUserRepository_Update_VersionConflict(t *testing.T) {
+	repo := createTestUserRepository()
+	user := createTestUser("test@example.com", "Test User")
+	require.NoError(t, repo.Create(context.Background(), user))
+
+	first, err := repo.GetByID(context.Background(), user.ID)
+	require.NoError(t, err)
+	second, err := repo.GetByID(context.Background(), user.ID)
+	require.NoError(t, err)
+
+	first.Name = "First"
+	require.NoError(t, repo.Update(context.Background(), first))
+	assert.Equal(t, int64(2), first.Version)
+
+	// second was read before first was written
+	second.Name = "Second"
+	assert.ErrorIs(t, repo.Update(context.Background(), second), domain.ErrVersionConflict)
+	assert.ErrorIs(t, repo.Delete(context.Background(), user.ID, second.Version), domain.ErrVersionConflict)
+
+	stored, err := repo.GetByID(context.Background(), user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "First", stored.Name)
+
+	// Listings see the update too
+	users, _, err := repo.List(context.Background(), 10, "")
+	require.NoError(t, err)
+	require.Len(t, users, 1)
+	assert.Equal(t, "First", users[0].Name)
+}
+
+func TestUserRepository_ReturnsCopies(t *testing.T) {
+	repo := createTestUserRepository()
+	user := createTestUser("test@example.com", "Test User")
+	require.NoError(t, repo.Create(context.Background(), user))
+
+	found, err := repo.GetByID(context.Background(), user.ID)
+	require.NoError(t, err)
+	found.Name = "Changed without Update"
+	user.Name = "Also changed"
+
+	stored, err := repo.GetByID(context.Background(), user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Test User", stored.Name)
+}
+
 func TestUserRepository_Delete_Success(t *testing.T) {
 	tests := []struct {
 		name string
@@ -216,7 +328,7 @@ func TestUserRepository_Delete_Success(t *testing.T) {
 			require.NoError(t, err)
 
 			// Delete user
-			err = repo.Delete(context.Background(), tt.user.ID)
+			err = repo.Delete(context.Background(), tt.user.ID, tt.user.Version)
 
 			assert.NoError(t, err)
 
@@ -224,6 +336,10 @@ func TestUserRepository_Delete_Success(t *testing.T) {
 			user, err := repo.GetByID(context.Background(), tt.user.ID)
 			assert.Error(t, err)
 			assert.Nil(t, user)
+
+			users, _, err := repo.List(context.Background(), 10, "")
+			require.NoError(t, err)
+			assert.Empty(t, users)
 		})
 	}
 }
@@ -243,10 +359,10 @@ func TestUserRepository_Delete_Fail(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			repo := createTestUserRepository()
 
-			err := repo.Delete(context.Background(), tt.id)
+			err := repo.Delete(context.Background(), tt.id, 0)
 
 			assert.Error(t, err)
-			assert.Contains(t, err.Error(), "email not found")
+			assert.ErrorIs(t, err, domain.ErrUserNotFound)
 		})
 	}
 }
@@ -361,20 +477,92 @@ func TestUserRepository_List_WithPageToken(t *testing.T) {
 	}
 }
 
+func TestUserRepository_List_DefaultPageSize(t *testing.T) {
+	tests := []struct {
+		name     string
+		pageSize int
+	}{
+		{name: "zero page size", pageSize: 0},
+		{name: "negative page size", pageSize: -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := createTestUserRepository()
+			for i := 0; i < 12; i++ {
+				require.NoError(t, repo.Create(context.Background(), createTestUser(fmt.Sprintf("user%d@example.com", i), "User")))
+			}
+
+			firstPage, nextToken, err := repo.List(context.Background(), tt.pageSize, "")
+			require.NoError(t, err)
+			assert.Len(t, firstPage, 10)
+			require.NotEmpty(t, nextToken)
+
+			secondPage, finalToken, err := repo.List(context.Background(), tt.pageSize, nextToken)
+			require.NoError(t, err)
+			assert.Len(t, secondPage, 2)
+			assert.Empty(t, finalToken)
+		})
+	}
+}
+
 func TestUserRepository_List_InvalidPageToken(t *testing.T) {
 	repo := createTestUserRepository()
 
-	// Create a user
 	user := createTestUser("test@example.com", "Test User")
-	err := repo.Create(context.Background(), user)
+	require.NoError(t, repo.Create(context.Background(), user))
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{name: "raw user id", token: user.ID},
+		{name: "garbage", token: "invalid-token"},
+		{
+			name:  "signed with another key",
+			token: pagination.NewCodec([]byte("other")).Encode(listScope, pagination.Cursor{CreatedAt: user.CreatedAt, ID: user.ID}),
+		},
+		{
+			name:  "issued for another listing",
+			token: pagination.NewCodec([]byte("test-secret")).Encode(pagination.NewScope("emails"), pagination.Cursor{CreatedAt: user.CreatedAt, ID: user.ID}),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users, nextToken, err := repo.List(context.Background(), 10, tt.token)
+
+			assert.ErrorIs(t, err, pagination.ErrInvalidToken)
+			assert.Nil(t, users)
+			assert.Empty(t, nextToken)
+		})
+	}
+}
+
+func TestUserRepository_List_PageTokenSurvivesInsert(t *testing.T) {
+	repo := createTestUserRepository()
+	base := time.Now()
+	for i := 0; i < 4; i++ {
+		user := createTestUser(fmt.Sprintf("user%d@example.com", i), fmt.Sprintf("User %d", i))
+		user.ID = fmt.Sprintf("user-%d", i)
+		user.CreatedAt = base.Add(time.Duration(i) * time.Second)
+		require.NoError(t, repo.Create(context.Background(), user))
+	}
+
+	firstPage, nextToken, err := repo.List(context.Background(), 2, "")
 	require.NoError(t, err)
+	require.Len(t, firstPage, 2)
 
-	// Use invalid page token - this will start from beginning since token not found
-	users, nextToken, err := repo.List(context.Background(), 10, "invalid-token")
+	// A user sorting before the cursor must not shift the next page
+	early := createTestUser("early@example.com", "Early")
+	early.CreatedAt = base.Add(-time.Hour)
+	require.NoError(t, repo.Create(context.Background(), early))
 
-	assert.NoError(t, err)
-	assert.Equal(t, 1, len(users)) // Should return all users since invalid token means start from beginning
-	assert.Empty(t, nextToken)
+	secondPage, _, err := repo.List(context.Background(), 2, nextToken)
+	require.NoError(t, err)
+	require.Len(t, secondPage, 2)
+	assert.Equal(t, "user-2", secondPage[0].ID)
+	assert.Equal(t, "user-3", secondPage[1].ID)
 }
 
 func TestUserRepository_SortUsers(t *testing.T) {
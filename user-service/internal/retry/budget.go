@@ -0,0 +1,68 @@
+package retry
+
+import "sync"
+
+// Budget throttles retries across every call that shares it, like gRPC's
+// retry throttling: each failed attempt takes a token from the bucket and
+// each success puts TokenRatio back. While the bucket is half empty or
+// worse calls are not retried, so an outage is not multiplied by retries.
+type Budget struct {
+	mu         sync.Mutex
+	maxTokens  float64
+	tokenRatio float64
+	tokens     float64
+}
+
+// NewBudget creates a full budget of maxTokens that regains tokenRatio
+// tokens per successful call
+func NewBudget(maxTokens, tokenRatio float64) *Budget {
+	return &Budget{
+		maxTokens:  maxTokens,
+		tokenRatio: tokenRatio,
+		tokens:     maxTokens,
+	}
+}
+
+// DefaultBudget allows retries until about ten more calls failed than
+// succeeded ten times over
+func DefaultBudget() *Budget {
+	return NewBudget(10, 0.1)
+}
+
+// Allow reports whether a retry may be made. A nil budget allows all.
+func (b *Budget) Allow() bool {
+	if b == nil {
+		return true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.tokens > b.maxTokens/2
+}
+
+// Tokens returns the tokens left in the bucket
+func (b *Budget) Tokens() float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.tokens
+}
+
+func (b *Budget) recordFailure() {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens = max(b.tokens-1, 0)
+}
+
+func (b *Budget) recordSuccess() {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens = min(b.tokens+b.tokenRatio, b.maxTokens)
+}
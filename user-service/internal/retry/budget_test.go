@@ -0,0 +1,207 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func fastBackoff(attempts int) *ExponentialBackoff {
+	return &ExponentialBackoff{InitialDelay: time.Millisecond, MaxDelay: time.Millisecond, MaxAttempts: attempts}
+}
+
+// failing returns a RetryableFunc that returns err and counts its calls
+func failing(err error, calls *int) RetryableFunc {
+	return func(context.Context) error {
+		*calls++
+		return err
+	}
+}
+
+func TestBudget_Allow(t *testing.T) {
+	b := NewBudget(4, 0.5)
+	assert.True(t, b.Allow())
+
+	b.recordFailure()
+	assert.True(t, b.Allow())
+
+	// Half the tokens are gone
+	b.recordFailure()
+	assert.False(t, b.Allow())
+
+	b.recordSuccess()
+	assert.True(t, b.Allow())
+	assert.Equal(t, 2.5, b.Tokens())
+
+	// The bucket neither overflows nor goes below empty
+	for range 10 {
+		b.recordSuccess()
+	}
+	assert.Equal(t, 4.0, b.Tokens())
+	for range 10 {
+		b.recordFailure()
+	}
+	assert.Equal(t, 0.0, b.Tokens())
+
+	var unlimited *Budget
+	assert.True(t, unlimited.Allow())
+}
+
+func TestRetrier_Do_Budget(t *testing.T) {
+	budget := NewBudget(4, 0.1)
+	r1 := New(fastBackoff(5), WithBudget(budget))
+	r2 := New(fastBackoff(5), WithBudget(budget))
+
+	// The first call spends the shared budget
+	calls := 0
+	err := r1.Do(context.Background(), failing(status.Error(codes.Unavailable, "down"), &calls))
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assert.Equal(t, 2, calls)
+
+	// so the other retrier makes a single attempt
+	calls = 0
+	err = r2.Do(context.Background(), failing(status.Error(codes.Unavailable, "down"), &calls))
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assert.Equal(t, 1, calls)
+}
+
+func TestRetrier_Do_Codes(t *testing.T) {
+	tests := []struct {
+		name  string
+		opts  []RetrierOption
+		err   error
+		calls int
+	}{
+		{name: "unavailable", err: status.Error(codes.Unavailable, "down"), calls: 3},
+		{name: "resource exhausted", err: status.Error(codes.ResourceExhausted, "busy"), calls: 3},
+		{name: "invalid argument", err: status.Error(codes.InvalidArgument, "bad"), calls: 1},
+		{name: "internal", err: status.Error(codes.Internal, "bug"), calls: 1},
+		{name: "plain error", err: errors.New("connection reset"), calls: 3},
+		{
+			name:  "custom codes",
+			opts:  []RetrierOption{WithRetryableCodes(codes.Internal)},
+			err:   status.Error(codes.Internal, "bug"),
+			calls: 3,
+		},
+		{
+			name:  "custom codes leave out the defaults",
+			opts:  []RetrierOption{WithRetryableCodes(codes.Internal)},
+			err:   status.Error(codes.Unavailable, "down"),
+			calls: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			err := New(fastBackoff(3), tt.opts...).Do(context.Background(), failing(tt.err, &calls))
+
+			assert.ErrorIs(t, err, tt.err)
+			assert.Equal(t, status.Code(tt.err), status.Code(err))
+			assert.Equal(t, tt.calls, calls)
+		})
+	}
+}
+
+func TestWithPushback(t *testing.T) {
+	errDown := status.Error(codes.Unavailable, "down")
+
+	tests := []struct {
+		name    string
+		err     error
+		trailer metadata.MD
+		want    error
+	}{
+		{name: "no error", trailer: metadata.Pairs(PushbackTrailer, "10")},
+		{name: "no trailer", err: errDown, want: errDown},
+		{
+			name:    "delay",
+			err:     errDown,
+			trailer: metadata.Pairs(PushbackTrailer, "1500"),
+			want:    &Pushback{Err: errDown, Delay: 1500 * time.Millisecond},
+		},
+		{
+			name:    "negative stops",
+			err:     errDown,
+			trailer: metadata.Pairs(PushbackTrailer, "-1"),
+			want:    &Pushback{Err: errDown, Stop: true},
+		},
+		{
+			name:    "malformed stops",
+			err:     errDown,
+			trailer: metadata.Pairs(PushbackTrailer, "soon"),
+			want:    &Pushback{Err: errDown, Stop: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, WithPushback(tt.err, tt.trailer))
+		})
+	}
+}
+
+func TestRetrier_Do_Pushback(t *testing.T) {
+	errBusy := status.Error(codes.ResourceExhausted, "busy")
+
+	t.Run("delay replaces the backoff", func(t *testing.T) {
+		r := New(&ExponentialBackoff{InitialDelay: time.Hour, MaxDelay: time.Hour, MaxAttempts: 2})
+
+		calls := 0
+		err := r.Do(context.Background(), func(context.Context) error {
+			calls++
+			return &Pushback{Err: errBusy, Delay: time.Millisecond}
+		})
+
+		// Do returns the server's errors, not the hints
+		assert.Equal(t, &Error{Errs: []error{errBusy, errBusy}}, err)
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("delay over the max delay ends the retries", func(t *testing.T) {
+		r := New(&ExponentialBackoff{InitialDelay: time.Millisecond, MaxDelay: time.Second, MaxAttempts: 3})
+
+		calls := 0
+		err := r.Do(context.Background(), func(context.Context) error {
+			calls++
+			return &Pushback{Err: errBusy, Delay: time.Hour}
+		})
+
+		assert.Equal(t, &Error{Errs: []error{errBusy}}, err)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("delay past the deadline ends the retries", func(t *testing.T) {
+		clock := newFakeClock()
+		r := New(&Deadline{Strategy: &Constant{Delay: time.Millisecond, MaxAttempts: 5}, MaxElapsed: time.Minute},
+			WithClock(clock))
+
+		calls := 0
+		err := r.Do(context.Background(), func(context.Context) error {
+			calls++
+			// Two 25s waits fit the minute, a third would run past it
+			return &Pushback{Err: errBusy, Delay: 25 * time.Second}
+		})
+
+		assert.Equal(t, &Error{Errs: []error{errBusy, errBusy, errBusy}}, err)
+		assert.Equal(t, 3, calls)
+		assert.Equal(t, 50*time.Second, clock.Now().Sub(newFakeClock().Now()))
+	})
+
+	t.Run("stop ends the retries", func(t *testing.T) {
+		calls := 0
+		err := New(fastBackoff(3)).Do(context.Background(), func(context.Context) error {
+			calls++
+			return &Pushback{Err: errBusy, Stop: true}
+		})
+
+		assert.Equal(t, &Error{Errs: []error{errBusy}}, err)
+		assert.Equal(t, 1, calls)
+	})
+}
@@ -0,0 +1,52 @@
+package retry
+
+import (
+	"fmt"
+	"strings"
+
+	"google.golang.org/grpc/status"
+)
+
+// Error is returned by Do when a call fails. Like an error from errors.Join
+// it wraps the error of every attempt, in order, so errors.Is and errors.As
+// look through all of them; when Do stops because its context is done the
+// context's error comes last.
+type Error struct {
+	Errs []error
+}
+
+// newError returns nil when no attempt failed
+func newError(errs []error) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return &Error{Errs: errs}
+}
+
+func (e *Error) Error() string {
+	if len(e.Errs) == 1 {
+		return e.Errs[0].Error()
+	}
+
+	messages := make([]string, len(e.Errs))
+	for i, err := range e.Errs {
+		messages[i] = err.Error()
+	}
+	return fmt.Sprintf("%d attempts failed: %s", len(e.Errs), strings.Join(messages, "; "))
+}
+
+func (e *Error) Unwrap() []error {
+	return e.Errs
+}
+
+// Last returns the error Do gave up on
+func (e *Error) Last() error {
+	return e.Errs[len(e.Errs)-1]
+}
+
+// GRPCStatus reports the status of the last error, so status.Code and
+// status.FromError see the error the call finally failed with rather than
+// the first one errors.As finds
+func (e *Error) GRPCStatus() *status.Status {
+	return status.Convert(e.Last())
+}
@@ -0,0 +1,250 @@
+package retry
+
+import (
+	"context"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// latencySamples is how many recent latencies a percentile is taken of
+	latencySamples = 100
+	// minLatencySamples are needed before the percentile replaces the delay
+	minLatencySamples = 10
+)
+
+// Hedger cuts tail latency by sending another request when the first is
+// slow and taking whichever answers first; the others are cancelled. It
+// suits idempotent reads, where extra load matters less than latency.
+//
+// Hedges are limited by a Budget: each call puts TokenRatio tokens in the
+// bucket and each hedge takes one, so with the default budget about one
+// call in ten is hedged once the bucket is half empty.
+type Hedger struct {
+	delay      time.Duration
+	percentile float64
+	maxHedges  int
+	budget     *Budget
+	when       func() bool
+	clock      Clock
+	latencies  *latencies
+}
+
+// HedgerOption configures a Hedger
+type HedgerOption func(*Hedger)
+
+// WithHedgeDelay sends a hedge once a request has taken d
+func WithHedgeDelay(d time.Duration) HedgerOption {
+	return func(h *Hedger) {
+		h.delay = d
+	}
+}
+
+// WithHedgePercentile sends a hedge once a request has taken longer than
+// the fraction p, e.g. 0.95, of recent successful requests. Until enough
+// requests were seen the delay from WithHedgeDelay is used.
+func WithHedgePercentile(p float64) HedgerOption {
+	return func(h *Hedger) {
+		h.percentile = p
+	}
+}
+
+// WithMaxHedges sets how many hedges a call may send, one per delay
+func WithMaxHedges(n int) HedgerOption {
+	return func(h *Hedger) {
+		h.maxHedges = n
+	}
+}
+
+// WithHedgeBudget limits hedges by budget, which should not be shared
+// with a Retrier
+func WithHedgeBudget(budget *Budget) HedgerOption {
+	return func(h *Hedger) {
+		h.budget = budget
+	}
+}
+
+// WithHedgeWhen only sends hedges while allow returns true, e.g. while a
+// circuit breaker is closed
+func WithHedgeWhen(allow func() bool) HedgerOption {
+	return func(h *Hedger) {
+		h.when = allow
+	}
+}
+
+// WithHedgeClock sets the clock requests are timed with
+func WithHedgeClock(clock Clock) HedgerOption {
+	return func(h *Hedger) {
+		h.clock = clock
+	}
+}
+
+// NewHedger creates a Hedger. Without WithHedgeDelay or WithHedgePercentile
+// it never hedges.
+func NewHedger(opts ...HedgerOption) *Hedger {
+	h := &Hedger{
+		maxHedges: 1,
+		budget:    DefaultBudget(),
+		clock:     realClock{},
+		latencies: &latencies{},
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// Do calls fn, hedging it when it is slow. It returns nil as soon as one
+// request succeeds, or the first request's error when all of them fail.
+func (h *Hedger) Do(ctx context.Context, fn RetryableFunc) error {
+	_, err := h.do(ctx, func(ctx context.Context, _ int) error {
+		return fn(ctx)
+	})
+	return err
+}
+
+// Hedge calls fn through h and returns the result of the request that
+// succeeded first
+func Hedge[T any](ctx context.Context, h *Hedger, fn func(ctx context.Context) (T, error)) (T, error) {
+	results := make([]T, h.maxHedges+1)
+
+	winner, err := h.do(ctx, func(ctx context.Context, i int) error {
+		result, err := fn(ctx)
+		results[i] = result
+		return err
+	})
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return results[winner], nil
+}
+
+type outcome struct {
+	request int
+	err     error
+	took    time.Duration
+}
+
+// do runs fn as request 0 and as hedges 1 to maxHedges, and returns the
+// request that succeeded
+func (h *Hedger) do(ctx context.Context, fn func(ctx context.Context, request int) error) (int, error) {
+	// Cancelling the context on return stops the requests that lost
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	outcomes := make(chan outcome, h.maxHedges+1)
+	launch := func(request int) {
+		go func() {
+			start := h.clock.Now()
+			err := fn(ctx, request)
+			outcomes <- outcome{request: request, err: err, took: h.clock.Now().Sub(start)}
+		}()
+	}
+
+	h.budget.recordSuccess()
+	launch(0)
+	sent, pending := 1, 1
+
+	delay, hedging := h.hedgeDelay()
+	var timer <-chan time.Time
+	if hedging && h.maxHedges > 0 {
+		timer = h.clock.After(delay)
+	}
+
+	var firstErr error
+	for pending > 0 {
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case <-timer:
+			timer = nil
+			if !h.allow() {
+				continue
+			}
+
+			h.budget.recordFailure()
+			trace.SpanFromContext(ctx).AddEvent("retry.hedge", trace.WithAttributes(
+				attribute.Int("retry.hedge", sent),
+				attribute.Int64("retry.delay_ms", delay.Milliseconds()),
+			))
+			launch(sent)
+			sent++
+			pending++
+
+			if sent <= h.maxHedges {
+				timer = h.clock.After(delay)
+			}
+		case o := <-outcomes:
+			pending--
+			if o.err == nil {
+				h.latencies.add(o.took)
+				return o.request, nil
+			}
+			if o.request == 0 {
+				firstErr = o.err
+			}
+		}
+	}
+
+	return 0, firstErr
+}
+
+func (h *Hedger) allow() bool {
+	if h.when != nil && !h.when() {
+		return false
+	}
+	return h.budget.Allow()
+}
+
+// hedgeDelay returns how long a request may take before it is hedged, and
+// false when calls are not hedged
+func (h *Hedger) hedgeDelay() (time.Duration, bool) {
+	if h.percentile > 0 {
+		if d, ok := h.latencies.percentile(h.percentile); ok {
+			return d, true
+		}
+	}
+	return h.delay, h.delay > 0
+}
+
+// latencies keeps the most recent successful request latencies
+type latencies struct {
+	mu      sync.Mutex
+	samples []time.Duration
+	next    int
+}
+
+func (l *latencies) add(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.samples) < latencySamples {
+		l.samples = append(l.samples, d)
+		return
+	}
+	l.samples[l.next] = d
+	l.next = (l.next + 1) % latencySamples
+}
+
+// percentile returns the latency the fraction p of the samples is at or
+// below, and false while there are too few samples
+func (l *latencies) percentile(p float64) (time.Duration, bool) {
+	l.mu.Lock()
+	sorted := slices.Clone(l.samples)
+	l.mu.Unlock()
+
+	if len(sorted) < minLatencySamples {
+		return 0, false
+	}
+
+	slices.Sort(sorted)
+	// The nearest-rank method
+	i := int(math.Ceil(p*float64(len(sorted)))) - 1
+	return sorted[min(max(i, 0), len(sorted)-1)], true
+}
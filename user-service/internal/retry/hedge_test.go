@@ -0,0 +1,167 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// slowFirst answers with the request number after the first request has
+// been cancelled, or right away for hedges
+func slowFirst(cancelled chan<- error) func(ctx context.Context) (int, error) {
+	var calls atomic.Int32
+	return func(ctx context.Context) (int, error) {
+		n := int(calls.Add(1))
+		if n == 1 {
+			<-ctx.Done()
+			cancelled <- ctx.Err()
+			return 0, ctx.Err()
+		}
+		return n, nil
+	}
+}
+
+func TestHedge_Success(t *testing.T) {
+	cancelled := make(chan error, 1)
+	h := NewHedger(WithHedgeDelay(time.Millisecond))
+
+	got, err := Hedge(context.Background(), h, slowFirst(cancelled))
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, got)
+	// The first request lost and was cancelled
+	assert.Equal(t, context.Canceled, <-cancelled)
+}
+
+func TestHedge_Fail(t *testing.T) {
+	errFirst := errors.New("first failed")
+
+	tests := []struct {
+		name  string
+		opts  []HedgerOption
+		fn    func(calls *atomic.Int32) func(ctx context.Context) (int, error)
+		err   error
+		calls int32
+	}{
+		{
+			name: "fails before the delay",
+			opts: []HedgerOption{WithHedgeDelay(time.Hour)},
+			fn: func(calls *atomic.Int32) func(ctx context.Context) (int, error) {
+				return func(context.Context) (int, error) {
+					calls.Add(1)
+					return 0, errFirst
+				}
+			},
+			err:   errFirst,
+			calls: 1,
+		},
+		{
+			name: "every request fails",
+			opts: []HedgerOption{WithHedgeDelay(time.Millisecond), WithMaxHedges(2)},
+			fn: func(calls *atomic.Int32) func(ctx context.Context) (int, error) {
+				return func(context.Context) (int, error) {
+					if calls.Add(1) == 1 {
+						time.Sleep(20 * time.Millisecond)
+						return 0, errFirst
+					}
+					return 0, errors.New("hedge failed")
+				}
+			},
+			// The first request's error is returned
+			err:   errFirst,
+			calls: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			_, err := Hedge(context.Background(), NewHedger(tt.opts...), tt.fn(&calls))
+
+			assert.Equal(t, tt.err, err)
+			assert.Equal(t, tt.calls, calls.Load())
+		})
+	}
+}
+
+func TestHedger_Do_NoHedge(t *testing.T) {
+	tests := []struct {
+		name  string
+		opts  []HedgerOption
+		setup func(t *testing.T, h *Hedger)
+	}{
+		{name: "no delay"},
+		{name: "percentile without samples", opts: []HedgerOption{WithHedgePercentile(0.5)}},
+		{name: "no hedges", opts: []HedgerOption{WithHedgeDelay(time.Millisecond), WithMaxHedges(0)}},
+		{name: "not allowed", opts: []HedgerOption{WithHedgeDelay(time.Millisecond), WithHedgeWhen(func() bool { return false })}},
+		{
+			name: "budget spent",
+			opts: []HedgerOption{WithHedgeDelay(time.Millisecond), WithHedgeBudget(NewBudget(2, 0))},
+			setup: func(t *testing.T, h *Hedger) {
+				// This call spends half the budget on a hedge
+				_, err := Hedge(context.Background(), h, slowFirst(make(chan error, 1)))
+				require.NoError(t, err)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHedger(tt.opts...)
+			if tt.setup != nil {
+				tt.setup(t, h)
+			}
+
+			var calls atomic.Int32
+			err := h.Do(context.Background(), func(context.Context) error {
+				calls.Add(1)
+				time.Sleep(20 * time.Millisecond)
+				return nil
+			})
+
+			assert.NoError(t, err)
+			assert.Equal(t, int32(1), calls.Load())
+		})
+	}
+}
+
+func TestHedger_Do_ContextDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := NewHedger(WithHedgeDelay(time.Hour)).Do(ctx, func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestHedger_hedgeDelay(t *testing.T) {
+	h := NewHedger(WithHedgeDelay(time.Second), WithHedgePercentile(0.9))
+
+	// The delay is used until there are enough samples
+	for i := 1; i < minLatencySamples; i++ {
+		h.latencies.add(time.Duration(i) * time.Millisecond)
+	}
+	delay, ok := h.hedgeDelay()
+	assert.True(t, ok)
+	assert.Equal(t, time.Second, delay)
+
+	h.latencies.add(10 * time.Millisecond)
+	delay, ok = h.hedgeDelay()
+	assert.True(t, ok)
+	assert.Equal(t, 9*time.Millisecond, delay)
+
+	// Old samples make way for new ones
+	for range latencySamples {
+		h.latencies.add(time.Minute)
+	}
+	delay, _ = h.hedgeDelay()
+	assert.Equal(t, time.Minute, delay)
+}
@@ -0,0 +1,68 @@
+package retry
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Attempt describes a failed attempt that is about to be retried
+type Attempt struct {
+	// Number counts attempts from 1
+	Number int
+	Err    error
+	// Delay is the wait before the next attempt
+	Delay time.Duration
+}
+
+// Result describes a finished call to Do
+type Result struct {
+	// Attempts is how many times the function was called
+	Attempts int
+	// Delay is the total time spent waiting between attempts
+	Delay time.Duration
+	// Err is the error Do returned
+	Err error
+}
+
+// WithOnRetry calls hook before each wait for the next attempt. Hooks run
+// in the order they were added, on the goroutine calling Do.
+func WithOnRetry(hook func(ctx context.Context, attempt Attempt)) RetrierOption {
+	return func(r *Retrier) {
+		r.onRetry = append(r.onRetry, hook)
+	}
+}
+
+// WithOnDone calls hook when a call to Do returns
+func WithOnDone(hook func(ctx context.Context, result Result)) RetrierOption {
+	return func(r *Retrier) {
+		r.onDone = append(r.onDone, hook)
+	}
+}
+
+func (r *Retrier) retrying(ctx context.Context, attempt Attempt) {
+	for _, hook := range r.onRetry {
+		hook(ctx, attempt)
+	}
+}
+
+func (r *Retrier) done(ctx context.Context, result Result) error {
+	for _, hook := range r.onDone {
+		hook(ctx, result)
+	}
+	return result.Err
+}
+
+// attempted records an attempt, made after waiting delay, on the span in ctx
+func attempted(ctx context.Context, number int, delay time.Duration, err error) {
+	attrs := []attribute.KeyValue{
+		attribute.Int("retry.attempt", number),
+		attribute.Int64("retry.delay_ms", delay.Milliseconds()),
+	}
+	if err != nil {
+		attrs = append(attrs, attribute.String("retry.error", err.Error()))
+	}
+	trace.SpanFromContext(ctx).AddEvent("retry.attempt", trace.WithAttributes(attrs...))
+}
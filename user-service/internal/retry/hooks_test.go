@@ -0,0 +1,139 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRetrier_Do_Hooks(t *testing.T) {
+	errDown := status.Error(codes.Unavailable, "down")
+
+	tests := []struct {
+		name    string
+		results []error
+		retries []Attempt
+		result  Result
+	}{
+		{
+			name:    "first attempt succeeds",
+			results: []error{nil},
+			result:  Result{Attempts: 1},
+		},
+		{
+			name:    "succeeds after retries",
+			results: []error{errDown, errDown, nil},
+			retries: []Attempt{
+				{Number: 1, Err: errDown, Delay: 10 * time.Millisecond},
+				{Number: 2, Err: errDown, Delay: 10 * time.Millisecond},
+			},
+			result: Result{Attempts: 3, Delay: 20 * time.Millisecond},
+		},
+		{
+			name:    "every attempt fails",
+			results: []error{errDown, errDown, errDown},
+			retries: []Attempt{
+				{Number: 1, Err: errDown, Delay: 10 * time.Millisecond},
+				{Number: 2, Err: errDown, Delay: 10 * time.Millisecond},
+			},
+			result: Result{
+				Attempts: 3,
+				Delay:    20 * time.Millisecond,
+				Err:      &Error{Errs: []error{errDown, errDown, errDown}},
+			},
+		},
+		{
+			name:    "pushback delay",
+			results: []error{&Pushback{Err: errDown, Delay: time.Second}, nil},
+			retries: []Attempt{{Number: 1, Err: errDown, Delay: time.Second}},
+			result:  Result{Attempts: 2, Delay: time.Second},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var retries []Attempt
+			var results []Result
+
+			r := New(&Constant{Delay: 10 * time.Millisecond, MaxAttempts: 3},
+				WithClock(newFakeClock()),
+				WithOnRetry(func(_ context.Context, a Attempt) { retries = append(retries, a) }),
+				WithOnDone(func(_ context.Context, r Result) { results = append(results, r) }),
+			)
+
+			calls := 0
+			err := r.Do(context.Background(), func(context.Context) error {
+				calls++
+				return tt.results[calls-1]
+			})
+
+			assert.Equal(t, tt.result.Err, err)
+			assert.Equal(t, tt.retries, retries)
+			assert.Equal(t, []Result{tt.result}, results)
+		})
+	}
+}
+
+func TestRetrier_Do_SpanEvents(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+
+	ctx, span := tracer.Start(context.Background(), "send")
+	calls := 0
+	_ = New(&Constant{Delay: 25 * time.Millisecond, MaxAttempts: 3}, WithClock(newFakeClock())).Do(ctx, func(context.Context) error {
+		calls++
+		if calls == 1 {
+			return errors.New("connection reset")
+		}
+		return nil
+	})
+	span.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	events := spans[0].Events()
+	require.Len(t, events, 2)
+
+	attrs := make([]map[string]any, len(events))
+	for i, event := range events {
+		assert.Equal(t, "retry.attempt", event.Name)
+		attrs[i] = map[string]any{}
+		for _, kv := range event.Attributes {
+			attrs[i][string(kv.Key)] = kv.Value.AsInterface()
+		}
+	}
+	assert.Equal(t, []map[string]any{
+		{"retry.attempt": int64(1), "retry.delay_ms": int64(0), "retry.error": "connection reset"},
+		{"retry.attempt": int64(2), "retry.delay_ms": int64(25)},
+	}, attrs)
+}
+
+func TestError(t *testing.T) {
+	errReset := errors.New("connection reset")
+	errDown := status.Error(codes.Unavailable, "down")
+
+	single := &Error{Errs: []error{errDown}}
+	assert.Equal(t, errDown.Error(), single.Error())
+
+	err := error(&Error{Errs: []error{errReset, errDown}})
+	assert.Equal(t, "2 attempts failed: connection reset; rpc error: code = Unavailable desc = down", err.Error())
+	assert.ErrorIs(t, err, errReset)
+	assert.ErrorIs(t, err, errDown)
+
+	// The status is the last attempt's, not the first one errors.As finds
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assert.Equal(t, codes.Unknown, status.Code(&Error{Errs: []error{errDown, errReset}}))
+
+	// A call that was cancelled while waiting ends with the context's error
+	canceled := &Error{Errs: []error{errDown, context.Canceled}}
+	assert.ErrorIs(t, canceled, context.Canceled)
+	assert.Equal(t, context.Canceled, canceled.Last())
+}
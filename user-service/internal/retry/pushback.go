@@ -0,0 +1,49 @@
+package retry
+
+import (
+	"strconv"
+	"time"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// PushbackTrailer is the trailer a server sets to say when to retry, in
+// milliseconds, as in gRPC's retry design. A negative or malformed value
+// asks the client not to retry at all.
+const PushbackTrailer = "grpc-retry-pushback-ms"
+
+// Pushback carries a server's retry hint along with the error it came with
+type Pushback struct {
+	Err error
+	// Delay replaces the strategy's delay before the next attempt
+	Delay time.Duration
+	// Stop is set when the server asked not to be retried
+	Stop bool
+}
+
+func (p *Pushback) Error() string {
+	return p.Err.Error()
+}
+
+func (p *Pushback) Unwrap() error {
+	return p.Err
+}
+
+// WithPushback attaches the retry hint in a call's trailer to its error.
+// err is returned as is when it is nil or the trailer holds no hint.
+func WithPushback(err error, trailer metadata.MD) error {
+	if err == nil {
+		return nil
+	}
+
+	values := trailer.Get(PushbackTrailer)
+	if len(values) == 0 {
+		return err
+	}
+
+	ms, parseErr := strconv.ParseInt(values[0], 10, 64)
+	if parseErr != nil || ms < 0 {
+		return &Pushback{Err: err, Stop: true}
+	}
+	return &Pushback{Err: err, Delay: time.Duration(ms) * time.Millisecond}
+}
@@ -2,9 +2,12 @@ package retry
 
 import (
 	"context"
+	"errors"
 	"math"
-	"math/rand"
 	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 // Strategy defines the retry strategy interface
@@ -22,6 +25,9 @@ type ExponentialBackoff struct {
 	Multiplier   float64
 	MaxAttempts  int
 	Jitter       bool
+	// Rand returns a number in [0, 1) for the jitter; math/rand is used
+	// when nil
+	Rand func() float64
 }
 
 // DefaultExponentialBackoff returns default exponential backoff configuration
@@ -48,7 +54,7 @@ func (e *ExponentialBackoff) NextDelay(attempt int) time.Duration {
 
 	if e.Jitter {
 		// Add jitter: random value between 0 and delay
-		jitter := rand.Float64() * delay * 0.3 // 30% jitter
+		jitter := random(e.Rand) * delay * 0.3 // 30% jitter
 		delay = delay + jitter
 	}
 
@@ -60,6 +66,22 @@ func (e *ExponentialBackoff) ShouldRetry(attempt int) bool {
 	return attempt < e.MaxAttempts
 }
 
+func (e *ExponentialBackoff) delayLimit() (time.Duration, bool) {
+	return maxDelayLimit(e.MaxDelay)
+}
+
+func (e *ExponentialBackoff) withInitialDelay(d time.Duration) Strategy {
+	copied := *e
+	copied.InitialDelay = d
+	return &copied
+}
+
+func (e *ExponentialBackoff) withMaxAttempts(n int) Strategy {
+	copied := *e
+	copied.MaxAttempts = n
+	return &copied
+}
+
 // RetryableFunc is a function that can be retried
 type RetryableFunc func(ctx context.Context) error
 
@@ -69,47 +91,154 @@ type RetryableError interface {
 	Retryable() bool
 }
 
+// DefaultRetryableCodes are the gRPC codes that say the call may succeed
+// if made again. Other codes are answers, retrying them only adds load.
+var DefaultRetryableCodes = []codes.Code{
+	codes.Unavailable,
+	codes.ResourceExhausted,
+	codes.Aborted,
+	codes.DeadlineExceeded,
+}
+
 // Retrier handles retry logic
 type Retrier struct {
-	strategy Strategy
+	strategy  Strategy
+	budget    *Budget
+	retryable map[codes.Code]bool
+	clock     Clock
+	onRetry   []func(context.Context, Attempt)
+	onDone    []func(context.Context, Result)
+}
+
+// RetrierOption configures a Retrier
+type RetrierOption func(*Retrier)
+
+// WithBudget limits retries by a budget, which may be shared by retriers
+func WithBudget(budget *Budget) RetrierOption {
+	return func(r *Retrier) {
+		r.budget = budget
+	}
+}
+
+// WithRetryableCodes sets which gRPC status codes are retried, in place of
+// DefaultRetryableCodes
+func WithRetryableCodes(retryable ...codes.Code) RetrierOption {
+	return func(r *Retrier) {
+		r.retryable = codeSet(retryable)
+	}
+}
+
+// WithClock sets the clock delays and deadlines are measured with
+func WithClock(clock Clock) RetrierOption {
+	return func(r *Retrier) {
+		r.clock = clock
+	}
 }
 
 // New creates a new Retrier with the given strategy
-func New(strategy Strategy) *Retrier {
+func New(strategy Strategy, opts ...RetrierOption) *Retrier {
 	if strategy == nil {
 		strategy = DefaultExponentialBackoff()
 	}
-	return &Retrier{strategy: strategy}
+
+	r := &Retrier{
+		strategy:  strategy,
+		retryable: codeSet(DefaultRetryableCodes),
+		clock:     realClock{},
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
 }
 
-// Do executes the function with retry logic
+// Do executes the function with retry logic. Errors are retried unless
+// they are a RetryableError that says otherwise, or a gRPC status whose
+// code is not retryable. A Pushback from fn sets the next delay or stops
+// the retries; it also stops them when its delay is longer than the
+// strategy's MaxDelay or the time left before its Deadline. When fn never succeeds Do returns an *Error wrapping the
+// error of each attempt, without their Pushback.
 func (r *Retrier) Do(ctx context.Context, fn RetryableFunc) error {
-	var lastErr error
+	var errs []error
+	var pushback *Pushback
+	var result Result
 
-	for attempt := 0; r.strategy.ShouldRetry(attempt); attempt++ {
+	strategy := start(r.strategy, r.clock)
+	for attempt := 0; strategy.ShouldRetry(attempt); attempt++ {
+		var delay time.Duration
 		if attempt > 0 {
-			delay := r.strategy.NextDelay(attempt)
+			if !r.budget.Allow() {
+				break
+			}
+
+			delay = strategy.NextDelay(attempt)
+			if pushback != nil {
+				delay = pushback.Delay
+			}
+			r.retrying(ctx, Attempt{Number: attempt, Err: errs[len(errs)-1], Delay: delay})
+
 			select {
 			case <-ctx.Done():
-				return ctx.Err()
-			case <-time.After(delay):
+				result.Err = newError(append(errs, ctx.Err()))
+				return r.done(ctx, result)
+			case <-r.clock.After(delay):
 			}
+			result.Delay += delay
 		}
 
+		result.Attempts++
 		err := fn(ctx)
+
+		pushback = nil
+		if errors.As(err, &pushback) {
+			err = pushback.Err
+		}
+		attempted(ctx, result.Attempts, delay, err)
+
 		if err == nil {
-			return nil
+			r.budget.recordSuccess()
+			return r.done(ctx, result)
 		}
+		errs = append(errs, err)
 
-		lastErr = err
+		if !r.isRetryable(err) {
+			break
+		}
+		r.budget.recordFailure()
 
-		// Check if error is retryable
-		if retryable, ok := err.(RetryableError); ok && !retryable.Retryable() {
-			return err
+		if pushback != nil && (pushback.Stop || outlasts(strategy, pushback.Delay)) {
+			break
 		}
 	}
 
-	return lastErr
+	result.Err = newError(errs)
+	return r.done(ctx, result)
+}
+
+// outlasts reports whether waiting delay is longer than s allows
+func outlasts(s Strategy, delay time.Duration) bool {
+	limit, ok := delayLimit(s)
+	return ok && delay > limit
+}
+
+func (r *Retrier) isRetryable(err error) bool {
+	var retryable RetryableError
+	if errors.As(err, &retryable) {
+		return retryable.Retryable()
+	}
+
+	if st, ok := status.FromError(err); ok {
+		return r.retryable[st.Code()]
+	}
+	return true
+}
+
+func codeSet(list []codes.Code) map[codes.Code]bool {
+	set := make(map[codes.Code]bool, len(list))
+	for _, code := range list {
+		set[code] = true
+	}
+	return set
 }
 
 // WithRetry is a helper function for simple retry logic
@@ -141,20 +270,25 @@ func WithStrategy(strategy Strategy) Option {
 	}
 }
 
-// WithMaxAttempts sets the maximum number of retry attempts
+// WithMaxAttempts sets the maximum number of attempts of the strategy set
+// so far, which is left unchanged
 func WithMaxAttempts(attempts int) Option {
 	return func(c *Config) {
-		if eb, ok := c.Strategy.(*ExponentialBackoff); ok {
-			eb.MaxAttempts = attempts
-		}
+		c.Strategy = setMaxAttempts(c.Strategy, attempts)
 	}
 }
 
-// WithInitialDelay sets the initial retry delay
+// WithInitialDelay sets the delay the strategy set so far starts from, or
+// for strategies without one the delay before the first retry
 func WithInitialDelay(delay time.Duration) Option {
 	return func(c *Config) {
-		if eb, ok := c.Strategy.(*ExponentialBackoff); ok {
-			eb.InitialDelay = delay
-		}
+		c.Strategy = setInitialDelay(c.Strategy, delay)
+	}
+}
+
+// WithMaxElapsed stops retrying once d has passed since the call began
+func WithMaxElapsed(d time.Duration) Option {
+	return func(c *Config) {
+		c.Strategy = &Deadline{Strategy: c.Strategy, MaxElapsed: d}
 	}
 }
@@ -79,7 +79,7 @@ func TestRetrier_MaxAttempts(t *testing.T) {
 		return testErr
 	})
 
-	if err != testErr {
+	if !errors.Is(err, testErr) {
 		t.Errorf("Expected test error, got %v", err)
 	}
 	if callCount != 3 {
@@ -112,7 +112,7 @@ func TestRetrier_ContextCancellation(t *testing.T) {
 		return testErr
 	})
 
-	if err != context.Canceled {
+	if !errors.Is(err, context.Canceled) {
 		t.Errorf("Expected context.Canceled, got %v", err)
 	}
 	if callCount != 1 {
@@ -144,7 +144,7 @@ func TestRetrier_NonRetryableError(t *testing.T) {
 		return nonRetryableErr
 	})
 
-	if err != nonRetryableErr {
+	if !errors.Is(err, nonRetryableErr) {
 		t.Errorf("Expected non-retryable error, got %v", err)
 	}
 	if callCount != 1 {
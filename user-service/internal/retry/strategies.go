@@ -0,0 +1,403 @@
+package retry
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Starter is a Strategy whose delays depend on earlier attempts of the same
+// call. Retrier calls Start when a call begins and uses the Strategy it
+// returns for that call only, so one Starter can be shared by calls.
+type Starter interface {
+	Start(clock Clock) Strategy
+}
+
+// start returns the strategy to use for one call
+func start(s Strategy, clock Clock) Strategy {
+	if starter, ok := s.(Starter); ok {
+		return starter.Start(clock)
+	}
+	return s
+}
+
+// initialDelayer is implemented by strategies whose delays grow from an
+// initial delay, so WithInitialDelay can change it
+type initialDelayer interface {
+	withInitialDelay(d time.Duration) Strategy
+}
+
+// setInitialDelay returns s starting from d. Strategies without an initial
+// delay wait d before the first retry and as before after that.
+func setInitialDelay(s Strategy, d time.Duration) Strategy {
+	if delayer, ok := s.(initialDelayer); ok {
+		return delayer.withInitialDelay(d)
+	}
+	return &firstDelay{Strategy: s, delay: d}
+}
+
+// maxAttempter is implemented by strategies with an attempt limit, so
+// WithMaxAttempts can change it
+type maxAttempter interface {
+	withMaxAttempts(n int) Strategy
+}
+
+// setMaxAttempts returns s making at most n attempts. The limit of other
+// strategies is replaced by n.
+func setMaxAttempts(s Strategy, n int) Strategy {
+	if attempter, ok := s.(maxAttempter); ok {
+		return attempter.withMaxAttempts(n)
+	}
+	return &maxAttempts{Strategy: s, attempts: n}
+}
+
+// delayLimiter is implemented by strategies that bound how long a call
+// waits before its next retry, so a Pushback cannot wait past them
+type delayLimiter interface {
+	delayLimit() (time.Duration, bool)
+}
+
+// delayLimit returns the longest s waits before the next retry, and false
+// when s does not limit it
+func delayLimit(s Strategy) (time.Duration, bool) {
+	if limiter, ok := s.(delayLimiter); ok {
+		return limiter.delayLimit()
+	}
+	return 0, false
+}
+
+// maxDelayLimit is the delay limit of a strategy with a MaxDelay, which
+// is unlimited when zero
+func maxDelayLimit(d time.Duration) (time.Duration, bool) {
+	return d, d > 0
+}
+
+// random returns a number in [0, 1) from r, or from math/rand when r is nil
+func random(r func() float64) float64 {
+	if r == nil {
+		return rand.Float64()
+	}
+	return r()
+}
+
+// Constant waits the same delay before every retry
+type Constant struct {
+	Delay       time.Duration
+	MaxAttempts int
+}
+
+func (c *Constant) NextDelay(int) time.Duration {
+	return c.Delay
+}
+
+func (c *Constant) ShouldRetry(attempt int) bool {
+	return attempt < c.MaxAttempts
+}
+
+func (c *Constant) withInitialDelay(d time.Duration) Strategy {
+	copied := *c
+	copied.Delay = d
+	return &copied
+}
+
+func (c *Constant) withMaxAttempts(n int) Strategy {
+	copied := *c
+	copied.MaxAttempts = n
+	return &copied
+}
+
+// Fibonacci grows the delay along the Fibonacci sequence: InitialDelay,
+// InitialDelay, 2×, 3×, 5× and so on up to MaxDelay. It backs off more
+// gently than doubling.
+type Fibonacci struct {
+	InitialDelay time.Duration
+	MaxDelay     time.Duration
+	MaxAttempts  int
+}
+
+func (f *Fibonacci) NextDelay(attempt int) time.Duration {
+	prev, cur := 0.0, 1.0
+	for i := 1; i < attempt; i++ {
+		prev, cur = cur, prev+cur
+	}
+
+	delay := float64(f.InitialDelay) * cur
+	if f.MaxDelay > 0 && delay > float64(f.MaxDelay) {
+		return f.MaxDelay
+	}
+	return time.Duration(delay)
+}
+
+func (f *Fibonacci) ShouldRetry(attempt int) bool {
+	return attempt < f.MaxAttempts
+}
+
+func (f *Fibonacci) delayLimit() (time.Duration, bool) {
+	return maxDelayLimit(f.MaxDelay)
+}
+
+func (f *Fibonacci) withInitialDelay(d time.Duration) Strategy {
+	copied := *f
+	copied.InitialDelay = d
+	return &copied
+}
+
+func (f *Fibonacci) withMaxAttempts(n int) Strategy {
+	copied := *f
+	copied.MaxAttempts = n
+	return &copied
+}
+
+// FullJitter waits a random delay between zero and the exponential
+// backoff delay. Clients that failed together spread their retries out
+// instead of retrying in step.
+type FullJitter struct {
+	InitialDelay time.Duration
+	MaxDelay     time.Duration
+	Multiplier   float64
+	MaxAttempts  int
+	// Rand returns a number in [0, 1); math/rand is used when nil
+	Rand func() float64
+}
+
+func (f *FullJitter) NextDelay(attempt int) time.Duration {
+	ceiling := float64(f.InitialDelay) * math.Pow(f.Multiplier, float64(max(attempt-1, 0)))
+	if f.MaxDelay > 0 && ceiling > float64(f.MaxDelay) {
+		ceiling = float64(f.MaxDelay)
+	}
+	return time.Duration(random(f.Rand) * ceiling)
+}
+
+func (f *FullJitter) ShouldRetry(attempt int) bool {
+	return attempt < f.MaxAttempts
+}
+
+func (f *FullJitter) delayLimit() (time.Duration, bool) {
+	return maxDelayLimit(f.MaxDelay)
+}
+
+func (f *FullJitter) withInitialDelay(d time.Duration) Strategy {
+	copied := *f
+	copied.InitialDelay = d
+	return &copied
+}
+
+func (f *FullJitter) withMaxAttempts(n int) Strategy {
+	copied := *f
+	copied.MaxAttempts = n
+	return &copied
+}
+
+// DecorrelatedJitter waits a random delay between InitialDelay and three
+// times the previous delay, capped at MaxDelay. Delays grow like
+// exponential backoff but vary more from call to call.
+type DecorrelatedJitter struct {
+	InitialDelay time.Duration
+	MaxDelay     time.Duration
+	MaxAttempts  int
+	// Rand returns a number in [0, 1); math/rand is used when nil
+	Rand func() float64
+}
+
+// NextDelay picks a delay as if the previous one was InitialDelay. Retrier
+// starts a DecorrelatedJitter per call to follow the actual delays.
+func (d *DecorrelatedJitter) NextDelay(int) time.Duration {
+	return d.next(d.InitialDelay)
+}
+
+func (d *DecorrelatedJitter) ShouldRetry(attempt int) bool {
+	return attempt < d.MaxAttempts
+}
+
+func (d *DecorrelatedJitter) Start(Clock) Strategy {
+	return &decorrelatedCall{DecorrelatedJitter: d, previous: d.InitialDelay}
+}
+
+func (d *DecorrelatedJitter) delayLimit() (time.Duration, bool) {
+	return maxDelayLimit(d.MaxDelay)
+}
+
+func (d *DecorrelatedJitter) withInitialDelay(delay time.Duration) Strategy {
+	copied := *d
+	copied.InitialDelay = delay
+	return &copied
+}
+
+func (d *DecorrelatedJitter) withMaxAttempts(n int) Strategy {
+	copied := *d
+	copied.MaxAttempts = n
+	return &copied
+}
+
+func (d *DecorrelatedJitter) next(previous time.Duration) time.Duration {
+	lower := float64(d.InitialDelay)
+	upper := max(float64(previous)*3, lower)
+	delay := lower + random(d.Rand)*(upper-lower)
+	if d.MaxDelay > 0 && delay > float64(d.MaxDelay) {
+		delay = float64(d.MaxDelay)
+	}
+	return time.Duration(delay)
+}
+
+// decorrelatedCall remembers the previous delay of one call
+type decorrelatedCall struct {
+	*DecorrelatedJitter
+	previous time.Duration
+}
+
+func (c *decorrelatedCall) NextDelay(int) time.Duration {
+	c.previous = c.next(c.previous)
+	return c.previous
+}
+
+// Deadline stops retrying once MaxElapsed has passed since the call began
+// and shortens the last delay so it does not run past it
+type Deadline struct {
+	Strategy
+	MaxElapsed time.Duration
+}
+
+func (d *Deadline) Start(clock Clock) Strategy {
+	return &deadlineCall{
+		Strategy: start(d.Strategy, clock),
+		clock:    clock,
+		deadline: clock.Now().Add(d.MaxElapsed),
+	}
+}
+
+func (d *Deadline) withInitialDelay(delay time.Duration) Strategy {
+	return &Deadline{Strategy: setInitialDelay(d.Strategy, delay), MaxElapsed: d.MaxElapsed}
+}
+
+func (d *Deadline) withMaxAttempts(n int) Strategy {
+	return &Deadline{Strategy: setMaxAttempts(d.Strategy, n), MaxElapsed: d.MaxElapsed}
+}
+
+// deadlineCall holds the deadline of one call
+type deadlineCall struct {
+	Strategy
+	clock    Clock
+	deadline time.Time
+}
+
+func (c *deadlineCall) NextDelay(attempt int) time.Duration {
+	return min(c.Strategy.NextDelay(attempt), c.deadline.Sub(c.clock.Now()))
+}
+
+// delayLimit is the time left before the deadline, or the limit of the
+// wrapped strategy when that is shorter
+func (c *deadlineCall) delayLimit() (time.Duration, bool) {
+	left := max(c.deadline.Sub(c.clock.Now()), 0)
+	if limit, ok := delayLimit(c.Strategy); ok && limit < left {
+		return limit, true
+	}
+	return left, true
+}
+
+func (c *deadlineCall) ShouldRetry(attempt int) bool {
+	if attempt > 0 && !c.clock.Now().Before(c.deadline) {
+		return false
+	}
+	return c.Strategy.ShouldRetry(attempt)
+}
+
+// maxAttempts replaces the attempt limit of a strategy without a
+// MaxAttempts of its own
+type maxAttempts struct {
+	Strategy
+	attempts int
+}
+
+func (m *maxAttempts) ShouldRetry(attempt int) bool {
+	return attempt < m.attempts
+}
+
+func (m *maxAttempts) delayLimit() (time.Duration, bool) {
+	return delayLimit(m.Strategy)
+}
+
+func (m *maxAttempts) Start(clock Clock) Strategy {
+	return &maxAttempts{Strategy: start(m.Strategy, clock), attempts: m.attempts}
+}
+
+func (m *maxAttempts) withInitialDelay(d time.Duration) Strategy {
+	return &maxAttempts{Strategy: setInitialDelay(m.Strategy, d), attempts: m.attempts}
+}
+
+func (m *maxAttempts) withMaxAttempts(n int) Strategy {
+	return &maxAttempts{Strategy: m.Strategy, attempts: n}
+}
+
+// firstDelay replaces the delay before the first retry of a strategy
+// without an initial delay of its own
+type firstDelay struct {
+	Strategy
+	delay time.Duration
+}
+
+func (f *firstDelay) NextDelay(attempt int) time.Duration {
+	if attempt <= 1 {
+		return f.delay
+	}
+	return f.Strategy.NextDelay(attempt)
+}
+
+func (f *firstDelay) delayLimit() (time.Duration, bool) {
+	return delayLimit(f.Strategy)
+}
+
+func (f *firstDelay) Start(clock Clock) Strategy {
+	return &firstDelay{Strategy: start(f.Strategy, clock), delay: f.delay}
+}
+
+func (f *firstDelay) withInitialDelay(d time.Duration) Strategy {
+	return &firstDelay{Strategy: f.Strategy, delay: d}
+}
+
+func (f *firstDelay) withMaxAttempts(n int) Strategy {
+	return &firstDelay{Strategy: setMaxAttempts(f.Strategy, n), delay: f.delay}
+}
+
+// Kind names a built-in strategy in configuration
+type Kind string
+
+const (
+	KindExponential        Kind = "exponential"
+	KindFullJitter         Kind = "full_jitter"
+	KindDecorrelatedJitter Kind = "decorrelated_jitter"
+	KindConstant           Kind = "constant"
+	KindFibonacci          Kind = "fibonacci"
+)
+
+// Valid reports whether k is a known kind. The empty kind is KindExponential.
+func (k Kind) Valid() bool {
+	switch k {
+	case "", KindExponential, KindFullJitter, KindDecorrelatedJitter, KindConstant, KindFibonacci:
+		return true
+	default:
+		return false
+	}
+}
+
+// NewStrategy builds a strategy of kind that makes at most attempts
+// attempts, waiting from initialDelay up to maxDelay between them
+func NewStrategy(kind Kind, initialDelay, maxDelay time.Duration, attempts int) Strategy {
+	switch kind {
+	case KindFullJitter:
+		return &FullJitter{InitialDelay: initialDelay, MaxDelay: maxDelay, Multiplier: 2, MaxAttempts: attempts}
+	case KindDecorrelatedJitter:
+		return &DecorrelatedJitter{InitialDelay: initialDelay, MaxDelay: maxDelay, MaxAttempts: attempts}
+	case KindConstant:
+		return &Constant{Delay: initialDelay, MaxAttempts: attempts}
+	case KindFibonacci:
+		return &Fibonacci{InitialDelay: initialDelay, MaxDelay: maxDelay, MaxAttempts: attempts}
+	default:
+		return &ExponentialBackoff{
+			InitialDelay: initialDelay,
+			MaxDelay:     maxDelay,
+			Multiplier:   2,
+			MaxAttempts:  attempts,
+			Jitter:       true,
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeClock moves forward only when waited on, so delays take no time
+type fakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	delays []time.Duration
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+	c.delays = append(c.delays, d)
+
+	ch := make(chan time.Time, 1)
+	ch <- c.now
+	return ch
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func (c *fakeClock) Delays() []time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]time.Duration(nil), c.delays...)
+}
+
+// fixed returns a Rand that always returns v
+func fixed(v float64) func() float64 {
+	return func() float64 { return v }
+}
+
+// delays runs a call that always fails and returns the delays it waited
+func delays(t *testing.T, strategy Strategy) []time.Duration {
+	t.Helper()
+
+	clock := newFakeClock()
+	err := New(strategy, WithClock(clock)).Do(context.Background(), func(context.Context) error {
+		return errors.New("service error")
+	})
+	assert.Error(t, err)
+
+	return clock.Delays()
+}
+
+func TestStrategies_NextDelay(t *testing.T) {
+	ms := time.Millisecond
+
+	tests := []struct {
+		name     string
+		strategy Strategy
+		want     []time.Duration
+	}{
+		{
+			name:     "constant",
+			strategy: &Constant{Delay: 50 * ms, MaxAttempts: 4},
+			want:     []time.Duration{50 * ms, 50 * ms, 50 * ms},
+		},
+		{
+			name:     "fibonacci",
+			strategy: &Fibonacci{InitialDelay: 10 * ms, MaxDelay: 45 * ms, MaxAttempts: 7},
+			want:     []time.Duration{10 * ms, 10 * ms, 20 * ms, 30 * ms, 45 * ms, 45 * ms},
+		},
+		{
+			name:     "exponential with jitter",
+			strategy: &ExponentialBackoff{InitialDelay: 100 * ms, MaxDelay: time.Second, Multiplier: 2, MaxAttempts: 3, Jitter: true, Rand: fixed(0.5)},
+			want:     []time.Duration{115 * ms, 230 * ms},
+		},
+		{
+			name:     "full jitter",
+			strategy: &FullJitter{InitialDelay: 100 * ms, MaxDelay: 300 * ms, Multiplier: 2, MaxAttempts: 5, Rand: fixed(0.5)},
+			want:     []time.Duration{50 * ms, 100 * ms, 150 * ms, 150 * ms},
+		},
+		{
+			name:     "decorrelated jitter at the top of its range",
+			strategy: &DecorrelatedJitter{InitialDelay: 10 * ms, MaxDelay: 200 * ms, MaxAttempts: 5, Rand: fixed(1)},
+			want:     []time.Duration{30 * ms, 90 * ms, 200 * ms, 200 * ms},
+		},
+		{
+			name:     "decorrelated jitter at the bottom of its range",
+			strategy: &DecorrelatedJitter{InitialDelay: 10 * ms, MaxDelay: 200 * ms, MaxAttempts: 4, Rand: fixed(0)},
+			want:     []time.Duration{10 * ms, 10 * ms, 10 * ms},
+		},
+		{
+			name: "deadline shortens the last delay",
+			strategy: &Deadline{
+				Strategy:   &Constant{Delay: 40 * ms, MaxAttempts: 10},
+				MaxElapsed: 100 * ms,
+			},
+			want: []time.Duration{40 * ms, 40 * ms, 20 * ms},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, delays(t, tt.strategy))
+		})
+	}
+}
+
+func TestDecorrelatedJitter_Start(t *testing.T) {
+	strategy := &DecorrelatedJitter{InitialDelay: 10 * time.Millisecond, MaxDelay: time.Second, MaxAttempts: 3, Rand: fixed(0.5)}
+
+	// Each call starts from InitialDelay, however far the last one got
+	first := delays(t, strategy)
+	second := delays(t, strategy)
+
+	assert.Equal(t, first, second)
+	assert.Equal(t, []time.Duration{20 * time.Millisecond, 35 * time.Millisecond}, first)
+}
+
+func TestDeadline_ShouldRetry(t *testing.T) {
+	clock := newFakeClock()
+	calls := 0
+
+	err := New(&Deadline{
+		Strategy:   &Constant{Delay: time.Millisecond, MaxAttempts: 10},
+		MaxElapsed: time.Second,
+	}, WithClock(clock)).Do(context.Background(), func(context.Context) error {
+		calls++
+		// Each attempt takes most of the time left
+		clock.Advance(600 * time.Millisecond)
+		return errors.New("slow")
+	})
+
+	assert.Error(t, err)
+	assert.Equal(t, 2, calls)
+}
+
+// custom is a strategy from outside the package
+type custom struct{}
+
+func (custom) NextDelay(int) time.Duration { return time.Minute }
+
+func (custom) ShouldRetry(attempt int) bool { return attempt < 2 }
+
+func TestOptions_AllStrategies(t *testing.T) {
+	ms := time.Millisecond
+
+	strategies := map[string]Strategy{
+		"exponential":  &ExponentialBackoff{InitialDelay: time.Second, MaxDelay: time.Second, Multiplier: 1, MaxAttempts: 2},
+		"constant":     &Constant{Delay: time.Second, MaxAttempts: 2},
+		"fibonacci":    &Fibonacci{InitialDelay: time.Second, MaxDelay: time.Second, MaxAttempts: 2},
+		"full jitter":  &FullJitter{InitialDelay: time.Second, MaxDelay: time.Second, Multiplier: 1, MaxAttempts: 2, Rand: fixed(0.5)},
+		"decorrelated": &DecorrelatedJitter{InitialDelay: time.Second, MaxDelay: time.Second, MaxAttempts: 2, Rand: fixed(0)},
+		"deadline":     &Deadline{Strategy: &Constant{Delay: time.Second, MaxAttempts: 2}, MaxElapsed: time.Hour},
+		"custom":       custom{},
+	}
+
+	for name, strategy := range strategies {
+		t.Run(name, func(t *testing.T) {
+			config := &Config{Strategy: strategy}
+			WithMaxAttempts(4)(config)
+			WithInitialDelay(10 * ms)(config)
+
+			got := delays(t, config.Strategy)
+
+			// Four attempts, the first retry after the new initial delay
+			assert.Len(t, got, 3)
+			assert.LessOrEqual(t, got[0], 10*ms)
+		})
+	}
+}
+
+func TestWithMaxElapsed(t *testing.T) {
+	config := &Config{Strategy: &Constant{Delay: 40 * time.Millisecond, MaxAttempts: 10}}
+	WithMaxElapsed(100 * time.Millisecond)(config)
+
+	assert.Equal(t, []time.Duration{40 * time.Millisecond, 40 * time.Millisecond, 20 * time.Millisecond}, delays(t, config.Strategy))
+}
+
+func TestNewStrategy(t *testing.T) {
+	tests := []struct {
+		kind Kind
+		want Strategy
+	}{
+		{kind: "", want: &ExponentialBackoff{}},
+		{kind: KindExponential, want: &ExponentialBackoff{}},
+		{kind: KindFullJitter, want: &FullJitter{}},
+		{kind: KindDecorrelatedJitter, want: &DecorrelatedJitter{}},
+		{kind: KindConstant, want: &Constant{}},
+		{kind: KindFibonacci, want: &Fibonacci{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.kind), func(t *testing.T) {
+			assert.True(t, tt.kind.Valid())
+
+			strategy := NewStrategy(tt.kind, 10*time.Millisecond, time.Second, 3)
+
+			assert.IsType(t, tt.want, strategy)
+			assert.Len(t, delays(t, strategy), 2)
+		})
+	}
+
+	assert.False(t, Kind("linear").Valid())
+}
@@ -0,0 +1,379 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/popeskul/mailflow/common/auth"
+	"github.com/popeskul/mailflow/common/logger"
+	"github.com/popeskul/mailflow/user-service/internal/domain"
+	"github.com/popeskul/mailflow/user-service/internal/passhash"
+)
+
+// refreshSecretLen is the number of random bytes in a refresh token
+const refreshSecretLen = 32
+
+// dummyHash is checked against when a login names an unknown user, so
+// that logins take as long whether or not the address is registered
+var dummyHash = sync.OnceValue(func() string {
+	hash, _ := passhash.Hash("not-a-real-password")
+	return hash
+})
+
+type AuthService struct {
+	users      domain.UserRepository
+	sessions   domain.SessionRepository
+	tokens     *auth.Tokens
+	refreshTTL time.Duration
+	admins     map[string]struct{}
+	logger     logger.Logger
+}
+
+// NewAuthService creates the auth service. Users whose address is one of
+// admins log in as auth.RoleAdmin. Without tokens every call fails with
+// domain.ErrAuthDisabled.
+func NewAuthService(
+	users domain.UserRepository,
+	sessions domain.SessionRepository,
+	tokens *auth.Tokens,
+	refreshTTL time.Duration,
+	admins []string,
+	l logger.Logger,
+) *AuthService {
+	adminSet := make(map[string]struct{}, len(admins))
+	for _, email := range admins {
+		adminSet[domain.NormalizeAddress(email)] = struct{}{}
+	}
+
+	return &AuthService{
+		users:      users,
+		sessions:   sessions,
+		tokens:     tokens,
+		refreshTTL: refreshTTL,
+		admins:     adminSet,
+		logger:     l.Named("auth_service"),
+	}
+}
+
+// Login checks a user's password and starts a new session
+func (s *AuthService) Login(ctx context.Context, email, password string) (*domain.User, *domain.TokenPair, error) {
+	if s.tokens == nil {
+		return nil, nil, domain.ErrAuthDisabled
+	}
+
+	user, err := s.users.GetByEmail(ctx, email)
+	if errors.Is(err, domain.ErrUserNotFound) {
+		_, _ = passhash.Verify(dummyHash(), password)
+		return nil, nil, domain.ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	l := s.logger.WithFields(logger.Fields{
+		"user_id": user.ID,
+	})
+
+	if err := s.checkPassword(user, password); err != nil {
+		l.Info("login failed")
+		return nil, nil, err
+	}
+
+	// Upgrade bcrypt hashes and outdated argon2id parameters while the
+	// plaintext is at hand. A failure only means trying again next time.
+	if passhash.NeedsRehash(user.PasswordHash) {
+		if err := s.setPassword(ctx, user, password); err != nil {
+			l.Warn("failed to rehash password",
+				logger.Field{Key: "error", Value: err},
+			)
+		}
+	}
+
+	tokens, err := s.startSession(ctx, user)
+	if err != nil {
+		l.Error("failed to start session",
+			logger.Field{Key: "error", Value: err},
+		)
+		return nil, nil, err
+	}
+
+	l.Info("user logged in")
+
+	return user, tokens, nil
+}
+
+// Refresh trades a refresh token for a new token pair. Each refresh token
+// works once: presenting an already rotated one means it leaked, so the
+// whole session is ended.
+func (s *AuthService) Refresh(ctx context.Context, refreshToken string) (*domain.TokenPair, error) {
+	if s.tokens == nil {
+		return nil, domain.ErrAuthDisabled
+	}
+
+	sessionID, secret, err := parseRefreshToken(refreshToken)
+	if err != nil {
+		return nil, err
+	}
+
+	l := s.logger.WithFields(logger.Fields{
+		"session_id": sessionID,
+	})
+
+	session, err := s.sessions.Get(ctx, sessionID)
+	if errors.Is(err, domain.ErrSessionNotFound) {
+		return nil, domain.ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	if !time.Now().Before(session.ExpiresAt) {
+		s.endSession(ctx, l, sessionID)
+		return nil, domain.ErrInvalidRefreshToken
+	}
+
+	user, err := s.users.GetByID(ctx, session.UserID)
+	if err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			s.endSession(ctx, l, sessionID)
+			return nil, domain.ErrInvalidRefreshToken
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	newSecret, err := newRefreshSecret()
+	if err != nil {
+		return nil, err
+	}
+	expiresAt := time.Now().Add(s.refreshTTL)
+
+	err = s.sessions.Rotate(ctx, sessionID, hashSecret(secret), hashSecret(newSecret), expiresAt)
+	switch {
+	case errors.Is(err, domain.ErrInvalidRefreshToken):
+		l.Warn("refresh token reused, ending session",
+			logger.Field{Key: "user_id", Value: session.UserID},
+		)
+		s.endSession(ctx, l, sessionID)
+		return nil, domain.ErrInvalidRefreshToken
+	case errors.Is(err, domain.ErrSessionNotFound):
+		return nil, domain.ErrInvalidRefreshToken
+	case err != nil:
+		return nil, fmt.Errorf("failed to rotate refresh token: %w", err)
+	}
+
+	return s.issue(user, sessionID, newSecret, expiresAt)
+}
+
+// Logout ends a session. Ending a session that is already gone is not an error.
+func (s *AuthService) Logout(ctx context.Context, sessionID string) error {
+	if s.tokens == nil {
+		return domain.ErrAuthDisabled
+	}
+
+	if err := s.sessions.Delete(ctx, sessionID); err != nil && !errors.Is(err, domain.ErrSessionNotFound) {
+		return fmt.Errorf("failed to end session: %w", err)
+	}
+
+	return nil
+}
+
+// ChangePassword replaces a user's password and ends all their sessions
+// except sessionID, the one the change was made from
+func (s *AuthService) ChangePassword(ctx context.Context, userID, sessionID, currentPassword, newPassword string) error {
+	if s.tokens == nil {
+		return domain.ErrAuthDisabled
+	}
+
+	if err := domain.ValidatePassword(newPassword); err != nil {
+		return err
+	}
+
+	l := s.logger.WithFields(logger.Fields{
+		"user_id": userID,
+	})
+
+	user, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if err := s.checkPassword(user, currentPassword); err != nil {
+		l.Info("password change rejected")
+		return err
+	}
+
+	if err := s.setPassword(ctx, user, newPassword); err != nil {
+		l.Error("failed to change password",
+			logger.Field{Key: "error", Value: err},
+		)
+		return err
+	}
+
+	if err := s.sessions.DeleteByUser(ctx, userID, sessionID); err != nil {
+		l.Error("failed to end other sessions",
+			logger.Field{Key: "error", Value: err},
+		)
+		return fmt.Errorf("failed to end other sessions: %w", err)
+	}
+
+	l.Info("password changed")
+
+	return nil
+}
+
+// Authenticate checks an access token and, for users, that its session has
+// not ended
+func (s *AuthService) Authenticate(ctx context.Context, accessToken string) (*auth.Principal, error) {
+	if s.tokens == nil {
+		return nil, domain.ErrAuthDisabled
+	}
+
+	principal, err := s.tokens.Parse(accessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	// Service tokens are short-lived and have no session
+	if principal.Role == auth.RoleService {
+		return principal, nil
+	}
+
+	session, err := s.sessions.Get(ctx, principal.SessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	if session.UserID != principal.UserID {
+		return nil, auth.ErrInvalidToken
+	}
+
+	return principal, nil
+}
+
+func (s *AuthService) checkPassword(user *domain.User, password string) error {
+	if user.PasswordHash == "" {
+		return domain.ErrInvalidCredentials
+	}
+
+	ok, err := passhash.Verify(user.PasswordHash, password)
+	if err != nil {
+		return fmt.Errorf("failed to verify password: %w", err)
+	}
+	if !ok {
+		return domain.ErrInvalidCredentials
+	}
+
+	return nil
+}
+
+func (s *AuthService) setPassword(ctx context.Context, user *domain.User, password string) error {
+	hash, err := passhash.Hash(password)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	user.PasswordHash = hash
+	user.UpdatedAt = time.Now()
+	if err := s.users.Update(ctx, user); err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+
+	return nil
+}
+
+func (s *AuthService) startSession(ctx context.Context, user *domain.User) (*domain.TokenPair, error) {
+	secret, err := newRefreshSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	session := &domain.Session{
+		ID:               uuid.New().String(),
+		UserID:           user.ID,
+		RefreshTokenHash: hashSecret(secret),
+		CreatedAt:        now,
+		ExpiresAt:        now.Add(s.refreshTTL),
+	}
+	if err := s.sessions.Create(ctx, session); err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+
+	return s.issue(user, session.ID, secret, session.ExpiresAt)
+}
+
+func (s *AuthService) endSession(ctx context.Context, l logger.Logger, sessionID string) {
+	if err := s.sessions.Delete(ctx, sessionID); err != nil && !errors.Is(err, domain.ErrSessionNotFound) {
+		l.Error("failed to end session",
+			logger.Field{Key: "error", Value: err},
+		)
+	}
+}
+
+func (s *AuthService) issue(user *domain.User, sessionID string, secret []byte, refreshExpiresAt time.Time) (*domain.TokenPair, error) {
+	accessToken, accessExpiresAt, err := s.tokens.Issue(auth.Principal{
+		UserID:    user.ID,
+		SessionID: sessionID,
+		Role:      s.role(user),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to issue access token: %w", err)
+	}
+
+	return &domain.TokenPair{
+		AccessToken:           accessToken,
+		AccessTokenExpiresAt:  accessExpiresAt,
+		RefreshToken:          sessionID + "." + base64.RawURLEncoding.EncodeToString(secret),
+		RefreshTokenExpiresAt: refreshExpiresAt,
+	}, nil
+}
+
+// role is looked up on every refresh, so removing an admin takes effect
+// within one access token lifetime. Only a verified address makes a user
+// an admin: anyone may sign up with, or change their email to, an admin
+// address nobody has claimed yet.
+func (s *AuthService) role(user *domain.User) auth.Role {
+	if !user.Verified {
+		return auth.RoleUser
+	}
+	if _, ok := s.admins[domain.NormalizeAddress(user.Email)]; ok {
+		return auth.RoleAdmin
+	}
+	return auth.RoleUser
+}
+
+// parseRefreshToken splits a "<session id>.<secret>" refresh token
+func parseRefreshToken(token string) (string, []byte, error) {
+	sessionID, encSecret, ok := strings.Cut(token, ".")
+	if !ok || sessionID == "" {
+		return "", nil, domain.ErrInvalidRefreshToken
+	}
+
+	secret, err := base64.RawURLEncoding.DecodeString(encSecret)
+	if err != nil || len(secret) != refreshSecretLen {
+		return "", nil, domain.ErrInvalidRefreshToken
+	}
+
+	return sessionID, secret, nil
+}
+
+func newRefreshSecret() ([]byte, error) {
+	secret := make([]byte, refreshSecretLen)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+	return secret, nil
+}
+
+func hashSecret(secret []byte) []byte {
+	sum := sha256.Sum256(secret)
+	return sum[:]
+}
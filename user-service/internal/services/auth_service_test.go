@@ -0,0 +1,520 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/popeskul/mailflow/common/auth"
+	"github.com/popeskul/mailflow/user-service/internal/domain"
+	"github.com/popeskul/mailflow/user-service/internal/passhash"
+	"github.com/popeskul/mailflow/user-service/internal/services/mocks"
+)
+
+func newTestAuthService(users *mocks.MockUserRepository, sessions *mocks.MockSessionRepository) *AuthService {
+	return NewAuthService(users, sessions, auth.NewTokens("secret", time.Minute), time.Hour,
+		[]string{"admin@example.com"}, createTestLogger())
+}
+
+func hashTestPassword(t *testing.T, password string) string {
+	t.Helper()
+	hash, err := passhash.Hash(password)
+	require.NoError(t, err)
+	return hash
+}
+
+func TestAuthService_Login_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	user := &domain.User{ID: "user-1", Email: "test@example.com", PasswordHash: hashTestPassword(t, "password123")}
+
+	users := mocks.NewMockUserRepository(ctrl)
+	users.EXPECT().GetByEmail(gomock.Any(), "test@example.com").Return(user, nil)
+
+	var created *domain.Session
+	sessions := mocks.NewMockSessionRepository(ctrl)
+	sessions.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, session *domain.Session) error {
+		created = session
+		return nil
+	})
+
+	service := newTestAuthService(users, sessions)
+
+	loggedIn, tokens, err := service.Login(context.Background(), "test@example.com", "password123")
+
+	require.NoError(t, err)
+	assert.Equal(t, user, loggedIn)
+	assert.Equal(t, "user-1", created.UserID)
+
+	principal, err := auth.NewTokens("secret", time.Minute).Parse(tokens.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, &auth.Principal{UserID: "user-1", SessionID: created.ID, Role: auth.RoleUser}, principal)
+
+	sessionID, secret, err := parseRefreshToken(tokens.RefreshToken)
+	require.NoError(t, err)
+	assert.Equal(t, created.ID, sessionID)
+	assert.Equal(t, created.RefreshTokenHash, hashSecret(secret))
+	assert.Equal(t, created.ExpiresAt, tokens.RefreshTokenExpiresAt)
+}
+
+func TestAuthService_Login_RehashesBcrypt(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	legacy, err := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	users := mocks.NewMockUserRepository(ctrl)
+	users.EXPECT().GetByEmail(gomock.Any(), gomock.Any()).Return(&domain.User{ID: "user-1", PasswordHash: string(legacy)}, nil)
+	users.EXPECT().Update(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, user *domain.User) error {
+		assert.True(t, strings.HasPrefix(user.PasswordHash, "$argon2id$"))
+		return nil
+	})
+
+	sessions := mocks.NewMockSessionRepository(ctrl)
+	sessions.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+
+	service := newTestAuthService(users, sessions)
+
+	_, _, err = service.Login(context.Background(), "test@example.com", "password123")
+
+	assert.NoError(t, err)
+}
+
+func TestAuthService_Login_Fail(t *testing.T) {
+	hash := hashTestPassword(t, "password123")
+
+	tests := []struct {
+		name        string
+		disabled    bool
+		password    string
+		setupMocks  func(*mocks.MockUserRepository)
+		expectedErr error
+	}{
+		{
+			name:        "auth disabled",
+			disabled:    true,
+			password:    "password123",
+			setupMocks:  func(*mocks.MockUserRepository) {},
+			expectedErr: domain.ErrAuthDisabled,
+		},
+		{
+			name:     "unknown address",
+			password: "password123",
+			setupMocks: func(users *mocks.MockUserRepository) {
+				users.EXPECT().GetByEmail(gomock.Any(), gomock.Any()).Return(nil, domain.ErrUserNotFound)
+			},
+			expectedErr: domain.ErrInvalidCredentials,
+		},
+		{
+			name:     "wrong password",
+			password: "password456",
+			setupMocks: func(users *mocks.MockUserRepository) {
+				users.EXPECT().GetByEmail(gomock.Any(), gomock.Any()).Return(&domain.User{ID: "user-1", PasswordHash: hash}, nil)
+			},
+			expectedErr: domain.ErrInvalidCredentials,
+		},
+		{
+			name:     "user without password",
+			password: "password123",
+			setupMocks: func(users *mocks.MockUserRepository) {
+				users.EXPECT().GetByEmail(gomock.Any(), gomock.Any()).Return(&domain.User{ID: "user-1"}, nil)
+			},
+			expectedErr: domain.ErrInvalidCredentials,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			users := mocks.NewMockUserRepository(ctrl)
+			tt.setupMocks(users)
+			sessions := mocks.NewMockSessionRepository(ctrl)
+
+			service := newTestAuthService(users, sessions)
+			if tt.disabled {
+				service = NewAuthService(users, sessions, nil, time.Hour, nil, createTestLogger())
+			}
+
+			user, tokens, err := service.Login(context.Background(), "test@example.com", tt.password)
+
+			assert.ErrorIs(t, err, tt.expectedErr)
+			assert.Nil(t, user)
+			assert.Nil(t, tokens)
+		})
+	}
+}
+
+func TestAuthService_Refresh_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	secret := make([]byte, refreshSecretLen)
+	refreshToken := "session-1." + base64.RawURLEncoding.EncodeToString(secret)
+
+	users := mocks.NewMockUserRepository(ctrl)
+	users.EXPECT().GetByID(gomock.Any(), "user-1").Return(&domain.User{ID: "user-1", Email: "Admin@Example.com", Verified: true}, nil)
+
+	var rotatedTo []byte
+	sessions := mocks.NewMockSessionRepository(ctrl)
+	sessions.EXPECT().Get(gomock.Any(), "session-1").Return(&domain.Session{
+		ID:               "session-1",
+		UserID:           "user-1",
+		RefreshTokenHash: hashSecret(secret),
+		ExpiresAt:        time.Now().Add(time.Hour),
+	}, nil)
+	sessions.EXPECT().Rotate(gomock.Any(), "session-1", hashSecret(secret), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ string, _, newHash []byte, _ time.Time) error {
+			rotatedTo = newHash
+			return nil
+		})
+
+	service := newTestAuthService(users, sessions)
+
+	tokens, err := service.Refresh(context.Background(), refreshToken)
+
+	require.NoError(t, err)
+	assert.NotEqual(t, refreshToken, tokens.RefreshToken)
+	_, newSecret, err := parseRefreshToken(tokens.RefreshToken)
+	require.NoError(t, err)
+	assert.Equal(t, rotatedTo, hashSecret(newSecret))
+
+	principal, err := auth.NewTokens("secret", time.Minute).Parse(tokens.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, auth.RoleAdmin, principal.Role, "configured admins get the admin role")
+}
+
+func TestAuthService_role(t *testing.T) {
+	tests := []struct {
+		name string
+		user *domain.User
+		role auth.Role
+	}{
+		{
+			name: "verified admin address",
+			user: &domain.User{Email: "Admin@Example.com", Verified: true},
+			role: auth.RoleAdmin,
+		},
+		{
+			name: "unverified admin address",
+			user: &domain.User{Email: "admin@example.com"},
+			role: auth.RoleUser,
+		},
+		{
+			name: "verified other address",
+			user: &domain.User{Email: "alice@example.com", Verified: true},
+			role: auth.RoleUser,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newTestAuthService(nil, nil)
+
+			assert.Equal(t, tt.role, service.role(tt.user))
+		})
+	}
+}
+
+func TestAuthService_Refresh_Fail(t *testing.T) {
+	secret := make([]byte, refreshSecretLen)
+	refreshToken := "session-1." + base64.RawURLEncoding.EncodeToString(secret)
+	session := func(expiresIn time.Duration) *domain.Session {
+		return &domain.Session{ID: "session-1", UserID: "user-1", ExpiresAt: time.Now().Add(expiresIn)}
+	}
+
+	tests := []struct {
+		name          string
+		refreshToken  string
+		setupMocks    func(*mocks.MockUserRepository, *mocks.MockSessionRepository)
+		expectedErr   error
+		expectedError string
+	}{
+		{
+			name:         "malformed token",
+			refreshToken: "session-1",
+			setupMocks:   func(*mocks.MockUserRepository, *mocks.MockSessionRepository) {},
+			expectedErr:  domain.ErrInvalidRefreshToken,
+		},
+		{
+			name:         "session ended",
+			refreshToken: refreshToken,
+			setupMocks: func(_ *mocks.MockUserRepository, sessions *mocks.MockSessionRepository) {
+				sessions.EXPECT().Get(gomock.Any(), "session-1").Return(nil, domain.ErrSessionNotFound)
+			},
+			expectedErr: domain.ErrInvalidRefreshToken,
+		},
+		{
+			name:         "session expired",
+			refreshToken: refreshToken,
+			setupMocks: func(_ *mocks.MockUserRepository, sessions *mocks.MockSessionRepository) {
+				sessions.EXPECT().Get(gomock.Any(), "session-1").Return(session(-time.Minute), nil)
+				sessions.EXPECT().Delete(gomock.Any(), "session-1").Return(nil)
+			},
+			expectedErr: domain.ErrInvalidRefreshToken,
+		},
+		{
+			name:         "user deleted",
+			refreshToken: refreshToken,
+			setupMocks: func(users *mocks.MockUserRepository, sessions *mocks.MockSessionRepository) {
+				sessions.EXPECT().Get(gomock.Any(), "session-1").Return(session(time.Hour), nil)
+				users.EXPECT().GetByID(gomock.Any(), "user-1").Return(nil, domain.ErrUserNotFound)
+				sessions.EXPECT().Delete(gomock.Any(), "session-1").Return(nil)
+			},
+			expectedErr: domain.ErrInvalidRefreshToken,
+		},
+		{
+			name:         "reused token ends session",
+			refreshToken: refreshToken,
+			setupMocks: func(users *mocks.MockUserRepository, sessions *mocks.MockSessionRepository) {
+				sessions.EXPECT().Get(gomock.Any(), "session-1").Return(session(time.Hour), nil)
+				users.EXPECT().GetByID(gomock.Any(), "user-1").Return(&domain.User{ID: "user-1"}, nil)
+				sessions.EXPECT().Rotate(gomock.Any(), "session-1", gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(domain.ErrInvalidRefreshToken)
+				sessions.EXPECT().Delete(gomock.Any(), "session-1").Return(nil)
+			},
+			expectedErr: domain.ErrInvalidRefreshToken,
+		},
+		{
+			name:         "repository failure",
+			refreshToken: refreshToken,
+			setupMocks: func(_ *mocks.MockUserRepository, sessions *mocks.MockSessionRepository) {
+				sessions.EXPECT().Get(gomock.Any(), "session-1").Return(nil, errors.New("database error"))
+			},
+			expectedError: "failed to get session",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			users := mocks.NewMockUserRepository(ctrl)
+			sessions := mocks.NewMockSessionRepository(ctrl)
+			tt.setupMocks(users, sessions)
+
+			service := newTestAuthService(users, sessions)
+
+			tokens, err := service.Refresh(context.Background(), tt.refreshToken)
+
+			assert.Error(t, err)
+			assert.Nil(t, tokens)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+			}
+			assert.Contains(t, err.Error(), tt.expectedError)
+		})
+	}
+}
+
+func TestAuthService_Logout_Success(t *testing.T) {
+	tests := []struct {
+		name      string
+		deleteErr error
+	}{
+		{name: "ends session"},
+		{name: "session already ended", deleteErr: domain.ErrSessionNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			sessions := mocks.NewMockSessionRepository(ctrl)
+			sessions.EXPECT().Delete(gomock.Any(), "session-1").Return(tt.deleteErr)
+
+			service := newTestAuthService(mocks.NewMockUserRepository(ctrl), sessions)
+
+			assert.NoError(t, service.Logout(context.Background(), "session-1"))
+		})
+	}
+}
+
+func TestAuthService_ChangePassword_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	users := mocks.NewMockUserRepository(ctrl)
+	users.EXPECT().GetByID(gomock.Any(), "user-1").Return(
+		&domain.User{ID: "user-1", Version: 3, PasswordHash: hashTestPassword(t, "password123")}, nil)
+	users.EXPECT().Update(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, user *domain.User) error {
+		assert.Equal(t, int64(3), user.Version)
+		ok, err := passhash.Verify(user.PasswordHash, "password456")
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		return nil
+	})
+
+	sessions := mocks.NewMockSessionRepository(ctrl)
+	sessions.EXPECT().DeleteByUser(gomock.Any(), "user-1", "session-1").Return(nil)
+
+	service := newTestAuthService(users, sessions)
+
+	err := service.ChangePassword(context.Background(), "user-1", "session-1", "password123", "password456")
+
+	assert.NoError(t, err)
+}
+
+func TestAuthService_ChangePassword_Fail(t *testing.T) {
+	hash := hashTestPassword(t, "password123")
+
+	tests := []struct {
+		name        string
+		current     string
+		newPassword string
+		setupMocks  func(*mocks.MockUserRepository)
+		expectedErr error
+	}{
+		{
+			name:        "new password too short",
+			current:     "password123",
+			newPassword: "short",
+			setupMocks:  func(*mocks.MockUserRepository) {},
+			expectedErr: domain.ErrWeakPassword,
+		},
+		{
+			name:        "wrong current password",
+			current:     "password000",
+			newPassword: "password456",
+			setupMocks: func(users *mocks.MockUserRepository) {
+				users.EXPECT().GetByID(gomock.Any(), "user-1").Return(&domain.User{ID: "user-1", PasswordHash: hash}, nil)
+			},
+			expectedErr: domain.ErrInvalidCredentials,
+		},
+		{
+			name:        "concurrent update",
+			current:     "password123",
+			newPassword: "password456",
+			setupMocks: func(users *mocks.MockUserRepository) {
+				users.EXPECT().GetByID(gomock.Any(), "user-1").Return(&domain.User{ID: "user-1", PasswordHash: hash}, nil)
+				users.EXPECT().Update(gomock.Any(), gomock.Any()).Return(domain.ErrVersionConflict)
+			},
+			expectedErr: domain.ErrVersionConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			users := mocks.NewMockUserRepository(ctrl)
+			tt.setupMocks(users)
+
+			service := newTestAuthService(users, mocks.NewMockSessionRepository(ctrl))
+
+			err := service.ChangePassword(context.Background(), "user-1", "session-1", tt.current, tt.newPassword)
+
+			assert.ErrorIs(t, err, tt.expectedErr)
+		})
+	}
+}
+
+func TestAuthService_Authenticate_Success(t *testing.T) {
+	tokens := auth.NewTokens("secret", time.Minute)
+
+	tests := []struct {
+		name       string
+		principal  auth.Principal
+		setupMocks func(*mocks.MockSessionRepository)
+	}{
+		{
+			name:      "user with a session",
+			principal: auth.Principal{UserID: "user-1", SessionID: "session-1", Role: auth.RoleUser},
+			setupMocks: func(sessions *mocks.MockSessionRepository) {
+				sessions.EXPECT().Get(gomock.Any(), "session-1").Return(&domain.Session{ID: "session-1", UserID: "user-1"}, nil)
+			},
+		},
+		{
+			name:       "service without a session",
+			principal:  auth.Principal{UserID: "email-service", Role: auth.RoleService},
+			setupMocks: func(*mocks.MockSessionRepository) {},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			sessions := mocks.NewMockSessionRepository(ctrl)
+			tt.setupMocks(sessions)
+
+			token, _, err := tokens.Issue(tt.principal)
+			require.NoError(t, err)
+
+			service := newTestAuthService(mocks.NewMockUserRepository(ctrl), sessions)
+
+			principal, err := service.Authenticate(context.Background(), token)
+
+			require.NoError(t, err)
+			assert.Equal(t, &tt.principal, principal)
+		})
+	}
+}
+
+func TestAuthService_Authenticate_Fail(t *testing.T) {
+	tokens := auth.NewTokens("secret", time.Minute)
+	token, _, err := tokens.Issue(auth.Principal{UserID: "user-1", SessionID: "session-1", Role: auth.RoleUser})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		token       string
+		setupMocks  func(*mocks.MockSessionRepository)
+		expectedErr error
+	}{
+		{
+			name:        "invalid token",
+			token:       "not-a-token",
+			setupMocks:  func(*mocks.MockSessionRepository) {},
+			expectedErr: auth.ErrInvalidToken,
+		},
+		{
+			name:  "logged out",
+			token: token,
+			setupMocks: func(sessions *mocks.MockSessionRepository) {
+				sessions.EXPECT().Get(gomock.Any(), "session-1").Return(nil, domain.ErrSessionNotFound)
+			},
+			expectedErr: domain.ErrSessionNotFound,
+		},
+		{
+			name:  "session of another user",
+			token: token,
+			setupMocks: func(sessions *mocks.MockSessionRepository) {
+				sessions.EXPECT().Get(gomock.Any(), "session-1").Return(&domain.Session{ID: "session-1", UserID: "user-2"}, nil)
+			},
+			expectedErr: auth.ErrInvalidToken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			sessions := mocks.NewMockSessionRepository(ctrl)
+			tt.setupMocks(sessions)
+
+			service := newTestAuthService(mocks.NewMockUserRepository(ctrl), sessions)
+
+			principal, err := service.Authenticate(context.Background(), tt.token)
+
+			assert.ErrorIs(t, err, tt.expectedErr)
+			assert.Nil(t, principal)
+		})
+	}
+}
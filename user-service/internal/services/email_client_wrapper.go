@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"time"
 
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 
 	"github.com/popeskul/mailflow/common/logger"
@@ -21,28 +23,125 @@ type EmailClientWrapper struct {
 	client         emailv1.EmailServiceClient
 	circuitBreaker *circuitbreaker.CircuitBreaker
 	retrier        *retry.Retrier
+	strategy       retry.Strategy
+	budget         *retry.Budget
+	retryOpts      []retry.RetrierOption
+	hedger         *retry.Hedger
+	hedgeOpts      []retry.HedgerOption
+	timeout        time.Duration
 	queue          *queue.EmailQueue
 	logger         logger.Logger
 }
 
+// WrapperOption configures an EmailClientWrapper
+type WrapperOption func(*EmailClientWrapper)
+
+// WithRetryStrategy sets how failed sends are retried
+func WithRetryStrategy(strategy retry.Strategy) WrapperOption {
+	return func(w *EmailClientWrapper) {
+		w.strategy = strategy
+	}
+}
+
+// WithRetryBudget limits retries by budget, which other clients may share
+func WithRetryBudget(budget *retry.Budget) WrapperOption {
+	return func(w *EmailClientWrapper) {
+		w.budget = budget
+	}
+}
+
+// WithRetryOptions adds options to the retrier, such as hooks
+func WithRetryOptions(opts ...retry.RetrierOption) WrapperOption {
+	return func(w *EmailClientWrapper) {
+		w.retryOpts = append(w.retryOpts, opts...)
+	}
+}
+
+// WithHedging hedges slow reads from the email service, see retry.Hedger.
+// Hedges are only sent while the circuit is closed.
+func WithHedging(opts ...retry.HedgerOption) WrapperOption {
+	return func(w *EmailClientWrapper) {
+		w.hedgeOpts = append(w.hedgeOpts, opts...)
+	}
+}
+
+// WithTimeout limits how long each send attempt may take
+func WithTimeout(timeout time.Duration) WrapperOption {
+	return func(w *EmailClientWrapper) {
+		w.timeout = timeout
+	}
+}
+
 // NewEmailClientWrapper creates a new wrapped email client
 func NewEmailClientWrapper(
 	client emailv1.EmailServiceClient,
 	cb *circuitbreaker.CircuitBreaker,
 	q *queue.EmailQueue,
 	l logger.Logger,
+	opts ...WrapperOption,
 ) *EmailClientWrapper {
-	return &EmailClientWrapper{
+	w := &EmailClientWrapper{
 		client:         client,
 		circuitBreaker: cb,
-		retrier:        retry.New(retry.DefaultExponentialBackoff()),
+		strategy:       retry.DefaultExponentialBackoff(),
+		budget:         retry.DefaultBudget(),
 		queue:          q,
 		logger:         l.Named("email_client_wrapper"),
 	}
+
+	for _, opt := range opts {
+		opt(w)
+	}
+	w.retrier = retry.New(w.strategy, append([]retry.RetrierOption{
+		retry.WithBudget(w.budget),
+		retry.WithOnRetry(w.onRetry),
+	}, w.retryOpts...)...)
+	w.hedger = retry.NewHedger(append([]retry.HedgerOption{
+		retry.WithHedgeWhen(w.circuitClosed),
+	}, w.hedgeOpts...)...)
+
+	cb.OnStateChange(w.onStateChange)
+
+	return w
+}
+
+// onStateChange logs circuit transitions and resends queued emails as soon
+// as the circuit closes instead of after the queue's retry delay
+func (w *EmailClientWrapper) onStateChange(from, to circuitbreaker.State) {
+	w.logger.Info("circuit breaker state changed",
+		logger.Field{Key: "from", Value: from.String()},
+		logger.Field{Key: "to", Value: to.String()},
+		logger.Field{Key: "queue_size", Value: w.queue.Size()},
+	)
+
+	if to == circuitbreaker.StateClosed {
+		w.queue.Flush()
+	}
+}
+
+// circuitClosed reports whether hedges may be sent. Half-open probes are
+// few, and hedging them would only spend them faster.
+func (w *EmailClientWrapper) circuitClosed() bool {
+	return w.circuitBreaker.GetState() == circuitbreaker.StateClosed
+}
+
+// onRetry logs a failed send that is about to be retried
+func (w *EmailClientWrapper) onRetry(_ context.Context, attempt retry.Attempt) {
+	w.logger.Debug("retrying email send",
+		logger.Field{Key: "attempt", Value: attempt.Number},
+		logger.Field{Key: "delay", Value: attempt.Delay},
+		logger.Field{Key: "error", Value: attempt.Err},
+	)
 }
 
 // SendEmail sends an email with circuit breaker and retry logic
 func (w *EmailClientWrapper) SendEmail(ctx context.Context, req *emailv1.SendEmailRequest) error {
+	return w.send(ctx, req, false)
+}
+
+// send sends an email, queueing it while the service is unavailable.
+// Sensitive emails are queued in memory only and never persisted.
+func (w *EmailClientWrapper) send(ctx context.Context, req *emailv1.SendEmailRequest, sensitive bool) error {
 	// First, try to send directly
 	err := w.sendWithCircuitBreaker(ctx, req)
 
@@ -57,13 +156,8 @@ func (w *EmailClientWrapper) SendEmail(ctx context.Context, req *emailv1.SendEma
 			logger.Field{Key: "error", Value: err},
 		)
 
-		// Convert to domain.Email for queueing
-		email := &domain.Email{
-			ID:      fmt.Sprintf("email_%d", time.Now().UnixNano()),
-			To:      req.To,
-			Subject: req.Subject,
-			Body:    req.Body,
-		}
+		email := emailFromRequest(req)
+		email.Sensitive = sensitive
 
 		if qErr := w.queue.Enqueue(email); qErr != nil {
 			w.logger.Error("failed to queue email request",
@@ -92,39 +186,97 @@ func (w *EmailClientWrapper) sendWithCircuitBreaker(ctx context.Context, req *em
 // sendWithRetry sends email with retry logic
 func (w *EmailClientWrapper) sendWithRetry(ctx context.Context, req *emailv1.SendEmailRequest) error {
 	return w.retrier.Do(ctx, func(ctx context.Context) error {
-		_, err := w.client.SendEmail(ctx, req)
-		if err != nil {
-			w.logger.Debug("email send attempt failed",
-				logger.Field{Key: "error", Value: err},
-				logger.Field{Key: "to", Value: req.To},
-			)
+		if w.timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, w.timeout)
+			defer cancel()
 		}
-		return err
+
+		// The email service may say when to retry in the trailer
+		var trailer metadata.MD
+		_, err := w.client.SendEmail(ctx, req, grpc.Trailer(&trailer))
+		return retry.WithPushback(err, trailer)
 	})
 }
 
-// ProcessQueue processes queued email requests
-func (w *EmailClientWrapper) ProcessQueue(ctx context.Context) {
-	ticker := time.NewTicker(10 * time.Second)
-	defer ticker.Stop()
+// GetEmailStatus fetches the delivery status of an email
+func (w *EmailClientWrapper) GetEmailStatus(
+	ctx context.Context,
+	req *emailv1.GetEmailStatusRequest,
+) (*emailv1.GetEmailStatusResponse, error) {
+	return read(ctx, w, func(ctx context.Context) (*emailv1.GetEmailStatusResponse, error) {
+		return w.client.GetEmailStatus(ctx, req)
+	})
+}
+
+// ListEmails lists a page of the emails the email service has sent
+func (w *EmailClientWrapper) ListEmails(
+	ctx context.Context,
+	req *emailv1.ListEmailsRequest,
+) (*emailv1.ListEmailsResponse, error) {
+	return read(ctx, w, func(ctx context.Context) (*emailv1.ListEmailsResponse, error) {
+		return w.client.ListEmails(ctx, req)
+	})
+}
+
+// GetEmailEvents fetches the delivery events of an email
+func (w *EmailClientWrapper) GetEmailEvents(
+	ctx context.Context,
+	req *emailv1.GetEmailEventsRequest,
+) (*emailv1.GetEmailEventsResponse, error) {
+	return read(ctx, w, func(ctx context.Context) (*emailv1.GetEmailEventsResponse, error) {
+		return w.client.GetEmailEvents(ctx, req)
+	})
+}
+
+// read makes a read call with circuit breaker protection, hedging it when
+// it is slow. Each request goes through the circuit breaker; the ones that
+// lose are cancelled, which the breaker does not count as failures.
+func read[T any](ctx context.Context, w *EmailClientWrapper, call func(ctx context.Context) (T, error)) (T, error) {
+	return retry.Hedge(ctx, w.hedger, func(ctx context.Context) (T, error) {
+		var resp T
+		err := w.circuitBreaker.Execute(ctx, func(ctx context.Context) error {
+			if w.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, w.timeout)
+				defer cancel()
+			}
+
+			var err error
+			resp, err = call(ctx)
+			return err
+		})
+		return resp, err
+	})
+}
 
+// ProcessQueue starts resending queued email requests in the background
+// until ctx is done or the wrapper is shut down
+func (w *EmailClientWrapper) ProcessQueue(ctx context.Context) {
 	w.logger.Info("starting queue processor")
 
-	for {
-		select {
-		case <-ctx.Done():
-			w.logger.Info("stopping queue processor")
-			return
-		case <-ticker.C:
-			w.processQueuedEmails(ctx)
+	w.queue.Start(ctx, func(email *domain.Email) error {
+		return w.sendWithCircuitBreaker(ctx, requestFromEmail(email))
+	})
+
+	go func() {
+		ticker := time.NewTicker(10 * time.Second)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				w.logger.Info("stopping queue processor")
+				return
+			case <-ticker.C:
+				w.processQueuedEmails(ctx)
+			}
 		}
-	}
+	}()
 }
 
-// processQueuedEmails processes emails from the queue
-func (w *EmailClientWrapper) processQueuedEmails(ctx context.Context) {
-	// Simple implementation - just log that we're checking the queue
-	// The actual processing is handled by the queue's Start method
+// processQueuedEmails reports the backlog; the queue sends the emails
+func (w *EmailClientWrapper) processQueuedEmails(_ context.Context) {
 	queueSize := w.queue.Size()
 	if queueSize > 0 {
 		w.logger.Info("emails in queue waiting for processing",
@@ -133,6 +285,56 @@ func (w *EmailClientWrapper) processQueuedEmails(ctx context.Context) {
 	}
 }
 
+// Requeue puts an email saved by a previous run back in the retry queue
+func (w *EmailClientWrapper) Requeue(email *domain.Email) error {
+	return w.queue.Enqueue(email)
+}
+
+// Shutdown stops queueing requests and drains the retry queue within ctx
+func (w *EmailClientWrapper) Shutdown(ctx context.Context) queue.DrainReport {
+	w.logger.Info("draining email retry queue",
+		logger.Field{Key: "queue_size", Value: w.queue.Size()},
+	)
+
+	return w.queue.Shutdown(ctx)
+}
+
+// emailFromRequest converts a send request into an email for the retry
+// queue, keeping every field so that it is sent again unchanged
+func emailFromRequest(req *emailv1.SendEmailRequest) *domain.Email {
+	email := &domain.Email{
+		ID:             fmt.Sprintf("email_%d", time.Now().UnixNano()),
+		To:             req.GetTo(),
+		Subject:        req.GetSubject(),
+		Body:           req.GetBody(),
+		Category:       req.GetCategory(),
+		UnsubscribeURL: req.GetUnsubscribeUrl(),
+		Tenant:         req.GetTenant(),
+		Tags:           req.GetTags(),
+	}
+	if tracking := req.GetTracking(); tracking != nil {
+		email.Tracking = &domain.EmailTracking{Opens: tracking.GetOpens(), Clicks: tracking.GetClicks()}
+	}
+	return email
+}
+
+// requestFromEmail converts a queued email back into its send request
+func requestFromEmail(email *domain.Email) *emailv1.SendEmailRequest {
+	req := &emailv1.SendEmailRequest{
+		To:             email.To,
+		Subject:        email.Subject,
+		Body:           email.Body,
+		Category:       email.Category,
+		UnsubscribeUrl: email.UnsubscribeURL,
+		Tenant:         email.Tenant,
+		Tags:           email.Tags,
+	}
+	if email.Tracking != nil {
+		req.Tracking = &emailv1.TrackingOptions{Opens: email.Tracking.Opens, Clicks: email.Tracking.Clicks}
+	}
+	return req
+}
+
 // isServiceUnavailable checks if the error indicates service unavailability
 func isServiceUnavailable(err error) bool {
 	if err == nil {
@@ -146,5 +348,6 @@ func isServiceUnavailable(err error) bool {
 
 	return st.Code() == codes.Unavailable ||
 		st.Code() == codes.DeadlineExceeded ||
-		st.Code() == codes.Aborted
+		st.Code() == codes.Aborted ||
+		st.Code() == codes.ResourceExhausted
 }
@@ -1,9 +1,20 @@
 package services
 
 import (
+	"context"
+
+	emailv1 "github.com/popeskul/mailflow/email-service/pkg/api/email/v1"
 	"github.com/popeskul/mailflow/user-service/internal/domain"
 )
 
 type Repositories interface {
 	User() domain.UserRepository
+	Subscriptions() domain.SubscriptionRepository
+	Sessions() domain.SessionRepository
+	PasswordResets() domain.PasswordResetRepository
+}
+
+// emailSender delivers an email, e.g. through EmailClientWrapper
+type emailSender interface {
+	SendEmail(ctx context.Context, req *emailv1.SendEmailRequest) error
 }
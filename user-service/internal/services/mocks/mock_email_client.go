@@ -42,6 +42,26 @@ func (m *MockEmailServiceClient) EXPECT() *MockEmailServiceClientMockRecorder {
 	return m.recorder
 }
 
+// GetEmailEvents mocks base method.
+func (m *MockEmailServiceClient) GetEmailEvents(ctx context.Context, in *emailv1.GetEmailEventsRequest, opts ...grpc.CallOption) (*emailv1.GetEmailEventsResponse, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx, in}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "GetEmailEvents", varargs...)
+	ret0, _ := ret[0].(*emailv1.GetEmailEventsResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetEmailEvents indicates an expected call of GetEmailEvents.
+func (mr *MockEmailServiceClientMockRecorder) GetEmailEvents(ctx, in any, opts ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx, in}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEmailEvents", reflect.TypeOf((*MockEmailServiceClient)(nil).GetEmailEvents), varargs...)
+}
+
 // GetEmailStatus mocks base method.
 func (m *MockEmailServiceClient) GetEmailStatus(ctx context.Context, in *emailv1.GetEmailStatusRequest, opts ...grpc.CallOption) (*emailv1.GetEmailStatusResponse, error) {
 	m.ctrl.T.Helper()
@@ -14,6 +14,7 @@ import (
 	reflect "reflect"
 
 	domain "github.com/popeskul/mailflow/user-service/internal/domain"
+	queue "github.com/popeskul/mailflow/user-service/internal/queue"
 	gomock "go.uber.org/mock/gomock"
 )
 
@@ -55,6 +56,20 @@ func (mr *MockQueueMockRecorder) Enqueue(email any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Enqueue", reflect.TypeOf((*MockQueue)(nil).Enqueue), email)
 }
 
+// Shutdown mocks base method.
+func (m *MockQueue) Shutdown(ctx context.Context) queue.DrainReport {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Shutdown", ctx)
+	ret0, _ := ret[0].(queue.DrainReport)
+	return ret0
+}
+
+// Shutdown indicates an expected call of Shutdown.
+func (mr *MockQueueMockRecorder) Shutdown(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Shutdown", reflect.TypeOf((*MockQueue)(nil).Shutdown), ctx)
+}
+
 // Size mocks base method.
 func (m *MockQueue) Size() int {
 	m.ctrl.T.Helper()
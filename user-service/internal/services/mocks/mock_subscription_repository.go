@@ -0,0 +1,84 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/popeskul/mailflow/user-service/internal/domain (interfaces: SubscriptionRepository)
+//
+// Generated by this command:
+//
+//	mockgen -destination=mocks/mock_subscription_repository.go -package=mocks github.com/popeskul/mailflow/user-service/internal/domain SubscriptionRepository
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockSubscriptionRepository is a mock of SubscriptionRepository interface.
+type MockSubscriptionRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockSubscriptionRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockSubscriptionRepositoryMockRecorder is the mock recorder for MockSubscriptionRepository.
+type MockSubscriptionRepositoryMockRecorder struct {
+	mock *MockSubscriptionRepository
+}
+
+// NewMockSubscriptionRepository creates a new mock instance.
+func NewMockSubscriptionRepository(ctrl *gomock.Controller) *MockSubscriptionRepository {
+	mock := &MockSubscriptionRepository{ctrl: ctrl}
+	mock.recorder = &MockSubscriptionRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSubscriptionRepository) EXPECT() *MockSubscriptionRepositoryMockRecorder {
+	return m.recorder
+}
+
+// IsOptedOut mocks base method.
+func (m *MockSubscriptionRepository) IsOptedOut(ctx context.Context, category, address string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsOptedOut", ctx, category, address)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsOptedOut indicates an expected call of IsOptedOut.
+func (mr *MockSubscriptionRepositoryMockRecorder) IsOptedOut(ctx, category, address any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsOptedOut", reflect.TypeOf((*MockSubscriptionRepository)(nil).IsOptedOut), ctx, category, address)
+}
+
+// OptIn mocks base method.
+func (m *MockSubscriptionRepository) OptIn(ctx context.Context, category, address string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OptIn", ctx, category, address)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// OptIn indicates an expected call of OptIn.
+func (mr *MockSubscriptionRepositoryMockRecorder) OptIn(ctx, category, address any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OptIn", reflect.TypeOf((*MockSubscriptionRepository)(nil).OptIn), ctx, category, address)
+}
+
+// OptOut mocks base method.
+func (m *MockSubscriptionRepository) OptOut(ctx context.Context, category, address string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OptOut", ctx, category, address)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// OptOut indicates an expected call of OptOut.
+func (mr *MockSubscriptionRepositoryMockRecorder) OptOut(ctx, category, address any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OptOut", reflect.TypeOf((*MockSubscriptionRepository)(nil).OptOut), ctx, category, address)
+}
@@ -0,0 +1,203 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/popeskul/mailflow/common/logger"
+	emailv1 "github.com/popeskul/mailflow/email-service/pkg/api/email/v1"
+	"github.com/popeskul/mailflow/user-service/internal/domain"
+	"github.com/popeskul/mailflow/user-service/internal/passhash"
+	"github.com/popeskul/mailflow/user-service/internal/ratelimit"
+)
+
+// resetTokenLen is the number of random bytes in a password reset token
+const resetTokenLen = 32
+
+type PasswordResetService struct {
+	users    domain.UserRepository
+	resets   domain.PasswordResetRepository
+	sessions domain.SessionRepository
+	email    emailSender
+	limiter  *ratelimit.Keyed
+	ttl      time.Duration
+	logger   logger.Logger
+	// sends tracks reset emails still being handed to the sender
+	sends sync.WaitGroup
+}
+
+// NewPasswordResetService creates the password reset service. limiter caps
+// reset requests per address. Without an email sender resets are disabled.
+func NewPasswordResetService(
+	users domain.UserRepository,
+	resets domain.PasswordResetRepository,
+	sessions domain.SessionRepository,
+	email emailSender,
+	limiter *ratelimit.Keyed,
+	ttl time.Duration,
+	l logger.Logger,
+) *PasswordResetService {
+	return &PasswordResetService{
+		users:    users,
+		resets:   resets,
+		sessions: sessions,
+		email:    email,
+		limiter:  limiter,
+		ttl:      ttl,
+		logger:   l.Named("password_reset_service"),
+	}
+}
+
+// RequestPasswordReset mails a reset token to the address. Unknown addresses
+// are not an error, so the response does not reveal who is registered.
+func (s *PasswordResetService) RequestPasswordReset(ctx context.Context, email string) error {
+	if s.email == nil {
+		return domain.ErrPasswordResetDisabled
+	}
+
+	// Limit by address, registered or not, before doing anything else
+	if !s.limiter.Allow(domain.NormalizeAddress(email)) {
+		s.logger.Warn("password reset rate limited",
+			logger.Field{Key: "email", Value: email},
+		)
+		return domain.ErrResetRateLimited
+	}
+
+	user, err := s.users.GetByEmail(ctx, email)
+	if errors.Is(err, domain.ErrUserNotFound) {
+		s.logger.Info("password reset requested for unknown address")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	l := s.logger.WithFields(logger.Fields{
+		"user_id": user.ID,
+	})
+
+	token := make([]byte, resetTokenLen)
+	if _, err := rand.Read(token); err != nil {
+		return fmt.Errorf("failed to generate reset token: %w", err)
+	}
+
+	now := time.Now()
+	if err := s.resets.Create(ctx, &domain.PasswordReset{
+		TokenHash: hashSecret(token),
+		UserID:    user.ID,
+		CreatedAt: now,
+		ExpiresAt: now.Add(s.ttl),
+	}); err != nil {
+		l.Error("failed to store password reset",
+			logger.Field{Key: "error", Value: err},
+		)
+		return fmt.Errorf("failed to store password reset: %w", err)
+	}
+
+	// Send in the background: a failure only known addresses could hit must
+	// not reach the caller, or the response would reveal who is registered
+	s.sends.Add(1)
+	go func() {
+		defer s.sends.Done()
+		s.sendResetEmail(context.WithoutCancel(ctx), l, user, token)
+	}()
+
+	l.Info("password reset requested")
+
+	return nil
+}
+
+// sendResetEmail mails the reset code, logging rather than returning failures
+func (s *PasswordResetService) sendResetEmail(ctx context.Context, l logger.Logger, user *domain.User, token []byte) {
+	// Transactional mail: no category, so opt-outs do not apply
+	err := s.email.SendEmail(ctx, &emailv1.SendEmailRequest{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hello %s,\n\nUse this code to choose a new password:\n\n%s\n\n"+
+			"It expires in %s and works once. If you did not ask for a reset, you can ignore this email.",
+			user.Name, base64.RawURLEncoding.EncodeToString(token), s.ttl),
+	})
+	if err != nil {
+		l.Error("failed to send password reset email",
+			logger.Field{Key: "error", Value: err},
+		)
+	}
+}
+
+// Wait blocks until reset emails in flight are handed to the sender or ctx
+// is done, whichever comes first
+func (s *PasswordResetService) Wait(ctx context.Context) {
+	done := make(chan struct{})
+	go func() {
+		s.sends.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		s.logger.Warn("shutdown deadline reached before password reset emails were sent")
+	}
+}
+
+// ConfirmPasswordReset sets a new password with a reset token and ends all
+// the user's sessions. The token is used up even if it has expired.
+func (s *PasswordResetService) ConfirmPasswordReset(ctx context.Context, token, newPassword string) error {
+	// Check the password first so a rejected one does not burn the token
+	if err := domain.ValidatePassword(newPassword); err != nil {
+		return err
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(raw) != resetTokenLen {
+		return domain.ErrInvalidResetToken
+	}
+
+	reset, err := s.resets.Consume(ctx, hashSecret(raw))
+	if err != nil {
+		return fmt.Errorf("failed to reset password: %w", err)
+	}
+	if !time.Now().Before(reset.ExpiresAt) {
+		return domain.ErrInvalidResetToken
+	}
+
+	l := s.logger.WithFields(logger.Fields{
+		"user_id": reset.UserID,
+	})
+
+	user, err := s.users.GetByID(ctx, reset.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	hash, err := passhash.Hash(newPassword)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+	user.PasswordHash = hash
+	user.UpdatedAt = time.Now()
+
+	if err := s.users.Update(ctx, user); err != nil {
+		l.Error("failed to reset password",
+			logger.Field{Key: "error", Value: err},
+		)
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+
+	// Whoever knew the old password is logged out
+	if err := s.sessions.DeleteByUser(ctx, user.ID, ""); err != nil {
+		l.Error("failed to end sessions after password reset",
+			logger.Field{Key: "error", Value: err},
+		)
+		return fmt.Errorf("failed to end sessions: %w", err)
+	}
+
+	l.Info("password reset")
+
+	return nil
+}
@@ -0,0 +1,299 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	emailv1 "github.com/popeskul/mailflow/email-service/pkg/api/email/v1"
+	"github.com/popeskul/mailflow/user-service/internal/circuitbreaker"
+	"github.com/popeskul/mailflow/user-service/internal/domain"
+	"github.com/popeskul/mailflow/user-service/internal/passhash"
+	"github.com/popeskul/mailflow/user-service/internal/queue"
+	"github.com/popeskul/mailflow/user-service/internal/ratelimit"
+	"github.com/popeskul/mailflow/user-service/internal/retry"
+	"github.com/popeskul/mailflow/user-service/internal/services/mocks"
+)
+
+var resetCodePattern = regexp.MustCompile(`(?m)^([A-Za-z0-9_-]{43})$`)
+
+type resetMocks struct {
+	users    *mocks.MockUserRepository
+	resets   *mocks.MockPasswordResetRepository
+	sessions *mocks.MockSessionRepository
+	email    *mocks.MockEmailServiceClient
+}
+
+func newResetMocks(ctrl *gomock.Controller) resetMocks {
+	return resetMocks{
+		users:    mocks.NewMockUserRepository(ctrl),
+		resets:   mocks.NewMockPasswordResetRepository(ctrl),
+		sessions: mocks.NewMockSessionRepository(ctrl),
+		email:    mocks.NewMockEmailServiceClient(ctrl),
+	}
+}
+
+func (m resetMocks) service(email emailSender, limiter *ratelimit.Keyed) *PasswordResetService {
+	return NewPasswordResetService(m.users, m.resets, m.sessions, email, limiter, time.Hour, createTestLogger())
+}
+
+func TestPasswordResetService_RequestPasswordReset_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := newResetMocks(ctrl)
+	m.users.EXPECT().GetByEmail(gomock.Any(), "test@example.com").Return(&domain.User{ID: "user-1", Email: "test@example.com"}, nil)
+
+	var stored *domain.PasswordReset
+	m.resets.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, reset *domain.PasswordReset) error {
+		stored = reset
+		return nil
+	})
+
+	var code string
+	m.email.EXPECT().SendEmail(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, req *emailv1.SendEmailRequest, _ ...grpc.CallOption) (*emailv1.SendEmailResponse, error) {
+			assert.Equal(t, "test@example.com", req.To)
+			assert.Empty(t, req.Category)
+			code = resetCodePattern.FindString(req.Body)
+			return &emailv1.SendEmailResponse{}, nil
+		})
+
+	// Reset emails go through the resilience wrapper
+	wrapper := NewEmailClientWrapper(m.email, circuitbreaker.New(circuitbreaker.DefaultConfig()),
+		queue.NewEmailQueue(100, zap.NewNop()), createTestLogger())
+	service := m.service(wrapper, ratelimit.NewKeyed(3, time.Hour))
+
+	err := service.RequestPasswordReset(context.Background(), "test@example.com")
+	service.Wait(context.Background())
+
+	require.NoError(t, err)
+	require.NotEmpty(t, code)
+	raw, err := base64.RawURLEncoding.DecodeString(code)
+	require.NoError(t, err)
+	assert.Equal(t, hashSecret(raw), stored.TokenHash, "only the hash of the mailed token is stored")
+	assert.Equal(t, "user-1", stored.UserID)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), stored.ExpiresAt, time.Second)
+}
+
+func TestPasswordResetService_RequestPasswordReset_UnknownAddress(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := newResetMocks(ctrl)
+	m.users.EXPECT().GetByEmail(gomock.Any(), gomock.Any()).Return(nil, domain.ErrUserNotFound)
+
+	service := m.service(clientSender{client: m.email}, ratelimit.NewKeyed(3, time.Hour))
+
+	assert.NoError(t, service.RequestPasswordReset(context.Background(), "nobody@example.com"))
+}
+
+func TestPasswordResetService_RequestPasswordReset_Fail(t *testing.T) {
+	tests := []struct {
+		name          string
+		disabled      bool
+		setupMocks    func(resetMocks)
+		expectedErr   error
+		expectedError string
+	}{
+		{
+			name:        "no email client",
+			disabled:    true,
+			setupMocks:  func(resetMocks) {},
+			expectedErr: domain.ErrPasswordResetDisabled,
+		},
+		{
+			name: "repository failure",
+			setupMocks: func(m resetMocks) {
+				m.users.EXPECT().GetByEmail(gomock.Any(), gomock.Any()).Return(&domain.User{ID: "user-1"}, nil)
+				m.resets.EXPECT().Create(gomock.Any(), gomock.Any()).Return(errors.New("database error"))
+			},
+			expectedError: "failed to store password reset",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			m := newResetMocks(ctrl)
+			tt.setupMocks(m)
+
+			var sender emailSender = clientSender{client: m.email}
+			if tt.disabled {
+				sender = nil
+			}
+			service := m.service(sender, ratelimit.NewKeyed(3, time.Hour))
+
+			err := service.RequestPasswordReset(context.Background(), "test@example.com")
+
+			assert.Error(t, err)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+			}
+			assert.Contains(t, err.Error(), tt.expectedError)
+		})
+	}
+}
+
+func TestPasswordResetService_RequestPasswordReset_SendFailure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := newResetMocks(ctrl)
+	m.users.EXPECT().GetByEmail(gomock.Any(), gomock.Any()).Return(&domain.User{ID: "user-1"}, nil)
+	m.resets.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+	m.email.EXPECT().SendEmail(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil, status.Error(codes.InvalidArgument, "bad address"))
+
+	service := m.service(clientSender{client: m.email}, ratelimit.NewKeyed(3, time.Hour))
+
+	// A registered address answers like an unknown one even when sending fails
+	assert.NoError(t, service.RequestPasswordReset(context.Background(), "test@example.com"))
+	service.Wait(context.Background())
+}
+
+func TestPasswordResetService_RequestPasswordReset_NotSpooled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := newResetMocks(ctrl)
+	m.users.EXPECT().GetByEmail(gomock.Any(), gomock.Any()).Return(&domain.User{ID: "user-1", Email: "test@example.com"}, nil)
+	m.resets.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+	m.email.EXPECT().SendEmail(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil, status.Error(codes.Unavailable, "service unavailable")).AnyTimes()
+
+	// The email service is down, so the reset email waits in the queue
+	spool := queue.NewFileSpool(filepath.Join(t.TempDir(), "spool.jsonl"))
+	wrapper := NewEmailClientWrapper(m.email, circuitbreaker.New(circuitbreaker.DefaultConfig()),
+		queue.NewEmailQueue(100, zap.NewNop(), queue.WithPersister(spool)), createTestLogger(),
+		WithRetryStrategy(&retry.Constant{Delay: time.Millisecond, MaxAttempts: 1}))
+	service := m.service(sensitiveSender{wrapper: wrapper}, ratelimit.NewKeyed(3, time.Hour))
+
+	require.NoError(t, service.RequestPasswordReset(context.Background(), "test@example.com"))
+	service.Wait(context.Background())
+	require.Equal(t, 1, wrapper.queue.Size())
+
+	// The code must not reach the plaintext spool at shutdown
+	report := wrapper.Shutdown(context.Background())
+
+	assert.Equal(t, queue.DrainReport{Dropped: 1}, report)
+	spooled, err := spool.Load()
+	require.NoError(t, err)
+	assert.Empty(t, spooled)
+}
+
+func TestPasswordResetService_RequestPasswordReset_RateLimited(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := newResetMocks(ctrl)
+	// Unknown or not, every request for the address counts
+	m.users.EXPECT().GetByEmail(gomock.Any(), gomock.Any()).Return(nil, domain.ErrUserNotFound).Times(2)
+
+	service := m.service(clientSender{client: m.email}, ratelimit.NewKeyed(2, time.Hour))
+
+	assert.NoError(t, service.RequestPasswordReset(context.Background(), "victim@example.com"))
+	assert.NoError(t, service.RequestPasswordReset(context.Background(), " Victim@Example.com"))
+	assert.ErrorIs(t, service.RequestPasswordReset(context.Background(), "victim@example.com"), domain.ErrResetRateLimited)
+}
+
+func TestPasswordResetService_ConfirmPasswordReset_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	token := make([]byte, resetTokenLen)
+
+	m := newResetMocks(ctrl)
+	m.resets.EXPECT().Consume(gomock.Any(), hashSecret(token)).Return(
+		&domain.PasswordReset{UserID: "user-1", ExpiresAt: time.Now().Add(time.Minute)}, nil)
+	m.users.EXPECT().GetByID(gomock.Any(), "user-1").Return(&domain.User{ID: "user-1"}, nil)
+	m.users.EXPECT().Update(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, user *domain.User) error {
+		ok, err := passhash.Verify(user.PasswordHash, "password456")
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		return nil
+	})
+	m.sessions.EXPECT().DeleteByUser(gomock.Any(), "user-1", "").Return(nil)
+
+	service := m.service(nil, ratelimit.NewKeyed(3, time.Hour))
+
+	err := service.ConfirmPasswordReset(context.Background(), base64.RawURLEncoding.EncodeToString(token), "password456")
+
+	assert.NoError(t, err)
+}
+
+func TestPasswordResetService_ConfirmPasswordReset_Fail(t *testing.T) {
+	token := base64.RawURLEncoding.EncodeToString(make([]byte, resetTokenLen))
+
+	tests := []struct {
+		name        string
+		token       string
+		password    string
+		setupMocks  func(resetMocks)
+		expectedErr error
+	}{
+		{
+			name:        "weak password keeps the token",
+			token:       token,
+			password:    "short",
+			setupMocks:  func(resetMocks) {},
+			expectedErr: domain.ErrWeakPassword,
+		},
+		{
+			name:        "malformed token",
+			token:       "abc",
+			password:    "password456",
+			setupMocks:  func(resetMocks) {},
+			expectedErr: domain.ErrInvalidResetToken,
+		},
+		{
+			name:     "used or unknown token",
+			token:    token,
+			password: "password456",
+			setupMocks: func(m resetMocks) {
+				m.resets.EXPECT().Consume(gomock.Any(), gomock.Any()).Return(nil, domain.ErrInvalidResetToken)
+			},
+			expectedErr: domain.ErrInvalidResetToken,
+		},
+		{
+			name:     "expired token",
+			token:    token,
+			password: "password456",
+			setupMocks: func(m resetMocks) {
+				m.resets.EXPECT().Consume(gomock.Any(), gomock.Any()).Return(
+					&domain.PasswordReset{UserID: "user-1", ExpiresAt: time.Now().Add(-time.Minute)}, nil)
+			},
+			expectedErr: domain.ErrInvalidResetToken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			m := newResetMocks(ctrl)
+			tt.setupMocks(m)
+
+			service := m.service(nil, ratelimit.NewKeyed(3, time.Hour))
+
+			err := service.ConfirmPasswordReset(context.Background(), tt.token, tt.password)
+
+			assert.ErrorIs(t, err, tt.expectedErr)
+		})
+	}
+}
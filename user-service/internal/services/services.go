@@ -1,22 +1,101 @@
 package services
 
 import (
+	"context"
+	"time"
+
+	"github.com/popeskul/mailflow/common/auth"
 	"github.com/popeskul/mailflow/common/logger"
 	emailv1 "github.com/popeskul/mailflow/email-service/pkg/api/email/v1"
 	"github.com/popeskul/mailflow/user-service/internal/domain"
+	"github.com/popeskul/mailflow/user-service/internal/queue"
+	"github.com/popeskul/mailflow/user-service/internal/ratelimit"
+	"github.com/popeskul/mailflow/user-service/internal/unsubscribe"
+	"github.com/popeskul/mailflow/user-service/internal/verification"
 )
 
 type Services struct {
-	user *UserService
+	user           *UserService
+	subscriptions  *SubscriptionService
+	auth           *AuthService
+	passwordResets *PasswordResetService
+	email          *EmailClientWrapper
+}
+
+// AuthOptions configures logins, password resets and verification emails
+type AuthOptions struct {
+	// Tokens signs access tokens; logins are disabled without it
+	Tokens     *auth.Tokens
+	RefreshTTL time.Duration
+	// Admins are the addresses of users who log in as auth.RoleAdmin
+	Admins []string
+	// ResetTTL is how long a password reset token is valid
+	ResetTTL time.Duration
+	// ResetLimit password resets may be requested per address per ResetWindow
+	ResetLimit  int
+	ResetWindow time.Duration
+	// ResendLimit verification emails may be resent per address per
+	// ResendWindow; resends are not limited when it is zero
+	ResendLimit  int
+	ResendWindow time.Duration
+}
+
+func (o AuthOptions) newAuthService(repos Repositories, logger logger.Logger) *AuthService {
+	return NewAuthService(repos.User(), repos.Sessions(), o.Tokens, o.RefreshTTL, o.Admins, logger)
+}
+
+func (o AuthOptions) newPasswordResetService(repos Repositories, email emailSender, logger logger.Logger) *PasswordResetService {
+	limiter := ratelimit.NewKeyed(o.ResetLimit, o.ResetWindow)
+	return NewPasswordResetService(repos.User(), repos.PasswordResets(), repos.Sessions(), email, limiter, o.ResetTTL, logger)
+}
+
+// limitResends caps the verification emails user sends per address
+func (o AuthOptions) limitResends(user *UserService) *UserService {
+	if o.ResendLimit > 0 {
+		user.resends = ratelimit.NewKeyed(o.ResendLimit, o.ResendWindow)
+	}
+	return user
+}
+
+// clientSender sends straight through the email client, without resilience
+type clientSender struct {
+	client emailv1.EmailServiceClient
+}
+
+func (s clientSender) SendEmail(ctx context.Context, req *emailv1.SendEmailRequest) error {
+	_, err := s.client.SendEmail(ctx, req)
+	return err
+}
+
+// sensitiveSender sends through the wrapper without ever spooling to disk
+type sensitiveSender struct {
+	wrapper *EmailClientWrapper
+}
+
+func (s sensitiveSender) SendEmail(ctx context.Context, req *emailv1.SendEmailRequest) error {
+	return s.wrapper.send(ctx, req, true)
 }
 
 func NewServices(
 	repos Repositories,
 	emailClient emailv1.EmailServiceClient,
+	links *unsubscribe.Links,
+	verifier *verification.Links,
+	authOpts AuthOptions,
 	logger logger.Logger,
 ) *Services {
+	subscriptions := NewSubscriptionService(repos.User(), repos.Subscriptions(), links, logger)
+
+	var sender emailSender
+	if emailClient != nil {
+		sender = clientSender{client: emailClient}
+	}
+
 	return &Services{
-		user: NewUserService(repos.User(), emailClient, logger),
+		user:           authOpts.limitResends(NewUserService(repos.User(), emailClient, subscriptions, verifier, logger)),
+		auth:           authOpts.newAuthService(repos, logger),
+		passwordResets: authOpts.newPasswordResetService(repos, sender, logger),
+		subscriptions:  subscriptions,
 	}
 }
 
@@ -24,13 +103,53 @@ func NewServices(
 func NewServicesWithWrapper(
 	repos Repositories,
 	emailWrapper *EmailClientWrapper,
+	links *unsubscribe.Links,
+	verifier *verification.Links,
+	authOpts AuthOptions,
 	logger logger.Logger,
 ) *Services {
+	subscriptions := NewSubscriptionService(repos.User(), repos.Subscriptions(), links, logger)
+
+	// Reset emails go through the wrapper so they survive email-service
+	// outages, but carry a code and so are never spooled to disk
+	var sender emailSender
+	if emailWrapper != nil {
+		sender = sensitiveSender{wrapper: emailWrapper}
+	}
+
 	return &Services{
-		user: NewUserServiceWithWrapper(repos.User(), emailWrapper, logger),
+		user:           authOpts.limitResends(NewUserServiceWithWrapper(repos.User(), emailWrapper, subscriptions, verifier, logger)),
+		auth:           authOpts.newAuthService(repos, logger),
+		passwordResets: authOpts.newPasswordResetService(repos, sender, logger),
+		subscriptions:  subscriptions,
+		email:          emailWrapper,
 	}
 }
 
 func (s Services) User() domain.UserService {
 	return s.user
 }
+
+func (s Services) Auth() domain.AuthService {
+	return s.auth
+}
+
+func (s Services) PasswordResets() domain.PasswordResetService {
+	return s.passwordResets
+}
+
+func (s Services) Subscriptions() domain.SubscriptionService {
+	return s.subscriptions
+}
+
+// Shutdown waits for reset emails in flight, then drains pending outgoing
+// emails. Draining is a no-op without an email wrapper.
+func (s Services) Shutdown(ctx context.Context) queue.DrainReport {
+	s.passwordResets.Wait(ctx)
+
+	if s.email == nil {
+		return queue.DrainReport{}
+	}
+
+	return s.email.Shutdown(ctx)
+}
@@ -0,0 +1,133 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/popeskul/mailflow/common/logger"
+	"github.com/popeskul/mailflow/user-service/internal/domain"
+	"github.com/popeskul/mailflow/user-service/internal/unsubscribe"
+)
+
+// SubscriptionService manages which mailing categories an address receives
+type SubscriptionService struct {
+	users  domain.UserRepository
+	repo   domain.SubscriptionRepository
+	links  *unsubscribe.Links
+	logger logger.Logger
+}
+
+// NewSubscriptionService creates the subscription service. Without links no
+// unsubscribe URLs are generated.
+func NewSubscriptionService(
+	users domain.UserRepository,
+	repo domain.SubscriptionRepository,
+	links *unsubscribe.Links,
+	l logger.Logger,
+) *SubscriptionService {
+	return &SubscriptionService{
+		users:  users,
+		repo:   repo,
+		links:  links,
+		logger: l.Named("subscription_service"),
+	}
+}
+
+func (s *SubscriptionService) GetPreferences(ctx context.Context, userID string) ([]domain.CategoryPreference, error) {
+	user, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	return s.preferences(ctx, user.Email)
+}
+
+func (s *SubscriptionService) UpdatePreferences(
+	ctx context.Context,
+	userID string,
+	prefs []domain.CategoryPreference,
+) ([]domain.CategoryPreference, error) {
+	l := s.logger.WithFields(logger.Fields{
+		"user_id": userID,
+	})
+
+	for _, pref := range prefs {
+		if !domain.IsValidCategory(pref.Category) {
+			return nil, fmt.Errorf("%w: %s", domain.ErrUnknownCategory, pref.Category)
+		}
+	}
+
+	user, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	for _, pref := range prefs {
+		if pref.Subscribed {
+			err = s.repo.OptIn(ctx, pref.Category, user.Email)
+		} else {
+			err = s.repo.OptOut(ctx, pref.Category, user.Email)
+		}
+		if err != nil {
+			l.Error("failed to update subscription",
+				logger.Field{Key: "error", Value: err},
+				logger.Field{Key: "category", Value: pref.Category},
+			)
+			return nil, fmt.Errorf("failed to update subscription: %w", err)
+		}
+	}
+
+	return s.preferences(ctx, user.Email)
+}
+
+// Unsubscribe adds the address to the category's opt-out list
+func (s *SubscriptionService) Unsubscribe(ctx context.Context, address, category string) error {
+	if !domain.IsValidCategory(category) {
+		return fmt.Errorf("%w: %s", domain.ErrUnknownCategory, category)
+	}
+
+	if err := s.repo.OptOut(ctx, category, address); err != nil {
+		return fmt.Errorf("failed to opt out: %w", err)
+	}
+
+	s.logger.Info("address unsubscribed",
+		logger.Field{Key: "category", Value: category},
+	)
+	return nil
+}
+
+// Allowed reports whether mail of the category may be sent to the address
+func (s *SubscriptionService) Allowed(ctx context.Context, address, category string) (bool, error) {
+	optedOut, err := s.repo.IsOptedOut(ctx, category, address)
+	if err != nil {
+		return false, fmt.Errorf("failed to check subscription: %w", err)
+	}
+
+	return !optedOut, nil
+}
+
+// UnsubscribeURL returns the one-click unsubscribe URL, or "" when unsubscribe
+// links are not configured
+func (s *SubscriptionService) UnsubscribeURL(address, category string) string {
+	if s.links == nil {
+		return ""
+	}
+
+	return s.links.URL(address, category)
+}
+
+func (s *SubscriptionService) preferences(ctx context.Context, address string) ([]domain.CategoryPreference, error) {
+	prefs := make([]domain.CategoryPreference, 0, len(domain.Categories))
+	for _, category := range domain.Categories {
+		optedOut, err := s.repo.IsOptedOut(ctx, category, address)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check subscription: %w", err)
+		}
+		prefs = append(prefs, domain.CategoryPreference{
+			Category:   category,
+			Subscribed: !optedOut,
+		})
+	}
+
+	return prefs, nil
+}
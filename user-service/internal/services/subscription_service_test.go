@@ -0,0 +1,231 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/popeskul/mailflow/user-service/internal/domain"
+	"github.com/popeskul/mailflow/user-service/internal/services/mocks"
+	"github.com/popeskul/mailflow/user-service/internal/unsubscribe"
+)
+
+func TestSubscriptionService_GetPreferences_Success(t *testing.T) {
+	tests := []struct {
+		name     string
+		optedOut map[string]bool
+		expected []domain.CategoryPreference
+	}{
+		{
+			name: "subscribed to everything by default",
+			expected: []domain.CategoryPreference{
+				{Category: domain.CategoryOnboarding, Subscribed: true},
+				{Category: domain.CategoryNewsletter, Subscribed: true},
+				{Category: domain.CategoryProductUpdates, Subscribed: true},
+			},
+		},
+		{
+			name:     "opted out of newsletter",
+			optedOut: map[string]bool{domain.CategoryNewsletter: true},
+			expected: []domain.CategoryPreference{
+				{Category: domain.CategoryOnboarding, Subscribed: true},
+				{Category: domain.CategoryNewsletter, Subscribed: false},
+				{Category: domain.CategoryProductUpdates, Subscribed: true},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			users := mocks.NewMockUserRepository(ctrl)
+			repo := mocks.NewMockSubscriptionRepository(ctrl)
+
+			users.EXPECT().GetByID(gomock.Any(), "user-1").Return(&domain.User{ID: "user-1", Email: "test@example.com"}, nil)
+			repo.EXPECT().IsOptedOut(gomock.Any(), gomock.Any(), "test@example.com").
+				DoAndReturn(func(_ context.Context, category, _ string) (bool, error) {
+					return tt.optedOut[category], nil
+				}).Times(len(domain.Categories))
+
+			service := NewSubscriptionService(users, repo, nil, createTestLogger())
+
+			prefs, err := service.GetPreferences(context.Background(), "user-1")
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, prefs)
+		})
+	}
+}
+
+func TestSubscriptionService_UpdatePreferences_Success(t *testing.T) {
+	tests := []struct {
+		name       string
+		updates    []domain.CategoryPreference
+		setupMocks func(*mocks.MockSubscriptionRepository)
+	}{
+		{
+			name:    "opt out of newsletter",
+			updates: []domain.CategoryPreference{{Category: domain.CategoryNewsletter, Subscribed: false}},
+			setupMocks: func(repo *mocks.MockSubscriptionRepository) {
+				repo.EXPECT().OptOut(gomock.Any(), domain.CategoryNewsletter, "test@example.com").Return(nil)
+			},
+		},
+		{
+			name:    "opt back in to product updates",
+			updates: []domain.CategoryPreference{{Category: domain.CategoryProductUpdates, Subscribed: true}},
+			setupMocks: func(repo *mocks.MockSubscriptionRepository) {
+				repo.EXPECT().OptIn(gomock.Any(), domain.CategoryProductUpdates, "test@example.com").Return(nil)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			users := mocks.NewMockUserRepository(ctrl)
+			repo := mocks.NewMockSubscriptionRepository(ctrl)
+
+			users.EXPECT().GetByID(gomock.Any(), "user-1").Return(&domain.User{ID: "user-1", Email: "test@example.com"}, nil)
+			tt.setupMocks(repo)
+			repo.EXPECT().IsOptedOut(gomock.Any(), gomock.Any(), gomock.Any()).Return(false, nil).Times(len(domain.Categories))
+
+			service := NewSubscriptionService(users, repo, nil, createTestLogger())
+
+			prefs, err := service.UpdatePreferences(context.Background(), "user-1", tt.updates)
+
+			assert.NoError(t, err)
+			assert.Len(t, prefs, len(domain.Categories))
+		})
+	}
+}
+
+func TestSubscriptionService_UpdatePreferences_Fail(t *testing.T) {
+	tests := []struct {
+		name          string
+		updates       []domain.CategoryPreference
+		setupMocks    func(*mocks.MockUserRepository, *mocks.MockSubscriptionRepository)
+		expectedError error
+		expectedText  string
+	}{
+		{
+			name:          "unknown category",
+			updates:       []domain.CategoryPreference{{Category: "spam"}},
+			setupMocks:    func(*mocks.MockUserRepository, *mocks.MockSubscriptionRepository) {},
+			expectedError: domain.ErrUnknownCategory,
+			expectedText:  "spam",
+		},
+		{
+			name:    "user not found",
+			updates: []domain.CategoryPreference{{Category: domain.CategoryNewsletter}},
+			setupMocks: func(users *mocks.MockUserRepository, _ *mocks.MockSubscriptionRepository) {
+				users.EXPECT().GetByID(gomock.Any(), "user-1").Return(nil, errors.New("not found"))
+			},
+			expectedText: "failed to get user",
+		},
+		{
+			name:    "repository failure",
+			updates: []domain.CategoryPreference{{Category: domain.CategoryNewsletter}},
+			setupMocks: func(users *mocks.MockUserRepository, repo *mocks.MockSubscriptionRepository) {
+				users.EXPECT().GetByID(gomock.Any(), "user-1").Return(&domain.User{ID: "user-1", Email: "test@example.com"}, nil)
+				repo.EXPECT().OptOut(gomock.Any(), domain.CategoryNewsletter, "test@example.com").Return(errors.New("database error"))
+			},
+			expectedText: "failed to update subscription",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			users := mocks.NewMockUserRepository(ctrl)
+			repo := mocks.NewMockSubscriptionRepository(ctrl)
+			tt.setupMocks(users, repo)
+
+			service := NewSubscriptionService(users, repo, nil, createTestLogger())
+
+			prefs, err := service.UpdatePreferences(context.Background(), "user-1", tt.updates)
+
+			assert.Error(t, err)
+			assert.Nil(t, prefs)
+			assert.Contains(t, err.Error(), tt.expectedText)
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+			}
+		})
+	}
+}
+
+func TestSubscriptionService_Unsubscribe_Fail(t *testing.T) {
+	tests := []struct {
+		name          string
+		category      string
+		setupMocks    func(*mocks.MockSubscriptionRepository)
+		expectedError string
+	}{
+		{
+			name:          "unknown category",
+			category:      "spam",
+			setupMocks:    func(*mocks.MockSubscriptionRepository) {},
+			expectedError: "unknown subscription category",
+		},
+		{
+			name:     "repository failure",
+			category: domain.CategoryNewsletter,
+			setupMocks: func(repo *mocks.MockSubscriptionRepository) {
+				repo.EXPECT().OptOut(gomock.Any(), domain.CategoryNewsletter, "test@example.com").Return(errors.New("database error"))
+			},
+			expectedError: "failed to opt out",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := mocks.NewMockSubscriptionRepository(ctrl)
+			tt.setupMocks(repo)
+
+			service := NewSubscriptionService(nil, repo, nil, createTestLogger())
+
+			err := service.Unsubscribe(context.Background(), "test@example.com", tt.category)
+
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), tt.expectedError)
+		})
+	}
+}
+
+func TestSubscriptionService_UnsubscribeURL_Success(t *testing.T) {
+	tests := []struct {
+		name     string
+		links    *unsubscribe.Links
+		expected string
+	}{
+		{
+			name:     "no links configured",
+			expected: "",
+		},
+		{
+			name:     "signed link",
+			links:    unsubscribe.NewLinks("https://example.com/", "secret"),
+			expected: unsubscribe.NewLinks("https://example.com", "secret").URL("test@example.com", domain.CategoryNewsletter),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := NewSubscriptionService(nil, nil, tt.links, createTestLogger())
+
+			assert.Equal(t, tt.expected, service.UnsubscribeURL("test@example.com", domain.CategoryNewsletter))
+		})
+	}
+}
@@ -2,29 +2,45 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/popeskul/mailflow/common/logger"
 	emailv1 "github.com/popeskul/mailflow/email-service/pkg/api/email/v1"
 	"github.com/popeskul/mailflow/user-service/internal/domain"
+	"github.com/popeskul/mailflow/user-service/internal/passhash"
+	"github.com/popeskul/mailflow/user-service/internal/ratelimit"
+	"github.com/popeskul/mailflow/user-service/internal/verification"
 )
 
 type UserService struct {
-	repo         domain.UserRepository
-	emailClient  emailv1.EmailServiceClient
-	emailWrapper *EmailClientWrapper
-	logger       logger.Logger
+	repo          domain.UserRepository
+	emailClient   emailv1.EmailServiceClient
+	emailWrapper  *EmailClientWrapper
+	subscriptions *SubscriptionService
+	verifier      *verification.Links
+	// resends limits verification emails per address, unlimited when nil
+	resends *ratelimit.Keyed
+	logger  logger.Logger
 }
 
+// NewUserService creates the user service. Without subscriptions welcome
+// emails are sent without unsubscribe headers or opt-out checks. Without a
+// verifier addresses are not verified and the welcome email goes out on sign-up.
 func NewUserService(
 	repo domain.UserRepository,
 	emailClient emailv1.EmailServiceClient,
+	subscriptions *SubscriptionService,
+	verifier *verification.Links,
 	l logger.Logger,
 ) *UserService {
 	return &UserService{
-		repo:        repo,
-		emailClient: emailClient,
-		logger:      l.Named("user_service"),
+		repo:          repo,
+		emailClient:   emailClient,
+		subscriptions: subscriptions,
+		verifier:      verifier,
+		logger:        l.Named("user_service"),
 	}
 }
 
@@ -32,16 +48,22 @@ func NewUserService(
 func NewUserServiceWithWrapper(
 	repo domain.UserRepository,
 	emailWrapper *EmailClientWrapper,
+	subscriptions *SubscriptionService,
+	verifier *verification.Links,
 	l logger.Logger,
 ) *UserService {
 	return &UserService{
-		repo:         repo,
-		emailWrapper: emailWrapper,
-		logger:       l.Named("user_service"),
+		repo:          repo,
+		emailWrapper:  emailWrapper,
+		subscriptions: subscriptions,
+		verifier:      verifier,
+		logger:        l.Named("user_service"),
 	}
 }
 
-func (s *UserService) Create(ctx context.Context, email, name string) (*domain.User, error) {
+// Create registers a user. The password is optional; users without one
+// cannot log in.
+func (s *UserService) Create(ctx context.Context, email, name, password string) (*domain.User, error) {
 	l := s.logger.WithFields(logger.Fields{
 		"email": email,
 		"name":  name,
@@ -49,47 +71,196 @@ func (s *UserService) Create(ctx context.Context, email, name string) (*domain.U
 
 	user := domain.NewUser(email, name)
 
+	if password != "" {
+		if err := domain.ValidatePassword(password); err != nil {
+			return nil, err
+		}
+		hash, err := passhash.Hash(password)
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash password: %w", err)
+		}
+		user.PasswordHash = hash
+	}
+
 	l.Info("creating new user",
 		logger.Field{Key: "user_id", Value: user.ID},
 	)
 
 	if err := s.repo.Create(ctx, user); err != nil {
+		if errors.Is(err, domain.ErrEmailTaken) {
+			l.Info("email address already registered")
+			return nil, fmt.Errorf("failed to create user: %w", err)
+		}
 		l.Error("failed to create user",
 			logger.Field{Key: "error", Value: err},
 		)
 		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
 
-	l.Info("sending welcome email")
-
-	// Use wrapper if available, otherwise use direct client
-	if s.emailWrapper != nil {
-		err := s.emailWrapper.SendEmail(ctx, &emailv1.SendEmailRequest{
-			To:      user.Email,
-			Subject: "Welcome to our service!",
-			Body:    fmt.Sprintf("Hello %s,\n\nWelcome to our service! We're glad to have you here.", user.Name),
-		})
-		if err != nil {
-			l.Error("failed to send welcome email",
+	// With double opt-in the welcome email waits for VerifyEmail
+	if s.verifier != nil {
+		if err := s.sendVerificationEmail(ctx, user); err != nil {
+			l.Error("failed to send verification email",
 				logger.Field{Key: "error", Value: err},
 			)
-			// Don't return error as user is already created
 		}
-	} else if s.emailClient != nil {
-		_, err := s.emailClient.SendEmail(ctx, &emailv1.SendEmailRequest{
-			To:      user.Email,
-			Subject: "Welcome to our service!",
-			Body:    fmt.Sprintf("Hello %s,\n\nWelcome to our service! We're glad to have you here.", user.Name),
-		})
+		return user, nil
+	}
+
+	s.sendWelcomeEmail(ctx, l, user)
+
+	return user, nil
+}
+
+// VerifyEmail completes double opt-in for the user and address named by
+// token and sends the welcome email. Verifying twice is not an error.
+func (s *UserService) VerifyEmail(ctx context.Context, token string) (*domain.User, error) {
+	if s.verifier == nil {
+		return nil, domain.ErrVerificationDisabled
+	}
+
+	userID, address, err := s.verifier.Parse(token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify email: %w", err)
+	}
+
+	l := s.logger.WithFields(logger.Fields{
+		"user_id": userID,
+	})
+
+	user, err := s.repo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	// The link is for an address the user has since changed
+	if domain.NormalizeAddress(user.Email) != domain.NormalizeAddress(address) {
+		return nil, fmt.Errorf("failed to verify email: %w", verification.ErrInvalidToken)
+	}
+	if user.Verified {
+		return user, nil
+	}
+
+	now := time.Now()
+	user.Verified = true
+	user.VerifiedAt = &now
+	user.UpdatedAt = now
+
+	if err := s.repo.Update(ctx, user); err != nil {
+		l.Error("failed to mark user verified",
+			logger.Field{Key: "error", Value: err},
+		)
+		return nil, fmt.Errorf("failed to update user: %w", err)
+	}
+
+	l.Info("email address verified")
+	s.sendWelcomeEmail(ctx, l, user)
+
+	return user, nil
+}
+
+// ResendVerification sends a fresh verification link to an unverified
+// address. Unknown and verified addresses are not an error, so the response
+// does not reveal who is registered.
+func (s *UserService) ResendVerification(ctx context.Context, email string) error {
+	if s.verifier == nil {
+		return domain.ErrVerificationDisabled
+	}
+
+	// Limit by address, registered or not, before doing anything else
+	if s.resends != nil && !s.resends.Allow(domain.NormalizeAddress(email)) {
+		s.logger.Warn("verification email rate limited",
+			logger.Field{Key: "email", Value: email},
+		)
+		return domain.ErrResendRateLimited
+	}
+
+	user, err := s.repo.GetByEmail(ctx, email)
+	if errors.Is(err, domain.ErrUserNotFound) {
+		s.logger.Info("verification email requested for unknown address")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	if user.Verified {
+		s.logger.Info("verification email requested for verified address",
+			logger.Field{Key: "user_id", Value: user.ID},
+		)
+		return nil
+	}
+
+	if err := s.sendVerificationEmail(ctx, user); err != nil {
+		s.logger.Error("failed to resend verification email",
+			logger.Field{Key: "error", Value: err},
+			logger.Field{Key: "user_id", Value: user.ID},
+		)
+		return fmt.Errorf("failed to send verification email: %w", err)
+	}
+
+	return nil
+}
+
+// sendVerificationEmail mails the user a link proving they own their address.
+// It is transactional, so it carries no category and ignores opt-outs.
+func (s *UserService) sendVerificationEmail(ctx context.Context, user *domain.User) error {
+	if s.emailWrapper == nil && s.emailClient == nil {
+		return errors.New("no email client configured")
+	}
+
+	return s.sendEmail(ctx, &emailv1.SendEmailRequest{
+		To:      user.Email,
+		Subject: "Confirm your email address",
+		Body: fmt.Sprintf("Hello %s,\n\nPlease confirm your email address by opening this link:\n\n%s\n\n"+
+			"If you did not sign up, you can ignore this email.", user.Name, s.verifier.URL(user.ID, user.Email)),
+	})
+}
+
+// sendEmail delivers through the wrapper if there is one, otherwise directly
+func (s *UserService) sendEmail(ctx context.Context, req *emailv1.SendEmailRequest) error {
+	if s.emailWrapper != nil {
+		return s.emailWrapper.SendEmail(ctx, req)
+	}
+	_, err := s.emailClient.SendEmail(ctx, req)
+	return err
+}
+
+// sendWelcomeEmail sends the onboarding email unless the address opted out.
+// Failures are only logged as the user is already created.
+func (s *UserService) sendWelcomeEmail(ctx context.Context, l logger.Logger, user *domain.User) {
+	if s.emailWrapper == nil && s.emailClient == nil {
+		return
+	}
+
+	req := &emailv1.SendEmailRequest{
+		To:       user.Email,
+		Subject:  "Welcome to our service!",
+		Body:     fmt.Sprintf("Hello %s,\n\nWelcome to our service! We're glad to have you here.", user.Name),
+		Category: domain.CategoryOnboarding,
+	}
+
+	if s.subscriptions != nil {
+		allowed, err := s.subscriptions.Allowed(ctx, user.Email, domain.CategoryOnboarding)
 		if err != nil {
-			l.Error("failed to send welcome email",
+			l.Error("failed to check subscription, skipping welcome email",
 				logger.Field{Key: "error", Value: err},
 			)
-			// Don't return error as user is already created
+			return
 		}
+		if !allowed {
+			l.Info("address unsubscribed from onboarding, skipping welcome email")
+			return
+		}
+		req.UnsubscribeUrl = s.subscriptions.UnsubscribeURL(user.Email, domain.CategoryOnboarding)
 	}
 
-	return user, nil
+	l.Info("sending welcome email")
+
+	if err := s.sendEmail(ctx, req); err != nil {
+		l.Error("failed to send welcome email",
+			logger.Field{Key: "error", Value: err},
+		)
+	}
 }
 
 func (s *UserService) Get(ctx context.Context, id string) (*domain.User, error) {
@@ -108,13 +279,31 @@ func (s *UserService) Get(ctx context.Context, id string) (*domain.User, error)
 	return user, nil
 }
 
-func (s *UserService) Update(ctx context.Context, id, email, name string) (*domain.User, error) {
+func (s *UserService) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
+	user, err := s.repo.GetByEmail(ctx, email)
+	if err != nil {
+		s.logger.Error("failed to get user by email",
+			logger.Field{Key: "error", Value: err},
+		)
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	return user, nil
+}
+
+// Update applies the fields set in update. With an etag the update is only
+// applied to that revision; without one it still fails with
+// domain.ErrVersionConflict if the user changes while being updated.
+func (s *UserService) Update(ctx context.Context, id string, update domain.UserUpdate) (*domain.User, error) {
 	l := s.logger.WithFields(logger.Fields{
 		"user_id": id,
-		"email":   email,
-		"name":    name,
 	})
 
+	expected, err := domain.VersionFromETag(update.ETag)
+	if err != nil {
+		return nil, err
+	}
+
 	user, err := s.repo.GetByID(ctx, id)
 	if err != nil {
 		l.Error("failed to get user for update",
@@ -123,8 +312,24 @@ func (s *UserService) Update(ctx context.Context, id, email, name string) (*doma
 		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
 
-	user.Email = email
-	user.Name = name
+	if expected != 0 && expected != user.Version {
+		return nil, fmt.Errorf("failed to update user: %w", domain.ErrVersionConflict)
+	}
+
+	reverify := false
+	if update.Email != nil {
+		// A new address has to be verified again
+		if s.verifier != nil && domain.NormalizeAddress(*update.Email) != domain.NormalizeAddress(user.Email) {
+			reverify = true
+			user.Verified = false
+			user.VerifiedAt = nil
+		}
+		user.Email = *update.Email
+	}
+	if update.Name != nil {
+		user.Name = *update.Name
+	}
+	user.UpdatedAt = time.Now()
 
 	if err := s.repo.Update(ctx, user); err != nil {
 		l.Error("failed to update user",
@@ -133,15 +338,29 @@ func (s *UserService) Update(ctx context.Context, id, email, name string) (*doma
 		return nil, fmt.Errorf("failed to update user: %w", err)
 	}
 
+	if reverify {
+		if err := s.sendVerificationEmail(ctx, user); err != nil {
+			l.Error("failed to send verification email",
+				logger.Field{Key: "error", Value: err},
+			)
+		}
+	}
+
 	return user, nil
 }
 
-func (s *UserService) Delete(ctx context.Context, id string) error {
+// Delete removes a user, only at the revision named by etag if one is given
+func (s *UserService) Delete(ctx context.Context, id, etag string) error {
 	l := s.logger.WithFields(logger.Fields{
 		"user_id": id,
 	})
 
-	if err := s.repo.Delete(ctx, id); err != nil {
+	version, err := domain.VersionFromETag(etag)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.Delete(ctx, id, version); err != nil {
 		l.Error("failed to delete user",
 			logger.Field{Key: "error", Value: err},
 		)
@@ -4,20 +4,31 @@ import (
 	"context"
 	"errors"
 	"io"
+	"path/filepath"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
 	"go.uber.org/zap"
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 
 	"github.com/popeskul/mailflow/common/logger"
 	emailv1 "github.com/popeskul/mailflow/email-service/pkg/api/email/v1"
 	"github.com/popeskul/mailflow/user-service/internal/circuitbreaker"
 	"github.com/popeskul/mailflow/user-service/internal/domain"
+	"github.com/popeskul/mailflow/user-service/internal/passhash"
 	"github.com/popeskul/mailflow/user-service/internal/queue"
+	"github.com/popeskul/mailflow/user-service/internal/ratelimit"
+	"github.com/popeskul/mailflow/user-service/internal/retry"
 	"github.com/popeskul/mailflow/user-service/internal/services/mocks"
+	"github.com/popeskul/mailflow/user-service/internal/verification"
 )
 
 func createTestLogger() logger.Logger {
@@ -48,7 +59,7 @@ func TestNewUserService_Success(t *testing.T) {
 				emailClient = mocks.NewMockEmailServiceClient(ctrl)
 			}
 
-			service := NewUserService(repo, emailClient, createTestLogger())
+			service := NewUserService(repo, emailClient, nil, nil, createTestLogger())
 
 			assert.NotNil(t, service)
 		})
@@ -82,7 +93,7 @@ func TestNewUserServiceWithWrapper_Success(t *testing.T) {
 				wrapper = NewEmailClientWrapper(emailClient, cb, q, createTestLogger())
 			}
 
-			service := NewUserServiceWithWrapper(repo, wrapper, createTestLogger())
+			service := NewUserServiceWithWrapper(repo, wrapper, nil, nil, createTestLogger())
 
 			assert.NotNil(t, service)
 		})
@@ -132,20 +143,20 @@ func TestUserService_Create_Success(t *testing.T) {
 
 			if tt.withEmailClient {
 				emailClient := mocks.NewMockEmailServiceClient(ctrl)
-				emailClient.EXPECT().SendEmail(gomock.Any(), gomock.Any()).Return(&emailv1.SendEmailResponse{}, nil)
-				service = NewUserService(repo, emailClient, createTestLogger())
+				emailClient.EXPECT().SendEmail(gomock.Any(), gomock.Any(), gomock.Any()).Return(&emailv1.SendEmailResponse{}, nil)
+				service = NewUserService(repo, emailClient, nil, nil, createTestLogger())
 			} else if tt.withWrapper {
 				emailClient := mocks.NewMockEmailServiceClient(ctrl)
-				emailClient.EXPECT().SendEmail(gomock.Any(), gomock.Any()).Return(&emailv1.SendEmailResponse{}, nil)
+				emailClient.EXPECT().SendEmail(gomock.Any(), gomock.Any(), gomock.Any()).Return(&emailv1.SendEmailResponse{}, nil)
 				cb := circuitbreaker.New(circuitbreaker.DefaultConfig())
 				q := queue.NewEmailQueue(100, zap.NewNop())
 				wrapper := NewEmailClientWrapper(emailClient, cb, q, createTestLogger())
-				service = NewUserServiceWithWrapper(repo, wrapper, createTestLogger())
+				service = NewUserServiceWithWrapper(repo, wrapper, nil, nil, createTestLogger())
 			} else {
-				service = NewUserService(repo, nil, createTestLogger())
+				service = NewUserService(repo, nil, nil, nil, createTestLogger())
 			}
 
-			user, err := service.Create(context.Background(), tt.email, tt.userName)
+			user, err := service.Create(context.Background(), tt.email, tt.userName, "")
 
 			assert.NoError(t, err)
 			assert.NotNil(t, user)
@@ -161,14 +172,23 @@ func TestUserService_Create_Fail(t *testing.T) {
 		name          string
 		email         string
 		userName      string
+		repoErr       error
 		expectedError string
 	}{
 		{
 			name:          "repository create failure",
 			email:         "test@example.com",
 			userName:      "Test User",
+			repoErr:       errors.New("database error"),
 			expectedError: "failed to create user",
 		},
+		{
+			name:          "email already registered",
+			email:         "test@example.com",
+			userName:      "Test User",
+			repoErr:       domain.ErrEmailTaken,
+			expectedError: "already registered",
+		},
 	}
 
 	for _, tt := range tests {
@@ -177,19 +197,42 @@ func TestUserService_Create_Fail(t *testing.T) {
 			defer ctrl.Finish()
 
 			repo := mocks.NewMockUserRepository(ctrl)
-			repo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(errors.New("database error"))
+			repo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(tt.repoErr)
 
-			service := NewUserService(repo, nil, createTestLogger())
+			service := NewUserService(repo, nil, nil, nil, createTestLogger())
 
-			user, err := service.Create(context.Background(), tt.email, tt.userName)
+			user, err := service.Create(context.Background(), tt.email, tt.userName, "")
 
 			assert.Error(t, err)
 			assert.Nil(t, user)
 			assert.Contains(t, err.Error(), tt.expectedError)
+			assert.ErrorIs(t, err, tt.repoErr)
 		})
 	}
 }
 
+func TestUserService_Create_WithPassword(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mocks.NewMockUserRepository(ctrl)
+	repo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, user *domain.User) error {
+		ok, err := passhash.Verify(user.PasswordHash, "password123")
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		return nil
+	})
+
+	service := NewUserService(repo, nil, nil, nil, createTestLogger())
+
+	_, err := service.Create(context.Background(), "test@example.com", "Test User", "password123")
+	assert.NoError(t, err)
+
+	// Too short passwords are rejected before anything is stored
+	_, err = service.Create(context.Background(), "test@example.com", "Test User", "short")
+	assert.ErrorIs(t, err, domain.ErrWeakPassword)
+}
+
 func TestUserService_Get_Success(t *testing.T) {
 	tests := []struct {
 		name         string
@@ -215,7 +258,7 @@ func TestUserService_Get_Success(t *testing.T) {
 			repo := mocks.NewMockUserRepository(ctrl)
 			repo.EXPECT().GetByID(gomock.Any(), tt.userID).Return(tt.expectedUser, nil)
 
-			service := NewUserService(repo, nil, createTestLogger())
+			service := NewUserService(repo, nil, nil, nil, createTestLogger())
 
 			user, err := service.Get(context.Background(), tt.userID)
 
@@ -249,7 +292,7 @@ func TestUserService_Get_Fail(t *testing.T) {
 			repo := mocks.NewMockUserRepository(ctrl)
 			repo.EXPECT().GetByID(gomock.Any(), tt.userID).Return(nil, errors.New("user not found"))
 
-			service := NewUserService(repo, nil, createTestLogger())
+			service := NewUserService(repo, nil, nil, nil, createTestLogger())
 
 			user, err := service.Get(context.Background(), tt.userID)
 
@@ -260,24 +303,64 @@ func TestUserService_Get_Fail(t *testing.T) {
 	}
 }
 
+func TestUserService_GetByEmail_Success(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	expected := &domain.User{ID: "user-123", Email: "test@example.com", Name: "Test User"}
+	repo := mocks.NewMockUserRepository(ctrl)
+	repo.EXPECT().GetByEmail(gomock.Any(), "Test@Example.com").Return(expected, nil)
+
+	service := NewUserService(repo, nil, nil, nil, createTestLogger())
+
+	user, err := service.GetByEmail(context.Background(), "Test@Example.com")
+
+	assert.NoError(t, err)
+	assert.Equal(t, expected, user)
+}
+
+func TestUserService_GetByEmail_Fail(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mocks.NewMockUserRepository(ctrl)
+	repo.EXPECT().GetByEmail(gomock.Any(), "missing@example.com").Return(nil, domain.ErrUserNotFound)
+
+	service := NewUserService(repo, nil, nil, nil, createTestLogger())
+
+	user, err := service.GetByEmail(context.Background(), "missing@example.com")
+
+	assert.ErrorIs(t, err, domain.ErrUserNotFound)
+	assert.Nil(t, user)
+}
+
 func TestUserService_Update_Success(t *testing.T) {
+	newEmail := "updated@example.com"
+	newName := "Updated User"
+
 	tests := []struct {
-		name         string
-		userID       string
-		email        string
-		userName     string
-		existingUser *domain.User
+		name          string
+		update        domain.UserUpdate
+		expectedEmail string
+		expectedName  string
 	}{
 		{
-			name:     "update user successfully",
-			userID:   "user-123",
-			email:    "updated@example.com",
-			userName: "Updated User",
-			existingUser: &domain.User{
-				ID:    "user-123",
-				Email: "old@example.com",
-				Name:  "Old User",
-			},
+			name:          "update all fields",
+			update:        domain.UserUpdate{Email: &newEmail, Name: &newName},
+			expectedEmail: newEmail,
+			expectedName:  newName,
+		},
+		{
+			name:          "update only the masked field",
+			update:        domain.UserUpdate{Name: &newName},
+			expectedEmail: "old@example.com",
+			expectedName:  newName,
+		},
+		{
+			name:          "update at matching etag",
+			update:        domain.UserUpdate{Name: &newName, ETag: "3"},
+			expectedEmail: "old@example.com",
+			expectedName:  newName,
 		},
 	}
 
@@ -286,46 +369,49 @@ func TestUserService_Update_Success(t *testing.T) {
 			ctrl := gomock.NewController(t)
 			defer ctrl.Finish()
 
+			existingUser := &domain.User{ID: "user-123", Email: "old@example.com", Name: "Old User", Version: 3}
+
 			repo := mocks.NewMockUserRepository(ctrl)
-			repo.EXPECT().GetByID(gomock.Any(), tt.userID).Return(tt.existingUser, nil)
+			repo.EXPECT().GetByID(gomock.Any(), "user-123").Return(existingUser, nil)
 			repo.EXPECT().Update(gomock.Any(), gomock.Any()).Return(nil)
 
-			service := NewUserService(repo, nil, createTestLogger())
+			service := NewUserService(repo, nil, nil, nil, createTestLogger())
 
-			user, err := service.Update(context.Background(), tt.userID, tt.email, tt.userName)
+			user, err := service.Update(context.Background(), "user-123", tt.update)
 
 			assert.NoError(t, err)
 			assert.NotNil(t, user)
-			assert.Equal(t, tt.email, user.Email)
-			assert.Equal(t, tt.userName, user.Name)
+			assert.Equal(t, tt.expectedEmail, user.Email)
+			assert.Equal(t, tt.expectedName, user.Name)
 		})
 	}
 }
 
 func TestUserService_Update_Fail(t *testing.T) {
+	name := "Test User"
+
 	tests := []struct {
 		name          string
 		userID        string
-		email         string
-		userName      string
+		update        domain.UserUpdate
 		setupMocks    func(*mocks.MockUserRepository)
 		expectedError string
+		expectedIs    error
 	}{
 		{
-			name:     "user not found",
-			userID:   "nonexistent",
-			email:    "test@example.com",
-			userName: "Test User",
+			name:   "user not found",
+			userID: "nonexistent",
+			update: domain.UserUpdate{Name: &name},
 			setupMocks: func(repo *mocks.MockUserRepository) {
-				repo.EXPECT().GetByID(gomock.Any(), "nonexistent").Return(nil, errors.New("user not found"))
+				repo.EXPECT().GetByID(gomock.Any(), "nonexistent").Return(nil, domain.ErrUserNotFound)
 			},
 			expectedError: "failed to get user",
+			expectedIs:    domain.ErrUserNotFound,
 		},
 		{
-			name:     "update failure",
-			userID:   "user-123",
-			email:    "test@example.com",
-			userName: "Test User",
+			name:   "update failure",
+			userID: "user-123",
+			update: domain.UserUpdate{Name: &name},
 			setupMocks: func(repo *mocks.MockUserRepository) {
 				existingUser := &domain.User{ID: "user-123", Email: "old@example.com", Name: "Old User"}
 				repo.EXPECT().GetByID(gomock.Any(), "user-123").Return(existingUser, nil)
@@ -333,6 +419,37 @@ func TestUserService_Update_Fail(t *testing.T) {
 			},
 			expectedError: "failed to update user",
 		},
+		{
+			name:   "stale etag",
+			userID: "user-123",
+			update: domain.UserUpdate{Name: &name, ETag: "1"},
+			setupMocks: func(repo *mocks.MockUserRepository) {
+				existingUser := &domain.User{ID: "user-123", Email: "old@example.com", Name: "Old User", Version: 2}
+				repo.EXPECT().GetByID(gomock.Any(), "user-123").Return(existingUser, nil)
+			},
+			expectedError: "failed to update user",
+			expectedIs:    domain.ErrVersionConflict,
+		},
+		{
+			name:   "concurrent write",
+			userID: "user-123",
+			update: domain.UserUpdate{Name: &name},
+			setupMocks: func(repo *mocks.MockUserRepository) {
+				existingUser := &domain.User{ID: "user-123", Email: "old@example.com", Name: "Old User", Version: 2}
+				repo.EXPECT().GetByID(gomock.Any(), "user-123").Return(existingUser, nil)
+				repo.EXPECT().Update(gomock.Any(), gomock.Any()).Return(domain.ErrVersionConflict)
+			},
+			expectedError: "failed to update user",
+			expectedIs:    domain.ErrVersionConflict,
+		},
+		{
+			name:          "malformed etag",
+			userID:        "user-123",
+			update:        domain.UserUpdate{Name: &name, ETag: "abc"},
+			setupMocks:    func(*mocks.MockUserRepository) {},
+			expectedError: "invalid etag",
+			expectedIs:    domain.ErrInvalidETag,
+		},
 	}
 
 	for _, tt := range tests {
@@ -343,26 +460,37 @@ func TestUserService_Update_Fail(t *testing.T) {
 			repo := mocks.NewMockUserRepository(ctrl)
 			tt.setupMocks(repo)
 
-			service := NewUserService(repo, nil, createTestLogger())
+			service := NewUserService(repo, nil, nil, nil, createTestLogger())
 
-			user, err := service.Update(context.Background(), tt.userID, tt.email, tt.userName)
+			user, err := service.Update(context.Background(), tt.userID, tt.update)
 
 			assert.Error(t, err)
 			assert.Nil(t, user)
 			assert.Contains(t, err.Error(), tt.expectedError)
+			if tt.expectedIs != nil {
+				assert.ErrorIs(t, err, tt.expectedIs)
+			}
 		})
 	}
 }
 
 func TestUserService_Delete_Success(t *testing.T) {
 	tests := []struct {
-		name   string
-		userID string
+		name            string
+		userID          string
+		etag            string
+		expectedVersion int64
 	}{
 		{
 			name:   "delete user successfully",
 			userID: "user-123",
 		},
+		{
+			name:            "delete at etag",
+			userID:          "user-123",
+			etag:            "4",
+			expectedVersion: 4,
+		},
 	}
 
 	for _, tt := range tests {
@@ -371,11 +499,11 @@ func TestUserService_Delete_Success(t *testing.T) {
 			defer ctrl.Finish()
 
 			repo := mocks.NewMockUserRepository(ctrl)
-			repo.EXPECT().Delete(gomock.Any(), tt.userID).Return(nil)
+			repo.EXPECT().Delete(gomock.Any(), tt.userID, tt.expectedVersion).Return(nil)
 
-			service := NewUserService(repo, nil, createTestLogger())
+			service := NewUserService(repo, nil, nil, nil, createTestLogger())
 
-			err := service.Delete(context.Background(), tt.userID)
+			err := service.Delete(context.Background(), tt.userID, tt.etag)
 
 			assert.NoError(t, err)
 		})
@@ -386,13 +514,25 @@ func TestUserService_Delete_Fail(t *testing.T) {
 	tests := []struct {
 		name          string
 		userID        string
+		etag          string
+		setupMocks    func(*mocks.MockUserRepository)
 		expectedError string
 	}{
 		{
-			name:          "delete failure",
-			userID:        "user-123",
+			name:   "delete failure",
+			userID: "user-123",
+			setupMocks: func(repo *mocks.MockUserRepository) {
+				repo.EXPECT().Delete(gomock.Any(), "user-123", int64(0)).Return(errors.New("delete failed"))
+			},
 			expectedError: "failed to delete user",
 		},
+		{
+			name:          "malformed etag",
+			userID:        "user-123",
+			etag:          "abc",
+			setupMocks:    func(*mocks.MockUserRepository) {},
+			expectedError: "invalid etag",
+		},
 	}
 
 	for _, tt := range tests {
@@ -401,11 +541,11 @@ func TestUserService_Delete_Fail(t *testing.T) {
 			defer ctrl.Finish()
 
 			repo := mocks.NewMockUserRepository(ctrl)
-			repo.EXPECT().Delete(gomock.Any(), tt.userID).Return(errors.New("delete failed"))
+			tt.setupMocks(repo)
 
-			service := NewUserService(repo, nil, createTestLogger())
+			service := NewUserService(repo, nil, nil, nil, createTestLogger())
 
-			err := service.Delete(context.Background(), tt.userID)
+			err := service.Delete(context.Background(), tt.userID, tt.etag)
 
 			assert.Error(t, err)
 			assert.Contains(t, err.Error(), tt.expectedError)
@@ -441,7 +581,7 @@ func TestUserService_List_Success(t *testing.T) {
 			repo := mocks.NewMockUserRepository(ctrl)
 			repo.EXPECT().List(gomock.Any(), tt.pageSize, tt.pageToken).Return(tt.expectedUsers, tt.expectedNextToken, nil)
 
-			service := NewUserService(repo, nil, createTestLogger())
+			service := NewUserService(repo, nil, nil, nil, createTestLogger())
 
 			users, nextToken, err := service.List(context.Background(), tt.pageSize, tt.pageToken)
 
@@ -475,7 +615,7 @@ func TestUserService_List_Fail(t *testing.T) {
 			repo := mocks.NewMockUserRepository(ctrl)
 			repo.EXPECT().List(gomock.Any(), tt.pageSize, tt.pageToken).Return(nil, "", errors.New("list failed"))
 
-			service := NewUserService(repo, nil, createTestLogger())
+			service := NewUserService(repo, nil, nil, nil, createTestLogger())
 
 			users, nextToken, err := service.List(context.Background(), tt.pageSize, tt.pageToken)
 
@@ -508,7 +648,7 @@ func TestEmailClientWrapper_SendEmail_Success(t *testing.T) {
 			defer ctrl.Finish()
 
 			client := mocks.NewMockEmailServiceClient(ctrl)
-			client.EXPECT().SendEmail(gomock.Any(), tt.request).Return(&emailv1.SendEmailResponse{}, nil)
+			client.EXPECT().SendEmail(gomock.Any(), tt.request, gomock.Any()).Return(&emailv1.SendEmailResponse{}, nil)
 
 			cb := circuitbreaker.New(circuitbreaker.DefaultConfig())
 			q := queue.NewEmailQueue(100, zap.NewNop())
@@ -538,7 +678,7 @@ func TestEmailClientWrapper_SendEmail_Fail(t *testing.T) {
 			},
 			setupMocks: func(client *mocks.MockEmailServiceClient) {
 				unavailableErr := status.Error(codes.Unavailable, "service unavailable")
-				client.EXPECT().SendEmail(gomock.Any(), gomock.Any()).Return(nil, unavailableErr).AnyTimes()
+				client.EXPECT().SendEmail(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, unavailableErr).AnyTimes()
 			},
 			shouldQueue: true,
 		},
@@ -574,3 +714,482 @@ func TestEmailClientWrapper_SendEmail_Fail(t *testing.T) {
 		})
 	}
 }
+
+func TestEmailClientWrapper_SendEmail_QueueRoundTrip(t *testing.T) {
+	tests := []struct {
+		name    string
+		request *emailv1.SendEmailRequest
+	}{
+		{
+			name: "every field survives queueing and spooling",
+			request: &emailv1.SendEmailRequest{
+				To:             "test@example.com",
+				Subject:        "Test Subject",
+				Body:           "Test Body",
+				Tracking:       &emailv1.TrackingOptions{Opens: true, Clicks: true},
+				Category:       "newsletter",
+				UnsubscribeUrl: "https://example.com/unsubscribe?token=abc",
+				Tenant:         "acme",
+				Tags:           []string{"welcome", "onboarding"},
+			},
+		},
+		{
+			name: "request without tracking",
+			request: &emailv1.SendEmailRequest{
+				To:       "test@example.com",
+				Subject:  "Test Subject",
+				Body:     "Test Body",
+				Category: "account",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			spool := queue.NewFileSpool(filepath.Join(t.TempDir(), "spool.jsonl"))
+
+			// The email service is down, so the email is queued and spooled at shutdown
+			down := mocks.NewMockEmailServiceClient(ctrl)
+			down.EXPECT().SendEmail(gomock.Any(), gomock.Any(), gomock.Any()).
+				Return(nil, status.Error(codes.Unavailable, "service unavailable")).AnyTimes()
+			wrapper := NewEmailClientWrapper(down, circuitbreaker.New(circuitbreaker.DefaultConfig()),
+				queue.NewEmailQueue(100, zap.NewNop(), queue.WithPersister(spool)), createTestLogger(),
+				WithRetryStrategy(&retry.Constant{Delay: time.Millisecond, MaxAttempts: 1}))
+
+			require.NoError(t, wrapper.SendEmail(context.Background(), tt.request))
+			report := wrapper.Shutdown(context.Background())
+			require.Equal(t, 1, report.Persisted)
+
+			// The next run resends it unchanged
+			sent := make(chan *emailv1.SendEmailRequest, 1)
+			up := mocks.NewMockEmailServiceClient(ctrl)
+			up.EXPECT().SendEmail(gomock.Any(), gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ context.Context, req *emailv1.SendEmailRequest, _ ...grpc.CallOption) (*emailv1.SendEmailResponse, error) {
+					sent <- req
+					return &emailv1.SendEmailResponse{}, nil
+				})
+			wrapper = NewEmailClientWrapper(up, circuitbreaker.New(circuitbreaker.DefaultConfig()),
+				queue.NewEmailQueue(100, zap.NewNop()), createTestLogger())
+
+			spooled, err := spool.Load()
+			require.NoError(t, err)
+			require.Len(t, spooled, 1)
+			require.NoError(t, wrapper.Requeue(spooled[0]))
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			wrapper.ProcessQueue(ctx)
+
+			select {
+			case req := <-sent:
+				assert.True(t, proto.Equal(tt.request, req), "sent %v, want %v", req, tt.request)
+			case <-time.After(time.Second):
+				t.Fatal("queued email was not resent")
+			}
+			wrapper.Shutdown(context.Background())
+		})
+	}
+}
+
+func TestEmailClientWrapper_SendEmail_Retries(t *testing.T) {
+	tests := []struct {
+		name string
+		// errs are returned by the email service in turn, then it succeeds
+		errs     []error
+		pushback string
+		budget   *retry.Budget
+		attempts int
+		wantErr  codes.Code
+		queued   int
+	}{
+		{
+			name:     "unavailable is retried",
+			errs:     []error{status.Error(codes.Unavailable, "down")},
+			attempts: 2,
+		},
+		{
+			name:     "pushback replaces the backoff delay",
+			errs:     []error{status.Error(codes.ResourceExhausted, "queue full")},
+			pushback: "1",
+			attempts: 2,
+		},
+		{
+			name:     "invalid argument is not retried",
+			errs:     []error{status.Error(codes.InvalidArgument, "bad address")},
+			attempts: 1,
+			wantErr:  codes.InvalidArgument,
+		},
+		{
+			name:     "negative pushback stops retries",
+			errs:     []error{status.Error(codes.ResourceExhausted, "queue full")},
+			pushback: "-1",
+			attempts: 1,
+			queued:   1,
+		},
+		{
+			name:     "spent budget stops retries",
+			errs:     []error{status.Error(codes.Unavailable, "down")},
+			budget:   retry.NewBudget(2, 0.1),
+			attempts: 1,
+			queued:   1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			attempts := 0
+			client := mocks.NewMockEmailServiceClient(ctrl)
+			client.EXPECT().SendEmail(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ context.Context, _ *emailv1.SendEmailRequest, opts ...grpc.CallOption) (*emailv1.SendEmailResponse, error) {
+					attempts++
+					if attempts > len(tt.errs) {
+						return &emailv1.SendEmailResponse{}, nil
+					}
+					for _, opt := range opts {
+						if trailer, ok := opt.(grpc.TrailerCallOption); ok && tt.pushback != "" {
+							*trailer.TrailerAddr = metadata.Pairs(retry.PushbackTrailer, tt.pushback)
+						}
+					}
+					return nil, tt.errs[attempts-1]
+				}).AnyTimes()
+
+			q := queue.NewEmailQueue(100, zap.NewNop())
+			// With a pushback only its delay makes the retry fast enough for the test
+			delay := time.Millisecond
+			if tt.pushback != "" {
+				delay = time.Hour
+			}
+			opts := []WrapperOption{
+				WithRetryStrategy(&retry.ExponentialBackoff{InitialDelay: delay, MaxDelay: delay, MaxAttempts: 3}),
+			}
+			if tt.budget != nil {
+				opts = append(opts, WithRetryBudget(tt.budget))
+			}
+			wrapper := NewEmailClientWrapper(client, circuitbreaker.New(circuitbreaker.DefaultConfig()), q, createTestLogger(), opts...)
+
+			err := wrapper.SendEmail(context.Background(), &emailv1.SendEmailRequest{To: "test@example.com"})
+
+			assert.Equal(t, tt.wantErr, status.Code(err))
+			assert.Equal(t, tt.attempts, attempts)
+			assert.Equal(t, tt.queued, q.Size())
+		})
+	}
+}
+
+func TestEmailClientWrapper_GetEmailStatus_Hedging(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    []retry.HedgerOption
+		open    bool
+		calls   int32
+		status  string
+		wantErr error
+	}{
+		{
+			name:   "slow request is hedged",
+			opts:   []retry.HedgerOption{retry.WithHedgeDelay(time.Millisecond)},
+			calls:  2,
+			status: "sent",
+		},
+		{
+			name:    "no hedges while the circuit is open",
+			opts:    []retry.HedgerOption{retry.WithHedgeDelay(time.Millisecond)},
+			open:    true,
+			wantErr: circuitbreaker.ErrCircuitOpen,
+		},
+		{
+			name:   "hedging is off by default",
+			calls:  1,
+			status: "sent",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			// The first request answers only after a hedge would have been sent
+			var calls atomic.Int32
+			client := mocks.NewMockEmailServiceClient(ctrl)
+			client.EXPECT().GetEmailStatus(gomock.Any(), gomock.Any()).DoAndReturn(
+				func(ctx context.Context, _ *emailv1.GetEmailStatusRequest, _ ...grpc.CallOption) (*emailv1.GetEmailStatusResponse, error) {
+					if calls.Add(1) == 1 {
+						select {
+						case <-ctx.Done():
+							return nil, status.FromContextError(ctx.Err()).Err()
+						case <-time.After(50 * time.Millisecond):
+						}
+					}
+					return &emailv1.GetEmailStatusResponse{Status: "sent"}, nil
+				}).AnyTimes()
+
+			cb := circuitbreaker.New(circuitbreaker.DefaultConfig())
+			if tt.open {
+				cb.ForceOpen()
+			}
+			wrapper := NewEmailClientWrapper(client, cb, queue.NewEmailQueue(100, zap.NewNop()), createTestLogger(),
+				WithHedging(tt.opts...))
+
+			resp, err := wrapper.GetEmailStatus(context.Background(), &emailv1.GetEmailStatusRequest{Id: "email_1"})
+
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.status, resp.GetStatus())
+			assert.Equal(t, tt.calls, calls.Load())
+			// The cancelled request is not counted as a failure
+			assert.Zero(t, cb.GetMetrics().Failures)
+		})
+	}
+}
+
+func TestUserService_Create_SendsVerification(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mocks.NewMockUserRepository(ctrl)
+	repo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+
+	// Only the verification email goes out; the welcome email waits
+	emailClient := mocks.NewMockEmailServiceClient(ctrl)
+	emailClient.EXPECT().SendEmail(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, req *emailv1.SendEmailRequest, _ ...grpc.CallOption) (*emailv1.SendEmailResponse, error) {
+			assert.Equal(t, "Confirm your email address", req.Subject)
+			assert.Contains(t, req.Body, "https://example.com/api/v1/verify-email?token=")
+			assert.Empty(t, req.Category)
+			return &emailv1.SendEmailResponse{}, nil
+		})
+
+	verifier := verification.NewLinks("https://example.com", "secret", time.Hour)
+	service := NewUserService(repo, emailClient, nil, verifier, createTestLogger())
+
+	user, err := service.Create(context.Background(), "test@example.com", "Test User", "")
+
+	assert.NoError(t, err)
+	assert.False(t, user.Verified)
+}
+
+func TestUserService_VerifyEmail_Success(t *testing.T) {
+	verifier := verification.NewLinks("https://example.com", "secret", time.Hour)
+	verifiedAt := time.Now()
+
+	tests := []struct {
+		name         string
+		user         *domain.User
+		expectUpdate bool
+	}{
+		{
+			name:         "first verification sends welcome email",
+			user:         &domain.User{ID: "user-1", Email: "test@example.com", Name: "Test User", Version: 1},
+			expectUpdate: true,
+		},
+		{
+			name: "verifying twice is a no-op",
+			user: &domain.User{ID: "user-1", Email: "test@example.com", Verified: true, VerifiedAt: &verifiedAt},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := mocks.NewMockUserRepository(ctrl)
+			repo.EXPECT().GetByID(gomock.Any(), "user-1").Return(tt.user, nil)
+
+			emailClient := mocks.NewMockEmailServiceClient(ctrl)
+			if tt.expectUpdate {
+				repo.EXPECT().Update(gomock.Any(), gomock.Any()).Return(nil)
+				emailClient.EXPECT().SendEmail(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ context.Context, req *emailv1.SendEmailRequest, _ ...grpc.CallOption) (*emailv1.SendEmailResponse, error) {
+						assert.Equal(t, "Welcome to our service!", req.Subject)
+						return &emailv1.SendEmailResponse{}, nil
+					})
+			}
+
+			service := NewUserService(repo, emailClient, nil, verifier, createTestLogger())
+
+			user, err := service.VerifyEmail(context.Background(), verifier.Token("user-1", "Test@Example.com"))
+
+			assert.NoError(t, err)
+			assert.True(t, user.Verified)
+			assert.NotNil(t, user.VerifiedAt)
+		})
+	}
+}
+
+func TestUserService_VerifyEmail_Fail(t *testing.T) {
+	verifier := verification.NewLinks("https://example.com", "secret", time.Hour)
+
+	tests := []struct {
+		name        string
+		verifier    *verification.Links
+		token       string
+		setupMocks  func(*mocks.MockUserRepository)
+		expectedErr error
+	}{
+		{
+			name:        "verification disabled",
+			token:       "token",
+			setupMocks:  func(*mocks.MockUserRepository) {},
+			expectedErr: domain.ErrVerificationDisabled,
+		},
+		{
+			name:        "invalid token",
+			verifier:    verifier,
+			token:       "bogus",
+			setupMocks:  func(*mocks.MockUserRepository) {},
+			expectedErr: verification.ErrInvalidToken,
+		},
+		{
+			name:     "address changed since the link was sent",
+			verifier: verifier,
+			token:    verifier.Token("user-1", "old@example.com"),
+			setupMocks: func(repo *mocks.MockUserRepository) {
+				repo.EXPECT().GetByID(gomock.Any(), "user-1").Return(&domain.User{ID: "user-1", Email: "new@example.com"}, nil)
+			},
+			expectedErr: verification.ErrInvalidToken,
+		},
+		{
+			name:     "user deleted",
+			verifier: verifier,
+			token:    verifier.Token("user-1", "test@example.com"),
+			setupMocks: func(repo *mocks.MockUserRepository) {
+				repo.EXPECT().GetByID(gomock.Any(), "user-1").Return(nil, domain.ErrUserNotFound)
+			},
+			expectedErr: domain.ErrUserNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := mocks.NewMockUserRepository(ctrl)
+			tt.setupMocks(repo)
+
+			service := NewUserService(repo, nil, nil, tt.verifier, createTestLogger())
+
+			user, err := service.VerifyEmail(context.Background(), tt.token)
+
+			assert.ErrorIs(t, err, tt.expectedErr)
+			assert.Nil(t, user)
+		})
+	}
+}
+
+func TestUserService_ResendVerification_Success(t *testing.T) {
+	tests := []struct {
+		name       string
+		setupMocks func(*mocks.MockUserRepository, *mocks.MockEmailServiceClient)
+	}{
+		{
+			name: "unverified address",
+			setupMocks: func(repo *mocks.MockUserRepository, emailClient *mocks.MockEmailServiceClient) {
+				repo.EXPECT().GetByEmail(gomock.Any(), "test@example.com").Return(&domain.User{ID: "user-1", Email: "test@example.com"}, nil)
+				emailClient.EXPECT().SendEmail(gomock.Any(), gomock.Any(), gomock.Any()).Return(&emailv1.SendEmailResponse{}, nil)
+			},
+		},
+		// Nothing is sent, but the caller cannot tell who is registered
+		{
+			name: "unknown address",
+			setupMocks: func(repo *mocks.MockUserRepository, _ *mocks.MockEmailServiceClient) {
+				repo.EXPECT().GetByEmail(gomock.Any(), gomock.Any()).Return(nil, domain.ErrUserNotFound)
+			},
+		},
+		{
+			name: "already verified",
+			setupMocks: func(repo *mocks.MockUserRepository, _ *mocks.MockEmailServiceClient) {
+				repo.EXPECT().GetByEmail(gomock.Any(), gomock.Any()).Return(&domain.User{ID: "user-1", Verified: true}, nil)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := mocks.NewMockUserRepository(ctrl)
+			emailClient := mocks.NewMockEmailServiceClient(ctrl)
+			tt.setupMocks(repo, emailClient)
+
+			verifier := verification.NewLinks("https://example.com", "secret", time.Hour)
+			service := NewUserService(repo, emailClient, nil, verifier, createTestLogger())
+
+			err := service.ResendVerification(context.Background(), "test@example.com")
+
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestUserService_ResendVerification_RateLimit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mocks.NewMockUserRepository(ctrl)
+	// Unknown or not, every request for the address counts
+	repo.EXPECT().GetByEmail(gomock.Any(), gomock.Any()).Return(nil, domain.ErrUserNotFound).Times(2)
+
+	verifier := verification.NewLinks("https://example.com", "secret", time.Hour)
+	service := NewUserService(repo, nil, nil, verifier, createTestLogger())
+	service.resends = ratelimit.NewKeyed(2, time.Hour)
+
+	assert.NoError(t, service.ResendVerification(context.Background(), "victim@example.com"))
+	assert.NoError(t, service.ResendVerification(context.Background(), " Victim@Example.com"))
+	assert.ErrorIs(t, service.ResendVerification(context.Background(), "victim@example.com"), domain.ErrResendRateLimited)
+}
+
+func TestUserService_ResendVerification_Fail(t *testing.T) {
+	tests := []struct {
+		name          string
+		setupMocks    func(*mocks.MockUserRepository, *mocks.MockEmailServiceClient)
+		expectedErr   error
+		expectedError string
+	}{
+		{
+			name: "repository failure",
+			setupMocks: func(repo *mocks.MockUserRepository, _ *mocks.MockEmailServiceClient) {
+				repo.EXPECT().GetByEmail(gomock.Any(), gomock.Any()).Return(nil, errors.New("db down"))
+			},
+			expectedError: "failed to get user",
+		},
+		{
+			name: "email service failure",
+			setupMocks: func(repo *mocks.MockUserRepository, emailClient *mocks.MockEmailServiceClient) {
+				repo.EXPECT().GetByEmail(gomock.Any(), gomock.Any()).Return(&domain.User{ID: "user-1", Email: "test@example.com"}, nil)
+				emailClient.EXPECT().SendEmail(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, errors.New("unavailable"))
+			},
+			expectedError: "failed to send verification email",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := mocks.NewMockUserRepository(ctrl)
+			emailClient := mocks.NewMockEmailServiceClient(ctrl)
+			tt.setupMocks(repo, emailClient)
+
+			verifier := verification.NewLinks("https://example.com", "secret", time.Hour)
+			service := NewUserService(repo, emailClient, nil, verifier, createTestLogger())
+
+			err := service.ResendVerification(context.Background(), "test@example.com")
+
+			assert.Error(t, err)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+			}
+			assert.Contains(t, err.Error(), tt.expectedError)
+		})
+	}
+}
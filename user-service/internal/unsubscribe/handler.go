@@ -0,0 +1,82 @@
+package unsubscribe
+
+import (
+	"context"
+	"html/template"
+	"net/http"
+
+	"github.com/popeskul/mailflow/common/logger"
+)
+
+// Unsubscriber adds an address to a category's opt-out list
+type Unsubscriber interface {
+	Unsubscribe(ctx context.Context, address, category string) error
+}
+
+var confirmPage = template.Must(template.New("confirm").Parse(`<!DOCTYPE html>
+<html><body>
+<form method="post" action="?token={{.Token}}">
+<p>Unsubscribe {{.Address}} from {{.Category}} emails?</p>
+<button type="submit" name="List-Unsubscribe" value="One-Click">Unsubscribe</button>
+</form>
+</body></html>
+`))
+
+// NewHandler serves RFC 8058 one-click unsubscribes. Mail clients POST to
+// the List-Unsubscribe URL; a GET only shows a confirmation form, so link
+// scanners that prefetch URLs never unsubscribe anyone.
+func NewHandler(links *Links, unsubscriber Unsubscriber, l logger.Logger) http.Handler {
+	return &handler{
+		links:        links,
+		unsubscriber: unsubscriber,
+		logger:       l.Named("unsubscribe_handler"),
+	}
+}
+
+type handler struct {
+	links        *Links
+	unsubscriber Unsubscriber
+	logger       logger.Logger
+}
+
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+
+	address, category, err := h.links.Parse(token)
+	if err != nil {
+		h.logger.Warn("rejected unsubscribe token",
+			logger.Field{Key: "remote_addr", Value: r.RemoteAddr},
+		)
+		http.Error(w, "invalid unsubscribe link", http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := confirmPage.Execute(w, map[string]string{
+			"Token":    token,
+			"Address":  address,
+			"Category": category,
+		}); err != nil {
+			h.logger.Error("failed to render unsubscribe page",
+				logger.Field{Key: "error", Value: err},
+			)
+		}
+	case http.MethodPost:
+		if err := h.unsubscriber.Unsubscribe(r.Context(), address, category); err != nil {
+			h.logger.Error("failed to unsubscribe",
+				logger.Field{Key: "error", Value: err},
+				logger.Field{Key: "category", Value: category},
+			)
+			http.Error(w, "failed to unsubscribe", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, _ = w.Write([]byte("You have been unsubscribed.\n"))
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
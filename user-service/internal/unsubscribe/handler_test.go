@@ -0,0 +1,112 @@
+package unsubscribe
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/popeskul/mailflow/common/logger"
+)
+
+type unsubscriberFunc func(ctx context.Context, address, category string) error
+
+func (f unsubscriberFunc) Unsubscribe(ctx context.Context, address, category string) error {
+	return f(ctx, address, category)
+}
+
+func TestHandler_Success(t *testing.T) {
+	links := NewLinks("https://example.com", "secret")
+	token := links.Token("user@example.com", "newsletter")
+
+	tests := []struct {
+		name             string
+		method           string
+		expectedStatus   int
+		expectedBody     string
+		expectedUnsubbed bool
+	}{
+		{
+			name:           "get renders confirmation without unsubscribing",
+			method:         http.MethodGet,
+			expectedStatus: http.StatusOK,
+			expectedBody:   "Unsubscribe user@example.com from newsletter emails?",
+		},
+		{
+			name:             "one-click post unsubscribes",
+			method:           http.MethodPost,
+			expectedStatus:   http.StatusOK,
+			expectedBody:     "You have been unsubscribed.",
+			expectedUnsubbed: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var unsubscribed bool
+			h := NewHandler(links, unsubscriberFunc(func(_ context.Context, address, category string) error {
+				assert.Equal(t, "user@example.com", address)
+				assert.Equal(t, "newsletter", category)
+				unsubscribed = true
+				return nil
+			}), logger.NewZapLogger(logger.WithOutputs(io.Discard)))
+
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(tt.method, Path+"?token="+token, nil))
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			assert.Contains(t, rec.Body.String(), tt.expectedBody)
+			assert.Equal(t, tt.expectedUnsubbed, unsubscribed)
+		})
+	}
+}
+
+func TestHandler_Fail(t *testing.T) {
+	links := NewLinks("https://example.com", "secret")
+	token := links.Token("user@example.com", "newsletter")
+
+	tests := []struct {
+		name           string
+		method         string
+		token          string
+		unsubscribeErr error
+		expectedStatus int
+	}{
+		{
+			name:           "invalid token",
+			method:         http.MethodPost,
+			token:          "bogus",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "unsupported method",
+			method:         http.MethodDelete,
+			token:          token,
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+		{
+			name:           "unsubscribe failure",
+			method:         http.MethodPost,
+			token:          token,
+			unsubscribeErr: errors.New("database error"),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHandler(links, unsubscriberFunc(func(context.Context, string, string) error {
+				return tt.unsubscribeErr
+			}), logger.NewZapLogger(logger.WithOutputs(io.Discard)))
+
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(tt.method, Path+"?token="+tt.token, nil))
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+		})
+	}
+}
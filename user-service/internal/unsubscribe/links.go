@@ -0,0 +1,54 @@
+package unsubscribe
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+
+	"github.com/popeskul/mailflow/common/signed"
+)
+
+// Path is where the one-click unsubscribe handler is served
+const Path = "/unsubscribe"
+
+// ErrInvalidToken is returned when an unsubscribe link was altered or cut short
+var ErrInvalidToken = errors.New("invalid unsubscribe token")
+
+// Links issues and verifies signed unsubscribe URLs. The token names the
+// address and category, so following a link needs no login.
+type Links struct {
+	signer  *signed.Signer
+	baseURL string
+}
+
+func NewLinks(baseURL, secret string) *Links {
+	return &Links{
+		signer:  signed.NewSigner(secret),
+		baseURL: strings.TrimRight(baseURL, "/"),
+	}
+}
+
+// URL returns the one-click unsubscribe URL for an address and category
+func (l *Links) URL(address, category string) string {
+	return l.baseURL + Path + "?token=" + url.QueryEscape(l.Token(address, category))
+}
+
+// Token signs an address and category into a URL-safe token
+func (l *Links) Token(address, category string) string {
+	return l.signer.Sign([]byte(category + "\n" + address))
+}
+
+// Parse verifies a token and returns the address and category it names
+func (l *Links) Parse(token string) (address, category string, err error) {
+	payload, err := l.signer.Open(token)
+	if err != nil {
+		return "", "", ErrInvalidToken
+	}
+
+	category, address, ok := strings.Cut(string(payload), "\n")
+	if !ok || address == "" {
+		return "", "", ErrInvalidToken
+	}
+
+	return address, category, nil
+}
@@ -0,0 +1,45 @@
+package unsubscribe
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLinks_Parse_Success(t *testing.T) {
+	links := NewLinks("https://example.com/", "secret")
+
+	address, category, err := links.Parse(links.Token("user@example.com", "newsletter"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "user@example.com", address)
+	assert.Equal(t, "newsletter", category)
+	assert.True(t, strings.HasPrefix(links.URL("user@example.com", "newsletter"), "https://example.com/unsubscribe?token="))
+}
+
+func TestLinks_Parse_Fail(t *testing.T) {
+	links := NewLinks("https://example.com", "secret")
+	valid := links.Token("user@example.com", "newsletter")
+	_, sig, _ := strings.Cut(valid, ".")
+	forged, _, _ := strings.Cut(links.Token("other@example.com", "newsletter"), ".")
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{name: "empty token", token: ""},
+		{name: "missing signature", token: "bmV3c2xldHRlcgp1c2Vy"},
+		{name: "malformed encoding", token: "!!!." + sig},
+		{name: "signed with another secret", token: NewLinks("https://example.com", "other").Token("user@example.com", "newsletter")},
+		{name: "tampered payload", token: forged + "." + sig},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := links.Parse(tt.token)
+
+			assert.ErrorIs(t, err, ErrInvalidToken)
+		})
+	}
+}
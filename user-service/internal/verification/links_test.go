@@ -0,0 +1,51 @@
+package verification
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLinks_Parse_Success(t *testing.T) {
+	links := NewLinks("https://example.com/", "secret", time.Hour)
+
+	userID, address, err := links.Parse(links.Token("user-1", "user@example.com"))
+
+	require.NoError(t, err)
+	assert.Equal(t, "user-1", userID)
+	assert.Equal(t, "user@example.com", address)
+	assert.True(t, strings.HasPrefix(links.URL("user-1", "user@example.com"), "https://example.com"+Path+"?token="))
+}
+
+func TestLinks_Parse_Fail(t *testing.T) {
+	links := NewLinks("https://example.com", "secret", time.Hour)
+	valid := links.Token("user-1", "user@example.com")
+	_, sig, _ := strings.Cut(valid, ".")
+	forged, _, _ := strings.Cut(links.Token("user-2", "user@example.com"), ".")
+
+	expired := NewLinks("https://example.com", "secret", time.Hour)
+	expired.now = func() time.Time { return time.Now().Add(-2 * time.Hour) }
+
+	tests := []struct {
+		name        string
+		token       string
+		expectedErr error
+	}{
+		{name: "empty token", token: "", expectedErr: ErrInvalidToken},
+		{name: "malformed encoding", token: "!!!." + sig, expectedErr: ErrInvalidToken},
+		{name: "signed with another secret", token: NewLinks("", "other", time.Hour).Token("user-1", "user@example.com"), expectedErr: ErrInvalidToken},
+		{name: "tampered payload", token: forged + "." + sig, expectedErr: ErrInvalidToken},
+		{name: "expired", token: expired.Token("user-1", "user@example.com"), expectedErr: ErrTokenExpired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := links.Parse(tt.token)
+
+			assert.ErrorIs(t, err, tt.expectedErr)
+		})
+	}
+}
@@ -0,0 +1,570 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: api/admin/v1/admin_service.proto
+
+package adminv1
+
+import (
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+
+	_ "google.golang.org/genproto/googleapis/api/annotations"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// CircuitBreaker is the state of one named circuit breaker.
+type CircuitBreaker struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// name identifies the downstream service or method the breaker guards.
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// state is "closed", "open" or "half-open".
+	State string `protobuf:"bytes,2,opt,name=state,proto3" json:"state,omitempty"`
+	// forced is set while the state was forced by an admin.
+	Forced bool `protobuf:"varint,3,opt,name=forced,proto3" json:"forced,omitempty"`
+	// failures are the consecutive failures, or the failures within the window.
+	Failures int32 `protobuf:"varint,4,opt,name=failures,proto3" json:"failures,omitempty"`
+	// successes are the successful probes in the half-open state.
+	Successes int32 `protobuf:"varint,5,opt,name=successes,proto3" json:"successes,omitempty"`
+	// half_open_requests are the probes let through in the half-open state.
+	HalfOpenRequests int32 `protobuf:"varint,6,opt,name=half_open_requests,json=halfOpenRequests,proto3" json:"half_open_requests,omitempty"`
+	// last_failure_time is when the circuit last opened, in RFC 3339.
+	LastFailureTime string `protobuf:"bytes,7,opt,name=last_failure_time,json=lastFailureTime,proto3" json:"last_failure_time,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *CircuitBreaker) Reset() {
+	*x = CircuitBreaker{}
+	mi := &file_api_admin_v1_admin_service_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CircuitBreaker) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CircuitBreaker) ProtoMessage() {}
+
+func (x *CircuitBreaker) ProtoReflect() protoreflect.Message {
+	mi := &file_api_admin_v1_admin_service_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CircuitBreaker.ProtoReflect.Descriptor instead.
+func (*CircuitBreaker) Descriptor() ([]byte, []int) {
+	return file_api_admin_v1_admin_service_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *CircuitBreaker) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *CircuitBreaker) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+func (x *CircuitBreaker) GetForced() bool {
+	if x != nil {
+		return x.Forced
+	}
+	return false
+}
+
+func (x *CircuitBreaker) GetFailures() int32 {
+	if x != nil {
+		return x.Failures
+	}
+	return 0
+}
+
+func (x *CircuitBreaker) GetSuccesses() int32 {
+	if x != nil {
+		return x.Successes
+	}
+	return 0
+}
+
+func (x *CircuitBreaker) GetHalfOpenRequests() int32 {
+	if x != nil {
+		return x.HalfOpenRequests
+	}
+	return 0
+}
+
+func (x *CircuitBreaker) GetLastFailureTime() string {
+	if x != nil {
+		return x.LastFailureTime
+	}
+	return ""
+}
+
+type ListCircuitBreakersRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListCircuitBreakersRequest) Reset() {
+	*x = ListCircuitBreakersRequest{}
+	mi := &file_api_admin_v1_admin_service_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListCircuitBreakersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListCircuitBreakersRequest) ProtoMessage() {}
+
+func (x *ListCircuitBreakersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_admin_v1_admin_service_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListCircuitBreakersRequest.ProtoReflect.Descriptor instead.
+func (*ListCircuitBreakersRequest) Descriptor() ([]byte, []int) {
+	return file_api_admin_v1_admin_service_proto_rawDescGZIP(), []int{1}
+}
+
+type ListCircuitBreakersResponse struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	CircuitBreakers []*CircuitBreaker      `protobuf:"bytes,1,rep,name=circuit_breakers,json=circuitBreakers,proto3" json:"circuit_breakers,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *ListCircuitBreakersResponse) Reset() {
+	*x = ListCircuitBreakersResponse{}
+	mi := &file_api_admin_v1_admin_service_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListCircuitBreakersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListCircuitBreakersResponse) ProtoMessage() {}
+
+func (x *ListCircuitBreakersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_admin_v1_admin_service_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListCircuitBreakersResponse.ProtoReflect.Descriptor instead.
+func (*ListCircuitBreakersResponse) Descriptor() ([]byte, []int) {
+	return file_api_admin_v1_admin_service_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ListCircuitBreakersResponse) GetCircuitBreakers() []*CircuitBreaker {
+	if x != nil {
+		return x.CircuitBreakers
+	}
+	return nil
+}
+
+type ForceOpenCircuitBreakerRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ForceOpenCircuitBreakerRequest) Reset() {
+	*x = ForceOpenCircuitBreakerRequest{}
+	mi := &file_api_admin_v1_admin_service_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ForceOpenCircuitBreakerRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ForceOpenCircuitBreakerRequest) ProtoMessage() {}
+
+func (x *ForceOpenCircuitBreakerRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_admin_v1_admin_service_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ForceOpenCircuitBreakerRequest.ProtoReflect.Descriptor instead.
+func (*ForceOpenCircuitBreakerRequest) Descriptor() ([]byte, []int) {
+	return file_api_admin_v1_admin_service_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ForceOpenCircuitBreakerRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type ForceOpenCircuitBreakerResponse struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	CircuitBreaker *CircuitBreaker        `protobuf:"bytes,1,opt,name=circuit_breaker,json=circuitBreaker,proto3" json:"circuit_breaker,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ForceOpenCircuitBreakerResponse) Reset() {
+	*x = ForceOpenCircuitBreakerResponse{}
+	mi := &file_api_admin_v1_admin_service_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ForceOpenCircuitBreakerResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ForceOpenCircuitBreakerResponse) ProtoMessage() {}
+
+func (x *ForceOpenCircuitBreakerResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_admin_v1_admin_service_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ForceOpenCircuitBreakerResponse.ProtoReflect.Descriptor instead.
+func (*ForceOpenCircuitBreakerResponse) Descriptor() ([]byte, []int) {
+	return file_api_admin_v1_admin_service_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ForceOpenCircuitBreakerResponse) GetCircuitBreaker() *CircuitBreaker {
+	if x != nil {
+		return x.CircuitBreaker
+	}
+	return nil
+}
+
+type ForceCloseCircuitBreakerRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ForceCloseCircuitBreakerRequest) Reset() {
+	*x = ForceCloseCircuitBreakerRequest{}
+	mi := &file_api_admin_v1_admin_service_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ForceCloseCircuitBreakerRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ForceCloseCircuitBreakerRequest) ProtoMessage() {}
+
+func (x *ForceCloseCircuitBreakerRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_admin_v1_admin_service_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ForceCloseCircuitBreakerRequest.ProtoReflect.Descriptor instead.
+func (*ForceCloseCircuitBreakerRequest) Descriptor() ([]byte, []int) {
+	return file_api_admin_v1_admin_service_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ForceCloseCircuitBreakerRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type ForceCloseCircuitBreakerResponse struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	CircuitBreaker *CircuitBreaker        `protobuf:"bytes,1,opt,name=circuit_breaker,json=circuitBreaker,proto3" json:"circuit_breaker,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ForceCloseCircuitBreakerResponse) Reset() {
+	*x = ForceCloseCircuitBreakerResponse{}
+	mi := &file_api_admin_v1_admin_service_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ForceCloseCircuitBreakerResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ForceCloseCircuitBreakerResponse) ProtoMessage() {}
+
+func (x *ForceCloseCircuitBreakerResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_admin_v1_admin_service_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ForceCloseCircuitBreakerResponse.ProtoReflect.Descriptor instead.
+func (*ForceCloseCircuitBreakerResponse) Descriptor() ([]byte, []int) {
+	return file_api_admin_v1_admin_service_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ForceCloseCircuitBreakerResponse) GetCircuitBreaker() *CircuitBreaker {
+	if x != nil {
+		return x.CircuitBreaker
+	}
+	return nil
+}
+
+type ResetCircuitBreakerRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResetCircuitBreakerRequest) Reset() {
+	*x = ResetCircuitBreakerRequest{}
+	mi := &file_api_admin_v1_admin_service_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResetCircuitBreakerRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResetCircuitBreakerRequest) ProtoMessage() {}
+
+func (x *ResetCircuitBreakerRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_admin_v1_admin_service_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResetCircuitBreakerRequest.ProtoReflect.Descriptor instead.
+func (*ResetCircuitBreakerRequest) Descriptor() ([]byte, []int) {
+	return file_api_admin_v1_admin_service_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ResetCircuitBreakerRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type ResetCircuitBreakerResponse struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	CircuitBreaker *CircuitBreaker        `protobuf:"bytes,1,opt,name=circuit_breaker,json=circuitBreaker,proto3" json:"circuit_breaker,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ResetCircuitBreakerResponse) Reset() {
+	*x = ResetCircuitBreakerResponse{}
+	mi := &file_api_admin_v1_admin_service_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResetCircuitBreakerResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResetCircuitBreakerResponse) ProtoMessage() {}
+
+func (x *ResetCircuitBreakerResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_admin_v1_admin_service_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResetCircuitBreakerResponse.ProtoReflect.Descriptor instead.
+func (*ResetCircuitBreakerResponse) Descriptor() ([]byte, []int) {
+	return file_api_admin_v1_admin_service_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ResetCircuitBreakerResponse) GetCircuitBreaker() *CircuitBreaker {
+	if x != nil {
+		return x.CircuitBreaker
+	}
+	return nil
+}
+
+var File_api_admin_v1_admin_service_proto protoreflect.FileDescriptor
+
+const file_api_admin_v1_admin_service_proto_rawDesc = "" +
+	"\n" +
+	" api/admin/v1/admin_service.proto\x12\badmin.v1\x1a\x1cgoogle/api/annotations.proto\x1a\x1fgoogle/api/field_behavior.proto\"\xe6\x01\n" +
+	"\x0eCircuitBreaker\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x14\n" +
+	"\x05state\x18\x02 \x01(\tR\x05state\x12\x16\n" +
+	"\x06forced\x18\x03 \x01(\bR\x06forced\x12\x1a\n" +
+	"\bfailures\x18\x04 \x01(\x05R\bfailures\x12\x1c\n" +
+	"\tsuccesses\x18\x05 \x01(\x05R\tsuccesses\x12,\n" +
+	"\x12half_open_requests\x18\x06 \x01(\x05R\x10halfOpenRequests\x12*\n" +
+	"\x11last_failure_time\x18\a \x01(\tR\x0flastFailureTime\"\x1c\n" +
+	"\x1aListCircuitBreakersRequest\"b\n" +
+	"\x1bListCircuitBreakersResponse\x12C\n" +
+	"\x10circuit_breakers\x18\x01 \x03(\v2\x18.admin.v1.CircuitBreakerR\x0fcircuitBreakers\"9\n" +
+	"\x1eForceOpenCircuitBreakerRequest\x12\x17\n" +
+	"\x04name\x18\x01 \x01(\tB\x03\xe0A\x02R\x04name\"d\n" +
+	"\x1fForceOpenCircuitBreakerResponse\x12A\n" +
+	"\x0fcircuit_breaker\x18\x01 \x01(\v2\x18.admin.v1.CircuitBreakerR\x0ecircuitBreaker\":\n" +
+	"\x1fForceCloseCircuitBreakerRequest\x12\x17\n" +
+	"\x04name\x18\x01 \x01(\tB\x03\xe0A\x02R\x04name\"e\n" +
+	" ForceCloseCircuitBreakerResponse\x12A\n" +
+	"\x0fcircuit_breaker\x18\x01 \x01(\v2\x18.admin.v1.CircuitBreakerR\x0ecircuitBreaker\"5\n" +
+	"\x1aResetCircuitBreakerRequest\x12\x17\n" +
+	"\x04name\x18\x01 \x01(\tB\x03\xe0A\x02R\x04name\"`\n" +
+	"\x1bResetCircuitBreakerResponse\x12A\n" +
+	"\x0fcircuit_breaker\x18\x01 \x01(\v2\x18.admin.v1.CircuitBreakerR\x0ecircuitBreaker2\x98\x05\n" +
+	"\fAdminService\x12\x8a\x01\n" +
+	"\x13ListCircuitBreakers\x12$.admin.v1.ListCircuitBreakersRequest\x1a%.admin.v1.ListCircuitBreakersResponse\"&\x82\xd3\xe4\x93\x02 \x12\x1e/api/v1/admin/circuit-breakers\x12\xab\x01\n" +
+	"\x17ForceOpenCircuitBreaker\x12(.admin.v1.ForceOpenCircuitBreakerRequest\x1a).admin.v1.ForceOpenCircuitBreakerResponse\";\x82\xd3\xe4\x93\x025:\x01*\"0/api/v1/admin/circuit-breakers/{name}:force-open\x12\xaf\x01\n" +
+	"\x18ForceCloseCircuitBreaker\x12).admin.v1.ForceCloseCircuitBreakerRequest\x1a*.admin.v1.ForceCloseCircuitBreakerResponse\"<\x82\xd3\xe4\x93\x026:\x01*\"1/api/v1/admin/circuit-breakers/{name}:force-close\x12\x9a\x01\n" +
+	"\x13ResetCircuitBreaker\x12$.admin.v1.ResetCircuitBreakerRequest\x1a%.admin.v1.ResetCircuitBreakerResponse\"6\x82\xd3\xe4\x93\x020:\x01*\"+/api/v1/admin/circuit-breakers/{name}:resetBDZBgithub.com/popeskul/mailflow/user-service/pkg/api/admin/v1;adminv1b\x06proto3"
+
+var (
+	file_api_admin_v1_admin_service_proto_rawDescOnce sync.Once
+	file_api_admin_v1_admin_service_proto_rawDescData []byte
+)
+
+func file_api_admin_v1_admin_service_proto_rawDescGZIP() []byte {
+	file_api_admin_v1_admin_service_proto_rawDescOnce.Do(func() {
+		file_api_admin_v1_admin_service_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_api_admin_v1_admin_service_proto_rawDesc), len(file_api_admin_v1_admin_service_proto_rawDesc)))
+	})
+	return file_api_admin_v1_admin_service_proto_rawDescData
+}
+
+var file_api_admin_v1_admin_service_proto_msgTypes = make([]protoimpl.MessageInfo, 9)
+var file_api_admin_v1_admin_service_proto_goTypes = []any{
+	(*CircuitBreaker)(nil),                   // 0: admin.v1.CircuitBreaker
+	(*ListCircuitBreakersRequest)(nil),       // 1: admin.v1.ListCircuitBreakersRequest
+	(*ListCircuitBreakersResponse)(nil),      // 2: admin.v1.ListCircuitBreakersResponse
+	(*ForceOpenCircuitBreakerRequest)(nil),   // 3: admin.v1.ForceOpenCircuitBreakerRequest
+	(*ForceOpenCircuitBreakerResponse)(nil),  // 4: admin.v1.ForceOpenCircuitBreakerResponse
+	(*ForceCloseCircuitBreakerRequest)(nil),  // 5: admin.v1.ForceCloseCircuitBreakerRequest
+	(*ForceCloseCircuitBreakerResponse)(nil), // 6: admin.v1.ForceCloseCircuitBreakerResponse
+	(*ResetCircuitBreakerRequest)(nil),       // 7: admin.v1.ResetCircuitBreakerRequest
+	(*ResetCircuitBreakerResponse)(nil),      // 8: admin.v1.ResetCircuitBreakerResponse
+}
+var file_api_admin_v1_admin_service_proto_depIdxs = []int32{
+	0, // 0: admin.v1.ListCircuitBreakersResponse.circuit_breakers:type_name -> admin.v1.CircuitBreaker
+	0, // 1: admin.v1.ForceOpenCircuitBreakerResponse.circuit_breaker:type_name -> admin.v1.CircuitBreaker
+	0, // 2: admin.v1.ForceCloseCircuitBreakerResponse.circuit_breaker:type_name -> admin.v1.CircuitBreaker
+	0, // 3: admin.v1.ResetCircuitBreakerResponse.circuit_breaker:type_name -> admin.v1.CircuitBreaker
+	1, // 4: admin.v1.AdminService.ListCircuitBreakers:input_type -> admin.v1.ListCircuitBreakersRequest
+	3, // 5: admin.v1.AdminService.ForceOpenCircuitBreaker:input_type -> admin.v1.ForceOpenCircuitBreakerRequest
+	5, // 6: admin.v1.AdminService.ForceCloseCircuitBreaker:input_type -> admin.v1.ForceCloseCircuitBreakerRequest
+	7, // 7: admin.v1.AdminService.ResetCircuitBreaker:input_type -> admin.v1.ResetCircuitBreakerRequest
+	2, // 8: admin.v1.AdminService.ListCircuitBreakers:output_type -> admin.v1.ListCircuitBreakersResponse
+	4, // 9: admin.v1.AdminService.ForceOpenCircuitBreaker:output_type -> admin.v1.ForceOpenCircuitBreakerResponse
+	6, // 10: admin.v1.AdminService.ForceCloseCircuitBreaker:output_type -> admin.v1.ForceCloseCircuitBreakerResponse
+	8, // 11: admin.v1.AdminService.ResetCircuitBreaker:output_type -> admin.v1.ResetCircuitBreakerResponse
+	8, // [8:12] is the sub-list for method output_type
+	4, // [4:8] is the sub-list for method input_type
+	4, // [4:4] is the sub-list for extension type_name
+	4, // [4:4] is the sub-list for extension extendee
+	0, // [0:4] is the sub-list for field type_name
+}
+
+func init() { file_api_admin_v1_admin_service_proto_init() }
+func file_api_admin_v1_admin_service_proto_init() {
+	if File_api_admin_v1_admin_service_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_api_admin_v1_admin_service_proto_rawDesc), len(file_api_admin_v1_admin_service_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   9,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_api_admin_v1_admin_service_proto_goTypes,
+		DependencyIndexes: file_api_admin_v1_admin_service_proto_depIdxs,
+		MessageInfos:      file_api_admin_v1_admin_service_proto_msgTypes,
+	}.Build()
+	File_api_admin_v1_admin_service_proto = out.File
+	file_api_admin_v1_admin_service_proto_goTypes = nil
+	file_api_admin_v1_admin_service_proto_depIdxs = nil
+}
@@ -0,0 +1,256 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: api/admin/v1/admin_service.proto
+
+package adminv1
+
+import (
+	context "context"
+
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	AdminService_ListCircuitBreakers_FullMethodName      = "/admin.v1.AdminService/ListCircuitBreakers"
+	AdminService_ForceOpenCircuitBreaker_FullMethodName  = "/admin.v1.AdminService/ForceOpenCircuitBreaker"
+	AdminService_ForceCloseCircuitBreaker_FullMethodName = "/admin.v1.AdminService/ForceCloseCircuitBreaker"
+	AdminService_ResetCircuitBreaker_FullMethodName      = "/admin.v1.AdminService/ResetCircuitBreaker"
+)
+
+// AdminServiceClient is the client API for AdminService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// AdminService lets operators inspect and override the service's circuit
+// breakers during incidents. Only admins may call it.
+type AdminServiceClient interface {
+	// ListCircuitBreakers returns the state of every circuit breaker.
+	ListCircuitBreakers(ctx context.Context, in *ListCircuitBreakersRequest, opts ...grpc.CallOption) (*ListCircuitBreakersResponse, error)
+	// ForceOpenCircuitBreaker holds a circuit breaker open, rejecting every
+	// call, until it is reset.
+	ForceOpenCircuitBreaker(ctx context.Context, in *ForceOpenCircuitBreakerRequest, opts ...grpc.CallOption) (*ForceOpenCircuitBreakerResponse, error)
+	// ForceCloseCircuitBreaker holds a circuit breaker closed, letting every
+	// call through, until it is reset.
+	ForceCloseCircuitBreaker(ctx context.Context, in *ForceCloseCircuitBreakerRequest, opts ...grpc.CallOption) (*ForceCloseCircuitBreakerResponse, error)
+	// ResetCircuitBreaker closes a circuit breaker, clears its counters and
+	// lets it trip again.
+	ResetCircuitBreaker(ctx context.Context, in *ResetCircuitBreakerRequest, opts ...grpc.CallOption) (*ResetCircuitBreakerResponse, error)
+}
+
+type adminServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAdminServiceClient(cc grpc.ClientConnInterface) AdminServiceClient {
+	return &adminServiceClient{cc}
+}
+
+func (c *adminServiceClient) ListCircuitBreakers(ctx context.Context, in *ListCircuitBreakersRequest, opts ...grpc.CallOption) (*ListCircuitBreakersResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListCircuitBreakersResponse)
+	err := c.cc.Invoke(ctx, AdminService_ListCircuitBreakers_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) ForceOpenCircuitBreaker(ctx context.Context, in *ForceOpenCircuitBreakerRequest, opts ...grpc.CallOption) (*ForceOpenCircuitBreakerResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ForceOpenCircuitBreakerResponse)
+	err := c.cc.Invoke(ctx, AdminService_ForceOpenCircuitBreaker_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) ForceCloseCircuitBreaker(ctx context.Context, in *ForceCloseCircuitBreakerRequest, opts ...grpc.CallOption) (*ForceCloseCircuitBreakerResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ForceCloseCircuitBreakerResponse)
+	err := c.cc.Invoke(ctx, AdminService_ForceCloseCircuitBreaker_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) ResetCircuitBreaker(ctx context.Context, in *ResetCircuitBreakerRequest, opts ...grpc.CallOption) (*ResetCircuitBreakerResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ResetCircuitBreakerResponse)
+	err := c.cc.Invoke(ctx, AdminService_ResetCircuitBreaker_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AdminServiceServer is the server API for AdminService service.
+// All implementations must embed UnimplementedAdminServiceServer
+// for forward compatibility.
+//
+// AdminService lets operators inspect and override the service's circuit
+// breakers during incidents. Only admins may call it.
+type AdminServiceServer interface {
+	// ListCircuitBreakers returns the state of every circuit breaker.
+	ListCircuitBreakers(context.Context, *ListCircuitBreakersRequest) (*ListCircuitBreakersResponse, error)
+	// ForceOpenCircuitBreaker holds a circuit breaker open, rejecting every
+	// call, until it is reset.
+	ForceOpenCircuitBreaker(context.Context, *ForceOpenCircuitBreakerRequest) (*ForceOpenCircuitBreakerResponse, error)
+	// ForceCloseCircuitBreaker holds a circuit breaker closed, letting every
+	// call through, until it is reset.
+	ForceCloseCircuitBreaker(context.Context, *ForceCloseCircuitBreakerRequest) (*ForceCloseCircuitBreakerResponse, error)
+	// ResetCircuitBreaker closes a circuit breaker, clears its counters and
+	// lets it trip again.
+	ResetCircuitBreaker(context.Context, *ResetCircuitBreakerRequest) (*ResetCircuitBreakerResponse, error)
+	mustEmbedUnimplementedAdminServiceServer()
+}
+
+// UnimplementedAdminServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedAdminServiceServer struct{}
+
+func (UnimplementedAdminServiceServer) ListCircuitBreakers(context.Context, *ListCircuitBreakersRequest) (*ListCircuitBreakersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListCircuitBreakers not implemented")
+}
+func (UnimplementedAdminServiceServer) ForceOpenCircuitBreaker(context.Context, *ForceOpenCircuitBreakerRequest) (*ForceOpenCircuitBreakerResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ForceOpenCircuitBreaker not implemented")
+}
+func (UnimplementedAdminServiceServer) ForceCloseCircuitBreaker(context.Context, *ForceCloseCircuitBreakerRequest) (*ForceCloseCircuitBreakerResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ForceCloseCircuitBreaker not implemented")
+}
+func (UnimplementedAdminServiceServer) ResetCircuitBreaker(context.Context, *ResetCircuitBreakerRequest) (*ResetCircuitBreakerResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ResetCircuitBreaker not implemented")
+}
+func (UnimplementedAdminServiceServer) mustEmbedUnimplementedAdminServiceServer() {}
+func (UnimplementedAdminServiceServer) testEmbeddedByValue()                      {}
+
+// UnsafeAdminServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AdminServiceServer will
+// result in compilation errors.
+type UnsafeAdminServiceServer interface {
+	mustEmbedUnimplementedAdminServiceServer()
+}
+
+func RegisterAdminServiceServer(s grpc.ServiceRegistrar, srv AdminServiceServer) {
+	// If the following call pancis, it indicates UnimplementedAdminServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&AdminService_ServiceDesc, srv)
+}
+
+func _AdminService_ListCircuitBreakers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListCircuitBreakersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).ListCircuitBreakers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_ListCircuitBreakers_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).ListCircuitBreakers(ctx, req.(*ListCircuitBreakersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_ForceOpenCircuitBreaker_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ForceOpenCircuitBreakerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).ForceOpenCircuitBreaker(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_ForceOpenCircuitBreaker_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).ForceOpenCircuitBreaker(ctx, req.(*ForceOpenCircuitBreakerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_ForceCloseCircuitBreaker_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ForceCloseCircuitBreakerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).ForceCloseCircuitBreaker(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_ForceCloseCircuitBreaker_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).ForceCloseCircuitBreaker(ctx, req.(*ForceCloseCircuitBreakerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_ResetCircuitBreaker_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ResetCircuitBreakerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).ResetCircuitBreaker(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_ResetCircuitBreaker_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).ResetCircuitBreaker(ctx, req.(*ResetCircuitBreakerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// AdminService_ServiceDesc is the grpc.ServiceDesc for AdminService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var AdminService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "admin.v1.AdminService",
+	HandlerType: (*AdminServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListCircuitBreakers",
+			Handler:    _AdminService_ListCircuitBreakers_Handler,
+		},
+		{
+			MethodName: "ForceOpenCircuitBreaker",
+			Handler:    _AdminService_ForceOpenCircuitBreaker_Handler,
+		},
+		{
+			MethodName: "ForceCloseCircuitBreaker",
+			Handler:    _AdminService_ForceCloseCircuitBreaker_Handler,
+		},
+		{
+			MethodName: "ResetCircuitBreaker",
+			Handler:    _AdminService_ResetCircuitBreaker_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "api/admin/v1/admin_service.proto",
+}